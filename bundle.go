@@ -0,0 +1,175 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// bundleEnv lists the environment variables recorded in a bundle: those
+// affecting how tests build and run. Others are left out, as they may hold
+// secrets.
+var bundleEnv = []string{
+	"GOFLAGS", "GOMAXPROCS", "GOGC", "GOMEMLIMIT", "GODEBUG", "GOEXPERIMENT",
+	"GOOS", "GOARCH", "GOAMD64", "GOARM", "GOARM64", "CGO_ENABLED", "CI",
+}
+
+// bundleMetadata describes the environment a bundle was made in.
+type bundleMetadata struct {
+	TangVersion string            `json:"tangVersion"`
+	TangGo      string            `json:"tangGo"`              // Go version tang was built with
+	GoVersion   string            `json:"goVersion,omitempty"` // Of the go command on the PATH, if any
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	CPUs        int               `json:"cpus"`
+	Created     time.Time         `json:"created"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+// newBundleMetadata describes the current environment.
+func newBundleMetadata() bundleMetadata {
+	meta := bundleMetadata{
+		TangVersion: tangVersion(),
+		TangGo:      runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CPUs:        runtime.NumCPU(),
+		Created:     time.Now().UTC(),
+		Env:         make(map[string]string),
+	}
+	if out, err := exec.Command("go", "env", "GOVERSION").Output(); err == nil {
+		meta.GoVersion = strings.TrimSpace(string(out))
+	}
+	for _, name := range bundleEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			meta.Env[name] = value
+		}
+	}
+	return meta
+}
+
+// runBundle implements the `tang bundle` subcommand, which packages a go
+// test -json stream with its summary and the environment into an archive
+// for attaching to bug reports.
+func runBundle(args []string) int {
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	infile := fs.String("f", "", "go test -json stream to bundle, e.g. from -jsonfile or -outfile (default stdin)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang bundle [-f file.json] <out.tar.gz>\n\n")
+		fmt.Fprintf(os.Stderr, "Package a go test -json stream, the JSON summary of each of its runs, tang's version and\n")
+		fmt.Fprintf(os.Stderr, "the environment (OS, Go version, Go-related environment variables) into a gzipped tar\n")
+		fmt.Fprintf(os.Stderr, "archive, for attaching to bug reports about tang or about flaky tests.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: bundle requires the archive to write\n")
+		return 1
+	}
+
+	var input io.Reader = os.Stdin
+	if *infile != "" {
+		f, err := os.Open(*infile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		input = f
+	}
+	stream, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		return 1
+	}
+
+	out, err := os.Create(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating bundle: %v\n", err)
+		return 1
+	}
+	runs, err := writeBundle(out, stream, newBundleMetadata())
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing bundle: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s: %d bytes of input, %d runs\n", fs.Arg(0), len(stream), runs)
+	return 0
+}
+
+// writeBundle writes a gzipped tar archive of the stream, as stream.json,
+// the summary of each of its runs, as summary.json, or summary-N.json for
+// run N when there are several, and meta, as metadata.json. It returns
+// the number of runs.
+func writeBundle(w io.Writer, stream []byte, meta bundleMetadata) (int, error) {
+	// Corrupted events are tolerated, as a stream tang misread is what
+	// a bug report may be about.
+	collector := results.NewCollector()
+	for evt := range engine.NewEngine(engine.WithResync()).Stream(bytes.NewReader(stream)) {
+		collector.Push(evt)
+	}
+	collector.Finish()
+	runs := collector.State().Runs
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: meta.Created}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := add("stream.json", stream); err != nil {
+		return 0, err
+	}
+	for _, run := range runs {
+		var buf bytes.Buffer
+		if err := format.WriteSummaryJSON(&buf, format.ComputeSummary(run)); err != nil {
+			return 0, err
+		}
+		name := "summary.json"
+		if len(runs) > 1 {
+			name = fmt.Sprintf("summary-%d.json", run.ID)
+		}
+		if err := add(name, buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := add("metadata.json", append(data, '\n')); err != nil {
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return len(runs), gz.Close()
+}
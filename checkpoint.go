@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// checkpointer writes snapshots of the run in progress to the -summary-json
+// and -markdown files as packages finish, every so many packages or every
+// so often, for -checkpoint-packages and -checkpoint-every, so a long run
+// which is killed before tang exits, e.g. by a CI job's timeout, still
+// leaves its latest partial report. The final report replaces them.
+// Like runStore, snapshots are taken by the collector's event handler and
+// written by a goroutine; only the latest one not yet written is kept.
+type checkpointer struct {
+	collector    *results.Collector
+	opts         format.SummaryOptions
+	jsonFile     string
+	markdownFile string
+	packages     int           // Packages finished between checkpoints; 0 for no limit
+	every        time.Duration // Time between checkpoints; 0 for no limit
+	now          func() time.Time
+
+	finished int       // Packages finished since the last checkpoint
+	last     time.Time // When the last checkpoint, or the run, started
+
+	mu      sync.Mutex
+	pending *checkpoint
+	wake    chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// checkpoint is a snapshot of a run in progress, rendered for each file.
+type checkpoint struct {
+	json, markdown []byte
+}
+
+// newCheckpointer starts writing checkpoints of the runs of collector to
+// jsonFile and markdownFile, either of which may be "". Register its Handle
+// method as an event handler, and Close it before the final reports are
+// written.
+func newCheckpointer(collector *results.Collector, opts format.SummaryOptions, jsonFile, markdownFile string, packages int, every time.Duration) *checkpointer {
+	c := &checkpointer{
+		collector:    collector,
+		opts:         opts,
+		jsonFile:     jsonFile,
+		markdownFile: markdownFile,
+		packages:     packages,
+		every:        every,
+		now:          time.Now,
+		wake:         make(chan struct{}, 1),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+// Handle takes a checkpoint when a package finishes, if one is due.
+func (c *checkpointer) Handle(evt results.Event) {
+	switch {
+	case evt.Type == results.EventRunStarted:
+		c.finished, c.last = 0, c.now()
+		return
+	case evt.Type != results.EventPackageUpdated || evt.PrevStatus != results.StatusRunning:
+		return
+	}
+	switch evt.Status {
+	case results.StatusPassed, results.StatusFailed, results.StatusSkipped, results.StatusBuildFailed:
+	default:
+		return
+	}
+	c.finished++
+	now := c.now()
+	if (c.packages <= 0 || c.finished < c.packages) && (c.every <= 0 || now.Sub(c.last) < c.every) {
+		return
+	}
+	// The collector is locked, so its state can be read.
+	run := c.collector.State().CurrentRun
+	if run == nil || run.ID != evt.RunID {
+		return
+	}
+	c.finished, c.last = 0, now
+
+	summary := format.ComputeSummary(run, format.WithOptions(c.opts))
+	var cp checkpoint
+	if c.jsonFile != "" {
+		var b bytes.Buffer
+		if err := format.WriteSummaryJSON(&b, summary); err == nil {
+			cp.json = b.Bytes()
+		}
+	}
+	if c.markdownFile != "" {
+		var b bytes.Buffer
+		if err := format.WriteMarkdown(&b, summary, format.WithOptions(c.opts)); err == nil {
+			cp.markdown = b.Bytes()
+		}
+	}
+	c.mu.Lock()
+	c.pending = &cp
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop writes checkpoints until Close is called.
+func (c *checkpointer) loop() {
+	defer close(c.done)
+	for {
+		select {
+		case <-c.wake:
+			c.flush()
+		case <-c.quit:
+			c.flush()
+			return
+		}
+	}
+}
+
+// flush writes the latest checkpoint, if it isn't written yet.
+func (c *checkpointer) flush() {
+	c.mu.Lock()
+	cp := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	if cp == nil {
+		return
+	}
+	if cp.json != nil {
+		if err := writeFileAtomic(c.jsonFile, cp.json); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing summary JSON checkpoint: %v\n", err)
+		}
+	}
+	if cp.markdown != nil {
+		if err := writeFileAtomic(c.markdownFile, cp.markdown); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing Markdown summary checkpoint: %v\n", err)
+		}
+	}
+}
+
+// Close writes the checkpoint still pending, and stops writing them.
+func (c *checkpointer) Close() {
+	close(c.quit)
+	<-c.done
+}
+
+// writeFileAtomic replaces the file at path with data, through a
+// temporary file renamed over it, so a reader, or a kill, never finds it
+// half written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
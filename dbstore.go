@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// runStore records the runs of a collector in a history database as they
+// progress, for -db: a run's row when it starts, each package and test as
+// it finishes, and the run's totals when it finishes, so a run is recorded
+// as far as it got even if tang is killed. Rows are queued by the
+// collector's event handler, which is called with the collector locked,
+// and written by a goroutine, so a slow disk doesn't hold up the stream.
+type runStore struct {
+	db        *history.DB
+	collector *results.Collector
+	opts      format.SummaryOptions
+
+	mu      sync.Mutex
+	pending []func() error
+	wake    chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+
+	// Used by loop only
+	runs   map[int]int64 // Database row IDs by run ID
+	failed bool          // A write failed
+}
+
+// newRunStore starts storing the runs of collector in db. Register its
+// Handle method as an event handler, and Close it once the collector is
+// finished.
+func newRunStore(db *history.DB, collector *results.Collector, opts format.SummaryOptions) *runStore {
+	s := &runStore{
+		db:        db,
+		collector: collector,
+		opts:      opts,
+		wake:      make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+		runs:      make(map[int]int64),
+	}
+	go s.loop()
+	return s
+}
+
+// Handle queues the write of the row evt adds or completes.
+func (s *runStore) Handle(evt results.Event) {
+	// The collector is locked, so its state can be read.
+	var run *results.Run
+	for _, r := range s.collector.State().Runs {
+		if r.ID == evt.RunID {
+			run = r
+		}
+	}
+	if run == nil {
+		return
+	}
+
+	switch evt.Type {
+	case results.EventRunStarted:
+		uid, start := run.UID, run.WallStartTime
+		s.queue(func() error {
+			id, err := s.db.StartRun(uid, start)
+			if err == nil {
+				s.runs[evt.RunID] = id
+			}
+			return err
+		})
+	case results.EventPackageUpdated:
+		pkg := run.Packages[evt.PackageName]
+		if pkg == nil || !finished(evt.Status) {
+			return
+		}
+		p := format.PackageJSON{
+			Name:     pkg.Name,
+			Status:   pkg.Status.String(),
+			Elapsed:  pkg.Elapsed.Seconds(),
+			Passed:   pkg.Counts.Passed,
+			Failed:   pkg.Counts.Failed,
+			Skipped:  pkg.Counts.Skipped,
+			Setup:    pkg.SetupTime.Seconds(),
+			Teardown: pkg.TeardownTime.Seconds(),
+		}
+		s.queueRow(evt.RunID, func(id int64) error { return s.db.AddPackage(id, p) })
+	case results.EventTestUpdated:
+		if !finished(evt.Status) {
+			return
+		}
+		r := format.TestResultJSON{
+			Package:   evt.PackageName,
+			Name:      evt.TestName,
+			Iteration: evt.Iteration,
+			Status:    evt.Status.String(),
+			Elapsed:   evt.Elapsed.Seconds(),
+		}
+		s.queueRow(evt.RunID, func(id int64) error { return s.db.AddTest(id, r) })
+	case results.EventRunFinished:
+		sj := format.NewSummaryJSON(format.ComputeSummary(run, format.WithOptions(s.opts)))
+		s.queueRow(evt.RunID, func(id int64) error { return s.db.FinishRun(id, sj) })
+	}
+}
+
+// finished reports whether a package or test execution with the status
+// has finished.
+func finished(status results.Status) bool {
+	switch status {
+	case results.StatusUnknown, results.StatusRunning, results.StatusPaused:
+		return false
+	}
+	return true
+}
+
+// queueRow queues write with the row ID of the run, skipped if the run
+// couldn't be stored.
+func (s *runStore) queueRow(runID int, write func(id int64) error) {
+	s.queue(func() error {
+		id, ok := s.runs[runID]
+		if !ok {
+			return nil
+		}
+		return write(id)
+	})
+}
+
+// queue queues a write for loop.
+func (s *runStore) queue(write func() error) {
+	s.mu.Lock()
+	s.pending = append(s.pending, write)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop writes the queued rows until Close is called.
+func (s *runStore) loop() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.wake:
+			s.flush()
+		case <-s.quit:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush writes the queued rows.
+func (s *runStore) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, write := range pending {
+		// Only the first error is reported, rather than one per test.
+		if err := write(); err != nil && !s.failed {
+			s.failed = true
+			fmt.Fprintf(os.Stderr, "Error writing history database: %v\n", err)
+		}
+	}
+}
+
+// Close writes the rows still queued and closes the database.
+func (s *runStore) Close() error {
+	close(s.quit)
+	<-s.done
+	return s.db.Close()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+	"github.com/charmbracelet/colorprofile"
+)
+
+// runDiffStreams implements the `tang diff-streams` subcommand, which
+// compares the tests of two recorded go test -json streams.
+func runDiffStreams(args []string) int {
+	fs := flag.NewFlagSet("diff-streams", flag.ContinueOnError)
+	outFormat := fs.String("format", "text", "Output format: text, markdown or json")
+	durationPct := fs.Float64("duration-pct", 50, "Percent change in a test's duration reported (0 disables)")
+	durationAbs := fs.Duration("duration-abs", time.Second, "Absolute change in a test's duration reported (0 disables)")
+	durationAll := fs.Bool("duration-all", false, "Report a duration change only when it exceeds both -duration-pct and -duration-abs, rather than either")
+	noColorFlag := fs.Bool("no-color", false, "Disable all ANSI color and style escape codes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang diff-streams [flags] <before.json> <after.json>\n\n")
+		fmt.Fprintf(os.Stderr, "Compare two recorded go test -json streams, e.g. from -jsonfile, aligning tests by package\n")
+		fmt.Fprintf(os.Stderr, "and name: status changes, duration changes over the thresholds, added and removed tests,\n")
+		fmt.Fprintf(os.Stderr, "and failures with a different message. Exits 1 if the streams differ, 2 on errors.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Error: diff-streams requires two recorded streams\n")
+		return 2
+	}
+	switch *outFormat {
+	case "text", "markdown", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown -format %q, want text, markdown or json\n", *outFormat)
+		return 2
+	}
+
+	before, err := replayStream(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stream: %v\n", err)
+		return 2
+	}
+	after, err := replayStream(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stream: %v\n", err)
+		return 2
+	}
+	diff := format.DiffStreams(before, after, format.RegressionThresholds{
+		Percent:  *durationPct,
+		Absolute: *durationAbs,
+		All:      *durationAll,
+	})
+
+	switch *outFormat {
+	case "markdown":
+		err = format.WriteStreamDiffMarkdown(os.Stdout, diff)
+	case "json":
+		err = format.WriteStreamDiffJSON(os.Stdout, diff)
+	default:
+		profile := colorprofile.Detect(os.Stdout, os.Environ())
+		err = format.WriteStreamDiff(os.Stdout, diff, *noColorFlag || profile == colorprofile.NoTTY)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing diff: %v\n", err)
+		return 2
+	}
+	if !diff.Empty() {
+		return 1
+	}
+	return 0
+}
+
+// replayStream returns the runs of the recorded go test -json stream in
+// the file name.
+func replayStream(name string) ([]*results.Run, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	collector := results.NewCollector()
+	for evt := range engine.NewEngine(engine.WithResync()).Stream(f) {
+		if evt.Type == engine.EventError {
+			return nil, fmt.Errorf("%s: %w", name, evt.Error)
+		}
+		collector.Push(evt)
+	}
+	collector.Finish()
+	return collector.State().Runs, nil
+}
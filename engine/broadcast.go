@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"io"
+	"slices"
+	"sync"
+)
+
+// Broadcaster fans the events of a stream out to several consumers, such as
+// the live display and the output files. Each consumer has its own queue,
+// so one that falls behind, or stops reading, never holds up the others or
+// the stream.
+type Broadcaster struct {
+	subs  []*subscriber
+	sinks sync.WaitGroup
+}
+
+// subscriber queues events for one consumer, delivering them in order on
+// out.
+type subscriber struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []Event
+	closed bool
+	out    chan Event
+}
+
+// NewBroadcaster returns a Broadcaster without consumers.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{}
+}
+
+// Subscribe registers a consumer and returns the channel its events are
+// delivered on, which is closed after the last event. It must be called
+// before Broadcast.
+func (b *Broadcaster) Subscribe() <-chan Event {
+	s := &subscriber{out: make(chan Event)}
+	s.cond = sync.NewCond(&s.mu)
+	b.subs = append(b.subs, s)
+	go s.deliver()
+	return s.out
+}
+
+// AddSink registers a consumer which calls sink with each event, in order,
+// from its own goroutine. It must be called before Broadcast.
+func (b *Broadcaster) AddSink(sink func(Event)) {
+	events := b.Subscribe()
+	b.sinks.Add(1)
+	go func() {
+		defer b.sinks.Done()
+		for evt := range events {
+			sink(evt)
+		}
+	}()
+}
+
+// Broadcast queues each event from src for every consumer, returning when
+// src is closed. The consumers' channels are closed once they've received
+// every event.
+func (b *Broadcaster) Broadcast(src <-chan Event) {
+	for evt := range src {
+		for _, s := range b.subs {
+			s.push(evt)
+		}
+	}
+	for _, s := range b.subs {
+		s.close()
+	}
+}
+
+// Wait waits for the sinks added with AddSink to handle every event. It
+// returns once the stream passed to Broadcast has ended.
+func (b *Broadcaster) Wait() {
+	b.sinks.Wait()
+}
+
+func (s *subscriber) push(evt Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, evt)
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+// deliver sends queued events on out until the subscriber is closed and
+// its queue is empty.
+func (s *subscriber) deliver() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		evt := s.queue[0]
+		s.queue[0] = Event{}
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.out <- evt
+	}
+}
+
+// LineWriter returns a sink for Broadcaster.AddSink which writes the input
+// line of each event of the given types to w, e.g. to save the raw output
+// of go test. With no types, every input line is written. Write errors
+// are ignored.
+func LineWriter(w io.Writer, types ...EventType) func(Event) {
+	return func(evt Event) {
+		if evt.Line == nil || (len(types) > 0 && !slices.Contains(types, evt.Type)) {
+			return
+		}
+		_, _ = w.Write(evt.Line)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcaster(t *testing.T) {
+	input := `not JSON
+{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestFoo"}
+{"ImportPath":"example.com/pkg","Action":"build-output","Output":"ok\n"}
+{"Unrelated":"json"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestFoo","Elapsed":1.5}`
+
+	var raw, json bytes.Buffer
+	b := NewBroadcaster()
+	b.AddSink(LineWriter(&raw))
+	b.AddSink(LineWriter(&json, EventTest, EventBuild, EventOther))
+	first := b.Subscribe()
+	second := b.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		b.Broadcast(NewEngine().Stream(strings.NewReader(input)))
+		close(done)
+	}()
+
+	// The second consumer isn't read from until the stream is over, which
+	// holds up neither the first consumer nor the stream.
+	var types []EventType
+	for evt := range first {
+		types = append(types, evt.Type)
+	}
+	<-done
+	assert.Equal(t, []EventType{EventRawLine, EventTest, EventBuild, EventOther, EventTest, EventComplete}, types)
+
+	var secondTypes []EventType
+	for evt := range second {
+		secondTypes = append(secondTypes, evt.Type)
+	}
+	assert.Equal(t, types, secondTypes)
+
+	b.Wait()
+	assert.Equal(t, input+"\n", raw.String())
+	lines := strings.Split(input, "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, strings.Join(lines[1:], "\n")+"\n", json.String())
+}
+
+func TestBroadcasterWithoutConsumers(t *testing.T) {
+	b := NewBroadcaster()
+	b.Broadcast(NewEngine().Stream(strings.NewReader("line\n")))
+	b.Wait()
+}
@@ -2,7 +2,9 @@ package engine
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"time"
 
 	"github.com/ansel1/tang/parser"
 )
@@ -11,20 +13,31 @@ import (
 type EventType string
 
 const (
-	EventRawLine  EventType = "raw"      // Non-JSON line from input
-	EventTest     EventType = "test"     // Parsed test event from go test -json
-	EventBuild    EventType = "build"    // Parsed build event from go test -json
-	EventError    EventType = "error"    // Error occurred during processing
-	EventComplete EventType = "complete" // Input stream finished
+	EventRawLine    EventType = "raw"        // Non-JSON line from input
+	EventTest       EventType = "test"       // Parsed test event from go test -json
+	EventBuild      EventType = "build"      // Parsed build event from go test -json
+	EventError      EventType = "error"      // Error occurred during processing
+	EventComplete   EventType = "complete"   // Input stream finished
+	EventOther      EventType = "other"      // JSON line that's neither a test nor a build event
+	EventMalformed  EventType = "malformed"  // Corrupted JSON event nothing was recovered from; see WithResync
+	EventHeader     EventType = "header"     // Header of a recording written by tang; see parser.Header
+	EventAnnotation EventType = "annotation" // Checkpoint injected by a wrapping tool; see NewSyntheticEvent
 )
 
 // Event represents a single event emitted by the engine
 type Event struct {
 	Type       EventType
+	Line       []byte            // The input line, for all but EventError and EventComplete
 	RawLine    []byte            // Populated for EventRawLine
 	TestEvent  parser.TestEvent  // Populated for EventTest
 	BuildEvent parser.BuildEvent // Populated for EventBuild
+	Header     parser.Header     // Populated for EventHeader
+	Annotation Annotation        // Populated for EventAnnotation
 	Error      error             // Populated for EventError
+
+	// Malformed marks a test or build event recovered from a corrupted
+	// line; see WithResync.
+	Malformed bool
 }
 
 // Engine processes raw input and broadcasts events
@@ -33,6 +46,8 @@ type Engine struct {
 	// Output writers for pass-through file writing
 	rawWriter  io.Writer
 	jsonWriter io.Writer
+	resync     bool
+	synthetic  <-chan Event
 }
 
 // Option configures the engine
@@ -52,6 +67,18 @@ func WithJSONOutput(w io.Writer) Option {
 	}
 }
 
+// WithResync makes the engine tolerate corrupted JSON events, e.g. lines
+// truncated by a crashed writer. Without it, they're raw lines, which end
+// the current run. With it, an event appended to a corrupted line is
+// parsed, and the corrupted part becomes a test or build event with what
+// fields can be recovered (see parser.RecoverEvent), marked Malformed, or
+// an EventMalformed if nothing can be.
+func WithResync() Option {
+	return func(e *Engine) {
+		e.resync = true
+	}
+}
+
 // NewEngine creates a new event processing engine
 func NewEngine(opts ...Option) *Engine {
 	e := &Engine{}
@@ -69,9 +96,25 @@ func (e *Engine) Stream(input io.Reader) <-chan Event {
 	go func() {
 		defer close(events)
 
+		// Synthetic events are forwarded while the input is read, so
+		// none follows EventComplete.
+		stopSynthetic := func() {}
+		if e.synthetic != nil {
+			done := make(chan struct{})
+			forwarded := make(chan struct{})
+			go e.forwardSynthetic(events, done, forwarded)
+			stopSynthetic = func() {
+				close(done)
+				<-forwarded
+			}
+		}
+
+		var lastTime time.Time // Of the latest event, for notes
 		scanner := bufio.NewScanner(input)
 		for scanner.Scan() {
-			line := scanner.Bytes()
+			// Input written on Windows, e.g. a saved -outfile, may end
+			// lines with CRLF.
+			line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
 
 			// Always write raw output to file if configured
 			if e.rawWriter != nil {
@@ -79,15 +122,39 @@ func (e *Engine) Stream(input io.Reader) <-chan Event {
 				_, _ = e.rawWriter.Write([]byte("\n"))
 			}
 
+			// Make a copy of the line since scanner reuses the buffer
+			lineCopy := make([]byte, len(line))
+			copy(lineCopy, line)
+
+			// A recording's header isn't an event, and isn't copied to
+			// the JSON output, which gets a header of its own.
+			if header, ok := parser.ParseHeader(line); ok {
+				events <- Event{Type: EventHeader, Line: lineCopy, Header: header}
+				continue
+			}
+
+			// A note is timed by the stream, so it's placed right in a
+			// replay, or by the clock before the first event.
+			if text, ok := ParseNote(string(line)); ok {
+				t := lastTime
+				if t.IsZero() {
+					t = time.Now()
+				}
+				events <- Event{Type: EventAnnotation, Line: lineCopy, Annotation: Annotation{Time: t, Text: text}}
+				continue
+			}
+
 			// Try to parse as JSON event (build or test)
 			parsedEvent, err := parser.ParseEvent(line)
+			if err != nil && e.resync && parser.LooksLikeEvent(line) {
+				e.resyncLine(events, lineCopy)
+				continue
+			}
 			if err != nil {
 				// Not a JSON event - emit raw line
-				// Make a copy of the line since scanner reuses the buffer
-				lineCopy := make([]byte, len(line))
-				copy(lineCopy, line)
 				events <- Event{
 					Type:    EventRawLine,
+					Line:    lineCopy,
 					RawLine: lineCopy,
 				}
 				continue
@@ -99,20 +166,12 @@ func (e *Engine) Stream(input io.Reader) <-chan Event {
 				_, _ = e.jsonWriter.Write([]byte("\n"))
 			}
 
-			// Determine event type and emit
-			if parsedEvent.IsBuildEvent() {
-				events <- Event{
-					Type:       EventBuild,
-					BuildEvent: parsedEvent.ToBuildEvent(),
-				}
-			} else if parsedEvent.IsTestEvent() {
-				events <- Event{
-					Type:      EventTest,
-					TestEvent: parsedEvent.ToTestEvent(),
-				}
+			if !parsedEvent.Time.IsZero() {
+				lastTime = parsedEvent.Time
 			}
-			// else: ignore unknown event types
+			events <- newParsedEvent(parsedEvent, lineCopy)
 		}
+		stopSynthetic()
 
 		// Check for scanner errors
 		if err := scanner.Err(); err != nil {
@@ -130,3 +189,43 @@ func (e *Engine) Stream(input io.Reader) <-chan Event {
 
 	return events
 }
+
+// newParsedEvent returns the Event for an event parsed from line.
+func newParsedEvent(parsed parser.Event, line []byte) Event {
+	switch {
+	case parsed.IsBuildEvent():
+		return Event{Type: EventBuild, Line: line, BuildEvent: parsed.ToBuildEvent()}
+	case parsed.IsTestEvent():
+		return Event{Type: EventTest, Line: line, TestEvent: parsed.ToTestEvent()}
+	default:
+		// Unknown event types are only of interest to sinks saving the
+		// input.
+		return Event{Type: EventOther, Line: line}
+	}
+}
+
+// resyncLine emits the events of a corrupted line: what can be recovered
+// from the corrupted part, then any event appended to it.
+func (e *Engine) resyncLine(events chan<- Event, line []byte) {
+	corrupted := line
+	i, appended := parser.Resync(line)
+	if i >= 0 {
+		corrupted = line[:i]
+	}
+
+	if recovered, ok := parser.RecoverEvent(corrupted); ok {
+		evt := newParsedEvent(recovered, corrupted)
+		evt.Malformed = true
+		events <- evt
+	} else {
+		events <- Event{Type: EventMalformed, Line: corrupted}
+	}
+
+	if i >= 0 {
+		if e.jsonWriter != nil {
+			_, _ = e.jsonWriter.Write(line[i:])
+			_, _ = e.jsonWriter.Write([]byte("\n"))
+		}
+		events <- newParsedEvent(appended, line[i:])
+	}
+}
@@ -3,6 +3,8 @@ package engine
 import (
 	"bytes"
 	"errors"
+	"io"
+	"os"
 	"strings"
 	"testing"
 
@@ -117,6 +119,29 @@ Another non-JSON line
 	assert.NotContains(t, output, "Another non-JSON line")
 }
 
+func TestEngine_Stream_RecognizesHeader(t *testing.T) {
+	input := `{"TangSchema":1,"TangVersion":"v1.0.0"}
+{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestFoo"}
+{"TangSchema":99,"Future":true}`
+
+	var jsonBuf bytes.Buffer
+	eng := NewEngine(WithJSONOutput(&jsonBuf))
+	var collected []Event
+	for evt := range eng.Stream(strings.NewReader(input)) {
+		collected = append(collected, evt)
+	}
+
+	require.Len(t, collected, 4)
+	assert.Equal(t, EventHeader, collected[0].Type)
+	assert.Equal(t, parser.Header{Schema: 1, Version: "v1.0.0"}, collected[0].Header)
+	assert.Equal(t, EventTest, collected[1].Type)
+	// Headers from newer versions are recognized too, rather than
+	// mistaken for events.
+	assert.Equal(t, EventHeader, collected[2].Type)
+	assert.Equal(t, 99, collected[2].Header.Schema)
+	assert.NotContains(t, jsonBuf.String(), "TangSchema")
+}
+
 func TestEngine_Stream_BothRawAndJSONOutput(t *testing.T) {
 	input := `Non-JSON line
 {"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestFoo"}`
@@ -250,3 +275,144 @@ func TestEngine_Stream_RealGoTestOutput(t *testing.T) {
 	assert.Equal(t, "pass", testEvents[4].Action)
 	assert.Equal(t, "pass", testEvents[5].Action)
 }
+
+func TestEngine_Stream_Test2JSONWithoutPackage(t *testing.T) {
+	// go tool test2json without -t, driving a test binary: no Time or Package.
+	input := `{"Action":"run","Test":"TestFoo"}
+{"Action":"pass","Test":"TestFoo","Elapsed":0.1}
+{"Action":"pass","Elapsed":0.2}
+{"Unrelated":"json"}`
+
+	eng := NewEngine()
+	var collected []Event
+	for evt := range eng.Stream(strings.NewReader(input)) {
+		collected = append(collected, evt)
+	}
+
+	require.Len(t, collected, 5)
+	for _, evt := range collected[:3] {
+		assert.Equal(t, EventTest, evt.Type)
+		assert.Empty(t, evt.TestEvent.Package)
+	}
+	assert.Equal(t, "TestFoo", collected[0].TestEvent.Test)
+	assert.Equal(t, EventOther, collected[3].Type)
+	assert.Equal(t, `{"Unrelated":"json"}`, string(collected[3].Line))
+	assert.Equal(t, EventComplete, collected[4].Type)
+}
+
+func TestEngine_Stream_CRLF(t *testing.T) {
+	f, err := os.Open("testdata/crlf.jsonl")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var raw bytes.Buffer
+	eng := NewEngine(WithRawOutput(&raw))
+	var testEvents []parser.TestEvent
+	var rawLines []string
+	for evt := range eng.Stream(f) {
+		switch evt.Type {
+		case EventTest:
+			testEvents = append(testEvents, evt.TestEvent)
+		case EventRawLine:
+			rawLines = append(rawLines, string(evt.RawLine))
+		}
+	}
+
+	require.Len(t, testEvents, 5)
+	assert.Equal(t, "fail", testEvents[4].Action)
+	assert.Equal(t, []string{"FAIL"}, rawLines)
+	assert.NotContains(t, raw.String(), "\r\n", "raw output should have LF line endings")
+}
+
+func TestEngine_Stream_Resync(t *testing.T) {
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestFoo"}
+{"Time":"2024-01-01T00:00:00.5Z","Action":"output","Package":"example.com/pkg","Test":"TestFoo","Output":"half a li
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Pack{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestFoo","Elapsed":1}
+{"Time":"2024-01-01T00:00:02Z","Action":"pa
+not JSON`
+
+	collect := func(opts ...Option) []Event {
+		var collected []Event
+		for evt := range NewEngine(opts...).Stream(strings.NewReader(input)) {
+			collected = append(collected, evt)
+		}
+		return collected
+	}
+
+	// Without resync, corrupted events are raw lines.
+	var types []EventType
+	for _, evt := range collect() {
+		types = append(types, evt.Type)
+	}
+	assert.Equal(t, []EventType{EventTest, EventRawLine, EventRawLine, EventRawLine, EventRawLine, EventComplete}, types)
+
+	collected := collect(WithResync())
+	require.Len(t, collected, 7)
+
+	// The truncated output is recovered as far as it goes.
+	assert.Equal(t, EventTest, collected[1].Type)
+	assert.True(t, collected[1].Malformed)
+	assert.Equal(t, "output", collected[1].TestEvent.Action)
+	assert.Equal(t, "TestFoo", collected[1].TestEvent.Test)
+	assert.Equal(t, "half a li", collected[1].TestEvent.Output)
+
+	// A pass cut off before its Test field can't be told from a package
+	// pass, so it isn't recovered, but the event appended to it is parsed.
+	assert.Equal(t, EventMalformed, collected[2].Type)
+	assert.Equal(t, `{"Time":"2024-01-01T00:00:01Z","Action":"pass","Pack`, string(collected[2].Line))
+	assert.Equal(t, EventTest, collected[3].Type)
+	assert.False(t, collected[3].Malformed)
+	assert.Equal(t, "pass", collected[3].TestEvent.Action)
+	assert.Equal(t, "TestFoo", collected[3].TestEvent.Test)
+
+	assert.Equal(t, EventMalformed, collected[4].Type)
+	assert.Equal(t, EventRawLine, collected[5].Type)
+	assert.Equal(t, EventComplete, collected[6].Type)
+}
+
+func TestEngine_Stream_SyntheticEvents(t *testing.T) {
+	input, writer := io.Pipe()
+	synthetic := make(chan Event)
+	events := NewEngine(WithSyntheticEvents(synthetic)).Stream(input)
+
+	_, err := writer.Write([]byte(`{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, EventTest, (<-events).Type)
+
+	synthetic <- NewPackageSyntheticEvent("example.com/pkg", "deploying fixture DB")
+	evt := <-events
+	assert.Equal(t, EventAnnotation, evt.Type)
+	assert.Equal(t, "example.com/pkg", evt.Annotation.Package)
+	assert.Equal(t, "deploying fixture DB", evt.Annotation.Text)
+	assert.False(t, evt.Annotation.Time.IsZero())
+
+	// Once the input is exhausted, synthetic events are no longer read.
+	require.NoError(t, writer.Close())
+	assert.Equal(t, EventComplete, (<-events).Type)
+	_, open := <-events
+	assert.False(t, open)
+	select {
+	case synthetic <- NewSyntheticEvent("too late"):
+		t.Error("Expected synthetic events not to be read after the input ended")
+	default:
+	}
+}
+
+func TestEngine_Stream_Notes(t *testing.T) {
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}
+##tang:note rebuilding the environment
+##tang:note
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"example.com/pkg"}`
+
+	var collected []Event
+	for evt := range NewEngine().Stream(strings.NewReader(input)) {
+		collected = append(collected, evt)
+	}
+
+	require.Len(t, collected, 5)
+	assert.Equal(t, EventAnnotation, collected[1].Type)
+	assert.Equal(t, "rebuilding the environment", collected[1].Annotation.Text)
+	assert.Equal(t, collected[0].TestEvent.Time, collected[1].Annotation.Time, "notes are timed by the stream")
+	assert.Equal(t, "##tang:note rebuilding the environment", string(collected[1].Line))
+	assert.Equal(t, EventRawLine, collected[2].Type, "a note without text is a raw line")
+}
@@ -25,6 +25,7 @@ type ReplayReader struct {
 	bufferPos     int
 	firstRead     bool
 	lastEventTime time.Time
+	clock         Clock
 }
 
 // NewReplayReader creates a new replay reader that simulates timing from test events
@@ -70,9 +71,17 @@ func NewReplayReader(r io.Reader, rate float64) (*ReplayReader, error) {
 		rate:       rate,
 		currentIdx: 0,
 		firstRead:  true,
+		clock:      SystemClock,
 	}, nil
 }
 
+// SetClock sets the clock the delays between lines are waited on, e.g. a
+// ManualClock, so they're simulated rather than slept. It's SystemClock by
+// default.
+func (r *ReplayReader) SetClock(clock Clock) {
+	r.clock = clock
+}
+
 // Read implements io.Reader, returning data line-by-line with timing delays
 func (r *ReplayReader) Read(p []byte) (n int, err error) {
 	// If we're in the middle of returning a line, continue from buffer
@@ -95,7 +104,7 @@ func (r *ReplayReader) Read(p []byte) (n int, err error) {
 		actualDelay := current.timestamp.Sub(r.lastEventTime)
 		if actualDelay > 0 {
 			adjustedDelay := time.Duration(float64(actualDelay) * r.rate)
-			time.Sleep(adjustedDelay)
+			r.clock.Sleep(adjustedDelay)
 		}
 	}
 
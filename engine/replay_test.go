@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayReader_Clock(t *testing.T) {
+	input := strings.Join([]string{
+		`{"Time":"2025-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}`,
+		`not an event`,
+		`{"Time":"2025-01-01T00:00:02Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2025-01-01T00:00:05Z","Action":"pass","Package":"example.com/pkg","Test":"TestA"}`,
+	}, "\n")
+	r, err := NewReplayReader(strings.NewReader(input), 0.5)
+	require.NoError(t, err)
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	r.SetClock(clock)
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, input+"\n", string(data))
+	// The 5s between the first and last events, at half speed, without
+	// sleeping.
+	assert.Equal(t, 2500*time.Millisecond, clock.Now().Sub(start))
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"strings"
+	"time"
+)
+
+// NoteMarker starts a line of the stream which annotates the run, e.g.
+// "##tang:note rebuilding the environment", printed by a test harness
+// between or amid go test's output. Unlike other lines which aren't JSON
+// events, it doesn't end the run.
+const NoteMarker = "##tang:note "
+
+// ParseNote returns the text of a note line, starting with NoteMarker, and
+// whether line is one.
+func ParseNote(line string) (string, bool) {
+	text, ok := strings.CutPrefix(strings.TrimSpace(line), NoteMarker)
+	text = strings.TrimSpace(text)
+	return text, ok && text != ""
+}
+
+// Annotation is a checkpoint in the event stream, e.g. "deploying fixture
+// DB", injected by a tool wrapping tang or noted in the stream itself (see
+// NoteMarker). It's shown as a marker on the run's timeline.
+type Annotation struct {
+	Time    time.Time
+	Package string // The package it concerns, or "" for the whole run
+	Text    string
+}
+
+// NewSyntheticEvent returns an EventAnnotation for the whole run with text,
+// stamped with the current time. Inject it with WithSyntheticEvents, or push
+// it to a collector directly.
+func NewSyntheticEvent(text string) Event {
+	return NewPackageSyntheticEvent("", text)
+}
+
+// NewPackageSyntheticEvent is like NewSyntheticEvent, for an annotation
+// concerning a package.
+func NewPackageSyntheticEvent(pkg, text string) Event {
+	return Event{
+		Type:       EventAnnotation,
+		Annotation: Annotation{Time: time.Now(), Package: pkg, Text: text},
+	}
+}
+
+// WithSyntheticEvents makes Stream interleave the events received from
+// synthetic, e.g. made with NewSyntheticEvent, with the events of its
+// input, until the input is exhausted.
+func WithSyntheticEvents(synthetic <-chan Event) Option {
+	return func(e *Engine) {
+		e.synthetic = synthetic
+	}
+}
+
+// forwardSynthetic sends the engine's synthetic events to events until
+// synthetic is closed or done is, then closes forwarded.
+func (e *Engine) forwardSynthetic(events chan<- Event, done <-chan struct{}, forwarded chan<- struct{}) {
+	defer close(forwarded)
+	for {
+		select {
+		case evt, ok := <-e.synthetic:
+			if !ok {
+				return
+			}
+			events <- evt
+		case <-done:
+			return
+		}
+	}
+}
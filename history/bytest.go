@@ -0,0 +1,148 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// MaxTestNames is the number of test names listed by FormatByTest.
+const MaxTestNames = 20
+
+// NameStats aggregates the executions of the tests sharing a name across
+// packages, e.g. a TestIntegration in every service package, to find
+// patterns which are slow or fail in many packages.
+type NameStats struct {
+	Name     string
+	Packages []string // Packages with a test of this name, sorted
+	Runs     int      // Executions across all packages and runs
+	Passed   int
+	Failed   int
+	Skipped  int
+	Total    time.Duration // Total elapsed time of the executions
+	Max      time.Duration // Longest execution
+}
+
+// Rate returns the fraction of executions which failed.
+func (n *NameStats) Rate() float64 {
+	if n.Runs == 0 {
+		return 0
+	}
+	return float64(n.Failed) / float64(n.Runs)
+}
+
+// Mean returns the mean elapsed time of the executions.
+func (n *NameStats) Mean() time.Duration {
+	if n.Runs == 0 {
+		return 0
+	}
+	return n.Total / time.Duration(n.Runs)
+}
+
+// ComputeByTest groups the test results of the given runs by test name,
+// ignoring the package. Only names found in more than one package are
+// returned, most failures first, then slowest in total.
+func ComputeByTest(runs []*format.SummaryJSON) []*NameStats {
+	names := make(map[string]*NameStats)
+	pkgs := make(map[string]map[string]bool)
+	for _, run := range runs {
+		for _, tr := range run.Results {
+			ns := names[tr.Name]
+			if ns == nil {
+				ns = &NameStats{Name: tr.Name}
+				names[tr.Name] = ns
+				pkgs[tr.Name] = make(map[string]bool)
+			}
+			if !pkgs[tr.Name][tr.Package] {
+				pkgs[tr.Name][tr.Package] = true
+				ns.Packages = append(ns.Packages, tr.Package)
+			}
+			ns.Runs++
+			elapsed := time.Duration(tr.Elapsed * float64(time.Second))
+			ns.Total += elapsed
+			ns.Max = max(ns.Max, elapsed)
+			switch tr.Status {
+			case results.StatusPassed.String():
+				ns.Passed++
+			case results.StatusFailed.String():
+				ns.Failed++
+			case results.StatusSkipped.String():
+				ns.Skipped++
+			}
+		}
+	}
+
+	var stats []*NameStats
+	for _, ns := range names {
+		if len(ns.Packages) > 1 {
+			sort.Strings(ns.Packages)
+			stats = append(stats, ns)
+		}
+	}
+	sortNameStats(stats)
+	return stats
+}
+
+// sortNameStats sorts stats by most failures, then slowest in total.
+func sortNameStats(stats []*NameStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Failed != b.Failed {
+			return a.Failed > b.Failed
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Name < b.Name
+	})
+}
+
+// FormatByTest writes a table of the tests sharing a name across packages,
+// as computed by ComputeByTest, over the given number of runs.
+func FormatByTest(w io.Writer, stats []*NameStats, runs int, noColor bool) error {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	boldWhite := lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
+	if noColor {
+		failStyle = lipgloss.NewStyle()
+		boldWhite = lipgloss.NewStyle()
+	}
+
+	if runs == 0 {
+		_, err := fmt.Fprintln(w, "No runs recorded.")
+		return err
+	}
+	if len(stats) == 0 {
+		_, err := fmt.Fprintln(w, "No test names found in more than one package.")
+		return err
+	}
+	stats = stats[:min(len(stats), MaxTestNames)]
+
+	maxNameLen := len("TEST")
+	for _, ns := range stats {
+		maxNameLen = max(maxNameLen, len(ns.Name))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(boldWhite.Render(fmt.Sprintf("TESTS BY NAME ACROSS PACKAGES (last %d runs)", runs)))
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "%s%-*s  %4s  %5s  %6s  %7s  %5s  %8s  %8s\n", format.IndentLevel, maxNameLen, "TEST", "PKGS", "RUNS", "FAILED", "SKIPPED", "RATE", "MEAN", "MAX")
+	for _, ns := range stats {
+		failed := fmt.Sprintf("%6d", ns.Failed)
+		rate := fmt.Sprintf("%4.0f%%", ns.Rate()*100)
+		if ns.Failed > 0 {
+			failed = failStyle.Render(failed)
+			rate = failStyle.Render(rate)
+		}
+		fmt.Fprintf(&sb, "%s%-*s  %4d  %5d  %s  %7d  %s  %7.2fs  %7.2fs\n", format.IndentLevel, maxNameLen, ns.Name,
+			len(ns.Packages), ns.Runs, failed, ns.Skipped, rate, ns.Mean().Seconds(), ns.Max.Seconds())
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,388 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+	_ "modernc.org/sqlite" // Pure Go, so tang builds without cgo
+)
+
+// dbSchema creates the tables of a history database, if they don't exist.
+// A run's scalar fields are columns, for querying; the rest of its
+// format.SummaryJSON, e.g. labels, is kept as JSON in runs.extra.
+const dbSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id         INTEGER PRIMARY KEY,
+	run_id     TEXT NOT NULL DEFAULT '',
+	start_time TEXT NOT NULL DEFAULT '',
+	status     TEXT NOT NULL,
+	tests      INTEGER NOT NULL,
+	passed     INTEGER NOT NULL,
+	failed     INTEGER NOT NULL,
+	skipped    INTEGER NOT NULL,
+	elapsed    REAL NOT NULL,
+	extra      TEXT NOT NULL DEFAULT '{}'
+);
+CREATE TABLE IF NOT EXISTS packages (
+	run      INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	seq      INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	status   TEXT NOT NULL,
+	elapsed  REAL NOT NULL,
+	passed   INTEGER NOT NULL,
+	failed   INTEGER NOT NULL,
+	skipped  INTEGER NOT NULL,
+	setup    REAL NOT NULL DEFAULT 0,
+	teardown REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (run, seq)
+);
+CREATE TABLE IF NOT EXISTS tests (
+	run        INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	seq        INTEGER NOT NULL,
+	package    TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	iteration  INTEGER NOT NULL,
+	attempt    INTEGER NOT NULL DEFAULT 0,
+	status     TEXT NOT NULL,
+	elapsed    REAL NOT NULL,
+	categories TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run, seq)
+);
+CREATE INDEX IF NOT EXISTS packages_name ON packages (name, run);
+CREATE UNIQUE INDEX IF NOT EXISTS packages_run ON packages (run, name);
+CREATE INDEX IF NOT EXISTS tests_name ON tests (package, name, run);
+CREATE UNIQUE INDEX IF NOT EXISTS tests_execution ON tests (run, package, name, iteration);
+CREATE INDEX IF NOT EXISTS tests_failed ON tests (status, run);
+`
+
+// runExtra holds the fields of a format.SummaryJSON without columns of
+// their own in the runs table.
+type runExtra struct {
+	Labels       map[string]string `json:"labels,omitempty"`
+	Modes        []string          `json:"modes,omitempty"`
+	Malformed    int               `json:"malformed_lines,omitempty"`
+	Interrupted  int               `json:"interrupted,omitempty"`
+	Inconsistent []string          `json:"inconsistencies,omitempty"`
+	Anomalies    []string          `json:"anomalies,omitempty"`
+	Suspicious   []string          `json:"suspicious,omitempty"`
+	Repro        *format.ReproJSON `json:"repro,omitempty"`
+}
+
+// DB is a history store in a SQLite database, an alternative to the JSON
+// Lines file read by Load for long histories: runs are written as they
+// progress, and read back and aggregated with indexed queries rather than
+// a scan of every run recorded.
+type DB struct {
+	db *sql.DB
+}
+
+// OpenDB opens the SQLite history database at path, creating it and its
+// tables if needed.
+func OpenDB(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite serializes writers; one connection avoids "database is
+	// locked" errors between tang's own goroutines.
+	db.SetMaxOpenConns(1)
+	// Rows are written as tests finish, each in its own transaction; with
+	// a write-ahead log, committing one doesn't wait for the disk.
+	if _, err := db.Exec(`PRAGMA journal_mode = WAL; PRAGMA synchronous = NORMAL`); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(dbSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating history tables: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// execer is the Exec method shared by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Append adds a run summary to the database, in one transaction, so a run
+// is recorded whole or not at all.
+func (d *DB) Append(sj *format.SummaryJSON) (err error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	run, err := insertRun(tx, sj.RunID, sj.StartTime, sj.Status)
+	if err != nil {
+		return err
+	}
+	if err := writeRun(tx, run, sj, true); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// StartRun adds a run which has just started to the database, with the
+// given run ID and start time, and returns its row ID, for AddPackage,
+// AddTest and FinishRun. Until FinishRun is called, its status is
+// "running", so a run cut short by tang being killed is recorded as far
+// as it got.
+func (d *DB) StartRun(runID string, start time.Time) (int64, error) {
+	return insertRun(d.db, runID, start, results.StatusRunning.String())
+}
+
+// AddPackage records a finished package of a started run, replacing its
+// earlier record, if any.
+func (d *DB) AddPackage(run int64, p format.PackageJSON) error {
+	return addPackage(d.db, run, p)
+}
+
+// AddTest records a finished test execution of a started run, replacing
+// its earlier record, if any, e.g. of a parent test reported passed before
+// one of its subtests failed.
+func (d *DB) AddTest(run int64, r format.TestResultJSON) error {
+	return addTest(d.db, run, r)
+}
+
+// FinishRun completes the record of a started run from its summary, in one
+// transaction: the run's totals and other fields, its packages, whose
+// counts only leave out parents failed by their subtests once the run is
+// summarized, and its tests with failure categories or package attempts.
+// Its other tests are expected to have been recorded by AddTest.
+func (d *DB) FinishRun(run int64, sj *format.SummaryJSON) (err error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err := writeRun(tx, run, sj, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRun adds a run with no results to the runs table and returns its
+// row ID.
+func insertRun(db execer, runID string, start time.Time, status string) (int64, error) {
+	res, err := db.Exec(`INSERT INTO runs (run_id, start_time, status, tests, passed, failed, skipped, elapsed)
+		VALUES (?, ?, ?, 0, 0, 0, 0, 0)`, runID, formatStartTime(start), status)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// formatStartTime formats a run's start time for the runs table, empty if
+// it's unknown.
+func formatStartTime(start time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	return start.UTC().Format(time.RFC3339Nano)
+}
+
+// writeRun sets the fields of run from sj and records its packages, and
+// its tests: all of them, or with allTests false only those AddTest
+// couldn't record whole.
+func writeRun(db execer, run int64, sj *format.SummaryJSON, allTests bool) error {
+	extra, err := json.Marshal(runExtra{
+		Labels:       sj.Labels,
+		Modes:        sj.Modes,
+		Malformed:    sj.Malformed,
+		Interrupted:  sj.Interrupted,
+		Inconsistent: sj.Inconsistent,
+		Anomalies:    sj.Anomalies,
+		Suspicious:   sj.Suspicious,
+		Repro:        sj.Repro,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`UPDATE runs SET run_id = ?, start_time = ?, status = ?, tests = ?, passed = ?, failed = ?,
+			skipped = ?, elapsed = ?, extra = ?
+		WHERE id = ?`,
+		sj.RunID, formatStartTime(sj.StartTime), sj.Status, sj.Tests, sj.Passed, sj.Failed, sj.Skipped, sj.Elapsed,
+		string(extra), run); err != nil {
+		return err
+	}
+	for _, p := range sj.Packages {
+		if err := addPackage(db, run, p); err != nil {
+			return err
+		}
+	}
+	for _, r := range sj.Results {
+		if !allTests && len(r.Categories) == 0 && r.Attempt == 0 {
+			continue
+		}
+		if err := addTest(db, run, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPackage inserts or replaces a package of run. New packages are
+// numbered in the order they're added.
+func addPackage(db execer, run int64, p format.PackageJSON) error {
+	_, err := db.Exec(`INSERT INTO packages (run, seq, name, status, elapsed, passed, failed, skipped, setup, teardown)
+		VALUES (?, (SELECT coalesce(max(seq) + 1, 0) FROM packages WHERE run = ?), ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run, name) DO UPDATE SET status = excluded.status, elapsed = excluded.elapsed,
+			passed = excluded.passed, failed = excluded.failed, skipped = excluded.skipped,
+			setup = excluded.setup, teardown = excluded.teardown`,
+		run, run, p.Name, p.Status, p.Elapsed, p.Passed, p.Failed, p.Skipped, p.Setup, p.Teardown)
+	return err
+}
+
+// addTest inserts or replaces a test execution of run. New executions are
+// numbered in the order they're added.
+func addTest(db execer, run int64, r format.TestResultJSON) error {
+	categories, err := json.Marshal(r.Categories)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO tests (run, seq, package, name, iteration, attempt, status, elapsed, categories)
+		VALUES (?, (SELECT coalesce(max(seq) + 1, 0) FROM tests WHERE run = ?), ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run, package, name, iteration) DO UPDATE SET attempt = excluded.attempt,
+			status = excluded.status, elapsed = excluded.elapsed, categories = excluded.categories`,
+		run, run, r.Package, r.Name, r.Iteration, r.Attempt, r.Status, r.Elapsed, string(categories))
+	return err
+}
+
+// Load returns the most recent n runs in chronological order, like the
+// package-level Load. If n <= 0, all runs are returned.
+func (d *DB) Load(n int) ([]*format.SummaryJSON, error) {
+	limit := -1 // No limit, to SQLite
+	if n > 0 {
+		limit = n
+	}
+	rows, err := d.db.Query(`SELECT id, run_id, start_time, status, tests, passed, failed, skipped, elapsed, extra
+		FROM runs ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	byID := make(map[int64]*format.SummaryJSON)
+	for rows.Next() {
+		var (
+			id           int64
+			start, extra string
+			sj           format.SummaryJSON
+		)
+		if err := rows.Scan(&id, &sj.RunID, &start, &sj.Status, &sj.Tests, &sj.Passed, &sj.Failed, &sj.Skipped, &sj.Elapsed, &extra); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if start != "" {
+			if sj.StartTime, err = time.Parse(time.RFC3339Nano, start); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("history run %d: %w", id, err)
+			}
+		}
+		var x runExtra
+		if err := json.Unmarshal([]byte(extra), &x); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("history run %d: %w", id, err)
+		}
+		sj.Labels, sj.Modes, sj.Malformed, sj.Interrupted = x.Labels, x.Modes, x.Malformed, x.Interrupted
+		sj.Inconsistent, sj.Anomalies, sj.Suspicious = x.Inconsistent, x.Anomalies, x.Suspicious
+		sj.Repro = x.Repro
+		sj.Packages = []format.PackageJSON{}
+		sj.Results = []format.TestResultJSON{}
+		ids = append(ids, id)
+		byID[id] = &sj
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	oldest := ids[len(ids)-1]
+
+	if err := d.loadPackages(oldest, byID); err != nil {
+		return nil, err
+	}
+	if err := d.loadTests(oldest, byID); err != nil {
+		return nil, err
+	}
+
+	runs := make([]*format.SummaryJSON, 0, len(ids))
+	for _, id := range slices.Backward(ids) {
+		runs = append(runs, byID[id])
+	}
+	return runs, nil
+}
+
+// loadPackages adds the packages of the runs from oldest on to byID.
+func (d *DB) loadPackages(oldest int64, byID map[int64]*format.SummaryJSON) error {
+	rows, err := d.db.Query(`SELECT run, name, status, elapsed, passed, failed, skipped, setup, teardown
+		FROM packages WHERE run >= ? ORDER BY run, seq`, oldest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			run int64
+			p   format.PackageJSON
+		)
+		if err := rows.Scan(&run, &p.Name, &p.Status, &p.Elapsed, &p.Passed, &p.Failed, &p.Skipped, &p.Setup, &p.Teardown); err != nil {
+			return err
+		}
+		if sj := byID[run]; sj != nil {
+			sj.Packages = append(sj.Packages, p)
+		}
+	}
+	return rows.Err()
+}
+
+// loadTests adds the test results of the runs from oldest on to byID.
+func (d *DB) loadTests(oldest int64, byID map[int64]*format.SummaryJSON) error {
+	rows, err := d.db.Query(`SELECT run, package, name, iteration, attempt, status, elapsed, categories
+		FROM tests WHERE run >= ? ORDER BY run, seq`, oldest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			run        int64
+			categories string
+			r          format.TestResultJSON
+		)
+		if err := rows.Scan(&run, &r.Package, &r.Name, &r.Iteration, &r.Attempt, &r.Status, &r.Elapsed, &categories); err != nil {
+			return err
+		}
+		if categories != "" {
+			if err := json.Unmarshal([]byte(categories), &r.Categories); err != nil {
+				return fmt.Errorf("history run %d: %w", run, err)
+			}
+		}
+		if sj := byID[run]; sj != nil {
+			sj.Results = append(sj.Results, r)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,185 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := OpenDB(filepath.Join(t.TempDir(), "tang.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestDBAppendAndLoad(t *testing.T) {
+	db := openTestDB(t)
+
+	runs, err := db.Load(0)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := &format.SummaryJSON{
+		RunID:     "20240102-030405-aaaaaa",
+		StartTime: start,
+		Status:    "failed",
+		Tests:     2,
+		Passed:    1,
+		Failed:    1,
+		Elapsed:   1.5,
+		Labels:    map[string]string{"job": "42"},
+		Repro:     &format.ReproJSON{Args: []string{"./..."}},
+		Packages: []format.PackageJSON{
+			{Name: "pkg/a", Status: "failed", Elapsed: 1.5, Passed: 1, Failed: 1, Setup: 0.25},
+		},
+		Results: []format.TestResultJSON{
+			{Package: "pkg/a", Name: "TestA", Iteration: 1, Status: "passed", Elapsed: 0.5},
+			{Package: "pkg/a", Name: "TestB", Iteration: 1, Status: "failed", Elapsed: 1, Categories: []string{"timeout"}},
+		},
+	}
+	require.NoError(t, db.Append(want))
+	for _, status := range []string{"passed", "passed"} {
+		require.NoError(t, db.Append(&format.SummaryJSON{Status: status}))
+	}
+
+	runs, err = db.Load(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.Equal(t, want, runs[0])
+
+	runs, err = db.Load(2)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Empty(t, runs[0].RunID)
+	assert.Equal(t, []format.PackageJSON{}, runs[0].Packages)
+}
+
+func TestDBIncremental(t *testing.T) {
+	db := openTestDB(t)
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	run, err := db.StartRun("20240102-030405-aaaaaa", start)
+	require.NoError(t, err)
+
+	// A parent reported passed, then failed by its subtest.
+	require.NoError(t, db.AddTest(run, format.TestResultJSON{Package: "pkg/a", Name: "TestA", Iteration: 1, Status: "passed", Elapsed: 1}))
+	require.NoError(t, db.AddTest(run, format.TestResultJSON{Package: "pkg/a", Name: "TestA/sub", Iteration: 1, Status: "failed", Elapsed: 1}))
+	require.NoError(t, db.AddTest(run, format.TestResultJSON{Package: "pkg/a", Name: "TestA", Iteration: 1, Status: "failed", Elapsed: 1}))
+	require.NoError(t, db.AddPackage(run, format.PackageJSON{Name: "pkg/a", Status: "failed", Elapsed: 2, Failed: 2}))
+
+	// Until it finishes, the run is recorded as far as it got.
+	runs, err := db.Load(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "running", runs[0].Status)
+	assert.Equal(t, start, runs[0].StartTime)
+	assert.Equal(t, []format.TestResultJSON{
+		{Package: "pkg/a", Name: "TestA", Iteration: 1, Status: "failed", Elapsed: 1},
+		{Package: "pkg/a", Name: "TestA/sub", Iteration: 1, Status: "failed", Elapsed: 1},
+	}, runs[0].Results)
+
+	sj := &format.SummaryJSON{
+		RunID:     "20240102-030405-aaaaaa",
+		StartTime: start.Add(time.Second),
+		Status:    "failed",
+		Tests:     2,
+		Failed:    1,
+		Elapsed:   2,
+		Packages:  []format.PackageJSON{{Name: "pkg/a", Status: "failed", Elapsed: 2, Failed: 1}},
+		Results: []format.TestResultJSON{
+			{Package: "pkg/a", Name: "TestA", Iteration: 1, Status: "failed", Elapsed: 1},
+			{Package: "pkg/a", Name: "TestA/sub", Iteration: 1, Status: "failed", Elapsed: 1, Categories: []string{"panic"}},
+		},
+	}
+	require.NoError(t, db.FinishRun(run, sj))
+
+	runs, err = db.Load(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, sj, runs[0])
+}
+
+func TestDBStats(t *testing.T) {
+	pass := func(name string) format.TestResultJSON {
+		return format.TestResultJSON{Package: "pkg/a", Name: name, Status: "passed"}
+	}
+	fail := func(name string) format.TestResultJSON {
+		return format.TestResultJSON{Package: "pkg/a", Name: name, Status: "failed"}
+	}
+	runs := []*format.SummaryJSON{
+		summaryJSON(map[string]string{"pkg/b": "passed"}, pass("TestFlaky"), pass("TestOld")),
+		summaryJSON(map[string]string{"pkg/a": "failed", "pkg/b": "passed"}, fail("TestFlaky"), fail("TestBroken")),
+		summaryJSON(map[string]string{"pkg/a": "passed"}, pass("TestFlaky"), fail("TestBroken")),
+		summaryJSON(map[string]string{"pkg/a": "failed", "pkg/b": "build failed"}, pass("TestFlaky"), fail("TestBroken")),
+	}
+	runs[0].Packages[0].Passed = 7
+	runs[1].Packages[1].Passed = 9
+	runs[3].Packages[1].Passed = 3
+	runs[0].Results = append(runs[0].Results, fail("TestOld"))
+	runs[0].Results[2].Iteration = 2
+
+	db := openTestDB(t)
+	for _, sj := range runs {
+		require.NoError(t, db.Append(sj))
+	}
+
+	for n, want := range map[int][]*format.SummaryJSON{0: runs, 3: runs[1:]} {
+		stats, err := db.Stats(n)
+		require.NoError(t, err)
+		assert.Equal(t, ComputeStats(want), stats, "last %d runs", n)
+	}
+
+	stats, err := db.Stats(3)
+	require.NoError(t, err)
+	require.Len(t, stats.Packages, 2)
+	assert.Equal(t, "x.x", string(stats.Packages[0].History))
+	assert.Equal(t, ". x", string(stats.Packages[1].History))
+	assert.Equal(t, 3, stats.Packages[1].Tests)
+	assert.Equal(t, 9, stats.Packages[1].MaxTests)
+	require.Len(t, stats.Flaky, 1)
+	assert.Equal(t, "TestFlaky", stats.Flaky[0].Name)
+
+	empty, err := openTestDB(t).Stats(0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Runs)
+}
+
+func TestDBByTest(t *testing.T) {
+	result := func(pkg, name, status string, elapsed float64) format.TestResultJSON {
+		return format.TestResultJSON{Package: pkg, Name: name, Status: status, Elapsed: elapsed}
+	}
+	runs := []*format.SummaryJSON{
+		{Results: []format.TestResultJSON{
+			result("svc/a", "TestIntegration", "passed", 4),
+			result("svc/b", "TestIntegration", "failed", 6),
+			result("svc/a", "TestSlow", "passed", 20),
+			result("svc/b", "TestSlow", "skipped", 0),
+			result("svc/a", "TestOnlyHere", "failed", 1),
+		}},
+		{Results: []format.TestResultJSON{
+			result("svc/c", "TestIntegration", "passed", 2),
+		}},
+	}
+
+	db := openTestDB(t)
+	for _, sj := range runs {
+		require.NoError(t, db.Append(sj))
+	}
+
+	stats, n, err := db.ByTest(0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, ComputeByTest(runs), stats)
+
+	stats, n, err = db.ByTest(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, stats, "only svc/c in the last run")
+}
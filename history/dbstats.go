@@ -0,0 +1,174 @@
+package history
+
+import (
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// recentRuns returns the row IDs of the most recent n runs, or all runs if
+// n <= 0, in chronological order.
+func (d *DB) recentRuns(n int) ([]int64, error) {
+	limit := -1 // No limit, to SQLite
+	if n > 0 {
+		limit = n
+	}
+	rows, err := d.db.Query(`SELECT id FROM runs ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	slices.Reverse(ids)
+	return ids, rows.Err()
+}
+
+// Stats is ComputeStats for the most recent n runs of the database, or
+// all of them if n <= 0, aggregated by SQLite rather than loaded.
+func (d *DB) Stats(n int) (*Stats, error) {
+	ids, err := d.recentRuns(n)
+	if err != nil || len(ids) == 0 {
+		return &Stats{}, err
+	}
+	stats := &Stats{Runs: len(ids)}
+	oldest := ids[0]
+	index := make(map[int64]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	failed, buildFailed := failedStatuses[0], failedStatuses[1]
+
+	pkgs := make(map[string]*PackageStats)
+	rows, err := d.db.Query(`SELECT name, count(*), sum(status IN (?, ?)), max(passed + failed + skipped)
+		FROM packages WHERE run >= ? GROUP BY name`, failed, buildFailed, oldest)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		ps := &PackageStats{History: []rune(strings.Repeat(" ", len(ids)))}
+		if err := rows.Scan(&ps.Name, &ps.Runs, &ps.Failed, &ps.MaxTests); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		pkgs[ps.Name] = ps
+		stats.Packages = append(stats.Packages, ps)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	// The history strips, and the test count of each package's latest run.
+	rows, err = d.db.Query(`SELECT name, run, status IN (?, ?), passed + failed + skipped
+		FROM packages WHERE run >= ? ORDER BY run`, failed, buildFailed, oldest)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			name      string
+			run       int64
+			pkgFailed bool
+			tests     int
+		)
+		if err := rows.Scan(&name, &run, &pkgFailed, &tests); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		ps, i := pkgs[name], index[run]
+		if ps == nil {
+			continue
+		}
+		ps.History[i] = '.'
+		if pkgFailed {
+			ps.History[i] = 'x'
+		}
+		ps.Tests = tests
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	passed := results.StatusPassed.String()
+	rows, err = d.db.Query(`SELECT package, name, sum(status = ?), sum(status = ?)
+		FROM tests WHERE run >= ? GROUP BY package, name
+		HAVING sum(status = ?) > 0 AND sum(status = ?) > 0`, passed, failed, oldest, passed, failed)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		ts := &TestStats{}
+		if err := rows.Scan(&ts.Package, &ts.Name, &ts.Passed, &ts.Failed); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		stats.Flaky = append(stats.Flaky, ts)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	stats.sort()
+	return stats, nil
+}
+
+// ByTest is ComputeByTest for the most recent n runs of the database, or
+// all of them if n <= 0, aggregated by SQLite rather than loaded. It also
+// returns the number of runs, for FormatByTest.
+func (d *DB) ByTest(n int) ([]*NameStats, int, error) {
+	ids, err := d.recentRuns(n)
+	if err != nil || len(ids) == 0 {
+		return nil, 0, err
+	}
+	rows, err := d.db.Query(`SELECT name, group_concat(DISTINCT package), count(*),
+			sum(status = ?), sum(status = ?), sum(status = ?), total(elapsed), max(elapsed)
+		FROM tests WHERE run >= ? GROUP BY name HAVING count(DISTINCT package) > 1`,
+		results.StatusPassed.String(), results.StatusFailed.String(), results.StatusSkipped.String(), ids[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []*NameStats
+	for rows.Next() {
+		var (
+			ns             NameStats
+			packages       string
+			total, longest float64
+		)
+		if err := rows.Scan(&ns.Name, &packages, &ns.Runs, &ns.Passed, &ns.Failed, &ns.Skipped, &total, &longest); err != nil {
+			return nil, 0, err
+		}
+		// Go import paths can't contain commas, group_concat's separator.
+		ns.Packages = strings.Split(packages, ",")
+		sort.Strings(ns.Packages)
+		ns.Total = time.Duration(total * float64(time.Second))
+		ns.Max = time.Duration(longest * float64(time.Second))
+		stats = append(stats, &ns)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	sortNameStats(stats)
+	return stats, len(ids), nil
+}
+
+// closeRows closes rows, returning the error which ended their iteration,
+// if any.
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// TestRecord is a test's result in one recorded run.
+type TestRecord struct {
+	Status  string        // As in format.TestResultJSON; "" if the test didn't run
+	Elapsed time.Duration // Of its longest execution
+}
+
+// Failed reports whether the test failed in the run.
+func (r TestRecord) Failed() bool {
+	return r.Status == results.StatusFailed.String()
+}
+
+// ForTest returns the results of a test in each of the given runs, in the
+// same order. A test which ran several times in a run, e.g. with -count=N,
+// failed if any execution did.
+func ForTest(runs []*format.SummaryJSON, pkg, name string) []TestRecord {
+	records := make([]TestRecord, len(runs))
+	for i, run := range runs {
+		for _, tr := range run.Results {
+			if tr.Package != pkg || tr.Name != name {
+				continue
+			}
+			r := &records[i]
+			if r.Status == "" || tr.Status == results.StatusFailed.String() {
+				r.Status = tr.Status
+			}
+			r.Elapsed = max(r.Elapsed, time.Duration(tr.Elapsed*float64(time.Second)))
+		}
+	}
+	return records
+}
+
+// Trend summarizes a test's records, oldest first, in one line: a strip
+// of its results, a sparkline of its durations, and whether a failure in
+// the current run (failedNow) is new or chronic, e.g.
+//
+//	✓✓✗✓✓  ▁▂█▁▂  failed 1 of 5 runs
+func Trend(records []TestRecord, failedNow bool) string {
+	var strip, spark strings.Builder
+	var longest time.Duration
+	ran, failed := 0, 0
+	for _, r := range records {
+		longest = max(longest, r.Elapsed)
+		if r.Status != "" {
+			ran++
+		}
+		if r.Failed() {
+			failed++
+		}
+	}
+	if ran == 0 {
+		return "no earlier runs"
+	}
+	for _, r := range records {
+		switch {
+		case r.Status == "":
+			strip.WriteString("·")
+			spark.WriteString(" ")
+			continue
+		case r.Failed():
+			strip.WriteString(format.SymbolFail)
+		case r.Status == results.StatusSkipped.String():
+			strip.WriteString(format.SymbolSkip)
+		default:
+			strip.WriteString(format.SymbolPass)
+		}
+		level := 0.0
+		if longest > 0 {
+			level = float64(r.Elapsed) / float64(longest)
+		}
+		spark.WriteRune(format.SparkBlock(level))
+	}
+
+	verdict := fmt.Sprintf("failed %d of %d runs", failed, ran)
+	if failedNow && failed == 0 {
+		verdict = "new failure"
+	}
+	return strip.String() + "  " + spark.String() + "  " + verdict
+}
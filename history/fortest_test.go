@@ -0,0 +1,36 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForTestAndTrend(t *testing.T) {
+	result := func(name, status string, elapsed float64) format.TestResultJSON {
+		return format.TestResultJSON{Package: "pkg/a", Name: name, Status: status, Elapsed: elapsed}
+	}
+	runs := []*format.SummaryJSON{
+		summaryJSON(nil, result("TestA", "passed", 1)),
+		summaryJSON(nil, result("TestB", "passed", 1)),
+		// With -count=2, one failed execution fails the run.
+		summaryJSON(nil, result("TestA", "failed", 4), result("TestA", "passed", 2)),
+		summaryJSON(nil, result("TestA", "skipped", 0)),
+	}
+
+	records := ForTest(runs, "pkg/a", "TestA")
+	assert.Equal(t, []TestRecord{
+		{Status: "passed", Elapsed: time.Second},
+		{},
+		{Status: "failed", Elapsed: 4 * time.Second},
+		{Status: "skipped"},
+	}, records)
+	assert.Equal(t, "✓·✗∅  ▃ █▁  failed 1 of 3 runs", Trend(records, true))
+
+	records = ForTest(runs, "pkg/a", "TestB")
+	assert.Equal(t, "·✓··   █    new failure", Trend(records, true))
+	assert.Equal(t, "·✓··   █    failed 0 of 1 runs", Trend(records, false))
+	assert.Equal(t, "no earlier runs", Trend(ForTest(runs, "pkg/b", "TestA"), true))
+}
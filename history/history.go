@@ -0,0 +1,78 @@
+// Package history records run summaries to a local store and reports
+// trends across them.
+//
+// The store is a JSON Lines file: each line is a format.SummaryJSON for one
+// run, appended in chronological order.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ansel1/tang/output/format"
+)
+
+// Append adds a run summary to the history file at path, creating the file
+// and its parent directories if needed.
+func Append(path string, sj *format.SummaryJSON) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(sj); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the history file at path and returns the most recent n runs in
+// chronological order. If n <= 0, all runs are returned. A missing file is
+// an empty history.
+func Load(path string, n int) ([]*format.SummaryJSON, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return Read(f, n)
+}
+
+// Read is like Load but reads the history from r.
+func Read(r io.Reader, n int) ([]*format.SummaryJSON, error) {
+	var runs []*format.SummaryJSON
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var sj format.SummaryJSON
+		if err := json.Unmarshal(scanner.Bytes(), &sj); err != nil {
+			return nil, fmt.Errorf("history line %d: %w", line, err)
+		}
+		runs = append(runs, &sj)
+		if n > 0 && len(runs) > n {
+			runs = runs[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,126 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func summaryJSON(pkgStatus map[string]string, tests ...format.TestResultJSON) *format.SummaryJSON {
+	sj := &format.SummaryJSON{Results: tests}
+	for _, name := range []string{"pkg/a", "pkg/b"} {
+		if status, ok := pkgStatus[name]; ok {
+			sj.Packages = append(sj.Packages, format.PackageJSON{Name: name, Status: status})
+		}
+	}
+	return sj
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+
+	runs, err := Load(path, 0)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	for _, status := range []string{"passed", "failed", "passed"} {
+		require.NoError(t, Append(path, &format.SummaryJSON{Status: status}))
+	}
+
+	runs, err = Load(path, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+
+	runs, err = Load(path, 2)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "failed", runs[0].Status)
+	assert.Equal(t, "passed", runs[1].Status)
+}
+
+func TestComputeStats(t *testing.T) {
+	pass := func(name string) format.TestResultJSON {
+		return format.TestResultJSON{Package: "pkg/a", Name: name, Status: "passed"}
+	}
+	fail := func(name string) format.TestResultJSON {
+		return format.TestResultJSON{Package: "pkg/a", Name: name, Status: "failed"}
+	}
+
+	stats := ComputeStats([]*format.SummaryJSON{
+		summaryJSON(map[string]string{"pkg/a": "failed", "pkg/b": "passed"}, fail("TestFlaky"), fail("TestBroken")),
+		summaryJSON(map[string]string{"pkg/a": "passed"}, pass("TestFlaky"), fail("TestBroken")),
+		summaryJSON(map[string]string{"pkg/a": "failed", "pkg/b": "build failed"}, pass("TestFlaky"), fail("TestBroken")),
+	})
+
+	require.Len(t, stats.Packages, 2)
+	assert.Equal(t, "pkg/a", stats.Packages[0].Name)
+	assert.Equal(t, 2, stats.Packages[0].Failed)
+	assert.Equal(t, "x.x", string(stats.Packages[0].History))
+	assert.Equal(t, "pkg/b", stats.Packages[1].Name)
+	assert.Equal(t, 2, stats.Packages[1].Runs)
+	assert.Equal(t, ". x", string(stats.Packages[1].History))
+
+	require.Len(t, stats.Flaky, 1)
+	assert.Equal(t, "TestFlaky", stats.Flaky[0].Name)
+
+	var sb strings.Builder
+	require.NoError(t, FormatStats(&sb, stats, true))
+	out := sb.String()
+	assert.Contains(t, out, "FAILURES BY PACKAGE (last 3 runs)")
+	assert.Contains(t, out, "    pkg/a        3       2    67%      0  x.x\n")
+	assert.Contains(t, out, "FLAKIEST TESTS\n    pkg/a TestFlaky  ✓2 ✗1\n")
+}
+
+func TestComputeStatsTestCounts(t *testing.T) {
+	run := func(tests int) *format.SummaryJSON {
+		return &format.SummaryJSON{Packages: []format.PackageJSON{{Name: "pkg/a", Status: "passed", Passed: tests - 1, Skipped: 1}}}
+	}
+	stats := ComputeStats([]*format.SummaryJSON{run(40), run(42), run(3)})
+
+	require.Len(t, stats.Packages, 1)
+	assert.Equal(t, 3, stats.Packages[0].Tests)
+	assert.Equal(t, 42, stats.Packages[0].MaxTests)
+}
+
+func TestComputeByTest(t *testing.T) {
+	result := func(pkg, name, status string, elapsed float64) format.TestResultJSON {
+		return format.TestResultJSON{Package: pkg, Name: name, Status: status, Elapsed: elapsed}
+	}
+	runs := []*format.SummaryJSON{
+		{Results: []format.TestResultJSON{
+			result("svc/a", "TestIntegration", "passed", 4),
+			result("svc/b", "TestIntegration", "failed", 6),
+			result("svc/a", "TestSlow", "passed", 20),
+			result("svc/b", "TestSlow", "skipped", 0),
+			result("svc/a", "TestOnlyHere", "failed", 1),
+		}},
+		{Results: []format.TestResultJSON{
+			result("svc/c", "TestIntegration", "passed", 2),
+		}},
+	}
+
+	stats := ComputeByTest(runs)
+	require.Len(t, stats, 2, "TestOnlyHere is in a single package")
+
+	integration := stats[0]
+	assert.Equal(t, "TestIntegration", integration.Name)
+	assert.Equal(t, []string{"svc/a", "svc/b", "svc/c"}, integration.Packages)
+	assert.Equal(t, 3, integration.Runs)
+	assert.Equal(t, 1, integration.Failed)
+	assert.InDelta(t, 1.0/3, integration.Rate(), 0.001)
+	assert.Equal(t, 4*time.Second, integration.Mean())
+	assert.Equal(t, 6*time.Second, integration.Max)
+
+	assert.Equal(t, "TestSlow", stats[1].Name)
+	assert.Equal(t, 1, stats[1].Skipped)
+
+	var sb strings.Builder
+	require.NoError(t, FormatByTest(&sb, stats, len(runs), true))
+	assert.Contains(t, sb.String(), "TESTS BY NAME ACROSS PACKAGES (last 2 runs)")
+	assert.Regexp(t, `TestIntegration\s+3\s+3\s+1\s+0\s+33%\s+4\.00s\s+6\.00s`, sb.String())
+}
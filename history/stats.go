@@ -0,0 +1,193 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// MaxFlakyTests is the number of flakiest tests listed by FormatStats.
+const MaxFlakyTests = 10
+
+// PackageStats is the failure history of a single package.
+type PackageStats struct {
+	Name    string
+	Runs    int    // Runs in which the package appeared
+	Failed  int    // Runs in which the package failed
+	History []rune // One glyph per run, oldest first: '.' pass, 'x' fail, ' ' absent
+
+	Tests    int // Tests in the most recent run the package appeared in
+	MaxTests int // Most tests in any run
+}
+
+// Rate returns the fraction of runs in which the package failed.
+func (p *PackageStats) Rate() float64 {
+	if p.Runs == 0 {
+		return 0
+	}
+	return float64(p.Failed) / float64(p.Runs)
+}
+
+// TestStats is the pass/fail history of a single test.
+type TestStats struct {
+	Package string
+	Name    string
+	Passed  int
+	Failed  int
+}
+
+// Flakiness scores how evenly a test alternates between passing and
+// failing: 0 for a test which always passes or always fails, up to 0.5.
+func (t *TestStats) Flakiness() float64 {
+	total := t.Passed + t.Failed
+	if total == 0 {
+		return 0
+	}
+	return float64(min(t.Passed, t.Failed)) / float64(total)
+}
+
+// Stats summarizes failures across recorded runs.
+type Stats struct {
+	Runs     int
+	Packages []*PackageStats // Sorted by failure rate, highest first
+	Flaky    []*TestStats    // Tests that both passed and failed, flakiest first
+}
+
+// ComputeStats aggregates failure rates per package and flaky tests from
+// the given runs, which should be in chronological order.
+func ComputeStats(runs []*format.SummaryJSON) *Stats {
+	stats := &Stats{Runs: len(runs)}
+	pkgs := make(map[string]*PackageStats)
+	tests := make(map[string]*TestStats)
+
+	for i, run := range runs {
+		for _, p := range run.Packages {
+			ps := pkgs[p.Name]
+			if ps == nil {
+				ps = &PackageStats{Name: p.Name, History: []rune(strings.Repeat(" ", len(runs)))}
+				pkgs[p.Name] = ps
+				stats.Packages = append(stats.Packages, ps)
+			}
+			ps.Runs++
+			ps.History[i] = '.'
+			ps.Tests = p.Passed + p.Failed + p.Skipped
+			ps.MaxTests = max(ps.MaxTests, ps.Tests)
+			if slices.Contains(failedStatuses, p.Status) {
+				ps.Failed++
+				ps.History[i] = 'x'
+			}
+		}
+		for _, tr := range run.Results {
+			key := tr.Package + "/" + tr.Name
+			ts := tests[key]
+			if ts == nil {
+				ts = &TestStats{Package: tr.Package, Name: tr.Name}
+				tests[key] = ts
+			}
+			switch tr.Status {
+			case results.StatusPassed.String():
+				ts.Passed++
+			case results.StatusFailed.String():
+				ts.Failed++
+			}
+		}
+	}
+
+	for _, ts := range tests {
+		if ts.Passed > 0 && ts.Failed > 0 {
+			stats.Flaky = append(stats.Flaky, ts)
+		}
+	}
+	stats.sort()
+	return stats
+}
+
+// failedStatuses are the statuses of a failed package.
+var failedStatuses = []string{results.StatusFailed.String(), results.StatusBuildFailed.String()}
+
+// sort sorts the packages and flaky tests of stats.
+func (stats *Stats) sort() {
+	sort.SliceStable(stats.Packages, func(i, j int) bool {
+		a, b := stats.Packages[i], stats.Packages[j]
+		if a.Rate() != b.Rate() {
+			return a.Rate() > b.Rate()
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(stats.Flaky, func(i, j int) bool {
+		a, b := stats.Flaky[i], stats.Flaky[j]
+		if a.Flakiness() != b.Flakiness() {
+			return a.Flakiness() > b.Flakiness()
+		}
+		if a.Failed != b.Failed {
+			return a.Failed > b.Failed
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Name < b.Name
+	})
+}
+
+// FormatStats writes a per-package failure table, with a run-by-run history
+// strip, followed by the flakiest tests.
+func FormatStats(w io.Writer, stats *Stats, noColor bool) error {
+	var sb strings.Builder
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	boldWhite := lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
+	if noColor {
+		failStyle = lipgloss.NewStyle()
+		boldWhite = lipgloss.NewStyle()
+	}
+
+	if stats.Runs == 0 {
+		_, err := fmt.Fprintln(w, "No runs recorded.")
+		return err
+	}
+
+	maxNameLen := len("PACKAGE")
+	for _, p := range stats.Packages {
+		maxNameLen = max(maxNameLen, len(p.Name))
+	}
+
+	sb.WriteString(boldWhite.Render(fmt.Sprintf("FAILURES BY PACKAGE (last %d runs)", stats.Runs)))
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "%s%-*s  %5s  %6s  %5s  %5s  %s\n", format.IndentLevel, maxNameLen, "PACKAGE", "RUNS", "FAILED", "RATE", "TESTS", "HISTORY")
+	for _, p := range stats.Packages {
+		rate := fmt.Sprintf("%4.0f%%", p.Rate()*100)
+		history := string(p.History)
+		if p.Failed > 0 {
+			rate = failStyle.Render(rate)
+			history = strings.ReplaceAll(history, "x", failStyle.Render("x"))
+		}
+		// Fewer tests than before may mean a suite was excluded by mistake,
+		// e.g. by a build tag.
+		tests := fmt.Sprintf("%5d", p.Tests)
+		if p.Tests < p.MaxTests {
+			tests = failStyle.Render(tests)
+		}
+		fmt.Fprintf(&sb, "%s%-*s  %5d  %6d  %s  %s  %s\n", format.IndentLevel, maxNameLen, p.Name, p.Runs, p.Failed, rate, tests, history)
+	}
+
+	if len(stats.Flaky) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(boldWhite.Render("FLAKIEST TESTS"))
+		sb.WriteString("\n")
+		for i, ts := range stats.Flaky {
+			if i == MaxFlakyTests {
+				break
+			}
+			fmt.Fprintf(&sb, "%s%s %s  %s%d %s%d\n", format.IndentLevel, ts.Package, ts.Name,
+				format.SymbolPass, ts.Passed, failStyle.Render(format.SymbolFail), ts.Failed)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
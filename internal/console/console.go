@@ -0,0 +1,26 @@
+// Package console detects whether output goes to an interactive terminal,
+// and prepares it for ANSI escape sequences.
+//
+// On Windows, a terminal is a console: the classic console host, or a
+// pseudoconsole (ConPTY) as used by Windows Terminal and VS Code. Terminal
+// emulators that present pipes instead, like mintty, are not detected as
+// terminals.
+package console
+
+import (
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// IsTerminal reports whether f is an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// EnableVirtualTerminal makes the terminal on f interpret ANSI escape
+// sequences, and returns a function restoring its previous mode. On
+// platforms other than Windows, terminals always do, and it does nothing.
+func EnableVirtualTerminal(f *os.File) (restore func(), err error) {
+	return enableVirtualTerminal(f)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package console
+
+import "os"
+
+func enableVirtualTerminal(*os.File) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,19 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegularFileIsNotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if IsTerminal(f) {
+		t.Error("Expected a regular file not to be a terminal")
+	}
+}
@@ -0,0 +1,27 @@
+// Package gitrepo finds the git checkout tests run in, so file names in test
+// output can be linked to the commit on a code hosting site.
+package gitrepo
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Head returns the root directory of the git checkout containing dir, and
+// the SHA of its checked out commit.
+func Head(dir string) (root, sha string, err error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "HEAD").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", "", errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", "", err
+	}
+	lines := strings.Fields(string(out))
+	if len(lines) != 2 {
+		return "", "", errors.New("unexpected git rev-parse output: " + strings.TrimSpace(string(out)))
+	}
+	return lines[0], lines[1], nil
+}
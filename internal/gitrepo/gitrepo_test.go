@@ -0,0 +1,29 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHead(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, sha, err := Head(wd)
+	if err != nil {
+		t.Skipf("not in a git checkout: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		t.Errorf("Head() root = %q, which has no go.mod", root)
+	}
+	if len(sha) != 40 {
+		t.Errorf("Head() sha = %q, want 40 hex digits", sha)
+	}
+
+	if _, _, err := Head(t.TempDir()); err == nil {
+		t.Error("Head() of a directory outside a checkout succeeded")
+	}
+}
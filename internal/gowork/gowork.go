@@ -0,0 +1,132 @@
+// Package gowork finds the modules of a go.work workspace, so packages from
+// a multi-module test run can be grouped by module.
+package gowork
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Modules returns the module paths of the workspace containing dir, in the
+// order of the go.work use directives. The go.work file is the one named by
+// $GOWORK, else the first found in dir or its parents. It returns nil if
+// there is no workspace or GOWORK=off.
+func Modules(dir string) ([]string, error) {
+	path, err := find(dir)
+	if path == "" || err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := parseUses(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []string
+	for _, d := range dirs {
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(filepath.Dir(path), d)
+		}
+		mod, err := modulePath(filepath.Join(d, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		if mod != "" {
+			modules = append(modules, mod)
+		}
+	}
+	return modules, nil
+}
+
+// find returns the path of the go.work file for dir, or "" if none.
+func find(dir string) (string, error) {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return gowork, nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// parseUses returns the directories of the use directives in a go.work
+// file, both single-line ("use ./a") and block ("use ( ./a ./b )") forms.
+func parseUses(r io.Reader) ([]string, error) {
+	var dirs []string
+	inBlock := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			dirs = append(dirs, unquote(line))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, unquote(strings.TrimSpace(strings.TrimPrefix(line, "use "))))
+		}
+	}
+	return dirs, scanner.Err()
+}
+
+// modulePath returns the module path declared in a go.mod file.
+func modulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if mod, ok := strings.CutPrefix(line, "module "); ok {
+			return unquote(strings.TrimSpace(mod)), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
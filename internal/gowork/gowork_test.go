@@ -0,0 +1,49 @@
+package gowork
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestModules(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.work", "go 1.25\n\nuse (\n\t./api // the API\n\t\"./tools\"\n)\nuse ./cli\n")
+	write("api/go.mod", "module example.com/api\n\ngo 1.25\n")
+	write("tools/go.mod", "// tools\nmodule \"example.com/tools\"\n")
+	write("cli/go.mod", "module example.com/cli\n")
+	t.Setenv("GOWORK", "")
+
+	modules, err := Modules(filepath.Join(root, "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"example.com/api", "example.com/tools", "example.com/cli"}
+	if !reflect.DeepEqual(modules, expected) {
+		t.Errorf("Expected %v, got %v", expected, modules)
+	}
+
+	t.Setenv("GOWORK", "off")
+	if modules, err := Modules(root); err != nil || modules != nil {
+		t.Errorf("Expected no modules with GOWORK=off, got %v, %v", modules, err)
+	}
+}
+
+func TestModulesWithoutWorkspace(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	modules, err := Modules(t.TempDir())
+	if err != nil || modules != nil {
+		t.Errorf("Expected no modules outside a workspace, got %v, %v", modules, err)
+	}
+}
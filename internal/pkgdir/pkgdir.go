@@ -0,0 +1,38 @@
+// Package pkgdir finds the source directories of packages, so file names in
+// test output can be resolved.
+package pkgdir
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Finder finds package directories with `go list`, remembering the results.
+// The zero Finder is ready to use.
+type Finder struct {
+	mu   sync.Mutex
+	dirs map[string]string
+}
+
+// Dir returns the source directory of the package with the given import
+// path, or "" if it can't be found, e.g. when replaying a test run recorded
+// elsewhere.
+func (f *Finder) Dir(pkg string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if dir, ok := f.dirs[pkg]; ok {
+		return dir
+	}
+	if f.dirs == nil {
+		f.dirs = make(map[string]string)
+	}
+
+	var dir string
+	out, err := exec.Command("go", "list", "-find", "-f", "{{.Dir}}", "--", pkg).Output()
+	if err == nil {
+		dir = strings.TrimSpace(string(out))
+	}
+	f.dirs[pkg] = dir
+	return dir
+}
@@ -0,0 +1,21 @@
+package pkgdir
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFinderDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var f Finder
+	if got := f.Dir("github.com/ansel1/tang/internal/pkgdir"); got != wd {
+		t.Errorf("Dir() = %q, want %q", got, wd)
+	}
+	if got := f.Dir("example.com/does/not/exist"); got != "" {
+		t.Errorf("Dir() of a missing package = %q, want \"\"", got)
+	}
+}
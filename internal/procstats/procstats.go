@@ -0,0 +1,57 @@
+// Package procstats samples the memory and CPU usage of a process group,
+// such as a go test process and the test binaries it runs.
+//
+// Linux reads /proc; macOS asks ps, which reads the kernel's process table
+// via sysctl. Other platforms return ErrUnsupported.
+package procstats
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupported is returned on platforms without process group stats.
+var ErrUnsupported = errors.New("process stats are not supported on this platform")
+
+// Sample is the resource usage of a process group at a point in time.
+type Sample struct {
+	RSS uint64        // Resident set size in bytes, summed over the group
+	CPU time.Duration // User+system CPU time consumed by the group's live processes
+}
+
+// Group returns a Sample for the process group pgid.
+func Group(pgid int) (Sample, error) {
+	return group(pgid)
+}
+
+// Sampler turns successive Samples of a process group into CPU utilization.
+type Sampler struct {
+	pgid     int
+	last     Sample
+	lastTime time.Time
+}
+
+// NewSampler creates a Sampler for the process group pgid.
+func NewSampler(pgid int) *Sampler {
+	return &Sampler{pgid: pgid}
+}
+
+// Sample returns the group's current RSS in bytes, and its CPU utilization
+// since the previous call as a percentage of one core (so 400 is four busy
+// cores). The first call reports 0% CPU.
+func (s *Sampler) Sample() (rss uint64, cpuPercent float64, err error) {
+	sample, err := Group(s.pgid)
+	if err != nil {
+		return 0, 0, err
+	}
+	now := time.Now()
+	if !s.lastTime.IsZero() {
+		// CPU time drops when a test binary exits; treat that interval
+		// as idle rather than negative.
+		if used := sample.CPU - s.last.CPU; used > 0 {
+			cpuPercent = float64(used) / float64(now.Sub(s.lastTime)) * 100
+		}
+	}
+	s.last, s.lastTime = sample, now
+	return sample.RSS, cpuPercent, nil
+}
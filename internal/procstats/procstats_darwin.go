@@ -0,0 +1,46 @@
+package procstats
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func group(pgid int) (Sample, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pgid=,rss=,time=").Output()
+	if err != nil {
+		return Sample{}, err
+	}
+
+	var sample Sample
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		if pg, err := strconv.Atoi(fields[0]); err != nil || pg != pgid {
+			continue
+		}
+		if kb, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			sample.RSS += kb * 1024
+		}
+		sample.CPU += parseCPUTime(fields[2])
+	}
+	return sample, nil
+}
+
+// parseCPUTime parses ps's cumulative CPU time, "[[dd-]hh:]mm:ss.ss".
+func parseCPUTime(s string) time.Duration {
+	var days float64
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		days, _ = strconv.ParseFloat(d, 64)
+		s = rest
+	}
+	var secs float64
+	for _, part := range strings.Split(s, ":") {
+		v, _ := strconv.ParseFloat(part, 64)
+		secs = secs*60 + v
+	}
+	return time.Duration((days*86400 + secs) * float64(time.Second))
+}
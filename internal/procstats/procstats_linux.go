@@ -0,0 +1,68 @@
+package procstats
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the kernel's USER_HZ, the unit of utime and stime in
+// /proc/<pid>/stat. It is 100 on all mainstream Linux architectures.
+const clockTicks = 100
+
+func group(pgid int) (Sample, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return Sample{}, err
+	}
+	pageSize := uint64(os.Getpagesize())
+
+	var sample Sample
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		// Processes may exit between listing and reading; skip them.
+		data, err := os.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		st, ok := parseStat(string(data))
+		if !ok || st.pgrp != pgid {
+			continue
+		}
+		sample.RSS += st.rssPages * pageSize
+		sample.CPU += time.Duration(st.ticks) * time.Second / clockTicks
+	}
+	return sample, nil
+}
+
+type procStat struct {
+	pgrp     int
+	ticks    uint64 // utime + stime
+	rssPages uint64
+}
+
+// parseStat parses the fields of /proc/<pid>/stat used for sampling. The
+// command name (field 2) may contain spaces and parentheses, so fields are
+// counted from after its closing parenthesis.
+func parseStat(s string) (procStat, bool) {
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return procStat{}, false
+	}
+	// fields[0] is field 3 (state) of proc(5).
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	pgrp, err1 := strconv.Atoi(fields[2])
+	utime, err2 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err3 := strconv.ParseUint(fields[12], 10, 64)
+	rss, err4 := strconv.ParseInt(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return procStat{}, false
+	}
+	return procStat{pgrp: pgrp, ticks: utime + stime, rssPages: uint64(max(rss, 0))}, true
+}
@@ -0,0 +1,31 @@
+package procstats
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseStat(t *testing.T) {
+	stat := "1234 (go test (x)) S 1 1230 1230 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 8 0 500 1000000 300 18446744073709551615"
+	st, ok := parseStat(stat)
+	if !ok {
+		t.Fatal("Expected stat to parse")
+	}
+	if st.pgrp != 1230 || st.ticks != 300 || st.rssPages != 300 {
+		t.Errorf("Unexpected stat: %+v", st)
+	}
+
+	if _, ok := parseStat("1234 (truncated) S 1"); ok {
+		t.Error("Expected truncated stat to fail")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	sample, err := Group(syscall.Getpgrp())
+	if err != nil {
+		t.Fatalf("Group: %v", err)
+	}
+	if sample.RSS == 0 {
+		t.Error("Expected the test's own process group to use memory")
+	}
+}
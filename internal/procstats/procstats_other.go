@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package procstats
+
+func group(int) (Sample, error) {
+	return Sample{}, ErrUnsupported
+}
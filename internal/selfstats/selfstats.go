@@ -0,0 +1,88 @@
+// Package selfstats serves profiles and stats of tang's own resource usage,
+// for diagnosing it when processing very large streams.
+package selfstats
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// SampleInterval is how often the stats are sampled.
+const SampleInterval = time.Second
+
+// Stats is a sample of tang's internal stats, published as the "tang"
+// expvar.
+type Stats struct {
+	Time         time.Time `json:"time"`
+	Events       uint64    `json:"events"`         // Events processed
+	EventsPerSec float64   `json:"events_per_sec"` // Over the last interval
+	Runs         int       `json:"runs"`
+	Packages     int       `json:"packages"`     // Collected packages, across all runs
+	TestResults  int       `json:"test_results"` // Collected test results, across all runs
+	Goroutines   int       `json:"goroutines"`
+}
+
+// latest is the most recent sample, published as the "tang" expvar.
+var latest atomic.Pointer[Stats]
+
+func init() {
+	expvar.Publish("tang", expvar.Func(func() any { return latest.Load() }))
+}
+
+// Serve listens on addr, e.g. ":6060", and serves net/http/pprof profiles
+// under /debug/pprof/, and expvars under /debug/vars: the runtime's memory
+// stats and a sample of the collector's stats taken every interval. It
+// returns the address listened on; serving continues in the background
+// until the process exits.
+func Serve(addr string, collector *results.Collector, interval time.Duration) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	sample(collector, nil)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			sample(collector, latest.Load())
+		}
+	}()
+	go func() { _ = http.Serve(ln, mux) }()
+	return ln.Addr(), nil
+}
+
+// sample records the current stats as the latest, with the event rate
+// since prev, if any.
+func sample(collector *results.Collector, prev *Stats) {
+	cs := collector.Stats()
+	s := &Stats{
+		Time:        time.Now(),
+		Events:      cs.Events,
+		Runs:        cs.Runs,
+		Packages:    cs.Packages,
+		TestResults: cs.TestResults,
+		Goroutines:  runtime.NumGoroutine(),
+	}
+	if prev != nil {
+		if d := s.Time.Sub(prev.Time).Seconds(); d > 0 {
+			s.EventsPerSec = float64(s.Events-prev.Events) / d
+		}
+	}
+	latest.Store(s)
+}
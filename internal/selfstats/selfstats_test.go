@@ -0,0 +1,66 @@
+package selfstats
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+	"github.com/ansel1/tang/results"
+)
+
+func get(t *testing.T, url string) []byte {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestServe(t *testing.T) {
+	collector := results.NewCollector()
+	addr, err := Serve("127.0.0.1:0", collector, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + addr.String()
+
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: "start", Package: "example.com/pkg"}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: "run", Package: "example.com/pkg", Test: "TestFoo"}})
+
+	var vars struct {
+		Tang     *Stats          `json:"tang"`
+		MemStats json.RawMessage `json:"memstats"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for vars.Tang == nil || vars.Tang.Events < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a sample of 2 events, got %+v", vars.Tang)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := json.Unmarshal(get(t, base+"/debug/vars"), &vars); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := *vars.Tang; got.Runs != 1 || got.Packages != 1 || got.TestResults != 1 || got.Goroutines == 0 {
+		t.Errorf("Unexpected stats %+v", got)
+	}
+	if len(vars.MemStats) == 0 {
+		t.Error("Expected the runtime's memstats in /debug/vars")
+	}
+
+	get(t, base+"/debug/pprof/")
+	get(t, base+"/debug/pprof/heap")
+}
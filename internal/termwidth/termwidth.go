@@ -16,6 +16,10 @@ import (
 
 const DefaultWidth = 80
 
+// DefaultHeight is the terminal height returned by Height when detection
+// fails.
+const DefaultHeight = 24
+
 // Get returns the terminal width. If COLUMNS is set to a positive integer,
 // it takes priority over ioctl detection. Otherwise the width is read from
 // the given file descriptor (typically os.Stdout). If detection fails, 80
@@ -30,6 +34,15 @@ func Get(fd uintptr) int {
 	return DefaultWidth
 }
 
+// Height returns the height of the terminal on the given file descriptor,
+// or DefaultHeight if detection fails.
+func Height(fd uintptr) int {
+	if _, h, err := term.GetSize(fd); err == nil && h > 0 {
+		return h
+	}
+	return DefaultHeight
+}
+
 // FromEnv returns the value of the COLUMNS environment variable, or 0 if
 // it is unset or not a valid positive integer.
 func FromEnv() int {
@@ -7,47 +7,164 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"regexp"
+	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
+	"text/template"
 
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/internal/console"
+	"github.com/ansel1/tang/internal/gitrepo"
+	"github.com/ansel1/tang/internal/gowork"
+	"github.com/ansel1/tang/internal/pkgdir"
+	"github.com/ansel1/tang/internal/selfstats"
 	"github.com/ansel1/tang/internal/termwidth"
 	"github.com/ansel1/tang/output"
 	"github.com/ansel1/tang/output/format"
 	"github.com/ansel1/tang/output/junit"
+	"github.com/ansel1/tang/parser"
 	"github.com/ansel1/tang/results"
 	"github.com/ansel1/tang/tui"
 	"github.com/charmbracelet/colorprofile"
 )
 
+// etaHistoryRuns is how many of the most recent -history runs are used to
+// estimate the time remaining in a run.
+const etaHistoryRuns = 10
+
 func main() {
 	os.Exit(run())
 }
 
 func run() int {
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		return runStats(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		return runMerge(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		return runValidate(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bundle" {
+		return runBundle(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "repro" {
+		return runRepro(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff-streams" {
+		return runDiffStreams(os.Args[2:])
+	}
+
 	testIdx := scanForTestSubcommand()
 
 	infile := flag.String("f", "", "Read from file instead of stdin")
 	outfile := flag.String("outfile", "", "Save all input to the specified file")
 	jsonfile := flag.String("jsonfile", "", "Save JSON events to the specified file")
+	normalizedJSON := flag.String("normalized-json", "", "Save a normalized go test -json stream to the specified file: missing start and run events injected, tests left running finished with their package, and each package's events grouped by test")
+	noJSONHeader := flag.Bool("no-jsonfile-header", false, "Don't start -jsonfile with a line marking the recording's tang version, for tools which only accept go test -json events")
 	junitfile := flag.String("junitfile", "", "Save cumulative test results to the specified JUnit XML file")
+	eventsOut := flag.String("events-out", "", "Stream tang's derived run, package and test status events to the specified file as JSON Lines")
+	plugin := flag.String("plugin", "", "Start the specified `command` and stream the events of -events-out to its stdin, e.g. './upload-failures -tracker=ci'")
+	progressFD := flag.Int("progress-fd", 0, "Write a machine-readable progress line every second to file descriptor N, e.g. 2 for stderr or 3 (0 disables)")
+	pprofAddr := flag.String("pprof", "", "Serve net/http/pprof profiles and tang's own stats (events/sec, collector sizes, goroutines) at /debug/vars on `addr`, e.g. :6060, to diagnose tang's resource usage")
 	notty := flag.Bool("notty", false, "Don't use live UI, output to stdout")
+	maxLineRate := flag.Int("max-line-rate", 0, "Drop a test's output beyond N lines per second, e.g. 10000, counting the dropped lines, to protect against runaway tests (0 keeps every line)")
+	keepRepeats := flag.Bool("keep-repeated-lines", false, "Keep every consecutive duplicate line of test output, instead of collapsing them into 'previous line repeated N times'")
+	strict := flag.Bool("strict", false, "Check the go test events for anomalies, e.g. output for a test which never ran, a package failing with tests still running or a test finishing twice, and report them as warnings in an ANOMALIES section of the summary")
+	resync := flag.Bool("resync", false, "Tolerate corrupted go test -json events, e.g. lines truncated by a crashed writer: recover what fields they have instead of ending the run, and report them in the summary")
+	mergeRetries := flag.Bool("merge-retries", false, "Treat a package starting again within a run as a retry of its tests, e.g. by 'gotestsum --rerun-fails', keeping earlier results as earlier attempts")
 	verbose := flag.Bool("v", false, "Verbose output (show all test output in -notty mode)")
+	firstFailure := flag.Bool("first-failure", false, "In -notty mode, print the first failing test's output in a delimited FIRST FAILURE block as soon as it fails")
 	replay := flag.Bool("replay", false, "Replay events with timing from original test run (requires -f)")
 	rate := flag.Float64("rate", 1.0, "Replay rate multiplier (0=instant, 1=original speed, 0.5=2x speed)")
-	slowThreshold := flag.Duration("slow-threshold", 10*time.Second, "Duration threshold for slow test detection")
+	packageName := flag.String("package-name", "", "Attribute test events without a Package field, e.g. from 'go tool test2json' on a test binary, to the specified package")
+	rollup := flag.Int("rollup", 0, "Roll the package summary up by directory, N levels beneath the module, with one row per directory; the packages of directories with failures are still listed (0 lists every package)")
+	pkgSlowThresholds := flag.String("pkg-slow-threshold", "", "Per-package slow thresholds as comma-separated `glob=duration` pairs, e.g. '*/integration/...=5m'")
+	trimPkgPrefix := flag.String("trim-pkg-prefix", "", "Strip the specified module prefix from displayed package names")
+	pkgSegments := flag.Int("pkg-segments", 0, "Display only the last N segments of package names (0 shows all)")
+	widthFlag := flag.Int("width", 0, "Render the live display, output and summary `N` columns wide instead of the terminal's width, e.g. for tools that hard-wrap or deterministic CI reports (0 detects the width)")
+	pkgWidth := flag.Int("pkg-width", 0, "Truncate displayed package names longer than N characters with an ellipsis (0 disables)")
+	slowThreshold := flag.Duration("slow-threshold", format.DefaultSlowThreshold, "Duration threshold for slow test detection (0 disables)")
+	stallThreshold := flag.Duration("stall-threshold", format.DefaultStallThreshold, "Flag running tests which have written no output for this long as stalled, in the live display and in a STALLED section of the summary of an interrupted run (0 disables)")
+	longRunning := flag.Duration("long-running", tui.DefaultLongRunningThreshold, "Show the elapsed time and last output line of tests running longer than this in their package header when they don't fit on screen (0 disables)")
 	includeSkipped := flag.Bool("include-skipped", false, "Include skipped tests in summary")
+	showOutput := flag.String("show-output", "", "Include the full output of passing tests whose name matches `regexp` in summary")
 	includeSlow := flag.Bool("include-slow", false, "Include slow tests in summary")
+	timeline := flag.Bool("timeline", false, "Include a timeline of when each package started and finished in summary")
+	recordCast := flag.String("record-cast", "", "Record the live display to the specified file in asciinema v2 cast format")
+	hideSubtests := flag.Bool("hide-subtests", false, "Give only top-level tests a line in the live display, with their subtests' counts and latest output line, so table-driven tests with many subtests don't flood the screen")
+	focusActive := flag.Bool("focus-active", false, "Fold the live display's passed packages, and those with no tests, into one line, keeping the screen for running and failed ones")
+	pinFailures := flag.Duration("pin-failures", 5*time.Second, "Pin each test which fails below the packages of the live display with its last 10 output lines for this long, one at a time, before it collapses back into its package's counts (0 disables)")
+	failuresPane := flag.Int("failures-pane", 5, "Show a pane of at most N lines below the packages of the live display listing the failed tests so far, most recent first (0 hides it)")
+	noHints := flag.Bool("no-hints", false, "Hide the line of key hints at the bottom of the live display (press ? for help)")
+	compactRuns := flag.Bool("compact-runs", false, "When the stream holds several runs, e.g. in watch mode, keep the live display open between them and summarize each finished run in one line above it instead of printing its full report; press e to print an earlier run's report. The last run's full report is printed when the stream ends")
+	interactive := flag.Bool("interactive", false, "Keep the final report open after the run; press f to cycle all/failures/slow views, r or F to rerun all or failed tests (with the test subcommand), o to print a test's elided output, q to exit")
+	includeEmpty := flag.Bool("include-empty", false, "Include passing tests that were faster than -empty-threshold and wrote no output in summary")
+	emptyThreshold := flag.Duration("empty-threshold", format.DefaultEmptyTestThreshold, "Duration under which a silent passing test is reported by -include-empty")
+	includeParallelism := flag.Bool("include-parallelism", false, "Include per-package test parallelism statistics in summary")
+	asciiFlag := flag.Bool("ascii", false, "Mark test results with ASCII symbols, e.g. +3 x1 -2, instead of Unicode ones, for terminals and log viewers without them; by default they're used when TERM is dumb or linux, or the locale isn't UTF-8 (-ascii=false disables that)")
 	noColorFlag := flag.Bool("no-color", false, "Disable all ANSI color and style escape codes")
+	themeName := flag.String("theme", "default", "Color theme: default (the terminal's ANSI palette), dark, light or colorblind")
+	packageOrderName := flag.String("package-order", "priority", "Order of the summary's PACKAGES section: priority (failed first, then slowest first), chronological (the order they started in), alphabetical or duration (slowest first)")
+	durationStyleName := flag.String("duration-style", "", "Show durations in the live display and every report in one `style`: compact (5.2s, 1.5m), clock (00:01:30.500) or go (1m30.5s); by default the live display is compact and reports use go")
+	themeColors := flag.String("theme-colors", "", "Override theme colors with comma-separated `key=color` pairs, e.g. 'fail=#ff5555,pass=#50fa7b'; keys are fail, pass, skip, slow, bright-<key>, emphasis and muted")
+	summaryFile := flag.String("summary-file", "", "Write the summary to the specified file instead of stdout, which keeps the test output")
+	summaryFD := flag.Int("summary-fd", 0, "Write the summary to the inherited file descriptor `N` instead of stdout, which keeps the test output, e.g. -summary-fd 3 with 3>summary.txt")
+	summaryJSONFile := flag.String("summary-json", "", "Save a JSON summary of the last run to the specified file")
+	markdownFile := flag.String("markdown", "", "Save a GitHub-flavored Markdown summary of the last run to the specified file, e.g. $GITHUB_STEP_SUMMARY")
+	checkpointEvery := flag.Duration("checkpoint-every", 0, "While a run is in progress, write a snapshot of its summary to the -summary-json and -markdown files when a package finishes this long after the last one, so a run killed before tang exits leaves a partial report (0 disables)")
+	checkpointPackages := flag.Int("checkpoint-packages", 0, "Like -checkpoint-every, write a snapshot of the run's summary every N packages finished (0 disables)")
+	baselineFile := flag.String("baseline", "", "Compare test durations against a JSON summary from a previous run (see -summary-json)")
+	regressionPct := flag.Float64("regression-pct", 50, "Percent duration increase over the baseline reported as a regression (0 disables)")
+	regressionAbs := flag.Duration("regression-abs", 0, "Absolute duration increase over the baseline reported as a regression (0 disables)")
+	regressionAll := flag.Bool("regression-all", false, "Report a duration regression only when the increase exceeds both -regression-pct and -regression-abs, rather than either, e.g. to ignore large relative changes of very fast tests")
+	setupMin := flag.Duration("setup-min", format.DefaultSetupMinimum, "Report packages that spend at least this long, and most of their runtime, outside their tests, e.g. in TestMain (0 disables)")
+	suiteChangePct := flag.Float64("suite-change-pct", format.DefaultSuiteChangePercent, "Percent change in a package's test count since the previous -history run (or -baseline) reported in summary (0 disables)")
+	historyFile := flag.String("history", "", "Append a JSON summary of the last run to the specified history file (see 'tang stats')")
+	dbFile := flag.String("db", "", "Store each run in the specified SQLite history database as it progresses, and read past runs from it rather than -history (see 'tang stats')")
+	failedOut := flag.String("failed-out", "", "Save the failed tests of the last run to the specified file, for rerunning")
+	failedOutFormat := flag.String("failed-out-format", output.FailedFormatRun, "Format of -failed-out: 'run' (a go test -run regexp) or 'list' (package and test per line)")
+	templateFile := flag.String("template", "", "Render the final report with the Go text/template in the specified file instead of the built-in format")
+	failureRulesFile := flag.String("failure-rules", "", "Classify failures with the 'category: regexp' rules in the specified file, tried before the built-in rules")
+	sourceContext := flag.Int("source-context", 0, "Show N lines of source around each file:line reference in a failure's output (0 disables)")
+	fileLinks := flag.String("file-links", "", "Link file:line references in failure output to the local files with the URL `template`, e.g. file://{path} or vscode://file{path}:{line}, where {path} is the file's absolute path; bare file names are resolved to their package's directory with go list. -repo-url takes precedence")
+	repoURL := flag.String("repo-url", "", "Link file:line references in failure output to a code hosting site with the URL `template`, e.g. https://github.com/org/repo/blob/{sha}/{path}#L{line}, where {sha} is the checked out commit")
+	fullGoroutineDumps := flag.Bool("full-goroutine-dumps", false, "Show goroutine dumps in failure output in full, instead of a count of goroutines by state and the culprit's stack")
+	keepParentFailures := flag.Bool("keep-parent-failures", false, "List and count parent tests which failed only because subtests did, instead of collapsing each into its failed subtests' entries")
+	rawTestify := flag.Bool("raw-testify", false, "Show testify assertion failures in failure output as testify prints them, instead of compacted to the error, its message and a trimmed trace")
+	splitLogs := flag.String("split-logs", "", "Stream each package's output to its own file in the specified directory as it arrives, for following with tail -f")
+	maxDetailsBytes := flag.Int("max-details-bytes", 0, "Cap the test details of the summary and -markdown, e.g. the output of failures, at `N` bytes, dropping the rest with a note of how much was dropped and where the full output is (0 is no cap, and 60000 for -markdown)")
+	extractLogs := flag.String("extract-logs", "", "Write the full output of each failed test to its own file in the specified directory")
+	artifacts := flag.String("artifacts", "", "Copy the files failed tests attach with 'TANG_ARTIFACT: path' output lines to the specified directory")
+	maxSkips := flag.Int("max-skips", -1, "Exit non-zero when more than N tests are skipped (-1 disables)")
+	noShortSkips := flag.Bool("no-short-skips", false, "Exit non-zero when any test is skipped because of go test -short, for CI jobs expected to run the full suite")
+	skipPatternFail := flag.String("skip-pattern-fail", "", "Exit non-zero when a skip reason matches `regexp`")
+	var labels results.Labels
+	var pkgFilter results.PackageFilter
+	flag.Var(&pkgFilter.Include, "pkg", "Only track and display packages matching the `glob`, e.g. 'example.com/svc/orders/...' (repeatable)")
+	flag.Var(&pkgFilter.Exclude, "skip-pkg", "Ignore packages matching the `glob` (repeatable)")
+	flag.Var(&labels, "label", "Attach a `key=value` label to the run, shown in the summary and embedded in JSON and JUnit output (repeatable)")
+	failOnInterrupted := flag.Bool("fail-on-interrupted", false, "Exit non-zero when input ends while packages are still running, e.g. a truncated -f file")
+	failOnRegression := flag.Bool("fail-on-regression", false, "Exit non-zero when duration regressions against -baseline are found")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: tang [flags] [test [go test flags]]\n\n")
 		fmt.Fprintf(os.Stderr, "Commands:\n")
-		fmt.Fprintf(os.Stderr, "  test    Run go test and summarize results (auto-adds -json)\n\n")
+		fmt.Fprintf(os.Stderr, "  test          Run go test and summarize results (auto-adds -json)\n")
+		fmt.Fprintf(os.Stderr, "  stats         Report failure rates and flaky tests from a -history file or -db database\n")
+		fmt.Fprintf(os.Stderr, "  merge         Merge the -summary-json files of a run's shards into one\n")
+		fmt.Fprintf(os.Stderr, "  validate      Check a recorded go test -json stream for structural problems\n")
+		fmt.Fprintf(os.Stderr, "  bundle        Package a go test -json stream, its summary and the environment for a bug report\n")
+		fmt.Fprintf(os.Stderr, "  repro         Rerun a run recorded with -history or -db as it ran\n")
+		fmt.Fprintf(os.Stderr, "  diff-streams  Compare the tests of two recorded go test -json streams\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
@@ -97,12 +214,252 @@ func run() int {
 		}
 	}
 
+	// Windows consoles only interpret the escape sequences of colors and
+	// the live display with virtual terminal processing enabled. Consoles
+	// too old to support it get plain output.
+	isTerminal := console.IsTerminal(os.Stdout)
 	profile := colorprofile.Detect(os.Stdout, os.Environ())
+	if isTerminal {
+		restore, err := console.EnableVirtualTerminal(os.Stdout)
+		defer restore()
+		if err != nil {
+			isTerminal = false
+			profile = colorprofile.NoTTY
+		}
+	}
 	if *noColorFlag {
 		profile = colorprofile.NoTTY
 	}
 	noColor := profile == colorprofile.NoTTY
 
+	summaryOut, err := openSummaryOutput(*summaryFile, *summaryFD, noColor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := summaryOut.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing summary file: %v\n", err)
+		}
+	}()
+
+	if *widthFlag < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -width must be >= 0\n")
+		return 1
+	}
+	if *maxDetailsBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-details-bytes must be >= 0\n")
+		return 1
+	}
+
+	if *failedOutFormat != output.FailedFormatRun && *failedOutFormat != output.FailedFormatList {
+		fmt.Fprintf(os.Stderr, "Error: -failed-out-format must be 'run' or 'list'\n")
+		return 1
+	}
+
+	if *failOnRegression && *baselineFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: -fail-on-regression requires -baseline <filename>\n")
+		return 1
+	}
+
+	exitPolicy := results.ExitPolicy{FailOnInterrupted: *failOnInterrupted}
+	skipPolicy := results.SkipPolicy{MaxSkips: *maxSkips, NoShortSkips: *noShortSkips}
+	if *skipPatternFail != "" {
+		re, err := regexp.Compile(*skipPatternFail)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -skip-pattern-fail: %v\n", err)
+			return 1
+		}
+		skipPolicy.Pattern = re
+	}
+
+	var showOutputRE *regexp.Regexp
+	if *showOutput != "" {
+		re, err := regexp.Compile(*showOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -show-output: %v\n", err)
+			return 1
+		}
+		showOutputRE = re
+	}
+
+	var baseline *format.SummaryJSON
+	if *baselineFile != "" {
+		f, err := os.Open(*baselineFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening baseline file: %v\n", err)
+			return 1
+		}
+		baseline, err = format.ReadSummaryJSON(f)
+		_ = f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading baseline file: %v\n", err)
+			return 1
+		}
+	}
+
+	// Estimate the time remaining from the package durations of recent
+	// runs; without any, the estimator falls back to the completion rate.
+	var pastRuns []*format.SummaryJSON
+	var historyDB *history.DB
+	if *dbFile != "" {
+		db, err := history.OpenDB(*dbFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening history database: %v\n", err)
+			return 1
+		}
+		historyDB = db
+		runs, err := historyDB.Load(etaHistoryRuns)
+		if err != nil {
+			_ = historyDB.Close()
+			fmt.Fprintf(os.Stderr, "Error reading history database: %v\n", err)
+			return 1
+		}
+		pastRuns = runs
+	} else if *historyFile != "" {
+		runs, err := history.Load(*historyFile, etaHistoryRuns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading history file: %v\n", err)
+			return 1
+		}
+		pastRuns = runs
+	}
+	historyRuns := pastRuns // Shown per test by -interactive, without the baseline
+	// Test counts are compared with the latest recorded run, or failing
+	// that the baseline.
+	previousRun := baseline
+	if len(pastRuns) > 0 {
+		previousRun = pastRuns[len(pastRuns)-1]
+	}
+	if baseline != nil {
+		pastRuns = append(pastRuns, baseline)
+	}
+	estimator := format.NewEstimator(pastRuns)
+
+	theme, err := format.ParseTheme(*themeName, *themeColors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -theme: %v\n", err)
+		return 1
+	}
+
+	symbols := format.DetectSymbols(os.Getenv)
+	if flagSet("ascii") {
+		symbols = format.SymbolsUnicode
+		if *asciiFlag {
+			symbols = format.SymbolsASCII
+		}
+	}
+
+	packageOrder, err := format.ParsePackageOrder(*packageOrderName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -package-order: %v\n", err)
+		return 1
+	}
+	durationStyle, err := format.ParseDurationStyle(*durationStyleName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -duration-style: %v\n", err)
+		return 1
+	}
+
+	packageThresholds, err := format.ParsePackageThresholds(*pkgSlowThresholds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -pkg-slow-threshold: %v\n", err)
+		return 1
+	}
+
+	var reportTemplate *template.Template
+	if *templateFile != "" {
+		reportTemplate, err = format.ParseTemplateFile(*templateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading report template: %v\n", err)
+			return 1
+		}
+	}
+
+	failureRules := format.DefaultFailureRules
+	if *failureRulesFile != "" {
+		custom, err := format.LoadFailureRules(*failureRulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading failure rules: %v\n", err)
+			return 1
+		}
+		failureRules = append(custom, format.DefaultFailureRules...)
+	}
+
+	repoLinks := format.RepoLinks{FileTemplate: *fileLinks}
+	if *repoURL != "" {
+		root, sha, err := gitrepo.Head(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -repo-url: %v\n", err)
+			return 1
+		}
+		repoLinks = format.RepoLinks{Template: *repoURL, SHA: sha, Root: root, FileTemplate: *fileLinks}
+	}
+
+	var emptyTestThreshold time.Duration
+	if *includeEmpty {
+		emptyTestThreshold = *emptyThreshold
+	}
+
+	// Group packages by module when run in a go.work workspace. A workspace
+	// that can't be read just isn't grouped; go test reports the problem.
+	modules, _ := gowork.Modules(".")
+
+	// The REPRODUCIBILITY block of exec mode shows `tang repro` reading
+	// the run from the history it's recorded in.
+	var reproStore string
+	switch {
+	case !isTestMode:
+	case *dbFile != "":
+		reproStore = "-db " + *dbFile
+	case *historyFile != "":
+		reproStore = "-history " + *historyFile
+	}
+
+	// summaryOpts is shared by the live TUI, SimpleOutput and every
+	// computed summary, so they all agree on what's slow and which sections
+	// are shown.
+	summaryOpts := format.NewSummaryOptions(
+		format.WithSlowThreshold(*slowThreshold),
+		format.WithStallThreshold(*stallThreshold),
+		format.WithPackageSlowThresholds(packageThresholds),
+		format.WithSkipped(*includeSkipped),
+		format.WithSlow(*includeSlow),
+		format.WithShowOutput(showOutputRE),
+		format.WithParallelism(*includeParallelism),
+		format.WithTimeline(*timeline),
+		format.WithEmptyTestThreshold(emptyTestThreshold),
+		format.WithLogDir(*extractLogs),
+		format.WithMaxDetailsBytes(*maxDetailsBytes, *jsonfile),
+		format.WithArtifactDir(*artifacts),
+		format.WithFullGoroutineDumps(*fullGoroutineDumps),
+		format.WithRawTestify(*rawTestify),
+		format.WithKeepParentFailures(*keepParentFailures),
+		format.WithSourceContext(*sourceContext, new(pkgdir.Finder).Dir),
+		format.WithRepoLinks(repoLinks),
+		format.WithPackageNames(format.PackageNameOptions{
+			TrimPrefix: *trimPkgPrefix,
+			Segments:   *pkgSegments,
+			MaxWidth:   *pkgWidth,
+		}),
+		format.WithModules(modules),
+		format.WithRollup(*rollup),
+		format.WithTemplate(reportTemplate),
+		format.WithFailureRules(failureRules),
+		format.WithTheme(theme),
+		format.WithDurationStyle(durationStyle),
+		format.WithPackageOrder(packageOrder),
+		format.WithSymbols(symbols),
+		format.WithBaseline(baseline, format.RegressionThresholds{
+			Percent:  *regressionPct,
+			Absolute: *regressionAbs,
+			All:      *regressionAll,
+		}),
+		format.WithPreviousRun(previousRun, *suiteChangePct),
+		format.WithSetupMinimum(*setupMin),
+		format.WithReproStore(reproStore),
+	)
+
 	if !isTestMode {
 		if *replay && *infile == "" {
 			fmt.Fprintf(os.Stderr, "Error: -replay requires -f <filename>\n")
@@ -117,13 +474,31 @@ func run() int {
 	var inputSource io.Reader
 	var goTestCmd *goTestProcess
 
+	// On a terminal, what go test does before its first event, e.g.
+	// downloading modules, is shown in a line redrawn in place, which the
+	// live display replaces.
+	startTests := func(args []string) (*goTestProcess, error) {
+		if *notty || !isTerminal {
+			return startGoTest(args, os.Stderr)
+		}
+		prep := newPreparePhase(os.Stdout, os.Stderr, durationStyle.Or(format.DurationStyleCompact).Format)
+		proc, err := startGoTest(args, prep)
+		if err != nil {
+			prep.stop()
+			return nil, err
+		}
+		proc.stdout = prep.watch(proc.stdout)
+		return proc, nil
+	}
+
 	if isTestMode {
-		proc, err := startGoTest(goTestArgs)
+		proc, err := startTests(goTestArgs)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return 1
 		}
-		defer proc.cleanup()
+		// Reruns replace goTestCmd; the last one is cleaned up on return.
+		defer func() { goTestCmd.cleanup() }()
 		goTestCmd = proc
 		inputSource = proc.stdout
 	} else if *infile != "" {
@@ -148,7 +523,9 @@ func run() int {
 		inputSource = os.Stdin
 	}
 
-	var opts []engine.Option
+	// Output files are sinks of each stream's broadcaster, consuming its
+	// events alongside the display.
+	var fileSinks []func(engine.Event)
 
 	if *outfile != "" {
 		f, err := os.Create(*outfile)
@@ -157,7 +534,7 @@ func run() int {
 			return 1
 		}
 		defer func() { _ = f.Close() }()
-		opts = append(opts, engine.WithRawOutput(f))
+		fileSinks = append(fileSinks, engine.LineWriter(f))
 	}
 
 	if *jsonfile != "" {
@@ -167,16 +544,165 @@ func run() int {
 			return 1
 		}
 		defer func() { _ = f.Close() }()
-		opts = append(opts, engine.WithJSONOutput(f))
+		if !*noJSONHeader {
+			_, _ = f.Write(append(parser.NewHeader(tangVersion()).Marshal(), '\n'))
+		}
+		fileSinks = append(fileSinks, engine.LineWriter(f, engine.EventTest, engine.EventBuild, engine.EventOther))
+	}
+
+	if *normalizedJSON != "" {
+		f, err := os.Create(*normalizedJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating normalized JSON file: %v\n", err)
+			return 1
+		}
+		normalized := output.NewNormalizedJSON(f)
+		defer func() {
+			if err := normalized.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing normalized JSON file: %v\n", err)
+			}
+			_ = f.Close()
+		}()
+		fileSinks = append(fileSinks, normalized.Sink)
+	}
+
+	if *splitLogs != "" {
+		logs, err := output.NewSplitLogs(*splitLogs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating split logs directory: %v\n", err)
+			return 1
+		}
+		defer func() {
+			if err := logs.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing split logs: %v\n", err)
+			}
+		}()
+		fileSinks = append(fileSinks, logs.Sink)
 	}
 
-	eng := engine.NewEngine(opts...)
-	engineEvents := eng.Stream(inputSource)
+	var engineOpts []engine.Option
+	if *resync {
+		engineOpts = append(engineOpts, engine.WithResync())
+	}
+	eng := engine.NewEngine(engineOpts...)
+	var broadcaster *engine.Broadcaster
+	// stream parses input and broadcasts its events to the file sinks,
+	// returning the channel the display consumes them from.
+	stream := func(input io.Reader) <-chan engine.Event {
+		if broadcaster != nil {
+			// Keep the files in order across reruns.
+			broadcaster.Wait()
+		}
+		broadcaster = engine.NewBroadcaster()
+		for _, sink := range fileSinks {
+			broadcaster.AddSink(sink)
+		}
+		events := broadcaster.Subscribe()
+		go broadcaster.Broadcast(eng.Stream(input))
+		return events
+	}
+	engineEvents := stream(inputSource)
 
 	collector := results.NewCollector()
+	stopSampling := func() {}
+	if goTestCmd != nil {
+		stopSampling = goTestCmd.sampleResources(collector, resourceSampleInterval)
+	}
+	defer func() { stopSampling() }()
+	if *pprofAddr != "" {
+		if _, err := selfstats.Serve(*pprofAddr, collector, selfstats.SampleInterval); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -pprof: %v\n", err)
+			return 1
+		}
+	}
 	if *replay {
 		collector.SetReplay(true, *rate)
 	}
+	collector.SetLabels(labels)
+	collector.SetModes(results.ModesFromArgs(goTestArgs))
+	var repro *results.Reproducibility
+	if isTestMode {
+		wd, _ := os.Getwd()
+		repro = results.NewReproducibility(goVersion(), wd, goTestArgs, os.Environ())
+		collector.SetReproducibility(repro)
+	}
+	collector.SetDefaultPackage(*packageName)
+	collector.SetKeepRepeatedLines(*keepRepeats)
+	collector.SetMaxLineRate(*maxLineRate)
+	collector.SetPackageFilter(pkgFilter)
+	collector.SetStrict(*strict)
+	collector.SetMergeRetries(*mergeRetries)
+
+	var eventHandlers []func(results.Event)
+	if *eventsOut != "" {
+		f, err := os.Create(*eventsOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating events file: %v\n", err)
+			return 1
+		}
+		ew := output.NewEventWriter(f)
+		eventHandlers = append(eventHandlers, ew.Write)
+		defer func() {
+			if err := ew.Err(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing events: %v\n", err)
+			}
+			_ = f.Close()
+		}()
+	}
+
+	if *plugin != "" {
+		args := strings.Fields(*plugin)
+		if len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: -plugin requires a command\n")
+			return 1
+		}
+		// The plugin writes to stderr, so it doesn't mix with the report.
+		p, err := output.StartPlugin(args[0], args[1:], os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting plugin: %v\n", err)
+			return 1
+		}
+		eventHandlers = append(eventHandlers, p.Write)
+		defer func() {
+			if err := p.Close(output.PluginExitTimeout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: plugin %s: %v\n", args[0], err)
+			}
+		}()
+	}
+	var checkpoints *checkpointer
+	if *checkpointEvery > 0 || *checkpointPackages > 0 {
+		if *summaryJSONFile == "" && *markdownFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: -checkpoint-every and -checkpoint-packages require -summary-json or -markdown\n")
+			return 1
+		}
+		checkpoints = newCheckpointer(collector, summaryOpts, *summaryJSONFile, *markdownFile, *checkpointPackages, *checkpointEvery)
+		eventHandlers = append(eventHandlers, checkpoints.Handle)
+	}
+	if historyDB != nil {
+		store := newRunStore(historyDB, collector, summaryOpts)
+		eventHandlers = append(eventHandlers, store.Handle)
+		defer func() {
+			if err := store.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing history database: %v\n", err)
+			}
+		}()
+	}
+	if len(eventHandlers) > 0 {
+		collector.SetEventHandler(func(evt results.Event) {
+			for _, handle := range eventHandlers {
+				handle(evt)
+			}
+		})
+	}
+
+	if *progressFD > 0 {
+		f := os.NewFile(uintptr(*progressFD), "progress")
+		if f == nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -progress-fd %d\n", *progressFD)
+			return 1
+		}
+		defer output.ReportProgress(f, collector, estimator, output.ProgressInterval)()
+	}
 
 	var writeJUnitOnce sync.Once
 	writeJUnit := func() {
@@ -197,6 +723,129 @@ func run() int {
 	}
 	defer writeJUnit()
 
+	var writeSummaryJSONOnce sync.Once
+	writeSummaryJSON := func() {
+		writeSummaryJSONOnce.Do(func() {
+			if *summaryJSONFile == "" {
+				return
+			}
+			lastRun := collector.State().MostRecentRun()
+			if lastRun == nil {
+				return
+			}
+			f, err := os.Create(*summaryJSONFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating summary JSON file: %v\n", err)
+				return
+			}
+			defer func() { _ = f.Close() }()
+
+			if err := format.WriteSummaryJSON(f, format.ComputeSummary(lastRun, format.WithOptions(summaryOpts))); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing summary JSON: %v\n", err)
+			}
+		})
+	}
+	defer writeSummaryJSON()
+
+	var writeMarkdownOnce sync.Once
+	writeMarkdown := func() {
+		writeMarkdownOnce.Do(func() {
+			if *markdownFile == "" {
+				return
+			}
+			lastRun := collector.State().MostRecentRun()
+			if lastRun == nil {
+				return
+			}
+			f, err := os.Create(*markdownFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating Markdown summary file: %v\n", err)
+				return
+			}
+			defer func() { _ = f.Close() }()
+
+			if err := format.WriteMarkdown(f, format.ComputeSummary(lastRun, format.WithOptions(summaryOpts)), format.WithOptions(summaryOpts)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing Markdown summary: %v\n", err)
+			}
+		})
+	}
+	defer writeMarkdown()
+	// Deferred last, so the last checkpoint is written before, not over,
+	// the final reports.
+	if checkpoints != nil {
+		defer checkpoints.Close()
+	}
+
+	var appendHistoryOnce sync.Once
+	appendHistory := func() {
+		appendHistoryOnce.Do(func() {
+			if *historyFile == "" {
+				return
+			}
+			lastRun := collector.State().MostRecentRun()
+			if lastRun == nil {
+				return
+			}
+			sj := format.NewSummaryJSON(format.ComputeSummary(lastRun, format.WithOptions(summaryOpts)))
+			if err := history.Append(*historyFile, sj); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing history file: %v\n", err)
+			}
+		})
+	}
+	defer appendHistory()
+
+	var writeFailedOnce sync.Once
+	writeFailed := func() {
+		writeFailedOnce.Do(func() {
+			if *failedOut == "" {
+				return
+			}
+			f, err := os.Create(*failedOut)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating failed tests file: %v\n", err)
+				return
+			}
+			defer func() { _ = f.Close() }()
+
+			if lastRun := collector.State().MostRecentRun(); lastRun != nil {
+				if err := output.WriteFailedTests(f, lastRun, *failedOutFormat); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing failed tests: %v\n", err)
+				}
+			}
+		})
+	}
+	defer writeFailed()
+
+	var extractLogsOnce sync.Once
+	writeLogs := func() {
+		extractLogsOnce.Do(func() {
+			if *extractLogs == "" {
+				return
+			}
+			if lastRun := collector.State().MostRecentRun(); lastRun != nil {
+				if _, err := output.ExtractLogs(*extractLogs, lastRun); err != nil {
+					fmt.Fprintf(os.Stderr, "Error extracting test logs: %v\n", err)
+				}
+			}
+		})
+	}
+	defer writeLogs()
+
+	var copyArtifactsOnce sync.Once
+	copyArtifacts := func() {
+		copyArtifactsOnce.Do(func() {
+			if *artifacts == "" {
+				return
+			}
+			if lastRun := collector.State().MostRecentRun(); lastRun != nil {
+				if _, err := output.CopyArtifacts(*artifacts, lastRun, new(pkgdir.Finder).Dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Error copying test artifacts: %v\n", err)
+				}
+			}
+		})
+	}
+	defer copyArtifacts()
+
 	var (
 		interrupted    atomic.Bool
 		shutdownOnce   sync.Once
@@ -225,6 +874,15 @@ func run() int {
 		shutdownMu.Unlock()
 	}()
 
+	// Let the file sinks catch up before their files are closed. After an
+	// interrupt, the stream may not end promptly, e.g. when replaying, so
+	// the files are left with what they have.
+	defer func() {
+		if !interrupted.Load() {
+			broadcaster.Wait()
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
@@ -234,38 +892,69 @@ func run() int {
 			if count == 1 {
 				triggerShutdown()
 			} else {
+				shutdownMu.Lock()
 				if goTestCmd != nil {
 					goTestCmd.cleanup()
 				}
+				shutdownMu.Unlock()
 			}
 		}
 	}()
 
 	var exitCode int
 
-	skipLive := *notty || (*infile != "" && !*replay)
+	// The live display needs a terminal; when output is redirected, e.g.
+	// to a file or a CI log, fall back to plain output.
+	skipLive := *notty || !isTerminal || (*infile != "" && !*replay)
 
-	termWidth := termwidth.Get(os.Stdout.Fd())
+	// The terminal may be resized during a run, so the final report is
+	// formatted for its width when printed rather than at startup.
+	currentWidth := func() int { return termwidth.Get(os.Stdout.Fd()) }
 	columnsOverride := termwidth.FromEnv()
-
-	summaryOpts := format.SummaryOptions{
-		IncludeSkipped: *includeSkipped,
-		IncludeSlow:    *includeSlow,
+	if *widthFlag > 0 {
+		currentWidth = func() int { return *widthFlag }
+		columnsOverride = *widthFlag
 	}
+	termWidth := currentWidth()
 
 	if skipLive {
-		simple := output.NewSimpleOutput(os.Stdout, collector, *slowThreshold, summaryOpts, *verbose, termWidth, noColor)
+		simple := output.NewSimpleOutput(os.Stdout, collector, summaryOpts, *verbose, termWidth, noColor)
+		simple.SetWidthFunc(currentWidth)
+		simple.SetFirstFailure(*firstFailure)
+		if summaryOut.separate() {
+			simple.SetSummaryWriter(summaryOut.w, summaryOut.noColor)
+		}
 		if err := simple.ProcessEvents(engineEvents); err != nil {
 			fmt.Fprintf(os.Stderr, "Error processing events: %v\n", err)
 			return 1
 		}
-		if simple.HasFailures() || interrupted.Load() {
-			exitCode = 1
-		}
+		exitCode = collector.State().ExitCode(exitPolicy)
 	} else {
 		var p *tea.Program
 		var pDone chan struct{}
-		var eventCount int
+		var repainter *tui.Repainter
+		var live *tui.Model
+
+		var castOut *tui.CastWriter
+		if *recordCast != "" {
+			f, err := os.Create(*recordCast)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating cast file: %v\n", err)
+				return 1
+			}
+			castOut, err = tui.NewCastWriter(os.Stdout, f, termWidth, termwidth.Height(os.Stdout.Fd()))
+			if err != nil {
+				_ = f.Close()
+				fmt.Fprintf(os.Stderr, "Error writing cast file: %v\n", err)
+				return 1
+			}
+			defer func() {
+				if err := castOut.Err(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing cast file: %v\n", err)
+				}
+				_ = f.Close()
+			}()
+		}
 
 		// SimpleOutput is only used in verbose live mode to replay test output
 		// after the TUI closes. In non-verbose mode the summary alone is the
@@ -273,10 +962,14 @@ func run() int {
 		var outputBuf bytes.Buffer
 		var simpleOut *output.SimpleOutput
 		if *verbose {
-			simpleOut = output.NewSimpleOutput(&outputBuf, collector, *slowThreshold, summaryOpts, *verbose, termWidth, noColor)
+			simpleOut = output.NewSimpleOutput(&outputBuf, collector, summaryOpts, *verbose, termWidth, noColor)
 			simpleOut.Init()
 		}
 
+		// reportView is the summary view printed by printSummary; it's
+		// chosen interactively when -interactive is set.
+		reportView := format.SummaryViewAll
+
 		printSummary := func() {
 			collector.Finish()
 
@@ -292,10 +985,13 @@ func run() int {
 				for _, line := range lastRun.NonTestOutput {
 					fmt.Print(line)
 				}
-				summary := format.ComputeSummary(lastRun, *slowThreshold)
-				if summary != nil {
-					summaryText := format.NewSummaryFormatter(termWidth, noColor, summaryOpts).Format(summary)
-					if len(lastRun.NonTestOutput) > 0 || summary.HasTestDetailsWithOptions(summaryOpts) {
+				summary, opts := reportView.Apply(format.ComputeSummary(lastRun, format.WithOptions(summaryOpts)), summaryOpts)
+				if summary != nil && summaryOut.separate() {
+					summaryText := format.NewSummaryFormatter(currentWidth(), summaryOut.noColor, opts).Format(summary)
+					_, _ = fmt.Fprintln(summaryOut.w, summaryText)
+				} else if summary != nil {
+					summaryText := format.NewSummaryFormatter(currentWidth(), noColor, opts).Format(summary)
+					if len(lastRun.NonTestOutput) > 0 || summary.HasTestDetailsWithOptions(opts) {
 						fmt.Print("\n")
 					}
 					fmt.Println(summaryText)
@@ -303,100 +999,201 @@ func run() int {
 			}
 		}
 
-	EventLoop:
-		for evt := range engineEvents {
-			collector.Push(evt)
-			if simpleOut != nil && evt.Type != engine.EventRawLine {
-				simpleOut.ProcessEvent(evt)
+		// With -compact-runs, each finished run is summarized in a line
+		// printed above the live display, which stays open for the next
+		// run, and full reports are printed on demand.
+		reportRun := func(run *results.Run) string {
+			return strings.TrimRight(format.NewSummaryFormatter(currentWidth(), noColor, summaryOpts).Format(format.ComputeSummary(run, format.WithOptions(summaryOpts))), "\n")
+		}
+		lineRunID := 0 // The ID of the last run summarized in a line
+		printRunLine := func(p *tea.Program) {
+			lastRun := collector.State().MostRecentRun()
+			if lastRun == nil || lastRun.ID == lineRunID {
+				return
+			}
+			lineRunID = lastRun.ID
+			if simpleOut != nil {
+				simpleOut.Flush()
+				if outputBuf.Len() > 0 {
+					p.Println(strings.TrimRight(outputBuf.String(), "\n"))
+				}
+				outputBuf.Reset()
+				simpleOut.Init()
 			}
+			summary := format.ComputeSummary(lastRun, format.WithOptions(summaryOpts))
+			p.Println(format.NewSummaryFormatter(currentWidth(), noColor, summaryOpts).FormatRunLine(summary))
+		}
 
-			if p == nil {
-				if collector.State().CurrentRun != nil {
-					m := tui.NewModel(*replay, *rate, collector)
-					m.SlowThreshold = *slowThreshold
-					m.OnInterrupt = triggerShutdown
-					var progOpts []tea.ProgramOption
-					progOpts = append(progOpts, tea.WithColorProfile(profile))
-					if columnsOverride > 0 {
-						progOpts = append(progOpts, tea.WithFilter(func(_ tea.Model, msg tea.Msg) tea.Msg {
-							if ws, ok := msg.(tea.WindowSizeMsg); ok {
-								ws.Width = columnsOverride
-								return ws
-							}
-							return msg
-						}))
-					}
-					p = tea.NewProgram(m, progOpts...)
-					pDone = make(chan struct{})
+		// With -interactive, the final report can ask for the tests to be
+		// run again, so tang drives a test loop until the user quits.
+		for {
+		EventLoop:
+			for evt := range engineEvents {
+				collector.Push(evt)
+				if simpleOut != nil && evt.Type != engine.EventRawLine {
+					simpleOut.ProcessEvent(evt)
+				}
+
+				if p == nil {
+					if collector.State().CurrentRun != nil {
+						m := tui.NewModel(*replay, *rate, collector)
+						m.SummaryOptions = summaryOpts
+						m.SetTheme(summaryOpts.Theme)
+						m.SetSymbols(summaryOpts.Symbols)
+						m.LongRunningThreshold = *longRunning
+						m.Estimator = estimator
+						m.OnInterrupt = triggerShutdown
+						m.ShowHints = !*noHints
+						m.FailuresPane = *failuresPane
+						m.PinFailures = *pinFailures
+						m.FocusActive = *focusActive
+						m.HideSubtests = *hideSubtests
+						if *compactRuns {
+							m.ReportRun = reportRun
+						}
+						live = m
+						var progOpts []tea.ProgramOption
+						progOpts = append(progOpts, tea.WithColorProfile(profile))
+						if columnsOverride > 0 || castOut != nil {
+							progOpts = append(progOpts, tea.WithFilter(func(_ tea.Model, msg tea.Msg) tea.Msg {
+								if ws, ok := msg.(tea.WindowSizeMsg); ok {
+									if columnsOverride > 0 {
+										ws.Width = columnsOverride
+									}
+									if castOut != nil {
+										castOut.Resize(ws.Width, ws.Height)
+									}
+									return ws
+								}
+								return msg
+							}))
+						}
+						if castOut != nil {
+							progOpts = append(progOpts, tea.WithOutput(castOut))
+						}
+						p = tea.NewProgram(m, progOpts...)
+						pDone = make(chan struct{})
+						repainter = tui.NewRepainter(p.Send)
 
-					go func() {
-						if _, err := p.Run(); err != nil {
-							fmt.Fprintf(os.Stderr, "Error running live UI: %v\n", err)
+						go func() {
+							if _, err := p.Run(); err != nil {
+								fmt.Fprintf(os.Stderr, "Error running live UI: %v\n", err)
+							}
+							close(pDone)
+						}()
+					} else {
+						if evt.Type == engine.EventRawLine {
+							fmt.Println(string(evt.RawLine))
 						}
-						close(pDone)
-					}()
+					}
 				} else {
-					if evt.Type == engine.EventRawLine {
-						fmt.Println(string(evt.RawLine))
+					select {
+					case <-pDone:
+						printSummary()
+						p = nil
+						pDone = nil
+						break EventLoop
+					default:
 					}
-				}
-			} else {
-				select {
-				case <-pDone:
-					printSummary()
-					p = nil
-					pDone = nil
-					break EventLoop
-				default:
-				}
 
-				collector.Lock()
-				currentRun := collector.State().CurrentRun
-				collector.Unlock()
+					collector.Lock()
+					currentRun := collector.State().CurrentRun
+					collector.Unlock()
 
-				if currentRun == nil {
-					p.Send(tui.QuitMsg{})
-					<-pDone
-					p = nil
-					pDone = nil
+					if currentRun == nil && *compactRuns {
+						// Keep the display open for the next run, with a
+						// line summarizing this one above it, unless the
+						// stream is over and the full report follows.
+						if evt.Type != engine.EventComplete {
+							printRunLine(p)
+						}
+						if evt.Type == engine.EventRawLine {
+							p.Println(string(evt.RawLine))
+						}
+					} else if currentRun == nil {
+						p.Send(tui.QuitMsg{})
+						<-pDone
+						p = nil
+						pDone = nil
 
-					printSummary()
+						printSummary()
 
-					if simpleOut != nil {
-						outputBuf.Reset()
-						simpleOut.Init()
-					}
+						if simpleOut != nil {
+							outputBuf.Reset()
+							simpleOut.Init()
+						}
 
-					if evt.Type == engine.EventRawLine {
-						fmt.Println(string(evt.RawLine))
-					}
-				} else {
-					eventCount++
-					if eventCount%50 == 0 {
-						p.Send(tui.RepaintMsg{})
+						if evt.Type == engine.EventRawLine {
+							fmt.Println(string(evt.RawLine))
+						}
+					} else {
+						repainter.Event(time.Now())
 					}
 				}
 			}
-		}
 
-		if p != nil {
-			p.Send(tui.QuitMsg{})
-			<-pDone
-			printSummary()
-		}
+			action := tui.ReportQuit
+			if p != nil {
+				p.Send(tui.QuitMsg{})
+				<-pDone
+				p = nil
+				if *interactive && !interrupted.Load() {
+					reportView, action = browseReport(collector, summaryOpts, noColor, profile, goTestCmd != nil, live.ElidedTests(), historyRuns)
+				}
+				printSummary()
+			}
+			if action == tui.ReportQuit {
+				break
+			}
 
-		if interrupted.Load() {
-			exitCode = 1
+			args := goTestArgs
+			if action == tui.ReportRerunFailed {
+				args = rerunFailedArgs(goTestArgs, collector.State().MostRecentRun())
+			}
+			stopSampling()
+			goTestCmd.wait()
+			goTestCmd.cleanup()
+			collector.SetReproducibility(repro.WithArgs(args))
+			proc, err := startTests(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				return 1
+			}
+			shutdownMu.Lock()
+			goTestCmd = proc
+			shutdownMu.Unlock()
+			stopSampling = proc.sampleResources(collector, resourceSampleInterval)
+			engineEvents = stream(proc.stdout)
+			reportView = format.SummaryViewAll
+			if simpleOut != nil {
+				outputBuf.Reset()
+				simpleOut.Init()
+			}
 		}
 
-		for _, run := range collector.State().Runs {
-			if run.Counts.Failed > 0 {
+		exitCode = collector.State().ExitCode(exitPolicy)
+	}
+
+	if interrupted.Load() {
+		exitCode = 1
+	}
+
+	if *failOnRegression {
+		if lastRun := collector.State().MostRecentRun(); lastRun != nil {
+			summary := format.ComputeSummary(lastRun, format.WithOptions(summaryOpts))
+			if len(summary.Regressions(summaryOpts)) > 0 {
 				exitCode = 1
-				break
 			}
 		}
 	}
 
+	for _, run := range collector.State().Runs {
+		for _, v := range skipPolicy.Check(run) {
+			fmt.Fprintf(os.Stderr, "Skip policy: %s\n", v)
+			exitCode = 1
+		}
+	}
+
 	if goTestCmd != nil {
 		childExit := goTestCmd.wait()
 		if childExit > exitCode {
@@ -406,3 +1203,60 @@ func run() int {
 
 	return exitCode
 }
+
+// browseReport finishes the current run and shows its summary in a
+// full-screen report until the user quits, returning the view they last
+// selected and whether they asked to rerun the tests, which requires
+// rerun: tang started go test itself. The output of the elided tests
+// (keys of the run's TestResults) can be printed from the report, and each
+// test's results in the past runs are shown with its output.
+func browseReport(collector *results.Collector, opts format.SummaryOptions, noColor bool, profile colorprofile.Profile, rerun bool, elided []string, past []*format.SummaryJSON) (format.SummaryView, tui.ReportAction) {
+	collector.Finish()
+	lastRun := collector.State().MostRecentRun()
+	if lastRun == nil {
+		return format.SummaryViewAll, tui.ReportQuit
+	}
+
+	m := tui.NewReportModel(format.ComputeSummary(lastRun, format.WithOptions(opts)), opts, noColor)
+	if rerun {
+		m.AllowRerun(len(lastRun.FailedTests()) > 0)
+	}
+	var replayable []*results.TestResult
+	for _, key := range elided {
+		if test := lastRun.TestResults[key]; test != nil {
+			replayable = append(replayable, test)
+		}
+	}
+	m.SetReplayable(replayable)
+	m.SetHistory(past)
+	_, err := tea.NewProgram(m, tea.WithColorProfile(profile)).Run()
+	for _, line := range m.Replays() {
+		fmt.Println(line)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running report UI: %v\n", err)
+		return m.SelectedView(), tui.ReportQuit
+	}
+	return m.SelectedView(), m.Action()
+}
+
+// tangVersion returns the module version tang was built from, or
+// "(devel)" for a build from a checkout.
+func tangVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// flagSet reports whether the flag named name was given on the command
+// line, rather than left at its default.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
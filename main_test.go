@@ -1,16 +1,25 @@
 package main
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/parser"
+	"github.com/ansel1/tang/results"
 	"github.com/stretchr/testify/require"
 )
 
@@ -173,3 +182,268 @@ func TestTangPipeModeRegression(t *testing.T) {
 	require.Contains(t, tangStdout.String(), "ok")
 	require.Empty(t, tangStderr.String())
 }
+
+func TestWidthFlag(t *testing.T) {
+	tangBinary := buildTangBinary(t)
+
+	pkg := "github.com/example/averyveryverylongpackagename/subpackage"
+	message := "x_test.go:12: the quick brown fox jumps over the lazy dog and keeps running far past the edge"
+	input := strings.Join([]string{
+		`{"Time":"2025-11-01T15:43:02.993511-05:00","Action":"start","Package":"` + pkg + `"}`,
+		`{"Time":"2025-11-01T15:43:02.993565-05:00","Action":"run","Package":"` + pkg + `","Test":"TestExample"}`,
+		`{"Time":"2025-11-01T15:43:02.993570-05:00","Action":"output","Package":"` + pkg + `","Test":"TestExample","Output":"    ` + message + `\n"}`,
+		`{"Time":"2025-11-01T15:43:02.993579-05:00","Action":"fail","Package":"` + pkg + `","Test":"TestExample","Elapsed":0.001}`,
+		`{"Time":"2025-11-01T15:43:02.993590-05:00","Action":"fail","Package":"` + pkg + `","Elapsed":0.002}`,
+	}, "\n")
+	infile := filepath.Join(t.TempDir(), "test.json")
+	require.NoError(t, os.WriteFile(infile, []byte(input), 0o644))
+
+	rule := func(stdout string) string {
+		for _, line := range strings.Split(stdout, "\n") {
+			if line != "" && strings.Trim(line, "-") == "" {
+				return line
+			}
+		}
+		return ""
+	}
+
+	for _, width := range []int{100, 140} {
+		exitCode, stdout, stderr := runTangCommand(t, tangBinary, "-notty", "-width", fmt.Sprint(width), "-f", infile)
+		require.Equal(t, 1, exitCode, stderr)
+		require.Len(t, rule(stdout), width, "summary rule at -width %d:\n%s", width, stdout)
+	}
+
+	// Narrower than the package line, the rule spans it and long lines are
+	// neither wrapped nor truncated, so they can still be copied.
+	exitCode, stdout, stderr := runTangCommand(t, tangBinary, "-notty", "-width", "40", "-f", infile)
+	require.Equal(t, 1, exitCode, stderr)
+	require.Greater(t, len(rule(stdout)), 40)
+	require.Contains(t, stdout, "        "+message+"\n")
+	require.Contains(t, stdout, "go test -count=1 -run '^TestExample$' "+pkg+"\n")
+	require.NotContains(t, stdout, "…")
+
+	exitCode, _, stderr = runTangCommand(t, tangBinary, "-width", "-1", "-f", infile)
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, stderr, "-width must be >= 0")
+}
+
+func TestRerunFailedArgs(t *testing.T) {
+	run := results.NewRun(1)
+	for _, name := range []string{"TestA", "TestB"} {
+		tr := results.NewTestResult("example.com/pkg", name)
+		tr.Latest().Status = results.StatusFailed
+		run.TestResults["example.com/pkg/"+name] = tr
+	}
+	run.Packages["example.com/pkg"] = &results.PackageResult{Name: "example.com/pkg", TestOrder: []string{"TestA", "TestB"}}
+	run.PackageOrder = []string{"example.com/pkg"}
+
+	got := rerunFailedArgs([]string{"-count", "1", "./...", "-args", "-v"}, run)
+	want := []string{"-count", "1", "./...", "-run", "^(TestA|TestB)$", "-args", "-v"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	got = rerunFailedArgs([]string{"-run", "TestA", "./..."}, run)
+	want = []string{"-run", "TestA", "./...", "-run", "^(TestA|TestB)$"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestReproArgs(t *testing.T) {
+	sj := &format.SummaryJSON{
+		Packages: []format.PackageJSON{{Name: "example.com/a"}, {Name: "example.com/b"}, {Name: "example.com/c"}},
+		Repro:    &format.ReproJSON{Args: []string{"-shuffle", "on", "-run", "TestA", "./...", "-args", "-v"}},
+	}
+	want := [][]string{{"-shuffle", "on", "-run", "TestA", "./...", "-args", "-v"}}
+	require.Equal(t, want, reproArgs(sj))
+
+	// One seed for every package reruns the command with it.
+	sj.Repro.ShuffleSeeds = map[string]string{"example.com/a": "1", "example.com/b": "1"}
+	want = [][]string{{"-run", "TestA", "./...", "-shuffle=1", "-args", "-v"}}
+	require.Equal(t, want, reproArgs(sj))
+
+	// Packages with differing seeds are rerun by seed.
+	sj.Repro.ShuffleSeeds = map[string]string{"example.com/a": "1", "example.com/b": "2", "example.com/c": "1"}
+	want = [][]string{
+		{"-run", "TestA", "-shuffle=1", "example.com/a", "example.com/c", "-args", "-v"},
+		{"-run", "TestA", "-shuffle=2", "example.com/b", "-args", "-v"},
+	}
+	require.Equal(t, want, reproArgs(sj))
+}
+
+func TestFindRun(t *testing.T) {
+	runs := []*format.SummaryJSON{{RunID: "20240102-030405-aaaaaa"}, {RunID: "20240102-030405-bbbbbb"}, {RunID: "20240103-000000-cccccc"}}
+	if sj, err := findRun(runs, "20240103"); err != nil || sj != runs[2] {
+		t.Errorf("Expected the run of a unique prefix, got %v, %v", sj, err)
+	}
+	if _, err := findRun(runs, "20240102"); err == nil {
+		t.Error("Expected an ambiguous prefix to be an error")
+	}
+	if _, err := findRun(runs, "nope"); err == nil {
+		t.Error("Expected an unknown run to be an error")
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile, markdownFile := filepath.Join(dir, "summary.json"), filepath.Join(dir, "summary.md")
+	now := time.Now()
+	collector := results.NewCollector()
+	// Checkpoints are flushed by the test rather than a goroutine.
+	c := &checkpointer{
+		collector:    collector,
+		opts:         format.NewSummaryOptions(),
+		jsonFile:     jsonFile,
+		markdownFile: markdownFile,
+		packages:     2,
+		every:        time.Minute,
+		now:          func() time.Time { return now },
+		wake:         make(chan struct{}, 1),
+	}
+	collector.SetEventHandler(c.Handle)
+
+	push := func(action, pkg string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: action, Package: pkg}})
+	}
+	for _, pkg := range []string{"example.com/a", "example.com/b", "example.com/c", "example.com/d"} {
+		push("start", pkg)
+	}
+
+	push("pass", "example.com/a")
+	c.flush()
+	require.NoFileExists(t, jsonFile, "checkpoint before 2 packages finished")
+
+	push("fail", "example.com/b")
+	c.flush()
+	sj, err := readSummaryJSONFile(jsonFile)
+	require.NoError(t, err)
+	require.Equal(t, "running", sj.Status)
+	require.Len(t, sj.Packages, 4)
+	markdown, err := os.ReadFile(markdownFile)
+	require.NoError(t, err)
+	require.Contains(t, string(markdown), "Tests in progress")
+
+	// A minute after the last checkpoint, one package is enough.
+	now = now.Add(time.Minute)
+	push("pass", "example.com/c")
+	c.flush()
+	sj, err = readSummaryJSONFile(jsonFile)
+	require.NoError(t, err)
+	require.Equal(t, "passed", sj.Packages[2].Status)
+}
+
+func TestRunStore(t *testing.T) {
+	db, err := history.OpenDB(filepath.Join(t.TempDir(), "tang.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	collector := results.NewCollector()
+	// Rows are flushed by the test rather than a goroutine.
+	s := &runStore{db: db, collector: collector, opts: format.NewSummaryOptions(), wake: make(chan struct{}, 1), runs: make(map[int]int64)}
+	collector.SetEventHandler(s.Handle)
+
+	push := func(action, test string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: action, Package: "example.com/a", Test: test}})
+	}
+	push("start", "")
+	push("run", "TestA")
+	push("run", "TestB")
+	push("pass", "TestA")
+	s.flush()
+
+	// The run is recorded as it progresses.
+	runs, err := db.Load(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, "running", runs[0].Status)
+	require.Empty(t, runs[0].Packages)
+	require.Equal(t, []format.TestResultJSON{{Package: "example.com/a", Name: "TestA", Iteration: 1, Status: "passed"}}, runs[0].Results)
+
+	push("fail", "TestB")
+	push("fail", "")
+	collector.Finish()
+	s.flush()
+
+	runs, err = db.Load(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, "failed", runs[0].Status)
+	require.Equal(t, 2, runs[0].Tests)
+	require.Len(t, runs[0].Packages, 1)
+	require.Equal(t, 1, runs[0].Packages[0].Failed)
+	require.Len(t, runs[0].Results, 2)
+	require.Equal(t, "failed", runs[0].Results[1].Status)
+}
+
+func TestWriteBundle(t *testing.T) {
+	stream := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"fail","Package":"example.com/pkg","Test":"TestA","Elapsed":1}
+{"Time":"2024-01-01T00:00:01Z","Action":"fail","Package":"example.com/pkg","Elapsed":1}
+`
+	var buf bytes.Buffer
+	runs, err := writeBundle(&buf, []byte(stream), bundleMetadata{TangVersion: "v1.2.3", OS: "linux"})
+	if err != nil || runs != 1 {
+		t.Fatalf("writeBundle() = %d, %v; want 1 run", runs, err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(data)
+	}
+
+	if files["stream.json"] != stream {
+		t.Errorf("Expected the stream as is, got %q", files["stream.json"])
+	}
+	sj, err := format.ReadSummaryJSON(strings.NewReader(files["summary.json"]))
+	if err != nil || sj.Failed != 1 || len(sj.Results) != 1 {
+		t.Errorf("Expected a summary with 1 failed test, got %+v (%v)", sj, err)
+	}
+	if !strings.Contains(files["metadata.json"], `"tangVersion": "v1.2.3"`) {
+		t.Errorf("Expected the metadata, got %q", files["metadata.json"])
+	}
+}
+
+func TestPreparePhase(t *testing.T) {
+	var out, stderr bytes.Buffer
+	p := &preparePhase{
+		out:     &out,
+		stderr:  &stderr,
+		format:  format.DurationStyleCompact.Format,
+		start:   time.Now(),
+		stopped: make(chan struct{}),
+	}
+	require.Equal(t, "preparing: building  3.0s", p.status(p.start.Add(3*time.Second)))
+
+	_, err := io.WriteString(p, "go: downloading golang.org/x/sys v0.20.0\ngo: down")
+	require.NoError(t, err)
+	_, err = io.WriteString(p, "loading example.com/mod v1.2.3\ngo: example.com/bad: no such module\n")
+	require.NoError(t, err)
+	require.Contains(t, p.status(time.Now()), "preparing: downloading example.com/mod v1.2.3 (2 modules)")
+	require.Equal(t, "go: example.com/bad: no such module\n", stderr.String(), "downloads feed the line, other lines pass through")
+	require.Contains(t, p.status(time.Now().Add(downloadQuiet)), "preparing: building")
+
+	// The first output of go test stops the phase, passing stderr through.
+	r := p.watch(io.NopCloser(strings.NewReader("{}\n")))
+	_, err = r.Read(make([]byte, 10))
+	require.NoError(t, err)
+	require.True(t, p.done)
+	_, err = io.WriteString(p, "go: downloading late v1.0.0\n")
+	require.NoError(t, err)
+	require.Contains(t, stderr.String(), "go: downloading late v1.0.0\n")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ansel1/tang/output/format"
+)
+
+// runMerge implements the `tang merge` subcommand, which merges the
+// -summary-json files of the shards of a test run into one.
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	outFile := fs.String("o", "", "Write the merged summary to the specified file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang merge [flags] <summary.json>...\n\n")
+		fmt.Fprintf(os.Stderr, "Merge the -summary-json files of the shards of a test run, e.g. parallel CI jobs, into\n")
+		fmt.Fprintf(os.Stderr, "one summary. Tests which ran in more than one shard with differing results are reported.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Error: merge requires at least one summary file\n")
+		return 1
+	}
+
+	shards := make([]*format.SummaryJSON, 0, fs.NArg())
+	for _, name := range fs.Args() {
+		sj, err := readSummaryJSONFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading summary file: %v\n", err)
+			return 1
+		}
+		shards = append(shards, sj)
+	}
+	merged := format.MergeSummaryJSON(shards)
+	for _, s := range merged.Suspicious {
+		fmt.Fprintf(os.Stderr, "Suspicious: %s\n", s)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating merged summary file: %v\n", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := format.EncodeSummaryJSON(w, merged); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing merged summary: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readSummaryJSONFile reads a summary written by -summary-json.
+func readSummaryJSONFile(name string) (*format.SummaryJSON, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	sj, err := format.ReadSummaryJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return sj, nil
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// CopyArtifacts copies the artifacts attached by each failed test in the run
+// (see results.ArtifactMarker) to dir, where format.ArtifactPaths lists
+// them, and returns the paths written. Relative artifact paths are resolved
+// against the package's directory, as returned by pkgDir. Artifacts which
+// can't be copied, e.g. because the test removed them, are skipped, and the
+// errors returned together.
+func CopyArtifacts(dir string, run *results.Run, pkgDir func(pkg string) string) ([]string, error) {
+	var paths []string
+	var errs []error
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := run.TestResults[pkgName+"/"+testName]
+			if tr == nil || !tr.Failed() {
+				continue
+			}
+			copies := format.ArtifactPaths(dir, tr)
+			for _, src := range slices.Sorted(maps.Keys(copies)) {
+				dst := copies[src]
+				if !filepath.IsAbs(src) && pkgDir != nil {
+					if d := pkgDir(pkgName); d != "" {
+						src = filepath.Join(d, src)
+					}
+				}
+				if err := copyFile(dst, src); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				paths = append(paths, dst)
+			}
+		}
+	}
+	return paths, errors.Join(errs...)
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/parser"
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// artifactEvents returns the events of a package whose failing test attaches
+// the given artifacts.
+func artifactEvents(pkg string, artifacts ...string) []engine.Event {
+	events := failingPackageEvents(pkg)
+	var attach []engine.Event
+	for _, artifact := range artifacts {
+		attach = append(attach, engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: baseTime, Action: "output", Package: pkg, Test: "TestFail", Output: "    test_fail.go:9: TANG_ARTIFACT: " + artifact + "\n"}})
+	}
+	return append(events[:3], append(attach, events[3:]...)...)
+}
+
+func TestCopyArtifacts(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "a", "b"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a", "shot.png"), []byte("one"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a", "b", "shot.png"), []byte("two"), 0o644))
+
+	collector := results.NewCollector()
+	for _, evt := range artifactEvents("example.com/a", "shot.png", filepath.Join(src, "a", "b", "shot.png"), "missing.txt") {
+		collector.Push(evt)
+	}
+	dir := filepath.Join(t.TempDir(), "artifacts")
+
+	paths, err := CopyArtifacts(dir, collector.State().MostRecentRun(), func(string) string { return filepath.Join(src, "a") })
+	require.ErrorContains(t, err, "missing.txt")
+	testDir := filepath.Join(dir, "example.com_a__TestFail")
+	assert.ElementsMatch(t, []string{filepath.Join(testDir, "shot.png"), filepath.Join(testDir, "2_shot.png")}, paths)
+
+	data, err := os.ReadFile(filepath.Join(testDir, "shot.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+	data, err = os.ReadFile(filepath.Join(testDir, "2_shot.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+}
+
+func TestSimpleOutput_ArtifactsInFailures(t *testing.T) {
+	collector := results.NewCollector()
+	var buf bytes.Buffer
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, true)
+
+	require.NoError(t, simple.ProcessEvents(sendEvents(artifactEvents("example.com/pkg", "/tmp/shot.png"))))
+	assert.Contains(t, buf.String(), "artifact: /tmp/shot.png")
+
+	collector = results.NewCollector()
+	buf.Reset()
+	simple = NewSimpleOutput(&buf, collector, format.NewSummaryOptions(format.WithArtifactDir("artifacts")), false, 80, true)
+
+	require.NoError(t, simple.ProcessEvents(sendEvents(artifactEvents("example.com/pkg", "/tmp/shot.png"))))
+	assert.Contains(t, buf.String(), "artifact: "+filepath.Join("artifacts", "example.com_pkg__TestFail", "shot.png"))
+}
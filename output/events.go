@@ -0,0 +1,77 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// EventJSON is the JSON Lines form of a results.Event, written by
+// -events-out. Statuses use results.Status names, e.g. "running" or
+// "failed".
+type EventJSON struct {
+	Type       results.EventType `json:"type"`
+	Run        int               `json:"run"`
+	Time       time.Time         `json:"time,omitzero"`
+	Package    string            `json:"package,omitempty"`
+	Test       string            `json:"test,omitempty"`
+	Iteration  int               `json:"iteration,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	PrevStatus string            `json:"prev_status,omitempty"`
+	Elapsed    float64           `json:"elapsed,omitempty"` // seconds
+	Output     string            `json:"output,omitempty"`
+}
+
+// NewEventJSON converts an Event into its JSON form.
+func NewEventJSON(evt results.Event) *EventJSON {
+	return &EventJSON{
+		Type:       evt.Type,
+		Run:        evt.RunID,
+		Time:       evt.Time,
+		Package:    evt.PackageName,
+		Test:       evt.TestName,
+		Iteration:  evt.Iteration,
+		Status:     statusName(evt.Status),
+		PrevStatus: statusName(evt.PrevStatus),
+		Elapsed:    evt.Elapsed.Seconds(),
+		Output:     evt.Output,
+	}
+}
+
+// statusName returns the name of a status, or "" for StatusUnknown so it
+// can be omitted.
+func statusName(s results.Status) string {
+	if s == results.StatusUnknown {
+		return ""
+	}
+	return s.String()
+}
+
+// EventWriter writes results.Events to an io.Writer as JSON Lines, one
+// object per event, as they happen. Use Write as a collector event handler.
+type EventWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewEventWriter returns an EventWriter writing to w. Each event is written
+// with a single Write call, so w needn't be buffered.
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w)}
+}
+
+// Write writes evt. After a write error, further events are dropped; the
+// error is returned by Err.
+func (ew *EventWriter) Write(evt results.Event) {
+	if ew.err != nil {
+		return
+	}
+	ew.err = ew.enc.Encode(NewEventJSON(evt))
+}
+
+// Err returns the first error encountered writing events.
+func (ew *EventWriter) Err() error {
+	return ew.err
+}
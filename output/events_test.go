@@ -0,0 +1,64 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewEventWriter(&buf)
+	collector := results.NewCollector()
+	collector.SetEventHandler(ew.Write)
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	collector.Push(engine.Event{Type: engine.EventComplete})
+	require.NoError(t, ew.Err())
+
+	var events []EventJSON
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var evt EventJSON
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &evt), scanner.Text())
+		events = append(events, evt)
+	}
+
+	type transition struct {
+		Type       results.EventType
+		Test       string
+		PrevStatus string
+		Status     string
+	}
+	var transitions []transition
+	var output []string
+	for _, evt := range events {
+		assert.Equal(t, 1, evt.Run)
+		if evt.Type == results.EventTestOutput {
+			output = append(output, evt.Output)
+			continue
+		}
+		transitions = append(transitions, transition{evt.Type, evt.Test, evt.PrevStatus, evt.Status})
+	}
+
+	assert.Equal(t, []transition{
+		{results.EventRunStarted, "", "", ""},
+		{results.EventPackageUpdated, "", "", "running"},
+		{results.EventTestUpdated, "TestFail", "", "running"},
+		{results.EventTestUpdated, "TestFail", "running", "failed"},
+		{results.EventPackageUpdated, "", "running", "failed"},
+		{results.EventRunFinished, "", "running", "failed"},
+	}, transitions)
+	assert.Equal(t, []string{
+		"=== RUN   TestFail",
+		"    test_fail.go:10: assertion failed",
+		"--- FAIL: TestFail (0.00s)",
+	}, output)
+}
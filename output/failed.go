@@ -0,0 +1,49 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ansel1/tang/results"
+)
+
+// Formats accepted by WriteFailedTests.
+const (
+	FailedFormatRun  = "run"  // A single `go test -run` regexp
+	FailedFormatList = "list" // One "<package> <test>" pair per line
+)
+
+// WriteFailedTests writes the run's failed tests to w for rerunning. With
+// FailedFormatRun the output is a -run regexp usable as
+// `go test -run "$(cat file)"`; with FailedFormatList it is a
+// newline-separated list of package and test name pairs. Nothing is written
+// when no tests failed.
+func WriteFailedTests(w io.Writer, run *results.Run, format string) error {
+	failed := run.FailedTests()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	switch format {
+	case FailedFormatRun:
+		var names []string
+		for _, pkgName := range run.PackageOrder {
+			names = append(names, failed[pkgName]...)
+		}
+		_, err := fmt.Fprintln(w, results.RunPattern(names))
+		return err
+
+	case FailedFormatList:
+		for _, pkgName := range run.PackageOrder {
+			for _, name := range failed[pkgName] {
+				if _, err := fmt.Fprintf(w, "%s %s\n", pkgName, name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown failed test format %q", format)
+	}
+}
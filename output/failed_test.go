@@ -0,0 +1,42 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFailedTests(t *testing.T) {
+	collector := results.NewCollector()
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	for _, evt := range failingPackageEvents("example.com/b") {
+		collector.Push(evt)
+	}
+	run := collector.State().MostRecentRun()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFailedTests(&buf, run, FailedFormatRun))
+	assert.Equal(t, "^TestFail$\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteFailedTests(&buf, run, FailedFormatList))
+	assert.Equal(t, "example.com/a TestFail\nexample.com/b TestFail\n", buf.String())
+
+	assert.Error(t, WriteFailedTests(&buf, run, "bogus"))
+}
+
+func TestWriteFailedTests_NoFailures(t *testing.T) {
+	collector := results.NewCollector()
+	for _, evt := range passingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFailedTests(&buf, collector.State().MostRecentRun(), FailedFormatRun))
+	assert.Empty(t, buf.String())
+}
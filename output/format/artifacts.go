@@ -0,0 +1,38 @@
+package format
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// ArtifactPaths returns where -artifacts copies the artifacts attached by a
+// test's failed executions in dir, keyed by the attached path: a directory
+// per test, named like its extracted log file without the extension, holding
+// the artifacts under their own names, e.g.
+// "<dir>/example.com_pkg__TestA/screenshot.png". Artifacts with the same
+// name are numbered, e.g. "2_screenshot.png".
+func ArtifactPaths(dir string, tr *results.TestResult) map[string]string {
+	testDir := filepath.Join(dir, strings.TrimSuffix(LogFileName(tr.Package, tr.Name), ".log"))
+	paths := make(map[string]string)
+	names := make(map[string]int)
+	for _, exec := range tr.Executions {
+		if exec.Status != results.StatusFailed {
+			continue
+		}
+		for _, artifact := range exec.Artifacts {
+			if _, ok := paths[artifact]; ok {
+				continue
+			}
+			name := filepath.Base(artifact)
+			names[name]++
+			if n := names[name]; n > 1 {
+				name = fmt.Sprintf("%d_%s", n, name)
+			}
+			paths[artifact] = filepath.Join(testDir, name)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,85 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarkdownMaxBytes is the size budget of the Markdown summary's failures
+// when SummaryOptions.MaxDetailsBytes isn't set: under the 65,536
+// characters GitHub allows in a pull request comment, leaving room for the
+// headline, the package table and the reproduce commands.
+const MarkdownMaxBytes = 60_000
+
+// detailsBudget caps the size of a report's test details, e.g. the failures'
+// output, so reports posted to size-limited places, like pull request
+// comments and CI annotations, fit. Text is taken in order until the budget
+// runs out; the rest is dropped, and counted for the trailer, so the same
+// run always truncates at the same place.
+type detailsBudget struct {
+	limit        int // Bytes; 0 is no limit
+	used         int
+	omittedBytes int
+	omittedLines int
+}
+
+// exhausted reports whether text no longer fits.
+func (b *detailsBudget) exhausted() bool {
+	return b.limit > 0 && b.used >= b.limit
+}
+
+// fits reports whether n more bytes fit in the budget, and takes them if so.
+func (b *detailsBudget) fits(n int) bool {
+	if b.limit > 0 && b.used+n > b.limit {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+// omit counts text as dropped.
+func (b *detailsBudget) omit(text string) {
+	b.omittedBytes += len(text)
+	b.omittedLines += strings.Count(text, "\n")
+	if b.limit > 0 {
+		b.used = b.limit // Nothing after dropped text is shown
+	}
+}
+
+// write writes text to sb, or as many of its leading whole lines as fit,
+// dropping the rest.
+func (b *detailsBudget) write(sb *strings.Builder, text string) {
+	if b.exhausted() {
+		b.omit(text)
+		return
+	}
+	if b.fits(len(text)) {
+		sb.WriteString(text)
+		return
+	}
+	n := strings.LastIndexByte(text[:b.limit-b.used], '\n') + 1
+	sb.WriteString(text[:n])
+	b.used += n
+	b.omit(text[n:])
+}
+
+// trailer returns a line noting how much was dropped, and where the full
+// output can be found, or "" if nothing was dropped.
+func (b *detailsBudget) trailer(opts SummaryOptions) string {
+	if b.omittedBytes == 0 {
+		return ""
+	}
+	lines := "lines"
+	if b.omittedLines == 1 {
+		lines = "line"
+	}
+	s := fmt.Sprintf("… %s (%d %s) of test details omitted to fit %s",
+		FormatBytes(uint64(b.omittedBytes)), b.omittedLines, lines, FormatBytes(uint64(b.limit)))
+	switch {
+	case opts.LogDir != "":
+		s += "; full output of each failure in " + opts.LogDir
+	case opts.FullOutput != "":
+		s += "; full output in " + opts.FullOutput
+	}
+	return s
+}
@@ -133,7 +133,7 @@ func TestComputeSummaryWithBuildFailures(t *testing.T) {
 	run.Packages["github.com/test/passing"] = pkg3
 	run.PackageOrder = []string{"github.com/test/broken", "github.com/test/working", "github.com/test/passing"}
 
-	summary := ComputeSummary(run, 10*time.Second)
+	summary := ComputeSummary(run)
 
 	// Verify Run field is set
 	if summary.Run != run {
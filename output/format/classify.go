@@ -0,0 +1,148 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// CategoryFlakeSuspect tags failures of tests which also passed in the same
+// run, e.g. with -count=N. It's assigned in addition to any rule category.
+const CategoryFlakeSuspect = "flake-suspect"
+
+// SkipCategoryShortMode tags skips caused by go test -short, grouping them
+// apart from skips which depend on the environment.
+const SkipCategoryShortMode = "short-mode"
+
+// FailureRule assigns Category to failures whose output matches Pattern.
+// Patterns are matched against the failure's output lines joined with
+// newlines, in multi-line mode, so ^ and $ match at line boundaries.
+type FailureRule struct {
+	Category string
+	Pattern  *regexp.Regexp
+}
+
+// DefaultFailureRules classify common causes of test failures. Rules are
+// tried in order and the first match wins, so more specific rules come
+// first: a test timeout is also a panic.
+var DefaultFailureRules = []FailureRule{
+	{"timeout", regexp.MustCompile(`(?m)^panic: test timed out after|context deadline exceeded`)},
+	{"panic", regexp.MustCompile(`(?m)^(panic: |fatal error: )|\[recovered\]`)},
+	{"docker", regexp.MustCompile(`(?i)docker daemon|docker: |testcontainers`)},
+	{"network", regexp.MustCompile(`connection refused|connection reset by peer|no such host|network is unreachable|i/o timeout|dial tcp`)},
+	{"assertion", regexp.MustCompile(`(?m)Error Trace:|^\s*Error:|\bexpected\b.*\bgot\b|\bgot\b.*\bwant\b`)},
+}
+
+// CategoryCount is the number of failures in a category.
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// ClassifyFailure returns the categories of a failed test execution: the
+// category of the first rule matching its output, if any, plus
+// CategoryFlakeSuspect if another execution of the test passed.
+func ClassifyFailure(rules []FailureRule, tr *results.TestResult, exec *results.TestExecution) []string {
+	var categories []string
+	output := strings.Join(exec.Output, "\n")
+	for _, rule := range rules {
+		if rule.Pattern.MatchString(output) {
+			categories = append(categories, rule.Category)
+			break
+		}
+	}
+	for _, other := range tr.Executions {
+		if other.Status == results.StatusPassed {
+			categories = append(categories, CategoryFlakeSuspect)
+			break
+		}
+	}
+	return categories
+}
+
+// countCategories counts failures per category, most frequent first.
+func countCategories(failures []*TestExecutionEntry) []CategoryCount {
+	counts := make(map[string]int)
+	for _, entry := range failures {
+		for _, c := range entry.Categories {
+			counts[c]++
+		}
+	}
+	result := make([]CategoryCount, 0, len(counts))
+	for c, n := range counts {
+		result = append(result, CategoryCount{Category: c, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Category < result[j].Category
+	})
+	return result
+}
+
+// categoriesByExecution maps each failed or skipped execution in the
+// summary to its categories, for exports which walk the run's tests
+// themselves.
+func (s *Summary) categoriesByExecution() map[*results.TestExecution][]string {
+	m := make(map[*results.TestExecution][]string, len(s.Failures)+len(s.Skipped))
+	for _, entry := range s.Failures {
+		m[entry.TestExecution] = entry.Categories
+	}
+	for _, entry := range s.Skipped {
+		if len(entry.Categories) > 0 {
+			m[entry.TestExecution] = entry.Categories
+		}
+	}
+	return m
+}
+
+// ParseFailureRules reads failure rules, one per line, in the form
+// "category: regexp". Blank lines and lines starting with # are ignored.
+func ParseFailureRules(r io.Reader) ([]FailureRule, error) {
+	var rules []FailureRule
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		category, pattern, ok := strings.Cut(text, ":")
+		category, pattern = strings.TrimSpace(category), strings.TrimSpace(pattern)
+		if !ok || category == "" || pattern == "" {
+			return nil, fmt.Errorf("line %d: want \"category: regexp\", got %q", line, text)
+		}
+		re, err := regexp.Compile("(?m)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, FailureRule{Category: category, Pattern: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadFailureRules reads failure rules from the file at path. See
+// ParseFailureRules.
+func LoadFailureRules(path string) ([]FailureRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	rules, err := ParseFailureRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,162 @@
+package format
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   []string
+		expected []string
+	}{
+		{"assertion", []string{"    foo_test.go:12: ", "        \tError Trace:\tfoo_test.go:12", "        \tError:      \tNot equal: "}, []string{"assertion"}},
+		{"got want", []string{"    foo_test.go:12: got 1, want 2"}, []string{"assertion"}},
+		{"timeout before panic", []string{"panic: test timed out after 10m0s"}, []string{"timeout"}},
+		{"panic", []string{"panic: runtime error: index out of range [recovered]"}, []string{"panic"}},
+		{"network", []string{"dial tcp 127.0.0.1:5432: connect: connection refused"}, []string{"network"}},
+		{"docker", []string{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock"}, []string{"docker"}},
+		{"unclassified", []string{"something odd"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := results.NewTestResult("pkg", "TestA")
+			exec := tr.Latest()
+			exec.Status = results.StatusFailed
+			exec.Output = tt.output
+			if got := ClassifyFailure(DefaultFailureRules, tr, exec); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClassifyFailureFlakeSuspect(t *testing.T) {
+	tr := results.NewTestResult("pkg", "TestA")
+	tr.Latest().Status = results.StatusPassed
+	exec := tr.AppendExecution()
+	exec.Status = results.StatusFailed
+	exec.Output = []string{"    a_test.go:5: expected 1, got 2"}
+
+	expected := []string{"assertion", CategoryFlakeSuspect}
+	if got := ClassifyFailure(DefaultFailureRules, tr, exec); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestParseFailureRules(t *testing.T) {
+	rules, err := ParseFailureRules(strings.NewReader(`
+# comment
+database: pq: .*
+fixtures:   ^missing fixture
+`))
+	if err != nil {
+		t.Fatalf("ParseFailureRules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Category != "database" || rules[1].Category != "fixtures" {
+		t.Fatalf("Unexpected rules %+v", rules)
+	}
+	if !rules[1].Pattern.MatchString("first line\nmissing fixture users") {
+		t.Error("Expected ^ to match at line starts")
+	}
+
+	for _, bad := range []string{"no separator", "empty:", ": pattern", "bad: ("} {
+		if _, err := ParseFailureRules(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSummaryFailureCategories(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, Elapsed: time.Second}
+	for _, tc := range []struct {
+		name   string
+		output string
+	}{
+		{"TestA", "    a_test.go:1: got 1, want 2"},
+		{"TestB", "    b_test.go:1: got 3, want 4"},
+		{"TestC", "dial tcp: lookup db: no such host"},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = results.StatusFailed
+		tr.Latest().Output = []string{tc.output}
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+		pkg.Counts.Failed++
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+
+	summary := ComputeSummary(run)
+	expected := []CategoryCount{{"assertion", 2}, {"network", 1}}
+	if !reflect.DeepEqual(summary.Categories, expected) {
+		t.Errorf("Expected %v, got %v", expected, summary.Categories)
+	}
+
+	output := NewSummaryFormatter(80, true).Format(summary)
+	for _, s := range []string{
+		"--- FAIL: TestA (0.00s) [assertion]",
+		"--- FAIL: TestC (0.00s) [network]",
+		"failure categories: assertion 2, network 1",
+	} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q, got:\n%s", s, output)
+		}
+	}
+
+	if summary := ComputeSummary(run, WithFailureRules([]FailureRule{})); len(summary.Categories) != 0 {
+		t.Errorf("Expected no categories without rules, got %v", summary.Categories)
+	}
+}
+
+func TestSummaryShortModeSkips(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed, Elapsed: time.Second}
+	for _, tc := range []struct {
+		name   string
+		reason string
+	}{
+		{"TestA", "skipping in short mode"},
+		{"TestB", "requires docker"},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = results.StatusSkipped
+		tr.Latest().SkipReason = tc.reason
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+		pkg.Counts.Skipped++
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+
+	opts := NewSummaryOptions(WithSkipped(true))
+	summary := ComputeSummary(run, WithOptions(opts))
+	if summary.ShortModeSkips != 1 {
+		t.Errorf("Expected 1 short mode skip, got %d", summary.ShortModeSkips)
+	}
+
+	output := NewSummaryFormatter(80, true, opts).Format(summary)
+	for _, s := range []string{
+		"--- SKIP: TestA (0.00s) [short-mode]",
+		"skips: 1 in short mode, 1 other",
+	} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q, got:\n%s", s, output)
+		}
+	}
+	if strings.Contains(output, "TestB (0.00s) [") {
+		t.Errorf("Expected no tag on an environment skip, got:\n%s", output)
+	}
+
+	sj := NewSummaryJSON(summary)
+	if len(sj.Results) != 2 || !reflect.DeepEqual(sj.Results[0].Categories, []string{SkipCategoryShortMode}) || sj.Results[1].Categories != nil {
+		t.Errorf("Expected only TestA to carry the short-mode category, got %+v", sj.Results)
+	}
+}
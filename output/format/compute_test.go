@@ -1,6 +1,7 @@
 package format
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -61,7 +62,7 @@ func TestComputeSummaryBasic(t *testing.T) {
 	run.TestResults["pkg2/TestE"] = tr5
 
 	// Compute summary
-	summary := ComputeSummary(run, 10*time.Second)
+	summary := ComputeSummary(run)
 
 	// Verify overall statistics
 	if summary.TotalTests != 5 {
@@ -147,7 +148,7 @@ func TestComputeSummarySlowTests(t *testing.T) {
 	}
 
 	// Compute summary with 10s threshold
-	summary := ComputeSummary(run, 10*time.Second)
+	summary := ComputeSummary(run)
 
 	// Verify slow tests detected
 	if len(summary.SlowTests) != 3 {
@@ -171,7 +172,7 @@ func TestComputeSummarySlowTests(t *testing.T) {
 // TestComputeSummaryEmptyResults tests summary with no tests.
 func TestComputeSummaryEmptyResults(t *testing.T) {
 	run := results.NewRun(1)
-	summary := ComputeSummary(run, 10*time.Second)
+	summary := ComputeSummary(run)
 
 	if summary.TotalTests != 0 {
 		t.Errorf("Expected 0 total tests, got %d", summary.TotalTests)
@@ -211,7 +212,7 @@ func TestComputeSummaryAllPass(t *testing.T) {
 		run.TestResults["pkg1/"+name] = tr
 	}
 
-	summary := ComputeSummary(run, 10*time.Second)
+	summary := ComputeSummary(run)
 
 	if summary.TotalTests != 3 {
 		t.Errorf("Expected 3 total tests, got %d", summary.TotalTests)
@@ -232,3 +233,131 @@ func TestComputeSummaryAllPass(t *testing.T) {
 		t.Errorf("Expected empty skipped list, got %d", len(summary.Skipped))
 	}
 }
+
+// TestComputeSummaryCachedPackages tests that cached packages are counted and
+// excluded from the fastest/slowest package statistics.
+func TestComputeSummaryCachedPackages(t *testing.T) {
+	run := results.NewRun(1)
+	for _, p := range []struct {
+		name    string
+		elapsed time.Duration
+		cached  bool
+	}{
+		{"pkg/cached", 0, true},
+		{"pkg/fast", 1 * time.Second, false},
+		{"pkg/slow", 3 * time.Second, false},
+	} {
+		pkg := &results.PackageResult{Name: p.name, Status: results.StatusPassed, Elapsed: p.elapsed, Cached: p.cached}
+		pkg.Counts.Passed = 1
+		if p.cached {
+			pkg.SummaryLine = "ok  \t" + p.name + "\t(cached)"
+		}
+		run.Packages[p.name] = pkg
+		run.PackageOrder = append(run.PackageOrder, p.name)
+	}
+
+	summary := ComputeSummary(run)
+
+	if summary.CachedPackages != 1 {
+		t.Errorf("Expected 1 cached package, got %d", summary.CachedPackages)
+	}
+	if summary.FastestPackage == nil || summary.FastestPackage.Name != "pkg/fast" {
+		t.Errorf("Expected fastest package pkg/fast, got %v", summary.FastestPackage)
+	}
+	if summary.SlowestPackage == nil || summary.SlowestPackage.Name != "pkg/slow" {
+		t.Errorf("Expected slowest package pkg/slow, got %v", summary.SlowestPackage)
+	}
+
+	out := NewSummaryFormatter(80, true).Format(summary)
+	if !strings.Contains(out, "(3 packages, 1 cached)") {
+		t.Errorf("Expected cache hit count in totals line, got:\n%s", out)
+	}
+	if !strings.Contains(out, "pkg/cached (cached)") {
+		t.Errorf("Expected (cached) tag on cached package, got:\n%s", out)
+	}
+}
+
+// TestComputeSummarySlowThresholdOption tests that the slow threshold comes
+// from the options, defaulting to DefaultSlowThreshold, and that zero
+// disables it.
+func TestComputeSummarySlowThresholdOption(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed}
+	tr := results.NewTestResult("pkg1", "TestA")
+	tr.Latest().Status = results.StatusPassed
+	tr.Latest().Elapsed = 2 * time.Second
+	run.TestResults["pkg1/TestA"] = tr
+	pkg.TestOrder = []string{"TestA"}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+
+	if slow := ComputeSummary(run).SlowTests; len(slow) != 0 {
+		t.Errorf("Expected no slow tests at the default threshold, got %d", len(slow))
+	}
+	if slow := ComputeSummary(run, WithSlowThreshold(time.Second)).SlowTests; len(slow) != 1 {
+		t.Errorf("Expected 1 slow test with a 1s threshold, got %d", len(slow))
+	}
+
+	opts := NewSummaryOptions(WithSlowThreshold(time.Second), WithSlow(true))
+	if slow := ComputeSummary(run, WithOptions(opts)).SlowTests; len(slow) != 1 {
+		t.Errorf("Expected WithOptions to carry the slow threshold, got %d slow tests", len(slow))
+	}
+	if got := NewSummaryOptions().SlowThreshold; got != DefaultSlowThreshold {
+		t.Errorf("Expected default slow threshold %v, got %v", DefaultSlowThreshold, got)
+	}
+	if slow := ComputeSummary(run, WithSlowThreshold(0)).SlowTests; len(slow) != 0 {
+		t.Errorf("Expected a zero threshold to disable slow tests, got %d", len(slow))
+	}
+}
+
+// TestComputeSummaryPackageSlowThresholds tests that per-package slow
+// thresholds override the default for matching packages only.
+func TestComputeSummaryPackageSlowThresholds(t *testing.T) {
+	run := results.NewRun(1)
+	for _, name := range []string{"example.com/app/unit", "example.com/app/integration/db"} {
+		pkg := &results.PackageResult{Name: name, Status: results.StatusPassed}
+		tr := results.NewTestResult(name, "TestA")
+		tr.Latest().Status = results.StatusPassed
+		tr.Latest().Elapsed = 30 * time.Second
+		run.TestResults[name+"/TestA"] = tr
+		pkg.TestOrder = []string{"TestA"}
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+	}
+
+	th, err := ParsePackageThresholds("example.com/app/integration/...=5m, */unit=1s")
+	if err != nil {
+		t.Fatalf("ParsePackageThresholds: %v", err)
+	}
+	summary := ComputeSummary(run, WithPackageSlowThresholds(th))
+	if len(summary.SlowTests) != 1 || summary.SlowTests[0].TestResult.Package != "example.com/app/unit" {
+		t.Errorf("Expected only the unit test to be slow, got %d slow tests", len(summary.SlowTests))
+	}
+}
+
+func TestParsePackageThresholds(t *testing.T) {
+	th, err := ParsePackageThresholds("")
+	if err != nil || len(th) != 0 {
+		t.Errorf("Expected no thresholds for an empty string, got %v, %v", th, err)
+	}
+	for _, bad := range []string{"pkg", "=1s", "pkg=soon", "[=1s"} {
+		if _, err := ParsePackageThresholds(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	opts := NewSummaryOptions(WithSlowThreshold(2*time.Second), WithPackageSlowThresholds([]PackageThreshold{
+		{Pattern: "a/...", Threshold: time.Minute},
+		{Pattern: "a/b", Threshold: time.Second},
+	}))
+	for pkg, want := range map[string]time.Duration{
+		"a":   time.Minute,
+		"a/b": time.Minute, // first match wins
+		"ab":  2 * time.Second,
+		"c/d": 2 * time.Second,
+	} {
+		if got := opts.SlowThresholdFor(pkg); got != want {
+			t.Errorf("SlowThresholdFor(%q) = %v, want %v", pkg, got, want)
+		}
+	}
+}
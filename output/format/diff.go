@@ -0,0 +1,72 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DiffKind classifies a line of test output that is part of an assertion
+// diff.
+type DiffKind int
+
+const (
+	DiffNone    DiffKind = iota // Not part of a diff, or unchanged context
+	DiffRemoved                 // Expected/wanted value ("-" lines)
+	DiffAdded                   // Actual/got value ("+" lines)
+)
+
+// ClassifyDiffLines detects common assertion diff patterns in test output
+// and returns the DiffKind of each line. Recognized patterns:
+//
+//   - testify "expected:" / "actual  :" lines
+//   - unified diff blocks introduced by a testify "Diff:" line or a
+//     "--- Expected" header
+//   - go-cmp style diffs introduced by a "(-want +got)" header
+//
+// Lines are classified by their content after leading whitespace, so the
+// indentation go test and testify add is irrelevant. A diff block ends at
+// the next testify field label (e.g. "Test:") or file:line location.
+func ClassifyDiffLines(lines []string) []DiffKind {
+	kinds := make([]DiffKind, len(lines))
+	inDiff := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "expected:"):
+			kinds[i] = DiffRemoved
+			continue
+		case strings.HasPrefix(trimmed, "actual") && strings.HasPrefix(strings.TrimLeft(trimmed[len("actual"):], " "), ":"):
+			kinds[i] = DiffAdded
+			continue
+		case trimmed == "Diff:",
+			strings.HasPrefix(trimmed, "--- Expected"),
+			strings.Contains(trimmed, "(-want +got)"),
+			strings.Contains(trimmed, "(-got +want)"),
+			strings.Contains(trimmed, "(-expected +actual)"):
+			inDiff = true
+			continue
+		}
+
+		if !inDiff {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "---"), strings.HasPrefix(trimmed, "+++"), strings.HasPrefix(trimmed, "@@"):
+			// Diff headers: part of the block but not highlighted.
+		case strings.HasPrefix(trimmed, "-"):
+			kinds[i] = DiffRemoved
+		case strings.HasPrefix(trimmed, "+"):
+			kinds[i] = DiffAdded
+		case diffEndRE.MatchString(trimmed):
+			inDiff = false
+		}
+	}
+	return kinds
+}
+
+// diffEndRE matches lines which terminate a diff block: testify field
+// labels ("Test:", "Messages:", "Error Trace:") and go test "file.go:12:"
+// locations.
+var diffEndRE = regexp.MustCompile(`^([A-Z][A-Za-z ]*:(\s|$)|\S+\.go:\d+:)`)
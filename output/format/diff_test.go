@@ -0,0 +1,94 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestClassifyDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []DiffKind
+	}{
+		{
+			name: "testify expected/actual and diff",
+			lines: []string{
+				"    foo_test.go:12: ",
+				"        \tError Trace:\tfoo_test.go:12",
+				"        \tError:      \tNot equal: ",
+				"        \t            \texpected: \"a\"",
+				"        \t            \tactual  : \"b\"",
+				"        \t            \t",
+				"        \t            \tDiff:",
+				"        \t            \t--- Expected",
+				"        \t            \t+++ Actual",
+				"        \t            \t@@ -1 +1 @@",
+				"        \t            \t-a",
+				"        \t            \t+b",
+				"        \tTest:       \tTestFoo",
+			},
+			want: []DiffKind{
+				DiffNone, DiffNone, DiffNone,
+				DiffRemoved, DiffAdded,
+				DiffNone, DiffNone, DiffNone, DiffNone, DiffNone,
+				DiffRemoved, DiffAdded,
+				DiffNone,
+			},
+		},
+		{
+			name: "go-cmp diff",
+			lines: []string{
+				"    foo_test.go:20: mismatch (-want +got):",
+				"          []string{",
+				"        - \t\"a\",",
+				"        + \t\"b\",",
+				"          }",
+				"    foo_test.go:21: -1 is not a diff",
+			},
+			want: []DiffKind{DiffNone, DiffNone, DiffRemoved, DiffAdded, DiffNone, DiffNone},
+		},
+		{
+			name:  "no diff context",
+			lines: []string{"-v flag", "+ok"},
+			want:  []DiffKind{DiffNone, DiffNone},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyDiffLines(tt.lines)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d kinds, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d %q: got %v, want %v", i, tt.lines[i], got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummaryFormatterHighlightsDiff(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, TestOrder: []string{"TestFoo"}}
+	pkg.Counts.Failed = 1
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+
+	tr := results.NewTestResult("pkg1", "TestFoo")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Output = []string{"expected: 1", "actual  : 2"}
+	run.TestResults["pkg1/TestFoo"] = tr
+
+	out := NewSummaryFormatter(80, false).Format(ComputeSummary(run))
+	if !strings.Contains(out, "\x1b[31mexpected: 1") {
+		t.Errorf("expected removed line in red, got %q", out)
+	}
+	if !strings.Contains(out, "\x1b[32mactual  : 2") {
+		t.Errorf("expected added line in green, got %q", out)
+	}
+}
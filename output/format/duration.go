@@ -0,0 +1,108 @@
+package format
+
+import (
+	"fmt"
+	"time"
+)
+
+// DurationStyle selects how durations are shown by the live display and
+// every report. The zero DurationStyle keeps each renderer's own style:
+// compact in the live display, Go's in the summary.
+type DurationStyle string
+
+const (
+	DurationStyleDefault DurationStyle = ""
+	DurationStyleCompact DurationStyle = "compact" // One decimal of seconds or minutes, e.g. "5.2s", "1.5m"
+	DurationStyleClock   DurationStyle = "clock"   // Hours, minutes and seconds to the millisecond, e.g. "00:01:30.500"
+	DurationStyleGo      DurationStyle = "go"      // Go's time.Duration format to the millisecond, e.g. "1m30.5s"
+)
+
+// DurationStyles lists the named styles, for -duration-style.
+var DurationStyles = []DurationStyle{DurationStyleCompact, DurationStyleClock, DurationStyleGo}
+
+// ParseDurationStyle parses a style name. An empty name is the default.
+func ParseDurationStyle(name string) (DurationStyle, error) {
+	if name == "" {
+		return DurationStyleDefault, nil
+	}
+	for _, s := range DurationStyles {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown duration style %q (want compact, clock or go)", name)
+}
+
+// Or returns s, or def if s is the default.
+func (s DurationStyle) Or(def DurationStyle) DurationStyle {
+	if s == DurationStyleDefault {
+		return def
+	}
+	return s
+}
+
+// Format formats d in the style; the default style is Go's.
+func (s DurationStyle) Format(d time.Duration) string {
+	switch s {
+	case DurationStyleCompact:
+		return formatCompactDuration(d)
+	case DurationStyleClock:
+		return formatClockDuration(d)
+	default:
+		return formatDuration(d)
+	}
+}
+
+// duration formats d in the summary's duration style.
+func (f *SummaryFormatter) duration(d time.Duration) string {
+	return f.options.DurationStyle.Format(d)
+}
+
+// formatTest formats the elapsed time of a test in the style; the default
+// style shows seconds to the hundredth.
+func (s DurationStyle) formatTest(d time.Duration) string {
+	if s == DurationStyleDefault {
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+	return s.Format(d)
+}
+
+// formatDuration formats a duration using Go's native String() with up to 3
+// fractional digits on the smallest unit (truncated, not rounded).
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	switch {
+	case d >= time.Second:
+		return d.Truncate(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Truncate(time.Microsecond).String()
+	default:
+		return d.Truncate(time.Nanosecond).String()
+	}
+}
+
+// formatCompactDuration formats a duration with one decimal, in seconds
+// under a minute and minutes from then on, so it stays short as the live
+// display ticks.
+func formatCompactDuration(d time.Duration) string {
+	if d < 50*time.Millisecond {
+		return "0.0s"
+	}
+	if d >= time.Minute {
+		return fmt.Sprintf("%.1fm", d.Minutes())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// formatClockDuration formats a duration as HH:MM:SS.mmm, truncated to the
+// millisecond.
+func formatClockDuration(d time.Duration) string {
+	d = max(d, 0).Truncate(time.Millisecond)
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	sec := d % time.Minute / time.Second
+	ms := d % time.Second / time.Millisecond
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, sec, ms)
+}
@@ -60,3 +60,63 @@ func TestFormatDurationTruncates(t *testing.T) {
 		})
 	}
 }
+
+func TestDurationStyleFormat(t *testing.T) {
+	d := time.Hour + 2*time.Minute + 3*time.Second + 456789*time.Microsecond
+	tests := []struct {
+		style    DurationStyle
+		duration time.Duration
+		expected string
+	}{
+		{DurationStyleDefault, d, "1h2m3.456s"},
+		{DurationStyleGo, d, "1h2m3.456s"},
+		{DurationStyleCompact, d, "62.1m"},
+		{DurationStyleCompact, 5200 * time.Millisecond, "5.2s"},
+		{DurationStyleCompact, 10 * time.Millisecond, "0.0s"},
+		{DurationStyleClock, d, "01:02:03.456"},
+		{DurationStyleClock, 1500 * time.Microsecond, "00:00:00.001"},
+		{DurationStyleClock, -time.Second, "00:00:00.000"},
+	}
+	for _, tt := range tests {
+		if got := tt.style.Format(tt.duration); got != tt.expected {
+			t.Errorf("%q.Format(%v) = %q, want %q", tt.style, tt.duration, got, tt.expected)
+		}
+	}
+
+	if got := DurationStyleDefault.formatTest(1234 * time.Millisecond); got != "1.23s" {
+		t.Errorf("Default test duration = %q, want 1.23s", got)
+	}
+	if got := DurationStyleClock.formatTest(1234 * time.Millisecond); got != "00:00:01.234" {
+		t.Errorf("Clock test duration = %q, want 00:00:01.234", got)
+	}
+}
+
+func TestParseDurationStyle(t *testing.T) {
+	for _, name := range []string{"", "compact", "clock", "go"} {
+		if style, err := ParseDurationStyle(name); err != nil || string(style) != name {
+			t.Errorf("ParseDurationStyle(%q) = %q, %v", name, style, err)
+		}
+	}
+	if _, err := ParseDurationStyle("iso"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        uint64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{1536 * 1024, "1.5MB"},
+		{3 << 30, "3.0GB"},
+	}
+
+	for _, tt := range tests {
+		if result := FormatBytes(tt.n); result != tt.expected {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, result, tt.expected)
+		}
+	}
+}
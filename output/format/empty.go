@@ -0,0 +1,59 @@
+package format
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// DefaultEmptyTestThreshold is the default duration under which a passing
+// test with no output is reported as possibly empty.
+const DefaultEmptyTestThreshold = time.Millisecond
+
+// PossiblyEmptyTests returns the leaf tests that passed in every execution,
+// each faster than threshold and without writing any output. Such tests
+// often compile but assert nothing. Parent tests are excluded, since their
+// subtests do the work. Results are in package order, then by name.
+func (s *Summary) PossiblyEmptyTests(threshold time.Duration) []*results.TestResult {
+	if s.Run == nil || threshold <= 0 {
+		return nil
+	}
+
+	var empty []*results.TestResult
+	for _, pkg := range s.Packages {
+		var pkgEmpty []*results.TestResult
+		for _, testName := range pkg.TestOrder {
+			tr := s.Run.TestResults[pkg.Name+"/"+testName]
+			if tr == nil || !possiblyEmpty(tr, threshold) || hasSubtests(pkg, testName) {
+				continue
+			}
+			pkgEmpty = append(pkgEmpty, tr)
+		}
+		sort.Slice(pkgEmpty, func(i, j int) bool { return pkgEmpty[i].Name < pkgEmpty[j].Name })
+		empty = append(empty, pkgEmpty...)
+	}
+	return empty
+}
+
+func possiblyEmpty(tr *results.TestResult, threshold time.Duration) bool {
+	if len(tr.Executions) == 0 {
+		return false
+	}
+	for _, exec := range tr.Executions {
+		if exec.Status != results.StatusPassed || exec.Elapsed >= threshold || len(exec.Output) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSubtests(pkg *results.PackageResult, testName string) bool {
+	for _, other := range pkg.TestOrder {
+		if strings.HasPrefix(other, testName+"/") {
+			return true
+		}
+	}
+	return false
+}
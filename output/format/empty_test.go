@@ -0,0 +1,57 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestSummaryPossiblyEmptyTests(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed}
+	for _, tc := range []struct {
+		name    string
+		status  results.Status
+		elapsed time.Duration
+		output  []string
+	}{
+		{"TestEmpty", results.StatusPassed, 0, nil},
+		{"TestLogs", results.StatusPassed, 0, []string{"    foo_test.go:10: checked"}},
+		{"TestSlow", results.StatusPassed, 5 * time.Millisecond, nil},
+		{"TestSkipped", results.StatusSkipped, 0, nil},
+		{"TestParent", results.StatusPassed, 0, nil},
+		{"TestParent/sub", results.StatusPassed, 0, nil},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = tc.status
+		tr.Latest().Elapsed = tc.elapsed
+		tr.Latest().Output = tc.output
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+	summary := ComputeSummary(run)
+
+	var names []string
+	for _, tr := range summary.PossiblyEmptyTests(time.Millisecond) {
+		names = append(names, tr.Name)
+	}
+	if got := strings.Join(names, ","); got != "TestEmpty,TestParent/sub" {
+		t.Errorf("Expected TestEmpty,TestParent/sub, got %q", got)
+	}
+
+	if empty := summary.PossiblyEmptyTests(0); len(empty) != 0 {
+		t.Errorf("Expected no tests with the check disabled, got %d", len(empty))
+	}
+
+	out := NewSummaryFormatter(80, true, SummaryOptions{EmptyTestThreshold: time.Millisecond}).Format(summary)
+	if !strings.Contains(out, "POSSIBLY EMPTY TESTS") || !strings.Contains(out, "    pkg1 TestEmpty\n") {
+		t.Errorf("Expected POSSIBLY EMPTY TESTS section, got:\n%s", out)
+	}
+	if out := NewSummaryFormatter(80, true).Format(summary); strings.Contains(out, "POSSIBLY EMPTY TESTS") {
+		t.Errorf("Expected no POSSIBLY EMPTY TESTS section by default, got:\n%s", out)
+	}
+}
@@ -0,0 +1,100 @@
+package format
+
+import (
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// Estimator estimates the time remaining in a run from the durations of
+// packages in previous runs, e.g. a -history file or a -baseline.
+//
+// Packages are weighted by their mean duration in previous runs, so the
+// fraction of the run's work that's done is the weight of its finished
+// packages, plus the elapsed part of its running ones, over the weight of
+// every package with a history. Until the run reaches a package with a
+// history, the estimate falls back to the rate at which packages have
+// completed. A nil Estimator has no history.
+type Estimator struct {
+	durations map[string]time.Duration // Mean duration per package
+	order     []string                 // Packages in durations, in first-seen order
+}
+
+// NewEstimator returns an Estimator using the package durations of runs.
+func NewEstimator(runs []*SummaryJSON) *Estimator {
+	e := &Estimator{durations: make(map[string]time.Duration)}
+	sums := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, run := range runs {
+		if run == nil {
+			continue
+		}
+		for _, pkg := range run.Packages {
+			if _, ok := counts[pkg.Name]; !ok {
+				e.order = append(e.order, pkg.Name)
+			}
+			sums[pkg.Name] += time.Duration(pkg.Elapsed * float64(time.Second))
+			counts[pkg.Name]++
+		}
+	}
+	for name, sum := range sums {
+		e.durations[name] = sum / time.Duration(counts[name])
+	}
+	return e
+}
+
+// Packages returns the number of packages expected to run: those with a
+// history, plus any others the run has started.
+func (e *Estimator) Packages(run *results.Run) int {
+	n := len(run.PackageOrder)
+	if e == nil {
+		return n
+	}
+	for _, name := range e.order {
+		if run.Packages[name] == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Remaining estimates the time left in run, which has been going for
+// elapsed. It returns false when there's nothing to estimate from yet: no
+// history, and no package finished.
+func (e *Estimator) Remaining(run *results.Run, elapsed time.Duration) (time.Duration, bool) {
+	if run.Status != results.StatusRunning {
+		return 0, true
+	}
+
+	var total, done time.Duration
+	if e != nil {
+		for _, name := range e.order {
+			weight := e.durations[name]
+			total += weight
+			pkg := run.Packages[name]
+			switch {
+			case pkg == nil:
+			case pkg.Status == results.StatusRunning:
+				done += min(weight, run.ScaleWall(time.Since(pkg.WallStartTime)))
+			default:
+				done += weight
+			}
+		}
+	}
+	if total > 0 && done > 0 {
+		fraction := float64(done) / float64(total)
+		if fraction >= 1 {
+			return 0, true
+		}
+		return time.Duration(float64(elapsed) * (1 - fraction) / fraction), true
+	}
+
+	// No history: extrapolate from the rate packages have completed, over
+	// the packages started so far.
+	started := len(run.PackageOrder)
+	finished := started - run.RunningPkgs
+	if finished <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) * float64(started-finished) / float64(finished)), true
+}
@@ -0,0 +1,65 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestEstimatorRemaining(t *testing.T) {
+	newRun := func(statuses map[string]results.Status) *results.Run {
+		run := results.NewRun(1)
+		run.Status = results.StatusRunning
+		for _, name := range []string{"a", "b", "c", "d"} {
+			status, ok := statuses[name]
+			if !ok {
+				continue
+			}
+			run.Packages[name] = &results.PackageResult{Name: name, Status: status, WallStartTime: time.Now()}
+			run.PackageOrder = append(run.PackageOrder, name)
+			if status == results.StatusRunning {
+				run.RunningPkgs++
+			}
+		}
+		return run
+	}
+
+	history := []*SummaryJSON{
+		{Packages: []PackageJSON{{Name: "a", Elapsed: 1}, {Name: "b", Elapsed: 3}, {Name: "c", Elapsed: 4}}},
+		{Packages: []PackageJSON{{Name: "a", Elapsed: 3}, {Name: "b", Elapsed: 3}, {Name: "c", Elapsed: 4}}},
+	}
+	est := NewEstimator(history)
+
+	// a (2s) and b (3s) of 9s are done: 5/9 of the work in 10s.
+	run := newRun(map[string]results.Status{"a": results.StatusPassed, "b": results.StatusFailed})
+	eta, ok := est.Remaining(run, 10*time.Second)
+	if !ok || eta != 8*time.Second {
+		t.Errorf("Expected 8s remaining, got %v, %v", eta, ok)
+	}
+	if n := est.Packages(run); n != 3 {
+		t.Errorf("Expected 3 packages, got %d", n)
+	}
+
+	// Nothing with a history has started: fall back to the completion rate
+	// of the packages started so far.
+	run = newRun(map[string]results.Status{"d": results.StatusRunning})
+	if _, ok := est.Remaining(run, 10*time.Second); ok {
+		t.Error("Expected no estimate before any package finished")
+	}
+
+	var noHistory *Estimator
+	run = newRun(map[string]results.Status{"a": results.StatusPassed, "b": results.StatusRunning, "c": results.StatusRunning})
+	eta, ok = noHistory.Remaining(run, 10*time.Second)
+	if !ok || eta != 20*time.Second {
+		t.Errorf("Expected 20s remaining from the completion rate, got %v, %v", eta, ok)
+	}
+	if n := noHistory.Packages(run); n != 3 {
+		t.Errorf("Expected 3 packages, got %d", n)
+	}
+
+	run.Status = results.StatusPassed
+	if eta, ok := est.Remaining(run, 10*time.Second); !ok || eta != 0 {
+		t.Errorf("Expected nothing remaining in a finished run, got %v, %v", eta, ok)
+	}
+}
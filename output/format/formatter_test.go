@@ -1,10 +1,13 @@
 package format
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ansel1/tang/engine"
 	"github.com/ansel1/tang/results"
 )
 
@@ -378,6 +381,68 @@ func TestSummaryFormatterTotalsLine(t *testing.T) {
 	}
 }
 
+func TestSummaryFormatterReplayLabel(t *testing.T) {
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed, Elapsed: 4 * time.Second}
+	pkg.Counts.Passed = 1
+
+	for _, tt := range []struct {
+		replay bool
+		rate   float64
+		want   string
+	}{
+		{false, 0, ""},
+		{true, 0.5, "(replayed at 2x)"},
+		{true, 3, "(replayed at 0.33x)"},
+		{true, 0, "(replayed instantly)"},
+	} {
+		run := results.NewRun(1)
+		run.Replay = tt.replay
+		run.ReplayRate = tt.rate
+		summary := &Summary{
+			Packages:     []*results.PackageResult{pkg},
+			TotalTests:   1,
+			PassedTests:  1,
+			TotalTime:    4 * time.Second,
+			PackageCount: 1,
+			Run:          run,
+		}
+
+		output := NewSummaryFormatter(80, true).Format(summary)
+		if tt.want == "" {
+			if strings.Contains(output, "replayed") {
+				t.Errorf("Expected no replay label, got:\n%s", output)
+			}
+		} else if !strings.Contains(output, "4s "+tt.want) {
+			t.Errorf("Expected %q after the total time, got:\n%s", tt.want, output)
+		}
+	}
+}
+
+func TestSummaryFormatterPeakResources(t *testing.T) {
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed, Elapsed: time.Second}
+	pkg.Counts.Passed = 1
+	run := results.NewRun(1)
+	summary := &Summary{
+		Packages:     []*results.PackageResult{pkg},
+		TotalTests:   1,
+		PassedTests:  1,
+		TotalTime:    time.Second,
+		PackageCount: 1,
+		Run:          run,
+	}
+
+	if output := NewSummaryFormatter(80, true).Format(summary); strings.Contains(output, "peak memory") {
+		t.Errorf("Expected no resource line without samples, got:\n%s", output)
+	}
+
+	run.Resources.PeakRSS = 1536 << 20
+	run.Resources.PeakCPU = 340
+	output := NewSummaryFormatter(80, true).Format(summary)
+	if !strings.Contains(output, "peak memory 1.5GB, peak CPU 340%") {
+		t.Errorf("Expected peak resource usage, got:\n%s", output)
+	}
+}
+
 func TestSummaryFormatterSymbols(t *testing.T) {
 	formatter := NewSummaryFormatter(80, false)
 
@@ -414,3 +479,382 @@ func TestSummaryFormatterSymbols(t *testing.T) {
 		t.Error("Expected fail symbol")
 	}
 }
+
+func TestSummaryFormatterRunHeader(t *testing.T) {
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed, Elapsed: time.Second}
+	pkg.Counts.Passed = 1
+	run := results.NewRun(1)
+	run.UID = "20260102-150405-3f9a1c"
+	run.Labels = results.Labels{{Key: "ci_job", Value: "1234"}, {Key: "os", Value: "linux"}}
+	summary := &Summary{
+		Packages:     []*results.PackageResult{pkg},
+		TotalTests:   1,
+		PassedTests:  1,
+		TotalTime:    time.Second,
+		PackageCount: 1,
+		Run:          run,
+	}
+
+	output := NewSummaryFormatter(80, true).Format(summary)
+	if !strings.HasPrefix(output, "run 20260102-150405-3f9a1c  ci_job=1234  os=linux\n\n") {
+		t.Errorf("Expected run header, got:\n%s", output)
+	}
+
+	sj := NewSummaryJSON(summary)
+	if sj.RunID != run.UID || sj.Labels["ci_job"] != "1234" || sj.Labels["os"] != "linux" {
+		t.Errorf("Expected run ID and labels in JSON summary, got %q %v", sj.RunID, sj.Labels)
+	}
+
+	run.Modes = []results.Mode{results.ModeRace, results.ModeCover}
+	output = NewSummaryFormatter(80, true).Format(summary)
+	if !strings.HasPrefix(output, "run 20260102-150405-3f9a1c  ci_job=1234  os=linux  RACE COVER\n\n") {
+		t.Errorf("Expected mode badges in the run header, got:\n%s", output)
+	}
+	if sj := NewSummaryJSON(summary); !slices.Equal(sj.Modes, []string{"race", "cover"}) {
+		t.Errorf("Expected modes in JSON summary, got %v", sj.Modes)
+	}
+}
+
+func TestSummaryFormatterInconsistencies(t *testing.T) {
+	run := results.NewRun(1)
+	summary := &Summary{Run: run}
+	if summary.HasTestDetailsWithOptions(NewSummaryOptions()) {
+		t.Error("Expected no details without inconsistencies")
+	}
+
+	run.Inconsistencies = []results.Inconsistency{{Package: "example.com/pkg", Problem: "passed, but 1 tests failed"}}
+	if !summary.HasTestDetailsWithOptions(NewSummaryOptions()) {
+		t.Error("Expected inconsistencies to count as details")
+	}
+	output := NewSummaryFormatter(80, true).Format(summary)
+	if !strings.Contains(output, "INCONSISTENT RESULTS") || !strings.Contains(output, IndentLevel+"example.com/pkg: passed, but 1 tests failed\n") {
+		t.Errorf("Expected the inconsistency in the summary, got:\n%s", output)
+	}
+	if sj := NewSummaryJSON(summary); !slices.Equal(sj.Inconsistent, []string{"example.com/pkg: passed, but 1 tests failed"}) {
+		t.Errorf("Expected the inconsistency in the JSON summary, got %v", sj.Inconsistent)
+	}
+}
+
+func TestSummaryFormatterAnomalies(t *testing.T) {
+	run := results.NewRun(1)
+	summary := &Summary{Run: run}
+	for i := range maxAnomalies + 2 {
+		run.Anomalies = append(run.Anomalies, results.Anomaly{Package: "example.com/pkg", Test: fmt.Sprintf("Test%d", i), Problem: "pass event before its run event"})
+	}
+	if !summary.HasTestDetailsWithOptions(NewSummaryOptions()) {
+		t.Error("Expected anomalies to count as details")
+	}
+	output := NewSummaryFormatter(80, true, SummaryOptions{PackageNames: PackageNameOptions{TrimPrefix: "example.com/"}}).Format(summary)
+	if !strings.Contains(output, "ANOMALIES") || !strings.Contains(output, IndentLevel+"pkg Test0: pass event before its run event\n") ||
+		!strings.Contains(output, IndentLevel+"...and 2 more\n") || strings.Contains(output, "Test20") {
+		t.Errorf("Expected the first anomalies in the summary, got:\n%s", output)
+	}
+	if sj := NewSummaryJSON(summary); len(sj.Anomalies) != maxAnomalies+2 {
+		t.Errorf("Expected every anomaly in the JSON summary, got %v", sj.Anomalies)
+	}
+}
+
+func TestSummaryFormatterInterrupted(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusInterrupted, TestOrder: []string{"TestHang"}}
+	pkg.Counts.Passed = 1
+	pkg.Counts.Interrupted = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	tr := results.NewTestResult(pkg.Name, "TestHang")
+	tr.Latest().Status = results.StatusInterrupted
+	tr.Latest().Elapsed = 3 * time.Second
+	for i := range maxInterruptedOutput + 2 {
+		tr.Latest().Output = append(tr.Latest().Output, fmt.Sprintf("waiting %d", i))
+	}
+	run.TestResults[pkg.Name+"/TestHang"] = tr
+
+	summary := ComputeSummary(run)
+	if summary.InterruptedTests != 1 || len(summary.Interrupted) != 1 || summary.TotalTests != 1 {
+		t.Fatalf("Expected 1 interrupted test apart from the total, got %d, %d and %d", summary.InterruptedTests, len(summary.Interrupted), summary.TotalTests)
+	}
+	output := NewSummaryFormatter(80, true).Format(summary)
+	for _, want := range []string{
+		"INTERRUPTED (still running when the run ended)\n" + IndentLevel + "TestHang example.com/pkg 3s\n",
+		"... 2 earlier lines\n",
+		"waiting 6",
+		"1 interrupted\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "waiting 1\n") {
+		t.Errorf("Expected only the last lines of output, got:\n%s", output)
+	}
+	if sj := NewSummaryJSON(summary); sj.Interrupted != 1 || len(sj.Results) != 1 || sj.Results[0].Status != "interrupted" {
+		t.Errorf("Expected the interrupted test in the JSON summary, got %+v", sj)
+	}
+}
+
+func TestSummaryFormatterReproduce(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, TestOrder: []string{"TestA", "TestA/sub case"}}
+	pkg.Counts.Failed = 2
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	for _, name := range pkg.TestOrder {
+		tr := results.NewTestResult(pkg.Name, name)
+		tr.Latest().Status = results.StatusFailed
+		run.TestResults[pkg.Name+"/"+name] = tr
+	}
+
+	summary := ComputeSummary(run)
+	want := "REPRODUCE\n" + IndentLevel + "go test -count=1 -run '^TestA$/^sub case$' example.com/pkg\n"
+	if output := NewSummaryFormatter(80, true).Format(summary); !strings.Contains(output, want) {
+		t.Errorf("Expected %q in:\n%s", want, output)
+	}
+
+	// The slow view leaves out the failures, and with them the commands.
+	slow, opts := SummaryViewSlow.Apply(summary, NewSummaryOptions())
+	if output := NewSummaryFormatter(80, true, opts).Format(slow); strings.Contains(output, "REPRODUCE") {
+		t.Errorf("Expected no REPRODUCE section in the slow view, got:\n%s", output)
+	}
+}
+
+func TestSummaryFormatterDurationStyle(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, Elapsed: 90500 * time.Millisecond, TestOrder: []string{"TestA"}}
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	tr := results.NewTestResult(pkg.Name, "TestA")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Elapsed = 1250 * time.Millisecond
+	run.TestResults[pkg.Name+"/TestA"] = tr
+
+	opts := NewSummaryOptions(WithDurationStyle(DurationStyleClock))
+	output := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	for _, want := range []string{"TestA (00:00:01.250)", "00:01:30.500"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+
+	// Views keep the style.
+	failures, fopts := SummaryViewFailures.Apply(ComputeSummary(run, WithOptions(opts)), opts)
+	if output := NewSummaryFormatter(100, true, fopts).Format(failures); !strings.Contains(output, "00:00:01.250") {
+		t.Errorf("Expected the failures view in clock style, got:\n%s", output)
+	}
+}
+
+func TestSummaryFormatterASCIISymbols(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, Elapsed: time.Second, TestOrder: []string{"TestA"}}
+	pkg.Counts.Passed = 2
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	run.Counts = pkg.Counts
+	tr := results.NewTestResult(pkg.Name, "TestA")
+	tr.Latest().Status = results.StatusFailed
+	run.TestResults[pkg.Name+"/TestA"] = tr
+
+	opts := NewSummaryOptions(WithSymbols(SymbolsASCII))
+	output := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	if !strings.Contains(output, "(+2 x1 -0)") {
+		t.Errorf("Expected ASCII counts in:\n%s", output)
+	}
+	for _, symbol := range []string{SymbolPass, SymbolFail, SymbolSkip} {
+		if strings.Contains(output, symbol) {
+			t.Errorf("Expected no %q in:\n%s", symbol, output)
+		}
+	}
+}
+
+func TestSummaryFormatterDetailsBudget(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, Elapsed: time.Second, TestOrder: []string{"TestA", "TestB"}}
+	pkg.Counts.Failed = 2
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	for _, name := range pkg.TestOrder {
+		tr := results.NewTestResult(pkg.Name, name)
+		tr.Latest().Status = results.StatusFailed
+		for i := range 10 {
+			tr.Latest().Output = append(tr.Latest().Output, fmt.Sprintf("%s line %d", name, i))
+		}
+		run.TestResults[pkg.Name+"/"+name] = tr
+	}
+
+	opts := NewSummaryOptions(WithMaxDetailsBytes(200, ""), WithLogDir("logs"))
+	output := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	// Whole lines are kept up to the budget.
+	if !strings.Contains(output, "TestA line 7\n… ") {
+		t.Errorf("Expected the details cut after a whole line, got:\n%s", output)
+	}
+	if strings.Contains(output, "TestA line 8") || strings.Contains(output, "FAIL: TestB") {
+		t.Errorf("Expected the details past the budget to be dropped, got:\n%s", output)
+	}
+	if !strings.Contains(output, "of test details omitted to fit 200B; full output of each failure in logs\n") {
+		t.Errorf("Expected a trailer, got:\n%s", output)
+	}
+	if !strings.Contains(output, "REPRODUCE") {
+		t.Errorf("Expected the sections after the details, got:\n%s", output)
+	}
+}
+
+func TestSummaryFormatterAnnotations(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := results.NewRun(1)
+	run.FirstEventTime = start
+	run.Annotations = []engine.Annotation{
+		{Time: start.Add(-time.Second), Text: "deploying fixture DB"},
+		{Time: start.Add(1500 * time.Millisecond), Package: "example.com/pkg", Text: "environment rebuilt"},
+	}
+
+	out := NewSummaryFormatter(80, true).Format(ComputeSummary(run))
+	want := "◆ 0s deploying fixture DB\n◆ 1.5s example.com/pkg: environment rebuilt\n\n"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("Expected the summary to start with:\n%s\ngot:\n%s", want, out)
+	}
+
+	run.UID = "20250101-000000-abcdef"
+	out = NewSummaryFormatter(80, true).Format(ComputeSummary(run))
+	if !strings.HasPrefix(out, "run 20250101-000000-abcdef\n"+want) {
+		t.Errorf("Expected the annotations under the run header, got:\n%s", out)
+	}
+}
+
+func TestSummaryFormatterParentFailures(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, Elapsed: time.Second,
+		TestOrder: []string{"TestParent", "TestParent/Case3", "TestOwn", "TestOwn/Case"}}
+	pkg.Counts.Failed = 4
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	run.Counts = pkg.Counts
+	for _, name := range pkg.TestOrder {
+		tr := results.NewTestResult(pkg.Name, name)
+		tr.Latest().Status = results.StatusFailed
+		if name != "TestParent" {
+			tr.Latest().Output = []string{name + " failed"}
+		}
+		run.TestResults[pkg.Name+"/"+name] = tr
+	}
+
+	t.Run("collapsed", func(t *testing.T) {
+		opts := NewSummaryOptions()
+		summary := ComputeSummary(run, WithOptions(opts))
+		if summary.FailedTests != 3 || summary.TotalTests != 3 {
+			t.Errorf("Expected 3 failed of 3 tests, got %d of %d", summary.FailedTests, summary.TotalTests)
+		}
+		output := NewSummaryFormatter(100, true, opts).Format(summary)
+		for _, want := range []string{"--- FAIL: TestParent → TestParent/Case3", "--- FAIL: TestOwn ", "(✓0 ✗3 ∅0)"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "--- FAIL: TestParent (") {
+			t.Errorf("Expected no entry of TestParent of its own in:\n%s", output)
+		}
+	})
+
+	t.Run("kept", func(t *testing.T) {
+		opts := NewSummaryOptions(WithKeepParentFailures(true))
+		summary := ComputeSummary(run, WithOptions(opts))
+		if summary.FailedTests != 4 {
+			t.Errorf("Expected 4 failed tests, got %d", summary.FailedTests)
+		}
+		output := NewSummaryFormatter(100, true, opts).Format(summary)
+		if strings.Contains(output, "→") || !strings.Contains(output, "(✓0 ✗4 ∅0)") {
+			t.Errorf("Expected failures as go test reports them in:\n%s", output)
+		}
+	})
+}
+
+func TestSummaryFormatterApproximateDurations(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusPassed, Elapsed: time.Second}
+	pkg.Counts.Passed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	run.Counts = pkg.Counts
+
+	output := NewSummaryFormatter(100, true, NewSummaryOptions()).Format(ComputeSummary(run))
+	if strings.Contains(output, "approximate") {
+		t.Errorf("Expected exact durations in:\n%s", output)
+	}
+
+	run.TimeSource = results.TimeSourceWall
+	summary := ComputeSummary(run)
+	output = NewSummaryFormatter(100, true, NewSummaryOptions()).Format(summary)
+	if !strings.Contains(output, "(durations approximate)") {
+		t.Errorf("Expected durations marked approximate in:\n%s", output)
+	}
+	if !NewSummaryJSON(summary).Approximate {
+		t.Error("Expected durations marked approximate in the JSON summary")
+	}
+}
+
+func TestSummaryFormatterReproducibility(t *testing.T) {
+	run := results.NewRun(1)
+	run.UID = "20240102-030405-abcdef"
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, Elapsed: time.Second, TestOrder: []string{"TestA"}, ShuffleSeed: "42"}
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	run.Counts = pkg.Counts
+	tr := results.NewTestResult(pkg.Name, "TestA")
+	tr.Latest().Status = results.StatusFailed
+	run.TestResults[pkg.Name+"/TestA"] = tr
+
+	output := NewSummaryFormatter(100, true, NewSummaryOptions()).Format(ComputeSummary(run))
+	if strings.Contains(output, "REPRODUCIBILITY") {
+		t.Errorf("Expected no REPRODUCIBILITY block outside exec mode in:\n%s", output)
+	}
+
+	run.Repro = results.NewReproducibility("go1.25.1 linux/amd64", "/src", []string{"-shuffle=on", "./..."}, []string{"GOFLAGS=-mod=mod"})
+	opts := NewSummaryOptions(WithReproStore("-history runs.jsonl"))
+	summary := ComputeSummary(run, WithOptions(opts))
+	output = NewSummaryFormatter(100, true, opts).Format(summary)
+	for _, want := range []string{
+		"REPRODUCIBILITY\n",
+		"    go: go1.25.1 linux/amd64\n",
+		"    command: go test -shuffle=on ./...\n",
+		"    shuffle: example.com/pkg 42\n",
+		"    env: GOFLAGS=-mod=mod (fingerprint " + run.Repro.EnvFingerprint + ")\n",
+		"    rerun: tang repro -history runs.jsonl 20240102-030405-abcdef\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+	if sj := NewSummaryJSON(summary); sj.Repro == nil || sj.Repro.ShuffleSeeds["example.com/pkg"] != "42" {
+		t.Errorf("Expected the reproducibility in the JSON summary, got %+v", sj.Repro)
+	}
+}
+
+func TestSummaryFormatterStalled(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusInterrupted, Elapsed: 10 * time.Minute, TestOrder: []string{"TestA", "TestB", "TestC"}}
+	pkg.Counts.Interrupted = 3
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	run.Status = results.StatusInterrupted
+	for name, silent := range map[string]time.Duration{"TestA": 3 * time.Minute, "TestB": 30 * time.Second, "TestC": 9 * time.Minute} {
+		tr := results.NewTestResult(pkg.Name, name)
+		tr.Latest().Status = results.StatusInterrupted
+		tr.Latest().SilentFor = silent
+		run.TestResults[pkg.Name+"/"+name] = tr
+	}
+
+	opts := NewSummaryOptions(WithStallThreshold(2 * time.Minute))
+	output := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	want := "STALLED (no output for 2m0s or more when the run ended)\n" +
+		"    TestC example.com/pkg stalled 9m0s\n" +
+		"    TestA example.com/pkg stalled 3m0s\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected:\n%s\nin:\n%s", want, output)
+	}
+
+	opts = NewSummaryOptions()
+	if output := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts))); strings.Contains(output, "STALLED") {
+		t.Errorf("Expected no STALLED section without a threshold in:\n%s", output)
+	}
+}
@@ -0,0 +1,159 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// goroutineHeaderRE matches the first line of a goroutine's stack in a
+// goroutine dump, e.g. "goroutine 7 [chan receive, 2 minutes]:".
+var goroutineHeaderRE = regexp.MustCompile(`^goroutine (\d+) (?:.* )?\[([^\]]+)\]:\s*$`)
+
+// goroutine is one goroutine of a goroutine dump.
+type goroutine struct {
+	id    string
+	state string   // e.g. "chan receive", without how long it's been blocked
+	lines []string // The header and the stack
+}
+
+// condenseGoroutineDumps replaces each goroutine dump in lines, e.g. from a
+// panic or a test timeout, with a count of its goroutines by state and the
+// stack of the culprit goroutine only, followed by a note of where the full
+// dump is, fullDump. Lines without a dump of several goroutines are
+// returned as they are.
+func condenseGoroutineDumps(lines []string, fullDump string) []string {
+	var out []string
+	condensed := false
+	for i := 0; i < len(lines); {
+		if !goroutineHeaderRE.MatchString(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		dump, end := parseGoroutineDump(lines, i)
+		if len(dump) < 2 {
+			out = append(out, lines[i:end]...)
+			i = end
+			continue
+		}
+		culprit := goroutineCulprit(dump, runningTests(lines[:i]))
+		out = append(out, fmt.Sprintf("[%d goroutines: %s; showing goroutine %s]", len(dump), goroutineStates(dump), culprit.id))
+		out = append(out, culprit.lines...)
+		out = append(out, "[full dump: "+fullDump+"]")
+		condensed = true
+		i = end
+	}
+	if !condensed {
+		return lines
+	}
+	return out
+}
+
+// parseGoroutineDump parses the goroutines of the dump starting at
+// lines[start], returning them and the index of the first line after the
+// dump. Goroutines are separated by blank lines; the dump ends at a line
+// which is neither part of a stack nor the start of another goroutine.
+func parseGoroutineDump(lines []string, start int) ([]goroutine, int) {
+	var dump []goroutine
+	i := start
+	for i < len(lines) {
+		m := goroutineHeaderRE.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		state, _, _ := strings.Cut(m[2], ",")
+		g := goroutine{id: m[1], state: state, lines: []string{lines[i]}}
+		for i++; i < len(lines) && isStackLine(lines[i]); i++ {
+			g.lines = append(g.lines, lines[i])
+		}
+		dump = append(dump, g)
+
+		// Skip the blank line before the next goroutine, if there is one.
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next == i || next == len(lines) || !goroutineHeaderRE.MatchString(lines[next]) {
+			break
+		}
+		i = next
+	}
+	return dump, i
+}
+
+// isStackLine reports whether line can be part of a goroutine's stack: a
+// function call, its file and line (indented by a tab), "created by", or a
+// note of elided frames.
+func isStackLine(line string) bool {
+	return strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "created by ") ||
+		strings.HasPrefix(line, "...") || (strings.Contains(line, "(") && !strings.HasPrefix(line, " "))
+}
+
+// runningTests returns the tests a test timeout panic lists as running, in
+// the lines before the dump, e.g. "TestA" from "\t\tTestA (10m0s)".
+func runningTests(lines []string) []string {
+	var tests []string
+	listing := false
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "running tests:":
+			listing = true
+		case listing && strings.HasPrefix(line, "\t\t"):
+			if name, _, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+				tests = append(tests, name)
+			}
+		default:
+			listing = false
+		}
+	}
+	return tests
+}
+
+// goroutineCulprit returns the goroutine of the dump to show: the first
+// running one of the given tests after a timeout, whose first goroutine is
+// only the test alarm, or else the first, which panicked.
+func goroutineCulprit(dump []goroutine, tests []string) goroutine {
+	alarm := slices.ContainsFunc(dump[0].lines, func(line string) bool {
+		return strings.HasPrefix(line, "testing.(*M).startAlarm")
+	})
+	if !alarm {
+		return dump[0]
+	}
+	for _, g := range dump[1:] {
+		for _, test := range tests {
+			top, _, _ := strings.Cut(test, "/")
+			if slices.ContainsFunc(g.lines, func(line string) bool {
+				return strings.Contains(line, "."+top+"(") || strings.Contains(line, "."+top+".func")
+			}) {
+				return g
+			}
+		}
+	}
+	return dump[0]
+}
+
+// goroutineStates returns the number of goroutines of the dump in each
+// state, most common first, e.g. "5 chan receive, 1 running".
+func goroutineStates(dump []goroutine) string {
+	counts := make(map[string]int)
+	var states []string
+	for _, g := range dump {
+		if counts[g.state] == 0 {
+			states = append(states, g.state)
+		}
+		counts[g.state]++
+	}
+	slices.SortStableFunc(states, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%d %s", counts[state], state)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,108 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+)
+
+// timeoutDump is the output of a test which timed out, trimmed.
+var timeoutDump = []string{
+	"panic: test timed out after 1s",
+	"\trunning tests:",
+	"\t\tTestHang (1s)",
+	"",
+	"goroutine 14 [running]:",
+	"testing.(*M).startAlarm.func1()",
+	"\t/usr/local/go/src/testing/testing.go:2959 +0x34a",
+	"created by time.goFunc",
+	"\t/usr/local/go/src/time/sleep.go:182 +0x2d",
+	"",
+	"goroutine 1 [chan receive]:",
+	"testing.(*T).Run(0xbd78599a008, {0x554bc3?, 0xbd785964aa0?}, 0x6d46b8)",
+	"\t/usr/local/go/src/testing/testing.go:2266 +0x4f2",
+	"main.main()",
+	"\t_testmain.go:46 +0x9b",
+	"",
+	"goroutine 7 [sleep, 2 minutes]:",
+	"time.Sleep(0x34630b8a000)",
+	"\t/usr/local/go/src/runtime/time.go:368 +0x165",
+	"example.com/gd.TestHang(0xbd78599a248?)",
+	"\t/tmp/gd/gd_test.go:16 +0xaa",
+	"",
+	"goroutine 8 [chan receive]:",
+	"example.com/gd.blockForever(...)",
+	"\t/tmp/gd/gd_test.go:8",
+	"created by example.com/gd.TestHang in goroutine 7",
+	"\t/tmp/gd/gd_test.go:13 +0x37",
+	"exit status 2",
+}
+
+func TestCondenseGoroutineDumps(t *testing.T) {
+	got := condenseGoroutineDumps(timeoutDump, "dump.log")
+	want := []string{
+		"panic: test timed out after 1s",
+		"\trunning tests:",
+		"\t\tTestHang (1s)",
+		"",
+		"[4 goroutines: 2 chan receive, 1 running, 1 sleep; showing goroutine 7]",
+		"goroutine 7 [sleep, 2 minutes]:",
+		"time.Sleep(0x34630b8a000)",
+		"\t/usr/local/go/src/runtime/time.go:368 +0x165",
+		"example.com/gd.TestHang(0xbd78599a248?)",
+		"\t/tmp/gd/gd_test.go:16 +0xaa",
+		"[full dump: dump.log]",
+		"exit status 2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Condensed timeout:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// After a panic, the panicking goroutine is listed first.
+	panicked := []string{
+		"panic: boom [recovered]",
+		"",
+		"goroutine 7 [running]:",
+		"example.com/pkg.TestPanic(0x1)",
+		"\t/src/pkg_test.go:10 +0x1",
+		"",
+		"goroutine 1 [chan receive]:",
+		"main.main()",
+		"\t_testmain.go:46 +0x9b",
+	}
+	got = condenseGoroutineDumps(panicked, "dump.log")
+	if got[2] != "[2 goroutines: 1 chan receive, 1 running; showing goroutine 7]" || got[3] != "goroutine 7 [running]:" {
+		t.Errorf("Condensed panic:\n%s", strings.Join(got, "\n"))
+	}
+
+	// A single goroutine is shown as it is.
+	single := panicked[:5]
+	if got := condenseGoroutineDumps(single, "dump.log"); strings.Join(got, "\n") != strings.Join(single, "\n") {
+		t.Errorf("Expected a single goroutine unchanged, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestFormatGoroutineDumps(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/gd", Status: results.StatusFailed, TestOrder: []string{"TestHang"}}
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	tr := results.NewTestResult(pkg.Name, "TestHang")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Output = timeoutDump
+	run.TestResults[pkg.Name+"/TestHang"] = tr
+
+	opts := NewSummaryOptions(WithLogDir("logs"))
+	out := NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	if !strings.Contains(out, "[full dump: "+LogPath("logs", pkg.Name, "TestHang")+"]") || strings.Contains(out, "goroutine 14") {
+		t.Errorf("Expected a condensed dump, got:\n%s", out)
+	}
+
+	opts = NewSummaryOptions(WithFullGoroutineDumps(true))
+	out = NewSummaryFormatter(100, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+	if !strings.Contains(out, "goroutine 14 [running]:") || strings.Contains(out, "full dump") {
+		t.Errorf("Expected the full dump, got:\n%s", out)
+	}
+}
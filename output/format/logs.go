@@ -0,0 +1,62 @@
+package format
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+)
+
+// maxLogFileName caps the length of extracted log file names, keeping
+// their paths within Windows' 260 character limit (MAX_PATH) in typical
+// directories, and within the 255 byte file name limit elsewhere.
+const maxLogFileName = 120
+
+// LogFileName returns the name of the file -extract-logs writes a test's
+// output to: the package path and test name joined by "__", with
+// characters that are unsafe in file names replaced by "_", e.g.
+// "example.com_pkg__TestA_sub.log".
+//
+// Names longer than maxLogFileName, e.g. of deeply nested table-driven
+// subtests, are truncated, with a hash of the package and test appended to
+// keep them unique.
+func LogFileName(pkg, test string) string {
+	return logFileName(sanitizeFileName(pkg)+"__"+sanitizeFileName(test), pkg+"/"+test)
+}
+
+// PackageLogFileName returns the name of the file -split-logs streams a
+// package's output to: the package path with characters that are unsafe
+// in file names replaced by "_", e.g. "example.com_pkg.log". Long names
+// are truncated as by LogFileName.
+func PackageLogFileName(pkg string) string {
+	return logFileName(sanitizeFileName(pkg), pkg)
+}
+
+// logFileName returns name with a ".log" extension, truncated to
+// maxLogFileName with a hash of key appended if it's too long.
+func logFileName(name, key string) string {
+	if len(name)+len(".log") > maxLogFileName {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		hash := fmt.Sprintf("~%08x", h.Sum32())
+		name = name[:maxLogFileName-len(".log")-len(hash)] + hash
+	}
+	return name + ".log"
+}
+
+// LogPath returns the path of a test's extracted log file in dir.
+func LogPath(dir, pkg, test string) string {
+	return filepath.Join(dir, LogFileName(pkg, test))
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_', r == '#':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
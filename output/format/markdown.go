@@ -0,0 +1,288 @@
+package format
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// MarkdownMaxOutputLines is how many lines of each failure's output the
+	// Markdown summary includes, keeping it within the size limits of pull
+	// request comments.
+	MarkdownMaxOutputLines = 50
+
+	// MarkdownMaxFailures is how many failures the Markdown summary details.
+	MarkdownMaxFailures = 20
+)
+
+// WriteMarkdown writes the summary to w as GitHub-flavored Markdown, for
+// posting as a pull request comment or writing to $GITHUB_STEP_SUMMARY: a
+// headline with the run's counts, a table of packages, and a collapsible
+// details block with the output of each failure, followed by the commands
+// reproducing the failures. With RepoLinks set in opts, each failure lists
+// links to the source lines its output references.
+func WriteMarkdown(w io.Writer, summary *Summary, opts ...SummaryOption) error {
+	options := NewSummaryOptions(opts...)
+	var sb strings.Builder
+
+	icon, outcome := "✅", "passed"
+	switch {
+	case summary.Run != nil && summary.Run.Status == results.StatusInterrupted:
+		icon, outcome = "⚠️", "interrupted"
+	case summary.Run != nil && summary.Run.Status == results.StatusRunning:
+		icon, outcome = "⏳", "in progress"
+	case summary.FailedTests > 0 || len(summary.BuildFailures) > 0:
+		icon, outcome = "❌", "failed"
+	}
+	fmt.Fprintf(&sb, "### %s Tests %s", icon, outcome)
+	if summary.Run != nil {
+		for _, badge := range summary.Run.Badges() {
+			sb.WriteString(" " + markdownCode(badge))
+		}
+	}
+	sb.WriteString("\n\n")
+	fmt.Fprintf(&sb, "**%d tests**: %d passed, %d failed, %d skipped in %s (%d packages)",
+		summary.TotalTests, summary.PassedTests, summary.FailedTests, summary.SkippedTests,
+		options.DurationStyle.Format(summary.TotalTime), summary.PackageCount)
+	if summary.Run != nil && summary.Run.ApproximateDurations() {
+		sb.WriteString(", durations approximate")
+	}
+	sb.WriteString("\n")
+
+	if len(summary.Packages) > 0 {
+		sb.WriteString("\n|   | Package | Passed | Failed | Skipped | Time |\n")
+		sb.WriteString("|---|---------|-------:|-------:|--------:|-----:|\n")
+		for _, pkg := range summary.OrderedPackages(options.PackageOrder) {
+			passed, failed, skipped := summary.PackageCounts(pkg)
+			fmt.Fprintf(&sb, "| %s | %s | %d | %d | %d | %s |\n",
+				markdownPackageIcon(pkg), markdownTableCell(markdownCode(pkg.Name)),
+				passed, failed, skipped, markdownPackageTime(pkg, options.DurationStyle))
+		}
+	}
+
+	var blocks []string
+	for _, pkg := range summary.BuildFailures {
+		var lines []string
+		if summary.Run != nil {
+			for _, be := range summary.Run.GetBuildErrors(pkg.FailedBuild) {
+				if be.Action == "build-output" && be.Output != "" {
+					lines = append(lines, strings.Split(strings.TrimRight(be.Output, "\n"), "\n")...)
+				}
+			}
+		}
+		blocks = append(blocks, markdownDetails("Build failed: <code>"+html.EscapeString(pkg.Name)+"</code>", lines, nil))
+	}
+	for _, pkg := range summary.Packages {
+		failed := pkg.Status == results.StatusFailed || pkg.Status == results.StatusBuildFailed
+		if failed && len(pkg.OutputLines) > 0 {
+			blocks = append(blocks, markdownDetails("Output of <code>"+html.EscapeString(pkg.Name)+"</code>", pkg.OutputLines, nil))
+		}
+	}
+	for _, entry := range markdownFailures(summary) {
+		tr, exec := entry.TestResult, entry.TestExecution
+		title := fmt.Sprintf("<code>%s</code> in <code>%s</code> (%s)",
+			html.EscapeString(entry.DisplayName(SymbolsUnicode)),
+			html.EscapeString(tr.Package), options.DurationStyle.formatTest(exec.Elapsed))
+		if exec.Interrupted {
+			title += " interrupted"
+		}
+		if len(entry.Categories) > 0 {
+			title += " [" + html.EscapeString(strings.Join(entry.Categories, ", ")) + "]"
+		}
+		output := exec.Output
+		if !options.RawTestify {
+			output = compactTestifyFailures(output)
+		}
+		blocks = append(blocks, markdownDetails(title, output, markdownSourceLinks(options, tr.Package, output)))
+	}
+
+	if len(blocks) > 0 {
+		sb.WriteString("\n#### Failures\n")
+		budget := detailsBudget{limit: cmp.Or(options.MaxDetailsBytes, MarkdownMaxBytes)}
+		for i, block := range blocks {
+			if i == MarkdownMaxFailures {
+				fmt.Fprintf(&sb, "\n…and %d more.\n", len(blocks)-i)
+				break
+			}
+			// A block is shown whole or not at all, so its code fence
+			// is never cut.
+			if budget.exhausted() || !budget.fits(len(block)+1) {
+				budget.omit(block)
+				continue
+			}
+			sb.WriteString("\n")
+			sb.WriteString(block)
+		}
+		if trailer := budget.trailer(options); trailer != "" {
+			fmt.Fprintf(&sb, "\n%s\n", trailer)
+		}
+	}
+
+	if summary.Run != nil && len(blocks) > 0 {
+		if commands := summary.Run.ReproduceCommands(); len(commands) > 0 {
+			fence := markdownFence(commands)
+			fmt.Fprintf(&sb, "\n#### Reproduce\n\n%ssh\n%s\n%s\n", fence, strings.Join(commands, "\n"), fence)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// markdownFailures returns the summary's failures in package and test
+// order.
+func markdownFailures(summary *Summary) []*TestExecutionEntry {
+	if summary.Run == nil {
+		return summary.Failures
+	}
+	byExec := make(map[*results.TestExecution]*TestExecutionEntry, len(summary.Failures))
+	for _, entry := range summary.Failures {
+		byExec[entry.TestExecution] = entry
+	}
+	failures := make([]*TestExecutionEntry, 0, len(summary.Failures))
+	for _, pkg := range summary.Packages {
+		for _, testName := range pkg.TestOrder {
+			tr := summary.Run.TestResults[pkg.Name+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			for _, exec := range tr.Executions {
+				if entry := byExec[exec]; entry != nil {
+					failures = append(failures, entry)
+				}
+			}
+		}
+	}
+	return failures
+}
+
+// markdownDetails renders a collapsible block titled with the HTML title,
+// containing lines in a code block, followed by a list of links. Lines past
+// MarkdownMaxOutputLines are elided.
+func markdownDetails(title string, lines, links []string) string {
+	var sb strings.Builder
+	sb.WriteString("<details>\n<summary>")
+	sb.WriteString(title)
+	sb.WriteString("</summary>\n\n")
+	if len(lines) > 0 {
+		stripped := make([]string, 0, min(len(lines), MarkdownMaxOutputLines+1))
+		for i, line := range lines {
+			if i == MarkdownMaxOutputLines {
+				stripped = append(stripped, fmt.Sprintf("… %d more lines", len(lines)-i))
+				break
+			}
+			stripped = append(stripped, ansi.Strip(line))
+		}
+		fence := markdownFence(stripped)
+		sb.WriteString(fence + "text\n")
+		for _, line := range stripped {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fence + "\n\n")
+	}
+	for _, link := range links {
+		sb.WriteString("- " + link + "\n")
+	}
+	if len(links) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</details>\n")
+	return sb.String()
+}
+
+// markdownSourceLinks returns a Markdown link to each source line referenced
+// by a failure's output of a test in pkg, once each, or nil without
+// RepoLinks.
+func markdownSourceLinks(opts SummaryOptions, pkg string, output []string) []string {
+	if opts.RepoLinks.Template == "" {
+		return nil
+	}
+	var dir string
+	if opts.SourceDir != nil {
+		dir = opts.SourceDir(pkg)
+	}
+	var links []string
+	seen := make(map[string]bool)
+	for _, line := range output {
+		ref, url, _, _ := opts.RepoLinks.sourceRef(ansi.Strip(line), dir)
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		links = append(links, "["+markdownCode(ref)+"]("+url+")")
+	}
+	return links
+}
+
+// markdownFence returns a code fence longer than any run of backticks in
+// lines, so no line can close the code block early.
+func markdownFence(lines []string) string {
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, longestRun(line, '`'))
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// markdownCode renders s as an inline code span, delimited by more
+// backticks than s contains in a row.
+func markdownCode(s string) string {
+	delim := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return delim + s + delim
+}
+
+// markdownTableCell escapes the pipes in s, which would otherwise end a
+// table cell even inside a code span.
+func markdownTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, n := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			n++
+			longest = max(longest, n)
+		} else {
+			n = 0
+		}
+	}
+	return longest
+}
+
+func markdownPackageIcon(pkg *results.PackageResult) string {
+	switch {
+	case pkg.FailedBuild != "", pkg.Status == results.StatusFailed:
+		return "❌"
+	case pkg.Status == results.StatusInterrupted:
+		return "⚠️"
+	case pkg.Status == results.StatusSkipped:
+		return "➖"
+	default:
+		return "✅"
+	}
+}
+
+func markdownPackageTime(pkg *results.PackageResult, style DurationStyle) string {
+	switch {
+	case pkg.FailedBuild != "":
+		return "build failed"
+	case pkg.Cached:
+		return "cached"
+	case pkg.Status == results.StatusSkipped:
+		return "no tests"
+	default:
+		return style.Format(pkg.Elapsed)
+	}
+}
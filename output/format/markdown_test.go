@@ -0,0 +1,173 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func markdownRun() *results.Run {
+	run := results.NewRun(1)
+	run.Status = results.StatusFailed
+
+	ok := &results.PackageResult{Name: "example.com/ok", Status: results.StatusPassed, Elapsed: 1500 * time.Millisecond}
+	ok.Counts.Passed = 2
+	bad := &results.PackageResult{Name: "example.com/bad", Status: results.StatusFailed, Elapsed: 250 * time.Millisecond}
+	bad.Counts.Passed, bad.Counts.Failed = 1, 1
+	for _, pkg := range []*results.PackageResult{ok, bad} {
+		run.Packages[pkg.Name] = pkg
+		run.PackageOrder = append(run.PackageOrder, pkg.Name)
+	}
+
+	tr := results.NewTestResult("example.com/bad", "TestFence")
+	exec := tr.Latest()
+	exec.Status = results.StatusFailed
+	exec.Elapsed = 120 * time.Millisecond
+	exec.Output = []string{
+		"    fence_test.go:10: got:",
+		"    ```",
+		"    <b>bold</b> | \x1b[31mred\x1b[0m",
+		"    ```",
+	}
+	bad.TestOrder = []string{"TestFence"}
+	run.TestResults["example.com/bad/TestFence"] = tr
+	return run
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteMarkdown(&sb, ComputeSummary(markdownRun())); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"### ❌ Tests failed\n",
+		"**4 tests**: 3 passed, 1 failed, 0 skipped in 0s (2 packages)\n",
+		"| ✅ | `example.com/ok` | 2 | 0 | 0 | 1.5s |\n",
+		"| ❌ | `example.com/bad` | 1 | 1 | 0 | 250ms |\n",
+		"<details>\n<summary><code>TestFence</code> in <code>example.com/bad</code> (0.12s)</summary>\n",
+		// The fence is longer than the backticks in the output, and ANSI
+		// escapes are stripped.
+		"\n````text\n    fence_test.go:10: got:\n    ```\n    <b>bold</b> | red\n    ```\n````\n",
+		"\n#### Reproduce\n\n```sh\ngo test -count=1 -run '^TestFence$' example.com/bad\n```\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMarkdownRepoLinks(t *testing.T) {
+	var sb strings.Builder
+	opts := NewSummaryOptions(
+		WithSourceContext(0, func(string) string { return "/src/bad" }),
+		WithRepoLinks(RepoLinks{Template: "https://example.com/{sha}/{path}#L{line}", SHA: "abc", Root: "/src"}),
+	)
+	if err := WriteMarkdown(&sb, ComputeSummary(markdownRun(), WithOptions(opts)), WithOptions(opts)); err != nil {
+		t.Fatal(err)
+	}
+	want := "````\n\n- [`fence_test.go:10`](https://example.com/abc/bad/fence_test.go#L10)\n\n</details>\n"
+	if out := sb.String(); !strings.Contains(out, want) {
+		t.Errorf("Expected %q in:\n%s", want, out)
+	}
+}
+
+func TestWriteMarkdownBuildFailedOutput(t *testing.T) {
+	run := markdownRun()
+	broken := &results.PackageResult{
+		Name:        "example.com/broken",
+		Status:      results.StatusBuildFailed,
+		OutputLines: []string{"# example.com/broken", "broken.go:3:1: syntax error"},
+	}
+	run.Packages[broken.Name] = broken
+	run.PackageOrder = append(run.PackageOrder, broken.Name)
+
+	var sb strings.Builder
+	if err := WriteMarkdown(&sb, ComputeSummary(run)); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"<summary>Output of <code>example.com/broken</code></summary>\n",
+		"broken.go:3:1: syntax error\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMarkdownTruncates(t *testing.T) {
+	run := markdownRun()
+	tr := run.TestResults["example.com/bad/TestFence"]
+	tr.Latest().Output = nil
+	for i := range MarkdownMaxOutputLines + 10 {
+		tr.Latest().Output = append(tr.Latest().Output, fmt.Sprintf("line %d", i))
+	}
+
+	var sb strings.Builder
+	if err := WriteMarkdown(&sb, ComputeSummary(run)); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	if !strings.Contains(out, fmt.Sprintf("line %d\n… 10 more lines\n", MarkdownMaxOutputLines-1)) {
+		t.Errorf("Expected the output to be truncated, got:\n%s", out)
+	}
+}
+
+func TestWriteMarkdownSizeBudget(t *testing.T) {
+	run := markdownRun()
+	bad := run.Packages["example.com/bad"]
+	for i := range 3 {
+		name := fmt.Sprintf("TestBig%d", i)
+		tr := results.NewTestResult("example.com/bad", name)
+		tr.Latest().Status = results.StatusFailed
+		tr.Latest().Output = []string{strings.Repeat("x", 400)}
+		bad.TestOrder = append(bad.TestOrder, name)
+		run.TestResults["example.com/bad/"+name] = tr
+	}
+
+	write := func() string {
+		var sb strings.Builder
+		if err := WriteMarkdown(&sb, ComputeSummary(run), WithMaxDetailsBytes(1000, "events.json")); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+	out := write()
+	// TestFence and TestBig0 fit in 1000 bytes; TestBig1 doesn't, so
+	// neither does anything after it.
+	for _, want := range []string{"<code>TestBig0</code>", "\n… 1.0KB (16 lines) of test details omitted to fit 1000B; full output in events.json\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"TestBig1</code>", "TestBig2</code>"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("Expected no %q in:\n%s", unwanted, out)
+		}
+	}
+	if again := write(); again != out {
+		t.Errorf("Expected the same truncation each time, got:\n%s\nthen:\n%s", out, again)
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := map[string]string{
+		"pkg":   "`pkg`",
+		"a`b":   "``a`b``",
+		"`a``":  "``` `a`` ```",
+		"a|b":   "`a|b`",
+		"``":    "``` `` ```",
+		"a```b": "````a```b````",
+	}
+	for in, want := range tests {
+		if got := markdownCode(in); got != want {
+			t.Errorf("markdownCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
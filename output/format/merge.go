@@ -0,0 +1,130 @@
+package format
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// MergeSummaryJSON merges the summaries of the shards of one test run, e.g.
+// CI jobs each running part of the suite, into the summary of the whole run.
+//
+// Shards may split a package by test name, so packages are merged by
+// summing their counts, with the longest elapsed time as the shards ran
+// concurrently. Results are merged per test: a test which ran in more than
+// one shard keeps each execution as a further iteration, and is reported
+// in Suspicious if their statuses differ, since each test should only run
+// in one shard.
+func MergeSummaryJSON(shards []*SummaryJSON) *SummaryJSON {
+	merged := &SummaryJSON{
+		Packages: make([]PackageJSON, 0),
+		Results:  make([]TestResultJSON, 0),
+	}
+	labels := make(map[string]string)
+	pkgIndex := make(map[string]int)
+	testIndex := make(map[string][]int)  // "package/test" -> indexes of its results in merged.Results
+	testShards := make(map[string][]int) // "package/test" -> shards it ran in
+	var testOrder []string
+
+	for shard, sj := range shards {
+		if merged.RunID == "" {
+			merged.RunID = sj.RunID
+		}
+		for k, v := range sj.Labels {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+		for _, mode := range sj.Modes {
+			if !slices.Contains(merged.Modes, mode) {
+				merged.Modes = append(merged.Modes, mode)
+			}
+		}
+		if !sj.StartTime.IsZero() && (merged.StartTime.IsZero() || sj.StartTime.Before(merged.StartTime)) {
+			merged.StartTime = sj.StartTime
+		}
+		merged.Status = mergeStatus(merged.Status, sj.Status)
+		merged.Tests += sj.Tests
+		merged.Passed += sj.Passed
+		merged.Failed += sj.Failed
+		merged.Skipped += sj.Skipped
+		merged.Interrupted += sj.Interrupted
+		merged.Elapsed = max(merged.Elapsed, sj.Elapsed)
+		merged.Malformed += sj.Malformed
+		merged.Inconsistent = append(merged.Inconsistent, sj.Inconsistent...)
+		merged.Anomalies = append(merged.Anomalies, sj.Anomalies...)
+
+		for _, pkg := range sj.Packages {
+			i, ok := pkgIndex[pkg.Name]
+			if !ok {
+				pkgIndex[pkg.Name] = len(merged.Packages)
+				merged.Packages = append(merged.Packages, pkg)
+				continue
+			}
+			m := &merged.Packages[i]
+			m.Status = mergeStatus(m.Status, pkg.Status)
+			m.Elapsed = max(m.Elapsed, pkg.Elapsed)
+			m.Passed += pkg.Passed
+			m.Failed += pkg.Failed
+			m.Skipped += pkg.Skipped
+			m.Setup = max(m.Setup, pkg.Setup)
+			m.Teardown = max(m.Teardown, pkg.Teardown)
+		}
+
+		for _, tr := range sj.Results {
+			key := tr.Package + "/" + tr.Name
+			if _, ok := testIndex[key]; !ok {
+				testOrder = append(testOrder, key)
+			}
+			if shards := testShards[key]; len(shards) == 0 || shards[len(shards)-1] != shard {
+				testShards[key] = append(shards, shard)
+			}
+			tr.Iteration = len(testIndex[key]) + 1
+			testIndex[key] = append(testIndex[key], len(merged.Results))
+			merged.Results = append(merged.Results, tr)
+		}
+	}
+	if len(labels) > 0 {
+		merged.Labels = labels
+	}
+
+	// Keep each test's executions together, in the order tests were first
+	// seen.
+	ordered := make([]TestResultJSON, 0, len(merged.Results))
+	for _, key := range testOrder {
+		statuses := make(map[string]bool)
+		for _, i := range testIndex[key] {
+			ordered = append(ordered, merged.Results[i])
+			statuses[merged.Results[i].Status] = true
+		}
+		if len(testShards[key]) > 1 && len(statuses) > 1 {
+			tr := merged.Results[testIndex[key][0]]
+			shardNames := make([]string, len(testShards[key]))
+			for i, shard := range testShards[key] {
+				shardNames[i] = fmt.Sprint(shard + 1)
+			}
+			merged.Suspicious = append(merged.Suspicious, fmt.Sprintf("%s %s: ran in shards %s with differing results: %s",
+				tr.Package, tr.Name, strings.Join(shardNames, ", "), strings.Join(slices.Sorted(maps.Keys(statuses)), ", ")))
+		}
+	}
+	merged.Results = ordered
+	return merged
+}
+
+// mergeStatus returns the status of a run or package made of shards with
+// statuses a and b: failed if either failed, else build failed or
+// interrupted if either was, else passed if either passed.
+func mergeStatus(a, b string) string {
+	for _, status := range []results.Status{results.StatusFailed, results.StatusBuildFailed, results.StatusInterrupted, results.StatusPassed} {
+		if a == status.String() || b == status.String() {
+			return status.String()
+		}
+	}
+	if a == "" {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeSummaryJSON(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	shard1 := &SummaryJSON{
+		RunID: "a", Labels: map[string]string{"shard": "1"}, Modes: []string{"race"}, StartTime: start.Add(time.Second),
+		Status: "passed", Tests: 3, Passed: 3, Elapsed: 10,
+		Packages: []PackageJSON{
+			{Name: "example.com/a", Status: "passed", Elapsed: 4, Passed: 2},
+			{Name: "example.com/b", Status: "passed", Elapsed: 6, Passed: 1},
+		},
+		Results: []TestResultJSON{
+			{Package: "example.com/a", Name: "TestOne", Iteration: 1, Status: "passed", Elapsed: 1},
+			{Package: "example.com/a", Name: "TestTwo", Iteration: 1, Status: "passed", Elapsed: 2},
+			{Package: "example.com/b", Name: "TestFlip", Iteration: 1, Status: "passed", Elapsed: 3},
+		},
+	}
+	shard2 := &SummaryJSON{
+		RunID: "b", Labels: map[string]string{"shard": "2", "go": "1.25"}, Modes: []string{"race", "short"}, StartTime: start,
+		Status: "failed", Tests: 3, Passed: 1, Failed: 1, Skipped: 1, Elapsed: 8,
+		Packages: []PackageJSON{
+			{Name: "example.com/a", Status: "failed", Elapsed: 7, Passed: 1, Failed: 1},
+			{Name: "example.com/b", Status: "passed", Elapsed: 2, Skipped: 1},
+		},
+		Results: []TestResultJSON{
+			{Package: "example.com/a", Name: "TestThree", Iteration: 1, Status: "passed", Elapsed: 1},
+			{Package: "example.com/a", Name: "TestFour", Iteration: 1, Status: "failed", Elapsed: 5},
+			{Package: "example.com/b", Name: "TestFlip", Iteration: 1, Status: "skipped", Elapsed: 0},
+		},
+	}
+
+	merged := MergeSummaryJSON([]*SummaryJSON{shard1, shard2})
+
+	if merged.RunID != "a" || merged.Status != "failed" || !merged.StartTime.Equal(start) || merged.Elapsed != 10 {
+		t.Errorf("Unexpected run: id %q, status %q, start %v, elapsed %v", merged.RunID, merged.Status, merged.StartTime, merged.Elapsed)
+	}
+	if merged.Tests != 6 || merged.Passed != 4 || merged.Failed != 1 || merged.Skipped != 1 {
+		t.Errorf("Unexpected totals %d/%d/%d/%d", merged.Tests, merged.Passed, merged.Failed, merged.Skipped)
+	}
+	if want := map[string]string{"shard": "1", "go": "1.25"}; !reflect.DeepEqual(merged.Labels, want) {
+		t.Errorf("Labels = %v, want %v", merged.Labels, want)
+	}
+	if want := []string{"race", "short"}; !reflect.DeepEqual(merged.Modes, want) {
+		t.Errorf("Modes = %v, want %v", merged.Modes, want)
+	}
+
+	wantPkgs := []PackageJSON{
+		{Name: "example.com/a", Status: "failed", Elapsed: 7, Passed: 3, Failed: 1},
+		{Name: "example.com/b", Status: "passed", Elapsed: 6, Passed: 1, Skipped: 1},
+	}
+	if !reflect.DeepEqual(merged.Packages, wantPkgs) {
+		t.Errorf("Packages = %+v, want %+v", merged.Packages, wantPkgs)
+	}
+
+	var got []string
+	for _, tr := range merged.Results {
+		got = append(got, fmt.Sprintf("%s#%s#%d", tr.Name, tr.Status, tr.Iteration))
+	}
+	want := []string{"TestOne#passed#1", "TestTwo#passed#1", "TestFlip#passed#1", "TestFlip#skipped#2", "TestThree#passed#1", "TestFour#failed#1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Results = %v, want %v", got, want)
+	}
+
+	wantSuspicious := []string{"example.com/b TestFlip: ran in shards 1, 2 with differing results: passed, skipped"}
+	if !reflect.DeepEqual(merged.Suspicious, wantSuspicious) {
+		t.Errorf("Suspicious = %v, want %v", merged.Suspicious, wantSuspicious)
+	}
+}
+
+func TestMergeSummaryJSONRepeatedInOneShard(t *testing.T) {
+	// A test run -count=2 in one shard with differing results is flaky,
+	// not misassigned to shards.
+	shard := &SummaryJSON{
+		Status: "failed",
+		Results: []TestResultJSON{
+			{Package: "example.com/a", Name: "TestFlaky", Iteration: 1, Status: "passed"},
+			{Package: "example.com/a", Name: "TestFlaky", Iteration: 2, Status: "failed"},
+		},
+	}
+	merged := MergeSummaryJSON([]*SummaryJSON{shard, {Status: "passed"}})
+	if len(merged.Suspicious) != 0 {
+		t.Errorf("Expected nothing suspicious, got %v", merged.Suspicious)
+	}
+	if merged.Status != "failed" || len(merged.Results) != 2 {
+		t.Errorf("Unexpected merge %+v", merged)
+	}
+}
@@ -0,0 +1,143 @@
+package format
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// ModuleGroup is a module of a workspace and the packages of a run which
+// belong to it.
+type ModuleGroup struct {
+	Module   string // Module path; "" for packages outside every module
+	Packages []*results.PackageResult
+}
+
+// ModuleOf returns the module pkg belongs to: the longest of modules which
+// is pkg or a path prefix of it, or "" if none is.
+func ModuleOf(pkg string, modules []string) string {
+	best := ""
+	for _, mod := range modules {
+		if (pkg == mod || strings.HasPrefix(pkg, mod+"/")) && len(mod) > len(best) {
+			best = mod
+		}
+	}
+	return best
+}
+
+// GroupByModule groups pkgs by module, ordered by each module's first
+// package. Packages keep their order within a group.
+func GroupByModule(pkgs []*results.PackageResult, modules []string) []ModuleGroup {
+	var groups []ModuleGroup
+	index := make(map[string]int)
+	for _, pkg := range pkgs {
+		mod := ModuleOf(pkg.Name, modules)
+		i, ok := index[mod]
+		if !ok {
+			i = len(groups)
+			index[mod] = i
+			groups = append(groups, ModuleGroup{Module: mod})
+		}
+		groups[i].Packages = append(groups[i].Packages, pkg)
+	}
+	return groups
+}
+
+// GroupByDirectory groups pkgs by their directory depth levels beneath their
+// module, e.g. "example.com/repo/services" at depth 1 for
+// "example.com/repo/services/orders/api" in module "example.com/repo",
+// ordered by each directory's first package. Packages outside every module
+// are grouped beneath the longest path prefix they all share. Packages no
+// deeper than depth are grouped by themselves. The Module of each group is
+// its directory's import path.
+func GroupByDirectory(pkgs []*results.PackageResult, depth int, modules []string) []ModuleGroup {
+	var outside []string
+	for _, pkg := range pkgs {
+		if ModuleOf(pkg.Name, modules) == "" {
+			outside = append(outside, pkg.Name)
+		}
+	}
+	prefix := commonPathPrefix(outside)
+
+	var groups []ModuleGroup
+	index := make(map[string]int)
+	for _, pkg := range pkgs {
+		base := ModuleOf(pkg.Name, modules)
+		if base == "" {
+			base = prefix
+		}
+		dir := pkg.Name
+		if rest, ok := strings.CutPrefix(pkg.Name, base+"/"); ok && base != "" {
+			segments := strings.Split(rest, "/")
+			dir = base + "/" + strings.Join(segments[:min(depth, len(segments))], "/")
+		}
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, ModuleGroup{Module: dir})
+		}
+		groups[i].Packages = append(groups[i].Packages, pkg)
+	}
+	return groups
+}
+
+// commonPathPrefix returns the longest path, of whole segments, which is
+// a prefix of every path in paths, or "" if they share none. A single path
+// is its own prefix.
+func commonPathPrefix(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	prefix := strings.Split(paths[0], "/")
+	for _, p := range paths[1:] {
+		segments := strings.Split(p, "/")
+		n := 0
+		for n < len(prefix) && n < len(segments) && prefix[n] == segments[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return strings.Join(prefix, "/")
+}
+
+// Failed reports whether any of the group's packages failed, including to
+// build.
+func (g ModuleGroup) Failed() bool {
+	for _, pkg := range g.Packages {
+		if pkg.FailedBuild != "" || pkg.Status == results.StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts returns the total passed, failed and skipped tests of the group's
+// packages.
+func (g ModuleGroup) Counts() (passed, failed, skipped int) {
+	for _, pkg := range g.Packages {
+		passed += pkg.Counts.Passed
+		failed += pkg.Counts.Failed
+		skipped += pkg.Counts.Skipped
+	}
+	return passed, failed, skipped
+}
+
+// Elapsed returns the wall time from the first of the group's packages
+// starting to the last finishing, or 0 without timestamps.
+func (g ModuleGroup) Elapsed() time.Duration {
+	var start, end time.Time
+	for _, pkg := range g.Packages {
+		if pkg.StartTime.IsZero() || pkg.EndTime.IsZero() {
+			continue
+		}
+		if start.IsZero() || pkg.StartTime.Before(start) {
+			start = pkg.StartTime
+		}
+		if pkg.EndTime.After(end) {
+			end = pkg.EndTime
+		}
+	}
+	return end.Sub(start)
+}
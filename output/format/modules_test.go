@@ -0,0 +1,125 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestModuleOf(t *testing.T) {
+	modules := []string{"example.com/app", "example.com/app/tools", "example.com/lib"}
+	tests := []struct {
+		pkg, want string
+	}{
+		{"example.com/app", "example.com/app"},
+		{"example.com/app/server", "example.com/app"},
+		{"example.com/app/tools/gen", "example.com/app/tools"},
+		{"example.com/application", ""},
+		{"other.org/x", ""},
+	}
+	for _, tt := range tests {
+		if got := ModuleOf(tt.pkg, modules); got != tt.want {
+			t.Errorf("ModuleOf(%q) = %q, want %q", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryFormatterGroupsByModule(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := results.NewRun(1)
+	for i, name := range []string{"example.com/app/a", "example.com/lib/x", "example.com/app/b"} {
+		pkg := &results.PackageResult{
+			Name:      name,
+			Status:    results.StatusPassed,
+			StartTime: start.Add(time.Duration(i) * time.Second),
+			EndTime:   start.Add(time.Duration(i+1) * time.Second),
+			Elapsed:   time.Second,
+		}
+		pkg.Counts.Passed = i + 1
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+	}
+	summary := ComputeSummary(run)
+
+	opts := NewSummaryOptions(WithModules([]string{"example.com/app", "example.com/lib"}))
+	output := NewSummaryFormatter(80, true, opts).Format(summary)
+	order := []string{
+		"ok    example.com/app/a",
+		"ok    example.com/app/b",
+		"example.com/app (2 packages)  (✓4 ✗0 ∅0) 4  3s",
+		"ok    example.com/lib/x",
+		"example.com/lib (1 package)   (✓2 ✗0 ∅0) 2  1s",
+	}
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(output[pos:], want)
+		if i < 0 {
+			t.Fatalf("Expected %q after offset %d in:\n%s", want, pos, output)
+		}
+		pos += i + len(want)
+	}
+
+	if output := NewSummaryFormatter(80, true).Format(summary); strings.Contains(output, "(2 packages)") {
+		t.Errorf("Expected no module subtotals without modules, got:\n%s", output)
+	}
+}
+
+func TestGroupByDirectory(t *testing.T) {
+	var pkgs []*results.PackageResult
+	for _, name := range []string{
+		"example.com/repo/services/orders/api",
+		"example.com/repo/pkg/log",
+		"example.com/repo/services/billing",
+		"example.com/repo",
+		"example.com/lib/x/y",
+		"example.com/lib/z",
+	} {
+		pkgs = append(pkgs, &results.PackageResult{Name: name})
+	}
+
+	var got []string
+	for _, g := range GroupByDirectory(pkgs, 1, []string{"example.com/lib"}) {
+		got = append(got, fmt.Sprintf("%s=%d", g.Module, len(g.Packages)))
+	}
+	want := "example.com/repo/services=2 example.com/repo/pkg=1 example.com/repo=1 example.com/lib/x=1 example.com/lib/z=1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("GroupByDirectory = %v, want %s", got, want)
+	}
+}
+
+func TestSummaryFormatterRollup(t *testing.T) {
+	run := results.NewRun(1)
+	for i, name := range []string{"example.com/repo/svc/a", "example.com/repo/svc/b", "example.com/repo/lib/c", "example.com/repo/lib/d", "example.com/repo/tool"} {
+		pkg := &results.PackageResult{Name: name, Status: results.StatusPassed}
+		pkg.Counts.Passed = i + 1
+		if name == "example.com/repo/lib/d" {
+			pkg.Status = results.StatusFailed
+			pkg.Counts.Failed = 1
+		}
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+	}
+
+	output := NewSummaryFormatter(100, true, NewSummaryOptions(WithRollup(1), WithPackageOrder(PackageOrderChronological))).Format(ComputeSummary(run))
+	order := []string{
+		"ok      example.com/repo/svc/... (2 packages)",
+		"FAIL    example.com/repo/lib/... (2 packages)",
+		"ok      example.com/repo/lib/c",
+		"FAIL    example.com/repo/lib/d",
+		"ok      example.com/repo/tool",
+	}
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(output[pos:], want)
+		if i < 0 {
+			t.Fatalf("Expected %q after offset %d in:\n%s", want, pos, output)
+		}
+		pos += i + len(want)
+	}
+	if strings.Contains(output, "example.com/repo/svc/a") {
+		t.Errorf("Expected the passing directory's packages rolled up, got:\n%s", output)
+	}
+}
@@ -0,0 +1,86 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// OutputLevel is the severity of a line of non-test output, i.e. output of
+// the go command, compiler or vet rather than of a test.
+type OutputLevel int
+
+const (
+	OutputInfo    OutputLevel = iota // e.g. "go: downloading ...", "# pkg"
+	OutputWarning                    // e.g. "go: warning: ...", deprecation notices
+	OutputError                      // e.g. compiler errors, "FAIL pkg [build failed]"
+)
+
+var (
+	// outputWarningRE matches warnings and deprecation notices. It's tried
+	// before outputErrorRE, so a warning mentioning an error stays a
+	// warning.
+	outputWarningRE = regexp.MustCompile(`(?i)\bwarning\b|\bdeprecated\b|\bdeprecation\b`)
+
+	// outputErrorRE matches errors of the go command and compiler, and
+	// vet's reports, e.g. "./foo.go:12:3: undefined: bar".
+	outputErrorRE = regexp.MustCompile(`(?i)\berror\b|^FAIL\b|^(panic|fatal error): |\[(build|setup) failed\]|\.go:\d+(:\d+)?: |^go: (cannot|no required module|missing|updates to go\.mod needed)`)
+)
+
+// ClassifyOutputLine returns the severity of a line of non-test output.
+// Lines which are neither warnings nor errors are OutputInfo.
+func ClassifyOutputLine(line string) OutputLevel {
+	line = strings.TrimSpace(ansi.Strip(line))
+	switch {
+	case line == "":
+		return OutputInfo
+	case outputWarningRE.MatchString(line):
+		return OutputWarning
+	case outputErrorRE.MatchString(line):
+		return OutputError
+	default:
+		return OutputInfo
+	}
+}
+
+// countOutputLevels returns the numbers of errors and warnings in lines.
+func countOutputLevels(lines []string) (errors, warnings int) {
+	for _, line := range lines {
+		switch ClassifyOutputLine(line) {
+		case OutputError:
+			errors++
+		case OutputWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// formatNonTestCounts writes the number of errors and warnings in the run's
+// non-test output, which is printed above the summary, so warnings aren't
+// mistaken for the errors failing the build.
+func (f *SummaryFormatter) formatNonTestCounts(sb *strings.Builder, summary *Summary) {
+	if summary.OutputErrors == 0 && summary.OutputWarnings == 0 {
+		return
+	}
+	var parts []string
+	if summary.OutputErrors > 0 {
+		parts = append(parts, f.failStyle.Render(countNoun(summary.OutputErrors, "error")))
+	}
+	if summary.OutputWarnings > 0 {
+		parts = append(parts, f.skipStyle.Render(countNoun(summary.OutputWarnings, "warning")))
+	}
+	sb.WriteString(f.dimStyle.Render("non-test output: "))
+	sb.WriteString(strings.Join(parts, f.dimStyle.Render(", ")))
+	sb.WriteString("\n\n")
+}
+
+// countNoun returns n and noun, pluralized unless n is 1, e.g. "2 errors".
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
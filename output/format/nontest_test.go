@@ -0,0 +1,60 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestClassifyOutputLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want OutputLevel
+	}{
+		{"go: downloading github.com/stretchr/testify v1.9.0", OutputInfo},
+		{"# example.com/pkg", OutputInfo},
+		{"", OutputInfo},
+		{"./foo.go:12:3: undefined: bar", OutputError},
+		{"foo_test.go:7: fmt.Sprintf format %d has arg s of wrong type string", OutputError},
+		{"FAIL\texample.com/pkg [build failed]", OutputError},
+		{"go: cannot find main module, but found .git/config", OutputError},
+		{"go: warning: \"./...\" matched no packages", OutputWarning},
+		{"ld: warning: ignoring duplicate libraries: '-lproc'", OutputWarning},
+		{"\x1b[33mwarning: GOPATH set to GOROOT has no effect\x1b[0m", OutputWarning},
+		{"go: module example.com/old is deprecated: use example.com/new", OutputWarning},
+	}
+	for _, tt := range tests {
+		if got := ClassifyOutputLine(tt.line); got != tt.want {
+			t.Errorf("ClassifyOutputLine(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryNonTestCounts(t *testing.T) {
+	run := results.NewRun(1)
+	run.NonTestOutput = []string{
+		"go: downloading example.com/dep v1.0.0",
+		"go: warning: ignoring symlink /src/link",
+		"# example.com/pkg",
+		"./foo.go:12:3: undefined: bar",
+		"./foo.go:13:3: undefined: baz",
+	}
+	summary := ComputeSummary(run)
+	if summary.OutputErrors != 2 || summary.OutputWarnings != 1 {
+		t.Fatalf("OutputErrors, OutputWarnings = %d, %d, want 2, 1", summary.OutputErrors, summary.OutputWarnings)
+	}
+	if !summary.HasTestDetails() {
+		t.Error("HasTestDetails() = false, want true with non-test errors")
+	}
+
+	output := NewSummaryFormatter(80, true).Format(summary)
+	if !strings.HasPrefix(output, "non-test output: 2 errors, 1 warning\n\n") {
+		t.Errorf("summary doesn't start with the non-test output counts:\n%s", output)
+	}
+
+	run.NonTestOutput = []string{"go: downloading example.com/dep v1.0.0"}
+	if output := NewSummaryFormatter(80, true).Format(ComputeSummary(run)); strings.Contains(output, "non-test output") {
+		t.Errorf("summary counts informational non-test output:\n%s", output)
+	}
+}
@@ -0,0 +1,423 @@
+package format
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// DefaultSlowThreshold is the slow test threshold NewSummaryOptions starts
+// from.
+const DefaultSlowThreshold = 10 * time.Second
+
+// DefaultStallThreshold is the default of -stall-threshold: how long a
+// running test goes without output before it's flagged as stalled.
+const DefaultStallThreshold = 2 * time.Minute
+
+// SummaryOptions controls which optional detail sections appear in the
+// formatted summary output. Failures and build failures are always shown.
+//
+// SummaryOptions also configures ComputeSummary, so consumers (the TUI and
+// SimpleOutput) should build one from shared config with NewSummaryOptions
+// and pass it everywhere, keeping their behavior in sync.
+type SummaryOptions struct {
+	// SlowThreshold is the duration at or above which a test is considered
+	// slow. Zero disables slow test detection.
+	SlowThreshold time.Duration
+
+	// StallThreshold is how long a running test goes without output before
+	// it's flagged as stalled, usually deadlocked: with a badge in the TUI,
+	// and listed under STALLED if the run is interrupted. Zero disables it.
+	StallThreshold time.Duration
+
+	// PackageSlowThresholds overrides SlowThreshold for matching packages;
+	// the first match wins. See SlowThresholdFor.
+	PackageSlowThresholds []PackageThreshold
+
+	IncludeSkipped bool // Show individual skipped test details
+	IncludeSlow    bool // Show individual slow test details
+
+	// IncludeParallelism shows the PARALLELISM section: peak and average
+	// concurrently running tests per package, with a utilization sparkline.
+	IncludeParallelism bool
+
+	// Timeline shows the TIMELINE section: a Gantt chart of when each
+	// package started and finished relative to the start of the run.
+	Timeline bool
+
+	// EmptyTestThreshold, when positive, enables the POSSIBLY EMPTY TESTS
+	// section, which lists passing tests faster than the threshold that
+	// wrote no output.
+	EmptyTestThreshold time.Duration
+
+	// ShowOutput, when set, enables the PASSED OUTPUT section, which shows
+	// the full output of passing tests whose name matches.
+	ShowOutput *regexp.Regexp
+
+	// LogDir, when set, is the -extract-logs directory; each failure in
+	// the summary lists the path of its extracted log file.
+	LogDir string
+
+	// ReproStore, when set, is the history store flag `tang repro` reads
+	// the run from, e.g. "-history runs.jsonl", shown in the
+	// REPRODUCIBILITY block.
+	ReproStore string
+
+	// MaxDetailsBytes, when positive, caps the size of a report's test
+	// details, e.g. the output of its failures, so it fits places with
+	// size limits, like pull request comments. The details past it are
+	// dropped, with a trailer saying how much was, and where the full
+	// output is: LogDir, or else FullOutput, e.g. the -jsonfile.
+	MaxDetailsBytes int
+	FullOutput      string
+
+	// KeepParentFailures lists and counts parent tests which failed only
+	// because subtests did, as go test reports them. By default they're
+	// collapsed into their failed subtests; see Summary.ParentFailures.
+	KeepParentFailures bool
+
+	// ArtifactDir, when set, is the -artifacts directory; each failure in
+	// the summary lists its artifacts where they were copied to, instead
+	// of where the test attached them.
+	ArtifactDir string
+
+	// SourceContext, when positive, is how many lines of source either side
+	// of each file:line reference in a failure's output are shown beneath
+	// it. SourceDir returns the directory of a package's source files,
+	// which relative file names are resolved in.
+	SourceContext int
+	SourceDir     func(pkg string) string
+
+	// RepoLinks, when set, links file:line references in failure output
+	// to the commit on a code hosting site: with OSC 8 hyperlinks in the
+	// summary, and full URLs in Markdown. Relative file names are resolved
+	// with SourceDir.
+	RepoLinks RepoLinks
+
+	// FullGoroutineDumps shows goroutine dumps in failure output, e.g. from
+	// a panic or a test timeout, in full. By default they're condensed to
+	// a count of goroutines by state and the culprit goroutine's stack.
+	FullGoroutineDumps bool
+
+	// RawTestify shows testify assertion failures in failure output as
+	// testify prints them. By default they're compacted to a line with the
+	// error and its message, the rest of the error, and a trimmed trace.
+	RawTestify bool
+
+	// PackageNames shortens package names in the TUI package list and the
+	// summary's package table.
+	PackageNames PackageNameOptions
+
+	// Modules are the module paths of a go.work workspace. With more than
+	// one, the package table and the TUI's package list are grouped by
+	// module, and the table gets a subtotal row per module.
+	Modules []string
+
+	// RollupDepth, when positive, rolls the package table up by directory,
+	// as grouped by GroupByDirectory at this depth: each directory gets one
+	// row totalling its packages, and only the packages of directories with
+	// failures are listed.
+	RollupDepth int
+
+	// Template, when set, renders the report instead of the built-in
+	// format. See TemplateData for the data model.
+	Template *template.Template
+
+	// FailureRules classify failures into categories, which tag each
+	// failure and are counted below the totals. Nil means
+	// DefaultFailureRules; an empty slice disables rule-based
+	// classification.
+	FailureRules []FailureRule
+
+	// Baseline, when set, enables the DURATION REGRESSIONS section, which
+	// lists tests that got slower than in the baseline by more than
+	// Regression.
+	Baseline   *SummaryJSON
+	Regression RegressionThresholds
+
+	// SetupMinimum, when positive, enables the SLOW SETUP section, which
+	// lists packages that spent at least SetupMinimum, and most of their
+	// runtime, in setup and teardown outside their tests.
+	SetupMinimum time.Duration
+
+	// PreviousRun, when set, enables the SUITE CHANGES section, which lists
+	// packages whose test count changed since PreviousRun by at least
+	// SuiteChangePercent.
+	PreviousRun        *SummaryJSON
+	SuiteChangePercent float64
+
+	// Theme colors the summary and the TUI. The zero Theme means
+	// DefaultTheme.
+	Theme Theme
+
+	// DurationStyle formats durations in the summary, the reports and the
+	// TUI, so they're consistent. The zero DurationStyle keeps each one's
+	// own style.
+	DurationStyle DurationStyle
+
+	// PackageOrder is the order of the packages in the PACKAGES section
+	// and the Markdown summary's table.
+	PackageOrder PackageOrder
+
+	// Symbols mark test results and states in the summary and the TUI.
+	// The zero SymbolSet is SymbolsUnicode.
+	Symbols SymbolSet
+}
+
+// SummaryOption configures SummaryOptions.
+type SummaryOption func(*SummaryOptions)
+
+// NewSummaryOptions returns SummaryOptions with defaults applied, then
+// modified by opts in order.
+func NewSummaryOptions(opts ...SummaryOption) SummaryOptions {
+	o := SummaryOptions{SlowThreshold: DefaultSlowThreshold}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.FailureRules == nil {
+		o.FailureRules = DefaultFailureRules
+	}
+	o.Theme = o.Theme.orDefault()
+	return o
+}
+
+// WithOptions replaces all options with o. Options after it still apply.
+func WithOptions(o SummaryOptions) SummaryOption {
+	return func(opts *SummaryOptions) { *opts = o }
+}
+
+// WithSlowThreshold sets the duration at or above which a test is slow, or
+// with zero, disables slow test detection.
+func WithSlowThreshold(d time.Duration) SummaryOption {
+	return func(opts *SummaryOptions) { opts.SlowThreshold = d }
+}
+
+// WithStallThreshold sets how long a running test goes without output
+// before it's flagged as stalled.
+func WithStallThreshold(d time.Duration) SummaryOption {
+	return func(opts *SummaryOptions) { opts.StallThreshold = d }
+}
+
+// WithSkipped toggles details of individual skipped tests.
+func WithSkipped(include bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.IncludeSkipped = include }
+}
+
+// WithSlow toggles details of individual slow tests.
+func WithSlow(include bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.IncludeSlow = include }
+}
+
+// WithParallelism toggles the PARALLELISM section.
+func WithParallelism(include bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.IncludeParallelism = include }
+}
+
+// WithTimeline toggles the TIMELINE section.
+func WithTimeline(include bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.Timeline = include }
+}
+
+// WithEmptyTestThreshold enables the POSSIBLY EMPTY TESTS section for
+// tests faster than d; zero disables it.
+func WithEmptyTestThreshold(d time.Duration) SummaryOption {
+	return func(opts *SummaryOptions) { opts.EmptyTestThreshold = d }
+}
+
+// WithShowOutput shows the output of passing tests whose name matches re;
+// nil disables it.
+func WithShowOutput(re *regexp.Regexp) SummaryOption {
+	return func(opts *SummaryOptions) { opts.ShowOutput = re }
+}
+
+// WithReproStore shows the `tang repro` command rerunning the run from the
+// history store named by store, e.g. "-history runs.jsonl".
+func WithReproStore(store string) SummaryOption {
+	return func(opts *SummaryOptions) { opts.ReproStore = store }
+}
+
+// WithLogDir lists each failure's extracted log file in dir.
+func WithLogDir(dir string) SummaryOption {
+	return func(opts *SummaryOptions) { opts.LogDir = dir }
+}
+
+// WithMaxDetailsBytes caps the size of a report's test details at n
+// bytes, pointing to fullOutput for the rest when there's no LogDir.
+func WithMaxDetailsBytes(n int, fullOutput string) SummaryOption {
+	return func(opts *SummaryOptions) {
+		opts.MaxDetailsBytes = n
+		opts.FullOutput = fullOutput
+	}
+}
+
+// WithKeepParentFailures lists and counts parent tests which failed only
+// because subtests did, rather than collapsing them into the subtests.
+func WithKeepParentFailures(keep bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.KeepParentFailures = keep }
+}
+
+// WithArtifactDir lists each failure's artifacts as copied to dir.
+func WithArtifactDir(dir string) SummaryOption {
+	return func(opts *SummaryOptions) { opts.ArtifactDir = dir }
+}
+
+// WithSourceContext shows n lines of source around each file:line reference
+// in a failure's output, resolving relative file names with dir.
+func WithSourceContext(n int, dir func(pkg string) string) SummaryOption {
+	return func(opts *SummaryOptions) {
+		opts.SourceContext = n
+		opts.SourceDir = dir
+	}
+}
+
+// WithRepoLinks links file:line references in failure output with links.
+func WithRepoLinks(links RepoLinks) SummaryOption {
+	return func(opts *SummaryOptions) { opts.RepoLinks = links }
+}
+
+// WithDurationStyle formats durations everywhere in style.
+func WithDurationStyle(style DurationStyle) SummaryOption {
+	return func(opts *SummaryOptions) { opts.DurationStyle = style }
+}
+
+// WithPackageOrder lists the summary's packages in order.
+func WithPackageOrder(order PackageOrder) SummaryOption {
+	return func(opts *SummaryOptions) { opts.PackageOrder = order }
+}
+
+// WithSymbols sets the symbols marking test results and states.
+func WithSymbols(symbols SymbolSet) SummaryOption {
+	return func(opts *SummaryOptions) { opts.Symbols = symbols }
+}
+
+// WithFullGoroutineDumps shows goroutine dumps in full instead of condensed.
+func WithFullGoroutineDumps(full bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.FullGoroutineDumps = full }
+}
+
+// WithRawTestify shows testify assertion failures as testify prints them
+// instead of compacted.
+func WithRawTestify(raw bool) SummaryOption {
+	return func(opts *SummaryOptions) { opts.RawTestify = raw }
+}
+
+// WithPackageNames shortens displayed package names.
+func WithPackageNames(names PackageNameOptions) SummaryOption {
+	return func(opts *SummaryOptions) { opts.PackageNames = names }
+}
+
+// WithRollup rolls the package table up by directory, depth levels beneath
+// each module.
+func WithRollup(depth int) SummaryOption {
+	return func(opts *SummaryOptions) { opts.RollupDepth = depth }
+}
+
+// WithModules groups packages by the given workspace modules.
+func WithModules(modules []string) SummaryOption {
+	return func(opts *SummaryOptions) { opts.Modules = modules }
+}
+
+// WithTemplate renders the report with tmpl instead of the built-in
+// format; nil restores the built-in format.
+func WithTemplate(tmpl *template.Template) SummaryOption {
+	return func(opts *SummaryOptions) { opts.Template = tmpl }
+}
+
+// WithFailureRules classifies failures with rules; nil restores
+// DefaultFailureRules.
+func WithFailureRules(rules []FailureRule) SummaryOption {
+	return func(opts *SummaryOptions) { opts.FailureRules = rules }
+}
+
+// WithTheme colors the output with t.
+func WithTheme(t Theme) SummaryOption {
+	return func(opts *SummaryOptions) { opts.Theme = t }
+}
+
+// WithBaseline enables the DURATION REGRESSIONS section, comparing against
+// baseline with the given thresholds. A nil baseline disables it.
+func WithBaseline(baseline *SummaryJSON, th RegressionThresholds) SummaryOption {
+	return func(opts *SummaryOptions) {
+		opts.Baseline = baseline
+		opts.Regression = th
+	}
+}
+
+// WithPreviousRun enables the SUITE CHANGES section, comparing package test
+// counts against previous. A nil previous run or zero percent disables it.
+func WithPreviousRun(previous *SummaryJSON, percent float64) SummaryOption {
+	return func(opts *SummaryOptions) {
+		opts.PreviousRun = previous
+		opts.SuiteChangePercent = percent
+	}
+}
+
+// WithSetupMinimum enables the SLOW SETUP section for packages spending at
+// least d outside their tests. Zero disables it.
+func WithSetupMinimum(d time.Duration) SummaryOption {
+	return func(opts *SummaryOptions) { opts.SetupMinimum = d }
+}
+
+// WithPackageSlowThresholds overrides the slow threshold for packages
+// matching each PackageThreshold's pattern.
+func WithPackageSlowThresholds(th []PackageThreshold) SummaryOption {
+	return func(opts *SummaryOptions) { opts.PackageSlowThresholds = th }
+}
+
+// PackageThreshold overrides the slow threshold for packages whose import
+// path matches Pattern.
+//
+// Pattern is matched with results.MatchPackage: a path.Match glob (e.g.
+// "*/integration"), or a package path ending in "/..." to match that
+// package and everything beneath it, like go's package patterns.
+type PackageThreshold struct {
+	Pattern   string
+	Threshold time.Duration
+}
+
+// Match reports whether the package import path matches the pattern.
+func (p PackageThreshold) Match(pkg string) bool {
+	return results.MatchPackage(p.Pattern, pkg)
+}
+
+// ParsePackageThresholds parses a comma-separated list of pattern=duration
+// pairs, e.g. "example.com/app/integration/...=5m,*/unit=1s".
+func ParsePackageThresholds(s string) ([]PackageThreshold, error) {
+	var thresholds []PackageThreshold
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid package threshold %q: want pattern=duration", entry)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid package pattern %q: %w", pattern, err)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package threshold %q: %w", entry, err)
+		}
+		thresholds = append(thresholds, PackageThreshold{Pattern: pattern, Threshold: d})
+	}
+	return thresholds, nil
+}
+
+// SlowThresholdFor returns the slow threshold for tests in the package: the
+// first matching PackageSlowThresholds entry, else SlowThreshold. Zero means
+// its tests are never slow.
+func (o SummaryOptions) SlowThresholdFor(pkg string) time.Duration {
+	for _, th := range o.PackageSlowThresholds {
+		if th.Match(pkg) {
+			return th.Threshold
+		}
+	}
+	return o.SlowThreshold
+}
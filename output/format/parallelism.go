@@ -0,0 +1,93 @@
+package format
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// sparkBlocks are the glyphs used for sparklines, lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// SparkBlock returns the sparkline glyph for level, from 0 (lowest) to 1.
+func SparkBlock(level float64) rune {
+	idx := int(level*float64(len(sparkBlocks)-1) + 0.5)
+	return sparkBlocks[max(0, min(idx, len(sparkBlocks)-1))]
+}
+
+// ParallelismBuckets is the number of time buckets (and sparkline glyphs)
+// used when summarizing a package's parallelism.
+const ParallelismBuckets = 20
+
+// Parallelism summarizes how many tests of a package ran concurrently.
+type Parallelism struct {
+	Package   *results.PackageResult
+	Peak      int     // Most tests running at once
+	Average   float64 // Time-weighted mean of running tests
+	Sparkline string  // Utilization over time, relative to Peak
+}
+
+// ComputeParallelism derives parallelism statistics from the package's
+// RunningSamples. Returns nil when there is not enough timing data, e.g.
+// events without timestamps or a package with no tests.
+func ComputeParallelism(pkg *results.PackageResult, buckets int) *Parallelism {
+	samples := pkg.RunningSamples
+	if len(samples) < 2 || buckets <= 0 {
+		return nil
+	}
+	start := samples[0].Time
+	end := samples[len(samples)-1].Time
+	span := end.Sub(start)
+	if span <= 0 {
+		return nil
+	}
+
+	p := &Parallelism{Package: pkg}
+	var integral float64 // running tests × seconds
+	bucketSum := make([]float64, buckets)
+	bucketWidth := span / time.Duration(buckets)
+	if bucketWidth <= 0 {
+		bucketWidth = 1
+	}
+
+	for i := 0; i < len(samples)-1; i++ {
+		s := samples[i]
+		if s.Running > p.Peak {
+			p.Peak = s.Running
+		}
+		from, to := s.Time, samples[i+1].Time
+		integral += float64(s.Running) * to.Sub(from).Seconds()
+
+		// Spread this interval over the buckets it overlaps.
+		for from.Before(to) {
+			b := int(from.Sub(start) / bucketWidth)
+			if b >= buckets {
+				b = buckets - 1
+			}
+			bucketEnd := start.Add(time.Duration(b+1) * bucketWidth)
+			if b == buckets-1 || bucketEnd.After(to) {
+				bucketEnd = to
+			}
+			bucketSum[b] += float64(s.Running) * bucketEnd.Sub(from).Seconds()
+			from = bucketEnd
+		}
+	}
+	p.Average = integral / span.Seconds()
+
+	var sb strings.Builder
+	for b := 0; b < buckets; b++ {
+		width := bucketWidth.Seconds()
+		if b == buckets-1 {
+			width = span.Seconds() - float64(buckets-1)*bucketWidth.Seconds()
+		}
+		level := 0.0
+		if width > 0 && p.Peak > 0 {
+			level = bucketSum[b] / width / float64(p.Peak)
+		}
+		sb.WriteRune(SparkBlock(level))
+	}
+	p.Sparkline = sb.String()
+
+	return p
+}
@@ -0,0 +1,66 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestComputeParallelism(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := &results.PackageResult{
+		Name: "pkg1",
+		RunningSamples: []results.RunningSample{
+			{Time: start, Running: 1},
+			{Time: start.Add(2 * time.Second), Running: 4},
+			{Time: start.Add(4 * time.Second), Running: 0},
+		},
+	}
+
+	p := ComputeParallelism(pkg, 4)
+	if p == nil {
+		t.Fatal("Expected parallelism stats")
+	}
+	if p.Peak != 4 {
+		t.Errorf("Expected peak 4, got %d", p.Peak)
+	}
+	// (1×2s + 4×2s) / 4s
+	if p.Average != 2.5 {
+		t.Errorf("Expected average 2.5, got %v", p.Average)
+	}
+	if p.Sparkline != "▃▃██" {
+		t.Errorf("Expected sparkline ▃▃██, got %s", p.Sparkline)
+	}
+
+	if ComputeParallelism(&results.PackageResult{Name: "empty"}, 4) != nil {
+		t.Error("Expected nil stats without samples")
+	}
+}
+
+func TestSummaryFormatterParallelism(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{
+		Name:   "pkg1",
+		Status: results.StatusPassed,
+		RunningSamples: []results.RunningSample{
+			{Time: start, Running: 2},
+			{Time: start.Add(time.Second), Running: 0},
+		},
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+	summary := ComputeSummary(run)
+
+	out := NewSummaryFormatter(80, true, SummaryOptions{IncludeParallelism: true}).Format(summary)
+	if !strings.Contains(out, "PARALLELISM\n    pkg1  peak   2  avg   2.0  ") {
+		t.Errorf("Expected parallelism section, got:\n%s", out)
+	}
+
+	out = NewSummaryFormatter(80, true).Format(summary)
+	if strings.Contains(out, "PARALLELISM") {
+		t.Errorf("Expected no parallelism section by default, got:\n%s", out)
+	}
+}
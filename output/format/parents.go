@@ -0,0 +1,108 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// collapseParentFailures drops from the summary's failures those of parent
+// tests which failed only because subtests did: go test fails a parent
+// when a subtest fails, so a failed subtest is otherwise listed, and
+// counted, twice. A parent failed only by its subtests wrote no output of
+// its own, and has a failed subtest in the same iteration. Its failed
+// subtests are labeled with it instead, e.g. "TestParent → TestParent/Case3",
+// and it's left out of FailedTests; see SummaryOptions.KeepParentFailures.
+func collapseParentFailures(summary *Summary) {
+	type testKey struct {
+		pkg, name string
+		iteration int
+	}
+	failed := make(map[testKey]*TestExecutionEntry, len(summary.Failures))
+	for _, entry := range summary.Failures {
+		failed[testKey{entry.TestResult.Package, entry.TestResult.Name, entry.Iteration}] = entry
+	}
+	parentOf := func(key testKey) (testKey, bool) {
+		i := strings.LastIndexByte(key.name, '/')
+		if i < 0 {
+			return testKey{}, false
+		}
+		key.name = key.name[:i]
+		return key, true
+	}
+
+	// A parent with a failed subtest has a failed child, since the
+	// subtest's own ancestors fail with it.
+	collapsed := make(map[testKey]bool)
+	for key := range failed {
+		parent, ok := parentOf(key)
+		if !ok {
+			continue
+		}
+		if entry := failed[parent]; entry != nil && !hasOwnOutput(entry.TestExecution) {
+			collapsed[parent] = true
+		}
+	}
+	if len(collapsed) == 0 {
+		return
+	}
+
+	kept := summary.Failures[:0]
+	for _, entry := range summary.Failures {
+		key := testKey{entry.TestResult.Package, entry.TestResult.Name, entry.Iteration}
+		if collapsed[key] {
+			if summary.ParentFailures == nil {
+				summary.ParentFailures = make(map[string]int)
+			}
+			summary.ParentFailures[key.pkg]++
+			summary.FailedTests--
+			summary.TotalTests--
+			continue
+		}
+		// Label the subtest with its topmost collapsed ancestor.
+		for parent, ok := parentOf(key); ok && collapsed[parent]; parent, ok = parentOf(parent) {
+			entry.CollapsedParent = parent.name
+		}
+		kept = append(kept, entry)
+	}
+	clear(summary.Failures[len(kept):])
+	summary.Failures = kept
+}
+
+// DisplayName returns the name the entry's execution is shown with (see
+// results.ExecutionDisplayName), prefixed with the parent collapsed into
+// it, if any, e.g. "TestParent → TestParent/Case3", with symbols' arrow.
+func (e *TestExecutionEntry) DisplayName(symbols SymbolSet) string {
+	name := results.ExecutionDisplayName(e.TestResult.Name, e.Iteration, e.TotalExecutions)
+	if e.CollapsedParent == "" {
+		return name
+	}
+	return e.CollapsedParent + " " + symbols.Arrow() + " " + name
+}
+
+// hasOwnOutput reports whether a test wrote any output other than blank
+// lines.
+func hasOwnOutput(exec *results.TestExecution) bool {
+	for _, line := range exec.Output {
+		if strings.TrimSpace(line) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageCounts returns the passed, failed and skipped tests of one of the
+// summary's packages, not counting parent failures collapsed into their
+// subtests' (see SummaryOptions.KeepParentFailures).
+func (s *Summary) PackageCounts(pkg *results.PackageResult) (passed, failed, skipped int) {
+	return pkg.Counts.Passed, pkg.Counts.Failed - s.ParentFailures[pkg.Name], pkg.Counts.Skipped
+}
+
+// GroupCounts is like PackageCounts, for the total of a group of packages.
+func (s *Summary) GroupCounts(group ModuleGroup) (passed, failed, skipped int) {
+	passed, failed, skipped = group.Counts()
+	for _, pkg := range group.Packages {
+		failed -= s.ParentFailures[pkg.Name]
+	}
+	return passed, failed, skipped
+}
@@ -0,0 +1,61 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// PassedOutput returns the passing executions, with output, of tests whose
+// name matches re, in package order, then test start order.
+func (s *Summary) PassedOutput(re *regexp.Regexp) []*TestExecutionEntry {
+	if s.Run == nil || re == nil {
+		return nil
+	}
+
+	var entries []*TestExecutionEntry
+	for _, pkg := range s.Packages {
+		for _, testName := range pkg.TestOrder {
+			tr := s.Run.TestResults[pkg.Name+"/"+testName]
+			if tr == nil || !re.MatchString(tr.Name) {
+				continue
+			}
+			for i, exec := range tr.Executions {
+				if exec.Status != results.StatusPassed || len(exec.Output) == 0 {
+					continue
+				}
+				entries = append(entries, &TestExecutionEntry{
+					TestResult:      tr,
+					TestExecution:   exec,
+					Iteration:       i + 1,
+					TotalExecutions: len(tr.Executions),
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// formatPassedOutput renders the PASSED OUTPUT section: the full output of
+// passing tests selected with -show-output, grouped by package.
+func (f *SummaryFormatter) formatPassedOutput(sb *strings.Builder, summary *Summary) {
+	entries := summary.PassedOutput(f.options.ShowOutput)
+	if len(entries) == 0 {
+		return
+	}
+
+	sb.WriteString(f.boldPass.Render("PASSED OUTPUT"))
+	sb.WriteString("\n")
+	pkgName := ""
+	for _, entry := range entries {
+		if entry.TestResult.Package != pkgName {
+			pkgName = entry.TestResult.Package
+			sb.WriteString("=== ")
+			sb.WriteString(pkgName)
+			sb.WriteString("\n")
+		}
+		f.formatTestIssue(sb, entry, "PASS", f.boldPass, f.passStyle)
+	}
+	sb.WriteString("\n")
+}
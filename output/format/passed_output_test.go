@@ -0,0 +1,55 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestSummaryPassedOutput(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, Elapsed: time.Second}
+	for _, tc := range []struct {
+		name   string
+		status results.Status
+		output []string
+	}{
+		{"TestLoad", results.StatusPassed, []string{"    load_test.go:10: loaded in 1.2s"}},
+		{"TestLoadSilent", results.StatusPassed, nil},
+		{"TestLoadFails", results.StatusFailed, []string{"    load_test.go:20: boom"}},
+		{"TestOther", results.StatusPassed, []string{"    other_test.go:5: not shown"}},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = tc.status
+		tr.Latest().Elapsed = 500 * time.Millisecond
+		tr.Latest().Output = tc.output
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+
+	opts := NewSummaryOptions(WithShowOutput(regexp.MustCompile("^TestLoad")))
+	summary := ComputeSummary(run, WithOptions(opts))
+
+	entries := summary.PassedOutput(opts.ShowOutput)
+	if len(entries) != 1 || entries[0].TestResult.Name != "TestLoad" {
+		t.Fatalf("Expected only TestLoad, got %d entries", len(entries))
+	}
+
+	output := NewSummaryFormatter(80, true, opts).Format(summary)
+	expected := "PASSED OUTPUT\n=== pkg1\n    --- PASS: TestLoad (0.50s)\n        load_test.go:10: loaded in 1.2s\n\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected output to contain:\n%s\ngot:\n%s", expected, output)
+	}
+	if strings.Contains(output, "not shown") {
+		t.Errorf("Expected output of unmatched tests to be omitted, got:\n%s", output)
+	}
+
+	if output := NewSummaryFormatter(80, true, NewSummaryOptions()).Format(summary); strings.Contains(output, "PASSED OUTPUT") {
+		t.Errorf("Expected no PASSED OUTPUT section without -show-output, got:\n%s", output)
+	}
+}
@@ -0,0 +1,34 @@
+package format
+
+import "strings"
+
+// PackageNameOptions shortens package import paths for display, so long
+// module paths don't push the counts off-screen. The steps apply in field
+// order; the zero value leaves names unchanged.
+type PackageNameOptions struct {
+	TrimPrefix string // Module prefix to strip, e.g. "github.com/org/repo"
+	Segments   int    // Keep only the last N path segments; 0 keeps all
+	MaxWidth   int    // Truncate longer names on the right, ending with "…"; 0 disables
+}
+
+// Shorten returns the display form of a package import path.
+func (o PackageNameOptions) Shorten(name string) string {
+	if prefix := strings.TrimSuffix(o.TrimPrefix, "/"); prefix != "" {
+		if rest, ok := strings.CutPrefix(name, prefix+"/"); ok {
+			name = rest
+		}
+	}
+
+	if o.Segments > 0 {
+		if parts := strings.Split(name, "/"); len(parts) > o.Segments {
+			name = "…/" + strings.Join(parts[len(parts)-o.Segments:], "/")
+		}
+	}
+
+	if o.MaxWidth > 0 {
+		if runes := []rune(name); len(runes) > o.MaxWidth {
+			name = string(runes[:max(o.MaxWidth-1, 0)]) + "…"
+		}
+	}
+	return name
+}
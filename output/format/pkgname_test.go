@@ -0,0 +1,65 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestPackageNameOptionsShorten(t *testing.T) {
+	const name = "github.com/org/repo/internal/storage/sql"
+	tests := []struct {
+		name     string
+		opts     PackageNameOptions
+		expected string
+	}{
+		{"zero value", PackageNameOptions{}, name},
+		{"trim prefix", PackageNameOptions{TrimPrefix: "github.com/org/repo"}, "internal/storage/sql"},
+		{"trim prefix with slash", PackageNameOptions{TrimPrefix: "github.com/org/repo/"}, "internal/storage/sql"},
+		{"trim prefix not matching", PackageNameOptions{TrimPrefix: "github.com/org/rep"}, name},
+		{"segments", PackageNameOptions{Segments: 2}, "…/storage/sql"},
+		{"segments covering all", PackageNameOptions{Segments: 6}, name},
+		{"max width", PackageNameOptions{MaxWidth: 12}, "github.com/…"},
+		{"combined", PackageNameOptions{TrimPrefix: "github.com/org/repo", Segments: 2, MaxWidth: 8}, "…/stora…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.opts.Shorten(name); result != tt.expected {
+				t.Errorf("Shorten(%q) = %q, want %q", name, result, tt.expected)
+			}
+		})
+	}
+
+	if result := (PackageNameOptions{TrimPrefix: "github.com/org/repo"}).Shorten("github.com/org/repo"); result != "github.com/org/repo" {
+		t.Errorf("Expected the module root package to keep its name, got %q", result)
+	}
+}
+
+func TestSummaryFormatterPackageNames(t *testing.T) {
+	pkg := &results.PackageResult{
+		Name:        "github.com/org/repo/internal/storage",
+		Status:      results.StatusPassed,
+		Elapsed:     time.Second,
+		SummaryLine: "ok  \tgithub.com/org/repo/internal/storage\t1.000s",
+	}
+	pkg.Counts.Passed = 1
+	summary := &Summary{
+		Packages:     []*results.PackageResult{pkg},
+		TotalTests:   1,
+		PassedTests:  1,
+		TotalTime:    time.Second,
+		PackageCount: 1,
+	}
+
+	opts := SummaryOptions{PackageNames: PackageNameOptions{TrimPrefix: "github.com/org/repo"}}
+	output := NewSummaryFormatter(80, true, opts).Format(summary)
+	if !strings.HasPrefix(output, "ok    internal/storage 1.000s  (") {
+		t.Errorf("Expected shortened package name in the package table, got:\n%s", output)
+	}
+	if strings.Contains(output, "github.com/org/repo") {
+		t.Errorf("Expected the module prefix to be stripped, got:\n%s", output)
+	}
+}
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// PackageOrder selects the order of the packages in the summary's PACKAGES
+// section and the Markdown summary's table. The zero PackageOrder is
+// PackageOrderPriority.
+type PackageOrder string
+
+const (
+	PackageOrderPriority      PackageOrder = ""              // Failed packages first, then by duration, slowest first
+	PackageOrderChronological PackageOrder = "chronological" // The order the packages started in
+	PackageOrderAlphabetical  PackageOrder = "alphabetical"  // By import path
+	PackageOrderDuration      PackageOrder = "duration"      // Slowest first, whatever their status
+)
+
+// ParsePackageOrder parses an order name: priority, chronological,
+// alphabetical or duration. An empty name is PackageOrderPriority.
+func ParsePackageOrder(name string) (PackageOrder, error) {
+	switch order := PackageOrder(name); order {
+	case "priority":
+		return PackageOrderPriority, nil
+	case PackageOrderPriority, PackageOrderChronological, PackageOrderAlphabetical, PackageOrderDuration:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown package order %q (want priority, chronological, alphabetical or duration)", name)
+}
+
+// OrderedPackages returns the summary's packages in order. Packages which
+// tie keep the order they started in.
+func (s *Summary) OrderedPackages(order PackageOrder) []*results.PackageResult {
+	if order == PackageOrderChronological {
+		return s.Packages
+	}
+	pkgs := slices.Clone(s.Packages)
+	slices.SortStableFunc(pkgs, func(a, b *results.PackageResult) int {
+		switch order {
+		case PackageOrderAlphabetical:
+			return strings.Compare(a.Name, b.Name)
+		case PackageOrderPriority:
+			if c := packageRank(a) - packageRank(b); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(b.Elapsed, a.Elapsed)
+	})
+	return pkgs
+}
+
+// packageRank ranks a package by status for PackageOrderPriority: failed,
+// then left running by an interrupted run, then passed, then without
+// tests.
+func packageRank(pkg *results.PackageResult) int {
+	switch {
+	case pkg.FailedBuild != "", pkg.Status == results.StatusFailed:
+		return 0
+	case pkg.Status == results.StatusRunning, pkg.Status == results.StatusInterrupted:
+		return 1
+	case pkg.Status == results.StatusSkipped:
+		return 3
+	}
+	return 2
+}
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestOrderedPackages(t *testing.T) {
+	run := results.NewRun(1)
+	for _, p := range []struct {
+		name    string
+		status  results.Status
+		elapsed time.Duration
+	}{
+		{"example.com/c", results.StatusPassed, 1 * time.Second},
+		{"example.com/a", results.StatusFailed, 2 * time.Second},
+		{"example.com/e", results.StatusSkipped, 0},
+		{"example.com/b", results.StatusPassed, 5 * time.Second},
+		{"example.com/d", results.StatusFailed, 3 * time.Second},
+	} {
+		run.Packages[p.name] = &results.PackageResult{Name: p.name, Status: p.status, Elapsed: p.elapsed}
+		run.PackageOrder = append(run.PackageOrder, p.name)
+	}
+	summary := ComputeSummary(run)
+
+	tests := []struct {
+		order PackageOrder
+		want  string
+	}{
+		{PackageOrderPriority, "d a b c e"},
+		{PackageOrderChronological, "c a e b d"},
+		{PackageOrderAlphabetical, "a b c d e"},
+		{PackageOrderDuration, "b d a c e"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, pkg := range summary.OrderedPackages(tt.order) {
+			names = append(names, strings.TrimPrefix(pkg.Name, "example.com/"))
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("OrderedPackages(%q) = %s, want %s", tt.order, got, tt.want)
+		}
+	}
+
+	// The formatter lists failed packages first by default.
+	output := NewSummaryFormatter(80, true, NewSummaryOptions()).Format(summary)
+	if d, c := strings.Index(output, "FAIL    example.com/d"), strings.Index(output, "ok      example.com/c"); d < 0 || c < 0 || d > c {
+		t.Errorf("Expected the failed packages before the passed ones in:\n%s", output)
+	}
+}
+
+func TestParsePackageOrder(t *testing.T) {
+	for name, want := range map[string]PackageOrder{
+		"":              PackageOrderPriority,
+		"priority":      PackageOrderPriority,
+		"chronological": PackageOrderChronological,
+		"alphabetical":  PackageOrderAlphabetical,
+		"duration":      PackageOrderDuration,
+	} {
+		if got, err := ParsePackageOrder(name); err != nil || got != want {
+			t.Errorf("ParsePackageOrder(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParsePackageOrder("random"); err == nil {
+		t.Error("Expected an error for an unknown order")
+	}
+}
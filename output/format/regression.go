@@ -0,0 +1,129 @@
+package format
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// RegressionThresholds controls when a test's duration increase over the
+// baseline is reported as a regression. A test regresses when its increase
+// exceeds either threshold, or with All, every threshold; a zero threshold is
+// disabled.
+type RegressionThresholds struct {
+	Percent  float64       // Relative increase, e.g. 50 for +50%
+	Absolute time.Duration // Absolute increase, e.g. 2s
+	All      bool          // Require every enabled threshold, not just one
+}
+
+// exceeded reports whether a change in duration from baseline exceeds the
+// thresholds.
+func (th RegressionThresholds) exceeded(baseline, change time.Duration) bool {
+	abs := th.Absolute > 0 && change > th.Absolute
+	pct := th.Percent > 0 && baseline > 0 && float64(change)/float64(baseline)*100 > th.Percent
+	if th.All {
+		return (abs || th.Absolute <= 0) && (pct || th.Percent <= 0)
+	}
+	return abs || pct
+}
+
+// DurationRegression describes a test that got slower than its baseline.
+type DurationRegression struct {
+	Package  string
+	Name     string
+	Baseline time.Duration
+	Current  time.Duration
+}
+
+// Increase returns how much slower the test got.
+func (r *DurationRegression) Increase() time.Duration {
+	return r.Current - r.Baseline
+}
+
+// Percent returns the relative increase as a percentage, or 0 when the
+// baseline duration was zero.
+func (r *DurationRegression) Percent() float64 {
+	if r.Baseline <= 0 {
+		return 0
+	}
+	return float64(r.Increase()) / float64(r.Baseline) * 100
+}
+
+// Regressions compares the summary's test durations against opts.Baseline
+// and returns the tests which exceeded opts.Regression, sorted by largest
+// increase first. Returns nil when no baseline is configured.
+//
+// When a test ran more than once (-count=N), the mean duration of its
+// non-skipped executions is compared on both sides. Tests missing from
+// either side are ignored.
+func (s *Summary) Regressions(opts SummaryOptions) []*DurationRegression {
+	if opts.Baseline == nil || s.Run == nil {
+		return nil
+	}
+	th := opts.Regression
+	if th.Percent <= 0 && th.Absolute <= 0 {
+		return nil
+	}
+
+	type acc struct {
+		total time.Duration
+		n     int
+	}
+	baseline := make(map[string]*acc)
+	for _, tr := range opts.Baseline.Results {
+		if tr.Status == results.StatusSkipped.String() {
+			continue
+		}
+		key := tr.Package + "/" + tr.Name
+		a := baseline[key]
+		if a == nil {
+			a = &acc{}
+			baseline[key] = a
+		}
+		a.total += time.Duration(tr.Elapsed * float64(time.Second))
+		a.n++
+	}
+
+	var regressions []*DurationRegression
+	for key, tr := range s.Run.TestResults {
+		base, ok := baseline[key]
+		if !ok {
+			continue
+		}
+		var cur acc
+		for _, exec := range tr.Executions {
+			if exec.Status == results.StatusPassed || exec.Status == results.StatusFailed {
+				cur.total += exec.Elapsed
+				cur.n++
+			}
+		}
+		if cur.n == 0 {
+			continue
+		}
+
+		r := &DurationRegression{
+			Package:  tr.Package,
+			Name:     tr.Name,
+			Baseline: base.total / time.Duration(base.n),
+			Current:  cur.total / time.Duration(cur.n),
+		}
+		if r.Increase() <= 0 {
+			continue
+		}
+		if th.exceeded(r.Baseline, r.Increase()) {
+			regressions = append(regressions, r)
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Increase() != regressions[j].Increase() {
+			return regressions[i].Increase() > regressions[j].Increase()
+		}
+		if regressions[i].Package != regressions[j].Package {
+			return regressions[i].Package < regressions[j].Package
+		}
+		return regressions[i].Name < regressions[j].Name
+	})
+	return regressions
+}
@@ -0,0 +1,123 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func regressionRun(elapsed map[string]time.Duration) *results.Run {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusPassed}
+	for _, name := range []string{"TestA", "TestB", "TestC"} {
+		d, ok := elapsed[name]
+		if !ok {
+			continue
+		}
+		tr := results.NewTestResult("pkg1", name)
+		tr.Latest().Status = results.StatusPassed
+		tr.Latest().Elapsed = d
+		run.TestResults["pkg1/"+name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, name)
+		pkg.Counts.Passed++
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+	return run
+}
+
+func TestSummaryJSONRoundTrip(t *testing.T) {
+	run := regressionRun(map[string]time.Duration{"TestA": time.Second, "TestB": 2 * time.Second})
+	run.Status = results.StatusPassed
+
+	var buf bytes.Buffer
+	if err := WriteSummaryJSON(&buf, ComputeSummary(run)); err != nil {
+		t.Fatalf("WriteSummaryJSON: %v", err)
+	}
+	sj, err := ReadSummaryJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadSummaryJSON: %v", err)
+	}
+
+	if sj.Status != "passed" || sj.Passed != 2 || sj.Tests != 2 {
+		t.Errorf("unexpected totals: %+v", sj)
+	}
+	if len(sj.Packages) != 1 || sj.Packages[0].Name != "pkg1" {
+		t.Fatalf("unexpected packages: %+v", sj.Packages)
+	}
+	if len(sj.Results) != 2 || sj.Results[1].Name != "TestB" || sj.Results[1].Elapsed != 2 {
+		t.Fatalf("unexpected results: %+v", sj.Results)
+	}
+}
+
+func TestSummaryRegressions(t *testing.T) {
+	baseline := NewSummaryJSON(ComputeSummary(regressionRun(map[string]time.Duration{
+		"TestA": 1 * time.Second,
+		"TestB": 10 * time.Second,
+		"TestC": 1 * time.Second,
+	})))
+
+	summary := ComputeSummary(regressionRun(map[string]time.Duration{
+		"TestA": 1200 * time.Millisecond, // +20%, +0.2s
+		"TestB": 13 * time.Second,        // +30%, +3s
+		"TestC": 2 * time.Second,         // +100%, +1s
+	}))
+
+	tests := []struct {
+		name string
+		th   RegressionThresholds
+		want []string
+	}{
+		{"disabled", RegressionThresholds{}, nil},
+		{"percent", RegressionThresholds{Percent: 50}, []string{"TestC"}},
+		{"absolute", RegressionThresholds{Absolute: 2 * time.Second}, []string{"TestB"}},
+		{"either", RegressionThresholds{Percent: 50, Absolute: 2 * time.Second}, []string{"TestB", "TestC"}},
+		{"all", RegressionThresholds{Percent: 25, Absolute: 2 * time.Second, All: true}, []string{"TestB"}},
+		{"all unmet", RegressionThresholds{Percent: 50, Absolute: 2 * time.Second, All: true}, nil},
+		{"all with one enabled", RegressionThresholds{Absolute: 500 * time.Millisecond, All: true}, []string{"TestB", "TestC"}},
+		{"sorted by increase", RegressionThresholds{Percent: 10}, []string{"TestB", "TestC", "TestA"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := summary.Regressions(SummaryOptions{Baseline: baseline, Regression: tt.th})
+			var got []string
+			for _, r := range regs {
+				got = append(got, r.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Regressions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryFormatterRegressions(t *testing.T) {
+	baseline := NewSummaryJSON(ComputeSummary(regressionRun(map[string]time.Duration{
+		"TestA": 1 * time.Second,
+	})))
+	summary := ComputeSummary(regressionRun(map[string]time.Duration{
+		"TestA": 3 * time.Second,
+	}))
+
+	opts := SummaryOptions{Baseline: baseline, Regression: RegressionThresholds{Percent: 50}}
+	out := NewSummaryFormatter(80, true, opts).Format(summary)
+
+	if !strings.Contains(out, "DURATION REGRESSIONS") {
+		t.Fatalf("expected regressions section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "pkg1 TestA  1s -> 3s (+2s, +200%)") {
+		t.Errorf("expected regression line, got:\n%s", out)
+	}
+	if !summary.HasTestDetailsWithOptions(opts) {
+		t.Error("expected HasTestDetailsWithOptions to report regressions")
+	}
+
+	out = NewSummaryFormatter(80, true).Format(summary)
+	if strings.Contains(out, "DURATION REGRESSIONS") {
+		t.Errorf("expected no regressions section without baseline, got:\n%s", out)
+	}
+}
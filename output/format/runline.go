@@ -0,0 +1,61 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// maxRunLineFailures is how many failed tests FormatRunLine names.
+const maxRunLineFailures = 3
+
+// FormatRunLine formats the summary as a single line, for runs whose full
+// report isn't printed, e.g. the earlier runs of a watch session:
+//
+//	FAIL  run 2  (✓6 ✗3 ∅1) 10  2.12s  TestA, TestB, TestC +2
+//
+// The line names the run by its ID, with its UID when it has one, and the
+// first of its failed tests or packages which failed to build.
+func (f *SummaryFormatter) FormatRunLine(summary *Summary) string {
+	status := f.boldWhite.Render("ok  ")
+	if summary.FailedTests > 0 || len(summary.BuildFailures) > 0 {
+		status = f.boldFail.Render("FAIL")
+	}
+	name := "run"
+	if run := summary.Run; run != nil {
+		name = "run " + strconv.Itoa(run.ID)
+		if run.UID != "" {
+			name += " " + f.dimStyle.Render(run.UID)
+		}
+	}
+	cw := countWidths{
+		passed:  len(strconv.Itoa(summary.PassedTests)),
+		failed:  len(strconv.Itoa(summary.FailedTests)),
+		skipped: len(strconv.Itoa(summary.SkippedTests)),
+		total:   len(strconv.Itoa(summary.TotalTests)),
+	}
+	line := fmt.Sprintf("%s  %s  %s  %s", status, name,
+		f.formatCounts(summary.PassedTests, summary.FailedTests, summary.SkippedTests, cw), f.duration(summary.TotalTime))
+
+	var failed []string
+	for _, pkg := range summary.BuildFailures {
+		failed = append(failed, f.options.PackageNames.Shorten(pkg.Name)+" [build failed]")
+	}
+	seen := make(map[*results.TestResult]bool)
+	for _, entry := range summary.Failures {
+		if !seen[entry.TestResult] {
+			seen[entry.TestResult] = true
+			failed = append(failed, entry.TestResult.Name)
+		}
+	}
+	if len(failed) > 0 {
+		shown := strings.Join(failed[:min(len(failed), maxRunLineFailures)], ", ")
+		if len(failed) > maxRunLineFailures {
+			shown += fmt.Sprintf(" +%d", len(failed)-maxRunLineFailures)
+		}
+		line += "  " + f.failStyle.Render(shown)
+	}
+	return line
+}
@@ -0,0 +1,38 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestFormatRunLine(t *testing.T) {
+	formatter := NewSummaryFormatter(80, true)
+
+	run := results.NewRun(2)
+	summary := &Summary{
+		Run:          run,
+		TotalTests:   10,
+		PassedTests:  10,
+		TotalTime:    2 * time.Second,
+		PackageCount: 1,
+	}
+	if got, want := formatter.FormatRunLine(summary), "ok    run 2  (✓10 ✗0 ∅0) 10  2s"; got != want {
+		t.Errorf("passing run line = %q, want %q", got, want)
+	}
+
+	var failures []*TestExecutionEntry
+	for _, name := range []string{"TestA", "TestB", "TestC", "TestD"} {
+		failures = append(failures, &TestExecutionEntry{TestResult: &results.TestResult{Name: name}})
+	}
+	summary.PassedTests = 5
+	summary.FailedTests = 4
+	summary.SkippedTests = 1
+	summary.Failures = failures
+	summary.BuildFailures = []*results.PackageResult{{Name: "example.com/broken"}}
+	want := "FAIL  run 2  (✓5 ✗4 ∅1) 10  2s  example.com/broken [build failed], TestA, TestB +2"
+	if got := formatter.FormatRunLine(summary); got != want {
+		t.Errorf("failing run line = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package format
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// DefaultSetupMinimum is the least time outside tests, in setup and
+// teardown, for which a package is reported in SLOW SETUP.
+const DefaultSetupMinimum = time.Second
+
+// setupDominantFraction is the share of a package's runtime spent outside
+// tests at which it's reported in SLOW SETUP.
+const setupDominantFraction = 0.5
+
+// SetupOverhead describes a package whose runtime was dominated by setup
+// and teardown outside its tests, e.g. in TestMain.
+type SetupOverhead struct {
+	Package *results.PackageResult
+	Total   time.Duration // From the package starting to it finishing
+}
+
+// Overhead returns the package's setup and teardown time.
+func (o *SetupOverhead) Overhead() time.Duration {
+	return o.Package.SetupTime + o.Package.TeardownTime
+}
+
+// Fraction returns the share of the package's runtime spent in setup and
+// teardown.
+func (o *SetupOverhead) Fraction() float64 {
+	return o.Overhead().Seconds() / o.Total.Seconds()
+}
+
+// SetupOverheads returns the packages that spent at least minimum, and at
+// least half their runtime, in setup and teardown, largest overhead first.
+// Returns nil when minimum is zero.
+func (s *Summary) SetupOverheads(minimum time.Duration) []*SetupOverhead {
+	if minimum <= 0 {
+		return nil
+	}
+	var overheads []*SetupOverhead
+	for _, pkg := range s.Packages {
+		o := &SetupOverhead{Package: pkg, Total: pkg.EndTime.Sub(pkg.StartTime)}
+		if o.Total <= 0 || o.Overhead() < minimum || o.Fraction() < setupDominantFraction {
+			continue
+		}
+		overheads = append(overheads, o)
+	}
+	sort.SliceStable(overheads, func(i, j int) bool {
+		return overheads[i].Overhead() > overheads[j].Overhead()
+	})
+	return overheads
+}
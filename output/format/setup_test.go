@@ -0,0 +1,54 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestSummarySetupOverheads(t *testing.T) {
+	start := time.Now()
+	run := results.NewRun(1)
+	add := func(name string, total, setup, teardown time.Duration) {
+		pkg := &results.PackageResult{
+			Name: name, Status: results.StatusPassed,
+			StartTime: start, EndTime: start.Add(total),
+			SetupTime: setup, TeardownTime: teardown,
+		}
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+	}
+	add("pkg/db", 10*time.Second, 6*time.Second, time.Second)        // 70% outside tests
+	add("pkg/tests", 10*time.Second, 2*time.Second, time.Second)     // Mostly tests
+	add("pkg/quick", 500*time.Millisecond, 400*time.Millisecond, 0)  // Under the minimum
+	add("pkg/docker", 20*time.Second, 15*time.Second, 4*time.Second) // 95% outside tests
+	summary := ComputeSummary(run)
+
+	if got := summary.SetupOverheads(0); got != nil {
+		t.Errorf("Expected no overheads when disabled, got %+v", got)
+	}
+	overheads := summary.SetupOverheads(DefaultSetupMinimum)
+	if len(overheads) != 2 || overheads[0].Package.Name != "pkg/docker" || overheads[1].Package.Name != "pkg/db" {
+		t.Fatalf("Expected pkg/docker and pkg/db, got %+v", overheads)
+	}
+	if f := overheads[1].Fraction(); f != 0.7 {
+		t.Errorf("Expected pkg/db to spend 70%% outside tests, got %v", f)
+	}
+
+	opts := NewSummaryOptions(WithSetupMinimum(DefaultSetupMinimum))
+	if !summary.HasTestDetailsWithOptions(opts) {
+		t.Error("Expected slow setup to count as test details")
+	}
+	out := NewSummaryFormatter(80, true, opts).Format(summary)
+	for _, want := range []string{
+		"SLOW SETUP (time outside tests, e.g. in TestMain)\n",
+		"    pkg/docker  setup 15s, teardown 4s (95% of 20s)\n",
+		"    pkg/db  setup 6s, teardown 1s (70% of 10s)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+}
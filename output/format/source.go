@@ -0,0 +1,174 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sourceRefRE matches the file:line reference t.Error and friends put at
+// the start of a line of test output, e.g. "    foo_test.go:42: ...". With
+// go test -fullpath, the file is an absolute path.
+var sourceRefRE = regexp.MustCompile(`^\s*([^\s:]+\.go):(\d+): `)
+
+// sourceFiles caches the lines of source files read for SourceContext;
+// unreadable files are cached as nil.
+type sourceFiles map[string][]string
+
+// lines returns the lines of the file at path, or nil if it can't be read.
+func (s sourceFiles) lines(path string) []string {
+	if lines, ok := s[path]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	s[path] = lines
+	return lines
+}
+
+// sourceContext returns n lines of source either side of the file:line
+// reference at the start of output, numbered, with the referenced line
+// marked by ">". Relative file names are resolved in dir. It returns nil
+// if output has no reference or the file can't be read.
+func (s sourceFiles) sourceContext(output, dir string, n int) []string {
+	m := sourceRefRE.FindStringSubmatch(output)
+	if m == nil {
+		return nil
+	}
+	file := m[1]
+	if !filepath.IsAbs(file) {
+		if dir == "" {
+			return nil
+		}
+		file = filepath.Join(dir, file)
+	}
+	line, err := strconv.Atoi(m[2])
+	if err != nil {
+		return nil
+	}
+	lines := s.lines(file)
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	first, last := max(line-n, 1), min(line+n, len(lines))
+	width := len(strconv.Itoa(last))
+	context := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		text := strings.TrimRight(expandTabs(strings.TrimRight(lines[i-1], "\r"), 4), " ")
+		context = append(context, strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, i, text), " "))
+	}
+	return context
+}
+
+// RepoLinks links file:line references in test output to a code hosting
+// site, or to the files on this machine. The zero RepoLinks links nothing.
+type RepoLinks struct {
+	// Template is the URL of a line of a file, with {sha}, {path} and
+	// {line} placeholders, e.g.
+	// "https://github.com/org/repo/blob/{sha}/{path}#L{line}".
+	Template string
+	SHA      string // Commit the tests ran at
+	Root     string // Root of the checkout, which {path} is relative to
+
+	// FileTemplate, used without a Template, is the URL of a line of a
+	// local file, with {path}, its absolute path with forward slashes, and
+	// {line} placeholders, e.g. "file://{path}" or
+	// "vscode://file{path}:{line}".
+	FileTemplate string
+}
+
+// enabled reports whether l links anything.
+func (l RepoLinks) enabled() bool {
+	return l.Template != "" || l.FileTemplate != ""
+}
+
+// URL returns the link to line of file, which is relative to dir unless
+// absolute, or "" if the file isn't in the checkout, or for a FileTemplate
+// doesn't exist, e.g. a bare name of a helper's file in another package.
+func (l RepoLinks) URL(dir, file string, line int) string {
+	if !l.enabled() {
+		return ""
+	}
+	if !filepath.IsAbs(file) {
+		if dir == "" {
+			return ""
+		}
+		file = filepath.Join(dir, file)
+	}
+	if l.Template == "" {
+		if _, err := os.Stat(file); err != nil {
+			return ""
+		}
+		// A Windows path, e.g. C:/src/foo_test.go, gets the leading slash
+		// a URL's path needs.
+		path := filepath.ToSlash(file)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		return strings.NewReplacer(
+			"{path}", path,
+			"{line}", strconv.Itoa(line),
+		).Replace(l.FileTemplate)
+	}
+	if l.Root == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(l.Root, file)
+	if err != nil || !filepath.IsLocal(rel) {
+		// The root comes from git with symlinks resolved, e.g. /private/tmp
+		// for /tmp on macOS, so try the file with its symlinks resolved.
+		resolved, rerr := filepath.EvalSymlinks(file)
+		if rerr != nil {
+			return ""
+		}
+		if rel, err = filepath.Rel(l.Root, resolved); err != nil || !filepath.IsLocal(rel) {
+			return ""
+		}
+	}
+	return strings.NewReplacer(
+		"{sha}", l.SHA,
+		"{path}", filepath.ToSlash(rel),
+		"{line}", strconv.Itoa(line),
+	).Replace(l.Template)
+}
+
+// sourceRef returns the file:line reference at the start of output, its
+// URL, and its start and end in output, or "" if there's no reference or it
+// can't be linked.
+func (l RepoLinks) sourceRef(output, dir string) (ref, url string, start, end int) {
+	m := sourceRefRE.FindStringSubmatchIndex(output)
+	if m == nil {
+		return "", "", 0, 0
+	}
+	line, err := strconv.Atoi(output[m[4]:m[5]])
+	if err != nil {
+		return "", "", 0, 0
+	}
+	url = l.URL(dir, output[m[2]:m[3]], line)
+	if url == "" {
+		return "", "", 0, 0
+	}
+	return output[m[2]:m[5]], url, m[2], m[5]
+}
+
+// hyperlink wraps the file:line reference at the start of output in an
+// OSC 8 hyperlink to its URL, for terminals which support them.
+func (l RepoLinks) hyperlink(output, dir string) string {
+	ref, url, start, end := l.sourceRef(output, dir)
+	if url == "" {
+		return output
+	}
+	return output[:start] + ansi.SetHyperlink(url) + ref + ansi.ResetHyperlink() + output[end:]
+}
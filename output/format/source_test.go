@@ -0,0 +1,154 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestFormatSourceContext(t *testing.T) {
+	dir := t.TempDir()
+	src := "package pkg\n\nfunc TestFoo(t *testing.T) {\n\tgot := 1\n\tif got != 2 {\n\t\tt.Errorf(\"got %d\", got)\n\t}\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "foo_test.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, TestOrder: []string{"TestFoo"}}
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	tr := results.NewTestResult(pkg.Name, "TestFoo")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Output = []string{
+		"    foo_test.go:6: got 1",
+		"    foo_test.go:6: got 1",
+		"    " + filepath.Join(dir, "foo_test.go") + ":8: absolute",
+		"    missing_test.go:3: no such file",
+	}
+	run.TestResults["example.com/pkg/TestFoo"] = tr
+
+	opts := NewSummaryOptions(WithSourceContext(1, func(pkg string) string {
+		if pkg != "example.com/pkg" {
+			t.Errorf("Unexpected package %q", pkg)
+		}
+		return dir
+	}))
+	out := NewSummaryFormatter(80, true, opts).Format(ComputeSummary(run, WithOptions(opts)))
+
+	want := "" +
+		"        foo_test.go:6: got 1\n" +
+		"          5 |     if got != 2 {\n" +
+		"        > 6 |         t.Errorf(\"got %d\", got)\n" +
+		"          7 |     }\n" +
+		"        foo_test.go:6: got 1\n" +
+		"        " + filepath.Join(dir, "foo_test.go") + ":8: absolute\n" +
+		"          7 |     }\n" +
+		"        > 8 | }\n" +
+		"        missing_test.go:3: no such file\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected source context:\n%s\nin:\n%s", want, out)
+	}
+
+	plain := NewSummaryFormatter(80, true).Format(ComputeSummary(run))
+	if strings.Contains(plain, "> 6 |") {
+		t.Errorf("Expected no source context by default, got:\n%s", plain)
+	}
+}
+
+func TestRepoLinks(t *testing.T) {
+	root := t.TempDir()
+	links := RepoLinks{Template: "https://example.com/repo/blob/{sha}/{path}#L{line}", SHA: "abc123", Root: root}
+	dir := filepath.Join(root, "pkg", "sub")
+
+	tests := []struct {
+		dir, file string
+		want      string
+	}{
+		{dir, "foo_test.go", "https://example.com/repo/blob/abc123/pkg/sub/foo_test.go#L42"},
+		{"", filepath.Join(root, "bar_test.go"), "https://example.com/repo/blob/abc123/bar_test.go#L42"},
+		{"", "foo_test.go", ""},                            // Unknown package directory
+		{t.TempDir(), "foo_test.go", ""},                   // Outside the checkout
+		{dir, filepath.Join("..", "..", "..", "x.go"), ""}, // Escapes the checkout
+	}
+	for _, tt := range tests {
+		if got := links.URL(tt.dir, tt.file, 42); got != tt.want {
+			t.Errorf("URL(%q, %q) = %q, want %q", tt.dir, tt.file, got, tt.want)
+		}
+	}
+	if got := (RepoLinks{}).URL(dir, "foo_test.go", 42); got != "" {
+		t.Errorf("URL() without a template = %q, want \"\"", got)
+	}
+
+	url := "https://example.com/repo/blob/abc123/pkg/sub/foo_test.go#L6"
+	want := "    \x1b]8;;" + url + "\x07foo_test.go:6\x1b]8;;\x07: got 1"
+	if got := links.hyperlink("    foo_test.go:6: got 1", dir); got != want {
+		t.Errorf("hyperlink() = %q, want %q", got, want)
+	}
+	if got := links.hyperlink("no reference", dir); got != "no reference" {
+		t.Errorf("hyperlink() without a reference = %q", got)
+	}
+}
+
+func TestFormatRepoLinks(t *testing.T) {
+	root := t.TempDir()
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "example.com/pkg", Status: results.StatusFailed, TestOrder: []string{"TestFoo"}}
+	pkg.Counts.Failed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	tr := results.NewTestResult(pkg.Name, "TestFoo")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Output = []string{"    foo_test.go:6: got 1"}
+	run.TestResults["example.com/pkg/TestFoo"] = tr
+
+	opts := NewSummaryOptions(
+		WithSourceContext(0, func(string) string { return filepath.Join(root, "pkg") }),
+		WithRepoLinks(RepoLinks{Template: "https://example.com/{sha}/{path}#L{line}", SHA: "abc", Root: root}),
+	)
+	link := "\x1b]8;;https://example.com/abc/pkg/foo_test.go#L6\x07foo_test.go:6\x1b]8;;\x07"
+	if out := NewSummaryFormatter(80, false, opts).Format(ComputeSummary(run, WithOptions(opts))); !strings.Contains(out, link) {
+		t.Errorf("Expected hyperlink %q in:\n%s", link, out)
+	}
+	// Plain output isn't cluttered with escape sequences.
+	if out := NewSummaryFormatter(80, true, opts).Format(ComputeSummary(run, WithOptions(opts))); strings.Contains(out, "\x1b]8;;") {
+		t.Errorf("Expected no hyperlinks without color, got:\n%q", out)
+	}
+}
+
+func TestFileLinks(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "foo_test.go")
+	if err := os.WriteFile(file, []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := RepoLinks{FileTemplate: "editor://open?file={path}&line={line}"}
+	want := "editor://open?file=" + filepath.ToSlash(file) + "&line=42"
+	if !strings.HasPrefix(filepath.ToSlash(file), "/") {
+		want = "editor://open?file=/" + filepath.ToSlash(file) + "&line=42"
+	}
+
+	tests := []struct {
+		dir, file string
+		want      string
+	}{
+		{dir, "foo_test.go", want}, // A bare name, resolved in its package's directory
+		{"", file, want},           // An absolute path, from go test -fullpath
+		{"", "foo_test.go", ""},    // Unknown package directory
+		{dir, "helper.go", ""},     // Not in the package's directory
+	}
+	for _, tt := range tests {
+		if got := links.URL(tt.dir, tt.file, 42); got != tt.want {
+			t.Errorf("URL(%q, %q) = %q, want %q", tt.dir, tt.file, got, tt.want)
+		}
+	}
+
+	// A repository template takes precedence.
+	links.Template, links.Root, links.SHA = "https://example.com/{sha}/{path}#L{line}", dir, "abc"
+	if got := links.URL(dir, "foo_test.go", 42); got != "https://example.com/abc/foo_test.go#L42" {
+		t.Errorf("URL() with a Template = %q", got)
+	}
+}
@@ -0,0 +1,295 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/ansel1/tang/results"
+)
+
+// TestSnapshot is a test's result in one of the streams compared by
+// DiffStreams.
+type TestSnapshot struct {
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"` // Seconds
+	Message string  `json:"message,omitempty"`
+}
+
+// TestDiff describes a test that differs between two streams. Before is
+// nil for a test only in the second stream, After for one only in the
+// first.
+type TestDiff struct {
+	Package string        `json:"package"`
+	Name    string        `json:"name"`
+	Before  *TestSnapshot `json:"before,omitempty"`
+	After   *TestSnapshot `json:"after,omitempty"`
+}
+
+// DurationChange returns how much longer the test took in the second
+// stream, negative if it got faster.
+func (d *TestDiff) DurationChange() time.Duration {
+	return time.Duration((d.After.Elapsed - d.Before.Elapsed) * float64(time.Second))
+}
+
+// StreamDiff is the structural difference between two recorded streams,
+// with tests aligned by package and name.
+type StreamDiff struct {
+	Tests           int         `json:"tests"` // Tests in both streams
+	StatusChanges   []*TestDiff `json:"status_changes"`
+	DurationChanges []*TestDiff `json:"duration_changes"`
+	Added           []*TestDiff `json:"added"`
+	Removed         []*TestDiff `json:"removed"`
+	MessageChanges  []*TestDiff `json:"message_changes"`
+}
+
+// Empty reports whether the streams didn't differ.
+func (d *StreamDiff) Empty() bool {
+	return len(d.StatusChanges)+len(d.DurationChanges)+len(d.Added)+len(d.Removed)+len(d.MessageChanges) == 0
+}
+
+// DiffStreams compares the tests of the runs of two streams, before and
+// after, e.g. recorded before and after a change to the test
+// infrastructure. A test's result is that of its latest execution in the
+// last run it appears in. It reports:
+//
+//   - tests whose status changed
+//   - tests which passed or failed in both and whose duration changed by
+//     more than the thresholds, in either direction
+//   - tests only in one of the streams
+//   - tests which failed in both with a different message, their output
+//     without go test's "===" and "---" framing lines
+//
+// Each list is sorted by package and name, but for duration changes,
+// which are largest change first.
+func DiffStreams(before, after []*results.Run, th RegressionThresholds) *StreamDiff {
+	a, b := latestResults(before), latestResults(after)
+	d := &StreamDiff{
+		StatusChanges:   []*TestDiff{},
+		DurationChanges: []*TestDiff{},
+		Added:           []*TestDiff{},
+		Removed:         []*TestDiff{},
+		MessageChanges:  []*TestDiff{},
+	}
+	for key, tr := range a {
+		if _, ok := b[key]; !ok {
+			d.Removed = append(d.Removed, &TestDiff{Package: tr.Package, Name: tr.Name, Before: snapshot(tr)})
+		}
+	}
+	for key, tr := range b {
+		prev, ok := a[key]
+		if !ok {
+			d.Added = append(d.Added, &TestDiff{Package: tr.Package, Name: tr.Name, After: snapshot(tr)})
+			continue
+		}
+		d.Tests++
+		diff := &TestDiff{Package: tr.Package, Name: tr.Name, Before: snapshot(prev), After: snapshot(tr)}
+		switch {
+		case diff.Before.Status != diff.After.Status:
+			d.StatusChanges = append(d.StatusChanges, diff)
+			continue
+		case diff.After.Status == results.StatusFailed.String() && diff.Before.Message != diff.After.Message:
+			d.MessageChanges = append(d.MessageChanges, diff)
+		}
+		if diff.After.Status == results.StatusSkipped.String() {
+			continue
+		}
+		before := time.Duration(diff.Before.Elapsed * float64(time.Second))
+		if th.exceeded(before, diff.DurationChange().Abs()) {
+			d.DurationChanges = append(d.DurationChanges, diff)
+		}
+	}
+
+	byName := func(diffs []*TestDiff) {
+		sort.Slice(diffs, func(i, j int) bool {
+			if diffs[i].Package != diffs[j].Package {
+				return diffs[i].Package < diffs[j].Package
+			}
+			return diffs[i].Name < diffs[j].Name
+		})
+	}
+	byName(d.StatusChanges)
+	byName(d.Added)
+	byName(d.Removed)
+	byName(d.MessageChanges)
+	byName(d.DurationChanges)
+	sort.SliceStable(d.DurationChanges, func(i, j int) bool {
+		return d.DurationChanges[i].DurationChange().Abs() > d.DurationChanges[j].DurationChange().Abs()
+	})
+	return d
+}
+
+// latestResults returns the finished tests of runs by "package/name", a
+// later run's result replacing an earlier one's.
+func latestResults(runs []*results.Run) map[string]*results.TestResult {
+	m := make(map[string]*results.TestResult)
+	for _, run := range runs {
+		for key, tr := range run.TestResults {
+			switch tr.Status() {
+			case results.StatusPassed, results.StatusFailed, results.StatusSkipped:
+				m[key] = tr
+			}
+		}
+	}
+	return m
+}
+
+// snapshot returns the result of tr's latest execution.
+func snapshot(tr *results.TestResult) *TestSnapshot {
+	exec := tr.Latest()
+	s := &TestSnapshot{Status: exec.Status.String(), Elapsed: exec.Elapsed.Seconds()}
+	if exec.Status == results.StatusFailed {
+		s.Message = failureMessage(exec.Output)
+	}
+	return s
+}
+
+// failureMessage returns the lines of a failed test's output, trimmed,
+// without blank lines and go test's framing lines, whose elapsed times
+// differ from run to run.
+func failureMessage(output []string) string {
+	var lines []string
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		lines = append(lines, trimmed)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteStreamDiff writes d to w as text.
+func WriteStreamDiff(w io.Writer, d *StreamDiff, noColor bool) error {
+	var sb strings.Builder
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	boldWhite := lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
+	if noColor {
+		failStyle = lipgloss.NewStyle()
+		passStyle = lipgloss.NewStyle()
+		boldWhite = lipgloss.NewStyle()
+	}
+	status := func(s *TestSnapshot) string {
+		switch s.Status {
+		case results.StatusFailed.String():
+			return failStyle.Render(s.Status)
+		case results.StatusPassed.String():
+			return passStyle.Render(s.Status)
+		}
+		return s.Status
+	}
+	section := func(title string, diffs []*TestDiff, detail func(*TestDiff) string) {
+		if len(diffs) == 0 {
+			return
+		}
+		sb.WriteString(boldWhite.Render(title))
+		sb.WriteString("\n")
+		for _, diff := range diffs {
+			fmt.Fprintf(&sb, "%s%s %s  %s\n", IndentLevel, diff.Package, diff.Name, detail(diff))
+		}
+		sb.WriteString("\n")
+	}
+
+	section("STATUS CHANGES", d.StatusChanges, func(diff *TestDiff) string {
+		return status(diff.Before) + " → " + status(diff.After)
+	})
+	section("DURATION CHANGES", d.DurationChanges, func(diff *TestDiff) string {
+		return fmt.Sprintf("%.2fs → %.2fs (%s)", diff.Before.Elapsed, diff.After.Elapsed, durationChange(diff))
+	})
+	section("ADDED", d.Added, func(diff *TestDiff) string { return status(diff.After) })
+	section("REMOVED", d.Removed, func(diff *TestDiff) string { return status(diff.Before) })
+	if len(d.MessageChanges) > 0 {
+		sb.WriteString(boldWhite.Render("FAILURE MESSAGE CHANGES"))
+		sb.WriteString("\n")
+		for _, diff := range d.MessageChanges {
+			fmt.Fprintf(&sb, "%s%s %s\n", IndentLevel, diff.Package, diff.Name)
+			for _, line := range strings.Split(diff.Before.Message, "\n") {
+				fmt.Fprintf(&sb, "%s%s\n", strings.Repeat(IndentLevel, 2), failStyle.Render("- "+line))
+			}
+			for _, line := range strings.Split(diff.After.Message, "\n") {
+				fmt.Fprintf(&sb, "%s%s\n", strings.Repeat(IndentLevel, 2), passStyle.Render("+ "+line))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if d.Empty() {
+		fmt.Fprintf(&sb, "No differences in %d tests.\n", d.Tests)
+	} else {
+		fmt.Fprintf(&sb, "%d tests in both: %d status changes, %d duration changes, %d added, %d removed, %d failure message changes\n",
+			d.Tests, len(d.StatusChanges), len(d.DurationChanges), len(d.Added), len(d.Removed), len(d.MessageChanges))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteStreamDiffMarkdown writes d to w as GitHub-flavored Markdown.
+func WriteStreamDiffMarkdown(w io.Writer, d *StreamDiff) error {
+	var sb strings.Builder
+	sb.WriteString("## Stream diff\n\n")
+	if d.Empty() {
+		fmt.Fprintf(&sb, "No differences in %d tests.\n", d.Tests)
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+	fmt.Fprintf(&sb, "%d tests in both: %d status changes, %d duration changes, %d added, %d removed, %d failure message changes\n",
+		d.Tests, len(d.StatusChanges), len(d.DurationChanges), len(d.Added), len(d.Removed), len(d.MessageChanges))
+
+	table := func(title, header string, diffs []*TestDiff, row func(*TestDiff) string) {
+		if len(diffs) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n| Package | Test | %s |\n|---|---|---|\n", title, header)
+		for _, diff := range diffs {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", markdownTableCell(markdownCode(diff.Package)),
+				markdownTableCell(markdownCode(diff.Name)), row(diff))
+		}
+	}
+	table("Status changes", "Status", d.StatusChanges, func(diff *TestDiff) string {
+		return diff.Before.Status + " → " + diff.After.Status
+	})
+	table("Duration changes", "Duration", d.DurationChanges, func(diff *TestDiff) string {
+		return fmt.Sprintf("%.2fs → %.2fs (%s)", diff.Before.Elapsed, diff.After.Elapsed, durationChange(diff))
+	})
+	table("Added", "Status", d.Added, func(diff *TestDiff) string { return diff.After.Status })
+	table("Removed", "Status", d.Removed, func(diff *TestDiff) string { return diff.Before.Status })
+	if len(d.MessageChanges) > 0 {
+		sb.WriteString("\n### Failure message changes\n")
+		for _, diff := range d.MessageChanges {
+			var lines []string
+			for _, line := range strings.Split(diff.Before.Message, "\n") {
+				lines = append(lines, "- "+line)
+			}
+			for _, line := range strings.Split(diff.After.Message, "\n") {
+				lines = append(lines, "+ "+line)
+			}
+			fence := markdownFence(lines)
+			fmt.Fprintf(&sb, "\n%s %s\n\n%sdiff\n%s\n%s\n", markdownCode(diff.Package), markdownCode(diff.Name),
+				fence, strings.Join(lines, "\n"), fence)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteStreamDiffJSON writes d to w as indented JSON.
+func WriteStreamDiffJSON(w io.Writer, d *StreamDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// durationChange formats the change in a test's duration, e.g. "+1.20s,
+// +150%", the percentage omitted when it previously took no time.
+func durationChange(diff *TestDiff) string {
+	delta := diff.After.Elapsed - diff.Before.Elapsed
+	if diff.Before.Elapsed <= 0 {
+		return fmt.Sprintf("%+.2fs", delta)
+	}
+	return fmt.Sprintf("%+.2fs, %+.0f%%", delta, delta/diff.Before.Elapsed*100)
+}
@@ -0,0 +1,142 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// streamRun returns a run of package pkg1 with a test for each entry of
+// tests, its status, elapsed time and output.
+func streamRun(tests map[string]*TestSnapshot) *results.Run {
+	run := results.NewRun(1)
+	for name, s := range tests {
+		tr := results.NewTestResult("pkg1", name)
+		exec := tr.Latest()
+		exec.Elapsed = time.Duration(s.Elapsed * float64(time.Second))
+		switch s.Status {
+		case "passed":
+			exec.Status = results.StatusPassed
+		case "failed":
+			exec.Status = results.StatusFailed
+		case "skipped":
+			exec.Status = results.StatusSkipped
+		}
+		if s.Message != "" {
+			exec.Output = []string{"=== RUN   " + name, "    x_test.go:1: " + s.Message, "--- FAIL: " + name + " (1.00s)"}
+		}
+		run.TestResults["pkg1/"+name] = tr
+	}
+	return run
+}
+
+func TestDiffStreams(t *testing.T) {
+	before := streamRun(map[string]*TestSnapshot{
+		"TestSame":    {Status: "passed", Elapsed: 1},
+		"TestBroke":   {Status: "passed", Elapsed: 1},
+		"TestSlower":  {Status: "passed", Elapsed: 1},
+		"TestFaster":  {Status: "passed", Elapsed: 10},
+		"TestJitter":  {Status: "passed", Elapsed: 1},
+		"TestMessage": {Status: "failed", Elapsed: 1, Message: "got 1"},
+		"TestStable":  {Status: "failed", Elapsed: 1, Message: "got 2"},
+		"TestGone":    {Status: "skipped"},
+	})
+	after := streamRun(map[string]*TestSnapshot{
+		"TestSame":    {Status: "passed", Elapsed: 1},
+		"TestBroke":   {Status: "failed", Elapsed: 1, Message: "boom"},
+		"TestSlower":  {Status: "passed", Elapsed: 4},
+		"TestFaster":  {Status: "passed", Elapsed: 2},
+		"TestJitter":  {Status: "passed", Elapsed: 1.2},
+		"TestMessage": {Status: "failed", Elapsed: 1, Message: "got 3"},
+		"TestStable":  {Status: "failed", Elapsed: 1.1, Message: "got 2"},
+		"TestNew":     {Status: "passed"},
+	})
+
+	d := DiffStreams([]*results.Run{before}, []*results.Run{after}, RegressionThresholds{Percent: 50, Absolute: time.Second})
+	names := func(diffs []*TestDiff) []string {
+		var s []string
+		for _, diff := range diffs {
+			s = append(s, diff.Name)
+		}
+		return s
+	}
+	check := func(what string, diffs []*TestDiff, want ...string) {
+		t.Helper()
+		if got := names(diffs); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s = %v, want %v", what, got, want)
+		}
+	}
+	if d.Tests != 7 {
+		t.Errorf("Tests = %d, want 7", d.Tests)
+	}
+	check("status changes", d.StatusChanges, "TestBroke")
+	check("duration changes", d.DurationChanges, "TestFaster", "TestSlower")
+	check("added", d.Added, "TestNew")
+	check("removed", d.Removed, "TestGone")
+	check("message changes", d.MessageChanges, "TestMessage")
+	if m := d.MessageChanges[0]; m.Before.Message != "x_test.go:1: got 1" || m.After.Message != "x_test.go:1: got 3" {
+		t.Errorf("messages = %q, %q; want the output without framing lines", m.Before.Message, m.After.Message)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStreamDiff(&buf, d, true); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, want := range []string{
+		"STATUS CHANGES\n    pkg1 TestBroke  passed → failed\n",
+		"    pkg1 TestFaster  10.00s → 2.00s (-8.00s, -80%)\n",
+		"ADDED\n    pkg1 TestNew  passed\n",
+		"REMOVED\n    pkg1 TestGone  skipped\n",
+		"    pkg1 TestMessage\n        - x_test.go:1: got 1\n        + x_test.go:1: got 3\n",
+		"7 tests in both: 1 status changes, 2 duration changes, 1 added, 1 removed, 1 failure message changes\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text diff missing %q:\n%s", want, text)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteStreamDiffMarkdown(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "| `pkg1` | `TestSlower` | 1.00s → 4.00s (+3.00s, +300%) |") {
+		t.Errorf("markdown diff missing duration row:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteStreamDiffJSON(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	var decoded StreamDiff
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	check("decoded added", decoded.Added, "TestNew")
+	if decoded.Added[0].Before != nil || decoded.Added[0].After.Status != "passed" {
+		t.Errorf("decoded added = %+v, want only an after result", decoded.Added[0])
+	}
+
+	// With All, a change must exceed both thresholds.
+	d = DiffStreams([]*results.Run{before}, []*results.Run{after}, RegressionThresholds{Percent: 50, Absolute: 5 * time.Second, All: true})
+	check("duration changes with all", d.DurationChanges, "TestFaster")
+}
+
+func TestDiffStreamsEmpty(t *testing.T) {
+	run := streamRun(map[string]*TestSnapshot{"TestA": {Status: "passed", Elapsed: 1}})
+	d := DiffStreams([]*results.Run{run}, []*results.Run{run}, RegressionThresholds{Percent: 50})
+	if !d.Empty() {
+		t.Fatalf("diff of a stream with itself isn't empty: %+v", d)
+	}
+	var buf bytes.Buffer
+	if err := WriteStreamDiff(&buf, d, true); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "No differences in 1 tests.\n" {
+		t.Errorf("text diff = %q", buf.String())
+	}
+}
@@ -0,0 +1,81 @@
+package format
+
+import "sort"
+
+// DefaultSuiteChangePercent is the relative change in a package's test
+// count, compared with the previous run, reported in SUITE CHANGES.
+const DefaultSuiteChangePercent = 20
+
+// minSuiteChange is the smallest change in a package's test count that's
+// reported, so adding a test to a tiny package isn't flagged. A package
+// that lost all its tests is always reported.
+const minSuiteChange = 3
+
+// SuiteChange describes a package whose test count changed markedly since
+// the previous run, e.g. because a build tag or a TestMain excluded tests.
+type SuiteChange struct {
+	Package  string
+	Previous int // Tests in the previous run
+	Current  int // Tests in this run
+}
+
+// Delta returns the change in the package's test count.
+func (c *SuiteChange) Delta() int {
+	return c.Current - c.Previous
+}
+
+// Percent returns the change in the package's test count as a percentage
+// of the previous count, or 100 when the package previously had no tests.
+func (c *SuiteChange) Percent() float64 {
+	if c.Previous == 0 {
+		return 100
+	}
+	return float64(c.Delta()) / float64(c.Previous) * 100
+}
+
+// SuiteChanges compares each package's test count with opts.PreviousRun
+// and returns the packages whose count changed by at least
+// opts.SuiteChangePercent, largest relative change first. Packages missing
+// from either run are ignored, so running a subset of packages reports
+// nothing. Returns nil when there's no previous run or the threshold is
+// zero.
+func (s *Summary) SuiteChanges(opts SummaryOptions) []*SuiteChange {
+	if opts.PreviousRun == nil || opts.SuiteChangePercent <= 0 || s.Run == nil {
+		return nil
+	}
+
+	previous := make(map[string]int, len(opts.PreviousRun.Packages))
+	for _, pkg := range opts.PreviousRun.Packages {
+		previous[pkg.Name] = pkg.Passed + pkg.Failed + pkg.Skipped
+	}
+
+	var changes []*SuiteChange
+	for _, pkg := range s.Packages {
+		prev, ok := previous[pkg.Name]
+		if !ok {
+			continue
+		}
+		c := &SuiteChange{
+			Package:  pkg.Name,
+			Previous: prev,
+			Current:  pkg.Counts.Passed + pkg.Counts.Failed + pkg.Counts.Skipped,
+		}
+		emptied := c.Current == 0 && c.Previous > 0
+		if !emptied && (abs(c.Delta()) < minSuiteChange || abs(c.Percent()) < opts.SuiteChangePercent) {
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return abs(changes[i].Percent()) > abs(changes[j].Percent())
+	})
+	return changes
+}
+
+func abs[T int | float64](x T) T {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
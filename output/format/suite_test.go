@@ -0,0 +1,66 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/results"
+)
+
+func suiteRun(counts map[string]int) *results.Run {
+	run := results.NewRun(1)
+	for _, name := range []string{"pkg/a", "pkg/b", "pkg/c", "pkg/d"} {
+		n, ok := counts[name]
+		if !ok {
+			continue
+		}
+		pkg := &results.PackageResult{Name: name, Status: results.StatusPassed}
+		pkg.Counts.Passed = n
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+	}
+	return run
+}
+
+func TestSummarySuiteChanges(t *testing.T) {
+	previous := NewSummaryJSON(ComputeSummary(suiteRun(map[string]int{
+		"pkg/a": 120, // Dropped by 90%
+		"pkg/b": 2,   // Lost every test
+		"pkg/c": 10,  // Gained one test: under the minimum change
+		"pkg/d": 50,  // Not run this time
+	})))
+	summary := ComputeSummary(suiteRun(map[string]int{"pkg/a": 12, "pkg/b": 0, "pkg/c": 11}))
+
+	if got := summary.SuiteChanges(NewSummaryOptions()); got != nil {
+		t.Errorf("Expected no suite changes without a previous run, got %+v", got)
+	}
+
+	opts := NewSummaryOptions(WithPreviousRun(previous, DefaultSuiteChangePercent))
+	changes := summary.SuiteChanges(opts)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 suite changes, got %+v", changes)
+	}
+	if c := changes[0]; c.Package != "pkg/b" || c.Previous != 2 || c.Current != 0 {
+		t.Errorf("Expected pkg/b to lose all its tests first, got %+v", c)
+	}
+	if c := changes[1]; c.Package != "pkg/a" || c.Delta() != -108 || c.Percent() != -90 {
+		t.Errorf("Expected pkg/a to drop 108 tests, got %+v", c)
+	}
+	if !summary.HasTestDetailsWithOptions(opts) {
+		t.Error("Expected suite changes to count as test details")
+	}
+
+	out := NewSummaryFormatter(80, true, opts).Format(summary)
+	for _, want := range []string{
+		"SUITE CHANGES (test counts since the previous run)\n",
+		"    pkg/b  2 -> 0 tests (-2, -100%)\n",
+		"    pkg/a  120 -> 12 tests (-108, -90%)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "    pkg/c  10 -> ") {
+		t.Errorf("Expected no change reported for pkg/c:\n%s", out)
+	}
+}
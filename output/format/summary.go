@@ -1,26 +1,27 @@
 package format
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/ansel1/tang/results"
 )
 
-// formatDuration formats a duration using Go's native String() with up to 3
-// fractional digits on the smallest unit (truncated, not rounded).
-func formatDuration(d time.Duration) string {
-	if d <= 0 {
-		return "0s"
+// FormatBytes formats a byte count with a binary unit, e.g. "1.5GB".
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
 	}
-	switch {
-	case d >= time.Second:
-		return d.Truncate(time.Millisecond).String()
-	case d >= time.Millisecond:
-		return d.Truncate(time.Microsecond).String()
-	default:
-		return d.Truncate(time.Nanosecond).String()
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 // Symbol constants for test results
@@ -76,6 +77,12 @@ type TestExecutionEntry struct {
 	TestExecution   *results.TestExecution
 	Iteration       int // 1-based iteration number
 	TotalExecutions int
+	Categories      []string // Failure categories (see ClassifyFailure), or SkipCategoryShortMode for skips
+
+	// CollapsedParent is the ancestor of a failed subtest whose failure,
+	// caused only by the subtest's, was collapsed into it, e.g. TestParent
+	// for TestParent/Case3. See SummaryOptions.KeepParentFailures.
+	CollapsedParent string
 }
 
 // Summary represents computed summary statistics from a test run.
@@ -85,25 +92,27 @@ type Summary struct {
 	PassedTests      int
 	FailedTests      int
 	SkippedTests     int
+	InterruptedTests int // Tests still running when the run ended; not in TotalTests
 	TotalTime        time.Duration
 	PackageCount     int
+	CachedPackages   int // Packages whose results came from go's test cache
 	Failures         []*TestExecutionEntry
 	Skipped          []*TestExecutionEntry
+	Interrupted      []*TestExecutionEntry // By package, then name
+	Stalled          []*TestExecutionEntry // Interrupted entries silent for StallThreshold, most silent first
+	ShortModeSkips   int                   // Skipped executions caused by go test -short
+	OutputErrors     int                   // Error lines in the run's non-test output (see ClassifyOutputLine)
+	OutputWarnings   int                   // Warning lines in the run's non-test output
 	SlowTests        []*TestExecutionEntry
 	BuildFailures    []*results.PackageResult // Packages that failed to build
+	Categories       []CategoryCount          // Failures per category, most frequent first
+	ParentFailures   map[string]int           // Parent failures collapsed into their subtests', by package; see PackageCounts
 	Run              *results.Run             // Reference to the run for accessing build errors
 	FastestPackage   *results.PackageResult
 	SlowestPackage   *results.PackageResult
 	MostTestsPackage *results.PackageResult
 }
 
-// SummaryOptions controls which optional detail sections appear in the
-// formatted summary output. Failures and build failures are always shown.
-type SummaryOptions struct {
-	IncludeSkipped bool // Show individual skipped test details
-	IncludeSlow    bool // Show individual slow test details
-}
-
 // HasTestDetails reports whether the summary contains test-level detail
 // messages (failures, skipped tests, slow tests, or build failures) that
 // will be rendered above the package summary table.
@@ -114,7 +123,13 @@ func (s *Summary) HasTestDetails() bool {
 // HasTestDetailsWithOptions is like HasTestDetails but respects the given options
 // for which optional sections to consider.
 func (s *Summary) HasTestDetailsWithOptions(opts SummaryOptions) bool {
-	if len(s.Failures) > 0 || len(s.BuildFailures) > 0 {
+	if len(s.Failures) > 0 || len(s.BuildFailures) > 0 || len(s.Interrupted) > 0 {
+		return true
+	}
+	if s.Run != nil && (s.Run.MalformedLines > 0 || len(s.Run.Inconsistencies) > 0 || len(s.Run.Anomalies) > 0) {
+		return true
+	}
+	if s.OutputErrors > 0 || s.OutputWarnings > 0 {
 		return true
 	}
 	if opts.IncludeSkipped && len(s.Skipped) > 0 {
@@ -123,6 +138,24 @@ func (s *Summary) HasTestDetailsWithOptions(opts SummaryOptions) bool {
 	if opts.IncludeSlow && len(s.SlowTests) > 0 {
 		return true
 	}
+	if len(s.Regressions(opts)) > 0 {
+		return true
+	}
+	if len(s.SuiteChanges(opts)) > 0 {
+		return true
+	}
+	if len(s.SetupOverheads(opts.SetupMinimum)) > 0 {
+		return true
+	}
+	if opts.IncludeParallelism && len(s.Parallelism()) > 0 {
+		return true
+	}
+	if len(s.PossiblyEmptyTests(opts.EmptyTestThreshold)) > 0 {
+		return true
+	}
+	if opts.Timeline && len(s.Packages) > 0 {
+		return true
+	}
 	for _, pkg := range s.Packages {
 		if len(pkg.OutputLines) > 0 {
 			return true
@@ -134,18 +167,14 @@ func (s *Summary) HasTestDetailsWithOptions(opts SummaryOptions) bool {
 // ComputeSummary calculates summary statistics from a Run.
 //
 // This function processes the run data and computes all necessary
-// statistics for display.
-//
-// Parameters:
-//   - run: The Run to summarize
-//   - slowThreshold: Duration threshold for slow test detection (e.g., 10s)
-//
-// Returns:
-//   - Summary with all computed statistics
-func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
+// statistics for display. Options default to those of NewSummaryOptions;
+// only the slow thresholds and failure rules affect the computed
+// statistics.
+func ComputeSummary(run *results.Run, opts ...SummaryOption) *Summary {
+	options := NewSummaryOptions(opts...)
 	summary := &Summary{
 		PackageCount: len(run.PackageOrder),
-		TotalTime:    run.LastEventTime.Sub(run.FirstEventTime),
+		TotalTime:    run.Elapsed(),
 		Run:          run,
 	}
 
@@ -157,6 +186,7 @@ func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
 		}
 	}
 	summary.Packages = packages
+	summary.OutputErrors, summary.OutputWarnings = countOutputLevels(run.NonTestOutput)
 
 	// Calculate overall test statistics from per-package counts.
 	// This correctly counts all executions (e.g., when -count=N causes
@@ -166,6 +196,7 @@ func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
 		summary.PassedTests += pkg.Counts.Passed
 		summary.FailedTests += pkg.Counts.Failed
 		summary.SkippedTests += pkg.Counts.Skipped
+		summary.InterruptedTests += pkg.Counts.Interrupted
 	}
 	summary.TotalTests = summary.PassedTests + summary.FailedTests + summary.SkippedTests
 
@@ -184,21 +215,48 @@ func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
 
 			switch exec.Status {
 			case results.StatusFailed:
+				entry.Categories = ClassifyFailure(options.FailureRules, testResult, exec)
 				summary.Failures = append(summary.Failures, entry)
 			case results.StatusSkipped:
+				if results.IsShortModeSkip(exec.SkipReason) {
+					entry.Categories = []string{SkipCategoryShortMode}
+					summary.ShortModeSkips++
+				}
 				summary.Skipped = append(summary.Skipped, entry)
+			case results.StatusInterrupted:
+				summary.Interrupted = append(summary.Interrupted, entry)
+				if options.StallThreshold > 0 && exec.SilentFor >= options.StallThreshold {
+					summary.Stalled = append(summary.Stalled, entry)
+				}
 			}
-			if exec.Elapsed >= slowThreshold {
+			if th := options.SlowThresholdFor(testResult.Package); th > 0 && exec.Elapsed >= th {
 				summary.SlowTests = append(summary.SlowTests, entry)
 			}
 		}
 	}
 
+	slices.SortFunc(summary.Interrupted, func(a, b *TestExecutionEntry) int {
+		return cmp.Or(
+			strings.Compare(a.TestResult.Package, b.TestResult.Package),
+			strings.Compare(a.TestResult.Name, b.TestResult.Name),
+			cmp.Compare(a.Iteration, b.Iteration),
+		)
+	})
+
+	slices.SortStableFunc(summary.Stalled, func(a, b *TestExecutionEntry) int {
+		return cmp.Compare(b.TestExecution.SilentFor, a.TestExecution.SilentFor)
+	})
+
 	// Sort slow tests by elapsed time (descending)
 	if len(summary.SlowTests) > 0 {
 		sortSlowTests(summary.SlowTests)
 	}
 
+	if !options.KeepParentFailures {
+		collapseParentFailures(summary)
+	}
+	summary.Categories = countCategories(summary.Failures)
+
 	// Collect packages with build failures
 	for _, pkg := range packages {
 		if pkg.FailedBuild != "" {
@@ -206,21 +264,22 @@ func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
 		}
 	}
 
-	// Calculate package statistics
+	// Calculate package statistics. Cached packages report the elapsed time
+	// of the original run (or ~0), so they're excluded from the
+	// fastest/slowest comparison.
 	if len(packages) > 0 {
-		summary.FastestPackage = packages[0]
-		summary.SlowestPackage = packages[0]
 		summary.MostTestsPackage = packages[0]
 
 		for _, pkg := range packages {
-			// Find fastest package
-			if pkg.Elapsed < summary.FastestPackage.Elapsed {
-				summary.FastestPackage = pkg
-			}
-
-			// Find slowest package
-			if pkg.Elapsed > summary.SlowestPackage.Elapsed {
-				summary.SlowestPackage = pkg
+			if pkg.Cached {
+				summary.CachedPackages++
+			} else {
+				if summary.FastestPackage == nil || pkg.Elapsed < summary.FastestPackage.Elapsed {
+					summary.FastestPackage = pkg
+				}
+				if summary.SlowestPackage == nil || pkg.Elapsed > summary.SlowestPackage.Elapsed {
+					summary.SlowestPackage = pkg
+				}
 			}
 
 			// Find package with most tests
@@ -235,6 +294,18 @@ func ComputeSummary(run *results.Run, slowThreshold time.Duration) *Summary {
 	return summary
 }
 
+// Parallelism returns parallelism statistics for each package with enough
+// timing data, in package order.
+func (s *Summary) Parallelism() []*Parallelism {
+	var stats []*Parallelism
+	for _, pkg := range s.Packages {
+		if p := ComputeParallelism(pkg, ParallelismBuckets); p != nil {
+			stats = append(stats, p)
+		}
+	}
+	return stats
+}
+
 // sortSlowTests sorts test execution entries by elapsed time in descending order.
 func sortSlowTests(tests []*TestExecutionEntry) {
 	n := len(tests)
@@ -1,9 +1,15 @@
 package format
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
 	"github.com/ansel1/tang/results"
@@ -60,28 +66,271 @@ func NewSummaryFormatter(width int, noColor bool, opts ...SummaryOptions) *Summa
 		f.dimStyle = neutral
 		f.boldWhite = neutral
 	} else {
-		f.failStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-		f.passStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
-		f.skipStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
-		f.slowStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
-		f.boldFail = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
-		f.boldSkip = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
-		f.boldSlow = lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Bold(true)
-		f.boldPass = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+		theme := options.Theme.orDefault()
+		f.failStyle = ColorStyle(theme.Fail)
+		f.passStyle = ColorStyle(theme.Pass)
+		f.skipStyle = ColorStyle(theme.Skip)
+		f.slowStyle = ColorStyle(theme.Slow)
+		f.boldFail = ColorStyle(theme.Fail).Bold(true)
+		f.boldSkip = ColorStyle(theme.Skip).Bold(true)
+		f.boldSlow = ColorStyle(theme.Slow).Bold(true)
+		f.boldPass = ColorStyle(theme.Pass).Bold(true)
 		f.dimStyle = lipgloss.NewStyle().Faint(true)
-		f.boldWhite = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
+		f.boldWhite = ColorStyle(theme.Emphasis).Bold(true)
 	}
 
 	return f
 }
 
 func (f *SummaryFormatter) Format(summary *Summary) string {
+	if f.options.Template != nil {
+		return f.formatTemplate(summary)
+	}
+
 	var sb strings.Builder
+	f.formatRunHeader(&sb, summary)
+	f.formatNonTestCounts(&sb, summary)
+	f.formatMalformed(&sb, summary)
+	f.formatInconsistencies(&sb, summary)
+	f.formatAnomalies(&sb, summary)
+	f.formatStalled(&sb, summary)
+	f.formatInterrupted(&sb, summary)
 	f.formatTestDetails(&sb, summary)
+	f.formatReproduce(&sb, summary)
+	f.formatReproducibility(&sb, summary)
+	f.formatPassedOutput(&sb, summary)
+	f.formatRegressions(&sb, summary)
+	f.formatSuiteChanges(&sb, summary)
+	f.formatSlowSetup(&sb, summary)
+	f.formatPossiblyEmpty(&sb, summary)
+	f.formatParallelism(&sb, summary)
+	f.formatTimeline(&sb, summary)
 	f.formatPackageSummary(&sb, summary)
 	return sb.String()
 }
 
+// formatRunHeader writes the run's unique ID and labels, so a report can be
+// matched up with the CI job that produced it, followed by badges for the
+// go test modes it was under, e.g. RACE, and the run's annotations.
+func (f *SummaryFormatter) formatRunHeader(sb *strings.Builder, summary *Summary) {
+	run := summary.Run
+	if run == nil {
+		return
+	}
+	if run.UID == "" && len(run.Labels) == 0 && len(run.Modes) == 0 {
+		if len(run.Annotations) > 0 {
+			f.formatAnnotations(sb, run)
+			sb.WriteString("\n")
+		}
+		return
+	}
+	parts := make([]string, 0, len(run.Labels)+1)
+	if run.UID != "" {
+		parts = append(parts, "run "+run.UID)
+	}
+	for _, label := range run.Labels {
+		parts = append(parts, label.String())
+	}
+	if len(parts) > 0 {
+		sb.WriteString(f.dimStyle.Render(strings.Join(parts, "  ")))
+	}
+	if badges := run.Badges(); len(badges) > 0 {
+		if len(parts) > 0 {
+			sb.WriteString("  ")
+		}
+		sb.WriteString(f.boldWhite.Render(strings.Join(badges, " ")))
+	}
+	sb.WriteString("\n")
+	f.formatAnnotations(sb, run)
+	sb.WriteString("\n")
+}
+
+// formatAnnotations lists the run's annotations, e.g. notes of a harness
+// rebuilding the environment, each with its time from the start of the run.
+func (f *SummaryFormatter) formatAnnotations(sb *strings.Builder, run *results.Run) {
+	for _, a := range run.Annotations {
+		var offset time.Duration
+		if !run.FirstEventTime.IsZero() {
+			offset = max(0, a.Time.Sub(run.FirstEventTime))
+		}
+		text := a.Text
+		if a.Package != "" {
+			text = f.options.PackageNames.Shorten(a.Package) + ": " + text
+		}
+		sb.WriteString(f.dimStyle.Render(fmt.Sprintf("%s %s %s", f.options.Symbols.Annotation(), f.duration(offset), text)))
+		sb.WriteString("\n")
+	}
+}
+
+// formatMalformed warns about corrupted JSON events in the input, whose
+// tests may be missing from or wrong in the report.
+func (f *SummaryFormatter) formatMalformed(sb *strings.Builder, summary *Summary) {
+	run := summary.Run
+	if run == nil || run.MalformedLines == 0 {
+		return
+	}
+	sb.WriteString(f.boldSkip.Render("MALFORMED INPUT"))
+	sb.WriteString(f.dimStyle.Render(" (corrupted go test -json events)"))
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, "%s%d lines, %d recovered; results may be incomplete\n\n", IndentLevel, run.MalformedLines, run.RecoveredLines)
+}
+
+// formatInconsistencies warns about packages whose counts disagree with go
+// test's verdict, which means tang misread the stream.
+func (f *SummaryFormatter) formatInconsistencies(sb *strings.Builder, summary *Summary) {
+	run := summary.Run
+	if run == nil || len(run.Inconsistencies) == 0 {
+		return
+	}
+	sb.WriteString(f.boldSkip.Render("INCONSISTENT RESULTS"))
+	sb.WriteString(f.dimStyle.Render(" (tang's counts disagree with go test; please report a bug)"))
+	sb.WriteString("\n")
+	for _, inc := range run.Inconsistencies {
+		fmt.Fprintf(sb, "%s%s: %s\n", IndentLevel, f.options.PackageNames.Shorten(inc.Package), inc.Problem)
+	}
+	sb.WriteString("\n")
+}
+
+// maxAnomalies is how many anomalies the ANOMALIES section lists.
+const maxAnomalies = 20
+
+// formatAnomalies warns about events breaking the stream's ordering
+// invariants, found with -strict, which tang may have misread.
+func (f *SummaryFormatter) formatAnomalies(sb *strings.Builder, summary *Summary) {
+	run := summary.Run
+	if run == nil || len(run.Anomalies) == 0 {
+		return
+	}
+	sb.WriteString(f.boldSkip.Render("ANOMALIES"))
+	sb.WriteString(f.dimStyle.Render(" (go test events out of order; results may be wrong)"))
+	sb.WriteString("\n")
+	for i, a := range run.Anomalies {
+		if i == maxAnomalies {
+			fmt.Fprintf(sb, "%s...and %d more\n", IndentLevel, len(run.Anomalies)-i)
+			break
+		}
+		a.Package = f.options.PackageNames.Shorten(a.Package)
+		fmt.Fprintf(sb, "%s%s\n", IndentLevel, a)
+	}
+	sb.WriteString("\n")
+}
+
+// maxInterruptedOutput is how many of an interrupted test's last lines of
+// output the INTERRUPTED section shows.
+const maxInterruptedOutput = 5
+
+// formatInterrupted lists the tests still running when the run ended, e.g.
+// when it was cancelled or timed out, with their last output, which is
+// usually where they were stuck.
+func (f *SummaryFormatter) formatInterrupted(sb *strings.Builder, summary *Summary) {
+	if len(summary.Interrupted) == 0 {
+		return
+	}
+	sb.WriteString(f.boldSkip.Render("INTERRUPTED"))
+	sb.WriteString(f.dimStyle.Render(" (still running when the run ended)"))
+	sb.WriteString("\n")
+	for _, entry := range summary.Interrupted {
+		name := results.ExecutionDisplayName(entry.TestResult.Name, entry.Iteration, entry.TotalExecutions)
+		fmt.Fprintf(sb, "%s%s %s %s\n", IndentLevel, f.boldSkip.Render(name),
+			f.dimStyle.Render(f.options.PackageNames.Shorten(entry.TestResult.Package)),
+			f.duration(entry.TestExecution.Elapsed))
+		output := entry.TestExecution.Output
+		if len(output) > maxInterruptedOutput {
+			fmt.Fprintf(sb, "%s%s\n", strings.Repeat(IndentLevel, 2), f.dimStyle.Render(fmt.Sprintf("... %d earlier lines", len(output)-maxInterruptedOutput)))
+			output = output[len(output)-maxInterruptedOutput:]
+		}
+		for _, line := range output {
+			fmt.Fprintf(sb, "%s%s\n", strings.Repeat(IndentLevel, 2), ensureReset(line))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// formatStalled lists the interrupted tests which had gone without output
+// for StallThreshold when the run ended, most silent first. They're
+// usually deadlocked, and the likeliest reason the run had to be killed.
+func (f *SummaryFormatter) formatStalled(sb *strings.Builder, summary *Summary) {
+	if len(summary.Stalled) == 0 {
+		return
+	}
+	sb.WriteString(f.boldFail.Render("STALLED"))
+	sb.WriteString(f.dimStyle.Render(fmt.Sprintf(" (no output for %s or more when the run ended)", f.duration(f.options.StallThreshold))))
+	sb.WriteString("\n")
+	for _, entry := range summary.Stalled {
+		name := results.ExecutionDisplayName(entry.TestResult.Name, entry.Iteration, entry.TotalExecutions)
+		fmt.Fprintf(sb, "%s%s %s %s\n", IndentLevel, f.boldFail.Render(name),
+			f.dimStyle.Render(f.options.PackageNames.Shorten(entry.TestResult.Package)),
+			"stalled "+f.duration(entry.TestExecution.SilentFor))
+	}
+	sb.WriteString("\n")
+}
+
+// formatReproduce lists the narrowest commands rerunning the failures, one
+// per package. They're left unstyled, so they copy cleanly.
+func (f *SummaryFormatter) formatReproduce(sb *strings.Builder, summary *Summary) {
+	if summary.Run == nil || (len(summary.Failures) == 0 && len(summary.BuildFailures) == 0) {
+		return
+	}
+	commands := summary.Run.ReproduceCommands()
+	if len(commands) == 0 {
+		return
+	}
+	sb.WriteString(f.boldFail.Render("REPRODUCE"))
+	sb.WriteString("\n")
+	for _, command := range commands {
+		sb.WriteString(IndentLevel + command + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// formatReproducibility lists what's needed to rerun a failed run of exec
+// mode identically: the go version, go test's working directory and
+// arguments, the shuffle seeds, the environment, and the packages run, and
+// with ReproStore, the `tang repro` command doing so.
+func (f *SummaryFormatter) formatReproducibility(sb *strings.Builder, summary *Summary) {
+	run := summary.Run
+	if run == nil || run.Repro == nil || (len(summary.Failures) == 0 && len(summary.BuildFailures) == 0) {
+		return
+	}
+	r := run.Repro
+	sb.WriteString(f.boldWhite.Render("REPRODUCIBILITY"))
+	sb.WriteString("\n")
+	line := func(label, value string) {
+		sb.WriteString(IndentLevel + f.dimStyle.Render(label+":") + " " + value + "\n")
+	}
+	if r.GoVersion != "" {
+		line("go", r.GoVersion)
+	}
+	if r.Dir != "" {
+		line("dir", r.Dir)
+	}
+	line("command", strings.Join(append([]string{"go", "test"}, r.Args...), " "))
+	if seeds := run.ShuffleSeeds(); len(seeds) > 0 {
+		parts := make([]string, 0, len(seeds))
+		for _, pkg := range run.PackageOrder {
+			if seed, ok := seeds[pkg]; ok {
+				parts = append(parts, f.options.PackageNames.Shorten(pkg)+" "+seed)
+			}
+		}
+		line("shuffle", strings.Join(parts, ", "))
+	}
+	env := make([]string, 0, len(r.Env)+1)
+	for _, name := range slices.Sorted(maps.Keys(r.Env)) {
+		env = append(env, name+"="+r.Env[name])
+	}
+	if r.EnvFingerprint != "" {
+		env = append(env, "(fingerprint "+r.EnvFingerprint+")")
+	}
+	if len(env) > 0 {
+		line("env", strings.Join(env, " "))
+	}
+	line("packages", fmt.Sprintf("%d", len(run.PackageOrder)))
+	if f.options.ReproStore != "" && run.UID != "" {
+		line("rerun", "tang repro "+f.options.ReproStore+" "+run.UID)
+	}
+	sb.WriteString("\n")
+}
+
 type packageIssue struct {
 	kind     string // "fail", "skip", "slow", "build", "output"
 	entry    *TestExecutionEntry
@@ -224,29 +473,36 @@ func (f *SummaryFormatter) formatTestDetails(sb *strings.Builder, summary *Summa
 		return
 	}
 
+	budget := detailsBudget{limit: f.options.MaxDetailsBytes}
 	for _, pkgName := range pkgOrder {
 		pd := pkgMap[pkgName]
 
-		sb.WriteString("=== ")
-		sb.WriteString(pkgName)
-		sb.WriteString("\n")
+		var pb strings.Builder
+		pb.WriteString("=== ")
+		pb.WriteString(pkgName)
+		pb.WriteString("\n")
 
 		for _, issue := range pd.issues {
 			switch issue.kind {
 			case "output":
-				f.formatPackageOutput(sb, issue.pkg)
+				f.formatPackageOutput(&pb, issue.pkg)
 			case "build":
-				f.formatBuildIssue(sb, issue.buildPkg, summary)
+				f.formatBuildIssue(&pb, issue.buildPkg, summary)
 			case "fail":
-				f.formatTestIssue(sb, issue.entry, "FAIL", f.boldFail, f.failStyle)
+				f.formatTestIssue(&pb, issue.entry, "FAIL", f.boldFail, f.failStyle)
 			case "skip":
-				f.formatTestIssue(sb, issue.entry, "SKIP", f.boldSkip, f.skipStyle)
+				f.formatTestIssue(&pb, issue.entry, "SKIP", f.boldSkip, f.skipStyle)
 			case "slow":
-				f.formatSlowTestIssue(sb, issue.entry)
+				f.formatSlowTestIssue(&pb, issue.entry)
 			}
 		}
 
-		sb.WriteString("\n")
+		pb.WriteString("\n")
+		budget.write(sb, pb.String())
+	}
+	if trailer := budget.trailer(f.options); trailer != "" {
+		sb.WriteString(f.dimStyle.Render(trailer))
+		sb.WriteString("\n\n")
 	}
 }
 
@@ -270,11 +526,12 @@ func (f *SummaryFormatter) formatTestIssue(sb *strings.Builder, entry *TestExecu
 	tr := entry.TestResult
 	exec := entry.TestExecution
 
-	// Use centralized naming helper for multi-execution tests
-	name := results.ExecutionDisplayName(tr.Name, entry.Iteration, entry.TotalExecutions)
-	indent := testIndent(name)
+	// A failed subtest its parent's failure was collapsed into is shown at
+	// the parent's depth.
+	name := entry.DisplayName(f.options.Symbols)
+	indent := testIndent(cmp.Or(entry.CollapsedParent, tr.Name))
 
-	annotation := fmt.Sprintf("(%.2fs)", exec.Elapsed.Seconds())
+	annotation := "(" + f.options.DurationStyle.formatTest(exec.Elapsed) + ")"
 	if exec.Interrupted && len(exec.Output) == 0 {
 		annotation = "(interrupted)"
 	}
@@ -286,16 +543,91 @@ func (f *SummaryFormatter) formatTestIssue(sb *strings.Builder, entry *TestExecu
 	sb.WriteString(colorStyle.Render(name))
 	sb.WriteString(" ")
 	sb.WriteString(f.dimStyle.Render(annotation))
+	if len(entry.Categories) > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(f.dimStyle.Render("[" + strings.Join(entry.Categories, ", ") + "]"))
+	}
 	sb.WriteString("\n")
 
-	for _, line := range exec.Output {
+	var sources sourceFiles
+	var sourceDir string
+	linked := exec.Status == results.StatusFailed && !f.noColor && f.options.RepoLinks.enabled()
+	if exec.Status == results.StatusFailed && (f.options.SourceContext > 0 || linked) && f.options.SourceDir != nil {
+		sourceDir = f.options.SourceDir(tr.Package)
+	}
+	if exec.Status == results.StatusFailed && f.options.SourceContext > 0 {
+		sources = make(sourceFiles)
+	}
+	shownRefs := make(map[string]bool)
+
+	output := exec.Output
+	if !f.options.RawTestify {
+		output = compactTestifyFailures(output)
+	}
+	if !f.options.FullGoroutineDumps {
+		fullDump := "rerun with -extract-logs"
+		if f.options.LogDir != "" && exec.Status == results.StatusFailed {
+			fullDump = LogPath(f.options.LogDir, tr.Package, tr.Name)
+		}
+		output = condenseGoroutineDumps(output, fullDump)
+	}
+
+	kinds := ClassifyDiffLines(output)
+	for i, line := range output {
 		sb.WriteString(indent)
-		if f.noColor {
-			sb.WriteString(line)
-		} else {
-			sb.WriteString(ensureReset(line))
+		shown := line
+		if linked {
+			shown = f.options.RepoLinks.hyperlink(line, sourceDir)
+		}
+		switch {
+		case f.noColor:
+			sb.WriteString(shown)
+		case kinds[i] == DiffRemoved:
+			sb.WriteString(f.failStyle.Render(ensureReset(shown)))
+		case kinds[i] == DiffAdded:
+			sb.WriteString(f.passStyle.Render(ensureReset(shown)))
+		default:
+			sb.WriteString(ensureReset(shown))
 		}
 		sb.WriteString("\n")
+
+		// Show the source around each referenced line once, e.g. when
+		// several assertions on one line fail in a loop.
+		if sources == nil {
+			continue
+		}
+		ref := strings.TrimSpace(sourceRefRE.FindString(line))
+		if shownRefs[ref] {
+			continue
+		}
+		context := sources.sourceContext(line, sourceDir, f.options.SourceContext)
+		if len(context) == 0 {
+			continue
+		}
+		shownRefs[ref] = true
+		for _, c := range context {
+			sb.WriteString(indent)
+			sb.WriteString(IndentLevel)
+			sb.WriteString(f.dimStyle.Render(c))
+			sb.WriteString("\n")
+		}
+	}
+
+	if exec.Status == results.StatusFailed && f.options.LogDir != "" {
+		sb.WriteString(indent)
+		sb.WriteString(f.dimStyle.Render("log: " + LogPath(f.options.LogDir, tr.Package, tr.Name)))
+		sb.WriteString("\n")
+	}
+	if exec.Status == results.StatusFailed && len(exec.Artifacts) > 0 {
+		var copied map[string]string
+		if f.options.ArtifactDir != "" {
+			copied = ArtifactPaths(f.options.ArtifactDir, tr)
+		}
+		for _, artifact := range exec.Artifacts {
+			sb.WriteString(indent)
+			sb.WriteString(f.dimStyle.Render("artifact: " + cmp.Or(copied[artifact], artifact)))
+			sb.WriteString("\n")
+		}
 	}
 }
 
@@ -307,7 +639,7 @@ func (f *SummaryFormatter) formatSlowTestIssue(sb *strings.Builder, entry *TestE
 	name := results.ExecutionDisplayName(tr.Name, entry.Iteration, entry.TotalExecutions)
 	indent := testIndent(name)
 
-	elapsed := fmt.Sprintf("(%.2fs)", exec.Elapsed.Seconds())
+	elapsed := "(" + f.options.DurationStyle.formatTest(exec.Elapsed) + ")"
 
 	sb.WriteString(indent)
 	sb.WriteString("--- ")
@@ -320,7 +652,12 @@ func (f *SummaryFormatter) formatSlowTestIssue(sb *strings.Builder, entry *TestE
 }
 
 func (f *SummaryFormatter) formatPackageOutput(sb *strings.Builder, pkg *results.PackageResult) {
-	for _, line := range pkg.OutputLines {
+	output := pkg.OutputLines
+	if !f.options.FullGoroutineDumps {
+		// -extract-logs only has the output of tests.
+		output = condenseGoroutineDumps(output, "rerun with -full-goroutine-dumps")
+	}
+	for _, line := range output {
 		sb.WriteString(IndentLevel)
 		if f.noColor {
 			sb.WriteString(line)
@@ -355,212 +692,350 @@ func (f *SummaryFormatter) formatBuildIssue(sb *strings.Builder, pkg *results.Pa
 	}
 }
 
-func (f *SummaryFormatter) formatPackageSummary(sb *strings.Builder, summary *Summary) {
-	if len(summary.Packages) == 0 {
+// formatRegressions renders the DURATION REGRESSIONS section listing tests
+// that got slower than the configured baseline.
+func (f *SummaryFormatter) formatRegressions(sb *strings.Builder, summary *Summary) {
+	regressions := summary.Regressions(f.options)
+	if len(regressions) == 0 {
 		return
 	}
 
-	type pkgLine struct {
-		statusWord   string
-		name         string
-		extra        string
-		showDuration bool
-		pkg          *results.PackageResult
+	sb.WriteString(f.boldSlow.Render("DURATION REGRESSIONS"))
+	sb.WriteString("\n")
+	for _, r := range regressions {
+		change := fmt.Sprintf("+%s", f.duration(r.Increase()))
+		if r.Baseline > 0 {
+			change += fmt.Sprintf(", +%.0f%%", r.Percent())
+		}
+		fmt.Fprintf(sb, "%s%s %s  %s -> %s %s\n",
+			IndentLevel,
+			r.Package,
+			f.slowStyle.Render(r.Name),
+			f.duration(r.Baseline),
+			f.boldWhite.Render(f.duration(r.Current)),
+			f.dimStyle.Render("("+change+")"),
+		)
 	}
+	sb.WriteString("\n")
+}
 
-	lines := make([]pkgLine, 0, len(summary.Packages))
+// formatSuiteChanges renders the SUITE CHANGES section listing packages
+// whose test count changed markedly since the previous run.
+func (f *SummaryFormatter) formatSuiteChanges(sb *strings.Builder, summary *Summary) {
+	changes := summary.SuiteChanges(f.options)
+	if len(changes) == 0 {
+		return
+	}
 
-	maxStatusLen := 0
-	maxNameExtraLen := 0
-	maxPassedLen := 0
-	maxFailedLen := 0
-	maxSkippedLen := 0
-	maxTotalLen := 0
+	sb.WriteString(f.boldSkip.Render("SUITE CHANGES"))
+	sb.WriteString(f.dimStyle.Render(" (test counts since the previous run)"))
+	sb.WriteString("\n")
+	for _, c := range changes {
+		style := f.passStyle
+		if c.Delta() < 0 {
+			style = f.failStyle
+		}
+		fmt.Fprintf(sb, "%s%s  %d -> %s tests %s\n",
+			IndentLevel,
+			c.Package,
+			c.Previous,
+			style.Render(strconv.Itoa(c.Current)),
+			f.dimStyle.Render(fmt.Sprintf("(%+d, %+.0f%%)", c.Delta(), c.Percent())),
+		)
+	}
+	sb.WriteString("\n")
+}
 
-	maxPassedLen = len(fmt.Sprintf("%d", summary.PassedTests))
-	maxFailedLen = len(fmt.Sprintf("%d", summary.FailedTests))
-	maxSkippedLen = len(fmt.Sprintf("%d", summary.SkippedTests))
-	maxTotalLen = len(fmt.Sprintf("%d", summary.TotalTests))
+// formatSlowSetup renders the SLOW SETUP section, listing packages whose
+// runtime was dominated by setup and teardown outside their tests.
+func (f *SummaryFormatter) formatSlowSetup(sb *strings.Builder, summary *Summary) {
+	overheads := summary.SetupOverheads(f.options.SetupMinimum)
+	if len(overheads) == 0 {
+		return
+	}
 
-	for _, pkg := range summary.Packages {
-		pl := pkgLine{pkg: pkg}
+	sb.WriteString(f.boldSlow.Render("SLOW SETUP"))
+	sb.WriteString(f.dimStyle.Render(" (time outside tests, e.g. in TestMain)"))
+	sb.WriteString("\n")
+	for _, o := range overheads {
+		fmt.Fprintf(sb, "%s%s  setup %s, teardown %s %s\n",
+			IndentLevel,
+			o.Package.Name,
+			f.slowStyle.Render(f.duration(o.Package.SetupTime)),
+			f.slowStyle.Render(f.duration(o.Package.TeardownTime)),
+			f.dimStyle.Render(fmt.Sprintf("(%.0f%% of %s)", o.Fraction()*100, f.duration(o.Total))),
+		)
+	}
+	sb.WriteString("\n")
+}
+
+// formatPossiblyEmpty renders the POSSIBLY EMPTY TESTS section, listing
+// passing tests that were suspiciously fast and silent.
+func (f *SummaryFormatter) formatPossiblyEmpty(sb *strings.Builder, summary *Summary) {
+	empty := summary.PossiblyEmptyTests(f.options.EmptyTestThreshold)
+	if len(empty) == 0 {
+		return
+	}
+
+	sb.WriteString(f.boldSkip.Render("POSSIBLY EMPTY TESTS"))
+	sb.WriteString(f.dimStyle.Render(fmt.Sprintf(" (passed in under %s with no output)", f.duration(f.options.EmptyTestThreshold))))
+	sb.WriteString("\n")
+	for _, tr := range empty {
+		fmt.Fprintf(sb, "%s%s %s\n", IndentLevel, tr.Package, f.skipStyle.Render(tr.Name))
+	}
+	sb.WriteString("\n")
+}
+
+// formatParallelism renders the PARALLELISM section, when enabled.
+func (f *SummaryFormatter) formatParallelism(sb *strings.Builder, summary *Summary) {
+	if !f.options.IncludeParallelism {
+		return
+	}
+	stats := summary.Parallelism()
+	if len(stats) == 0 {
+		return
+	}
+
+	sb.WriteString(f.boldWhite.Render("PARALLELISM"))
+	sb.WriteString("\n")
+	t := &table{indent: IndentLevel}
+	for _, p := range stats {
+		t.addRow(p.Package.Name, fmt.Sprintf("peak %3d", p.Peak), fmt.Sprintf("avg %5.1f", p.Average), f.slowStyle.Render(p.Sparkline))
+	}
+	t.render(sb)
+	sb.WriteString("\n")
+}
+
+func (f *SummaryFormatter) formatPackageSummary(sb *strings.Builder, summary *Summary) {
+	if len(summary.Packages) == 0 {
+		return
+	}
+
+	// Count columns are sized to fit the run totals, which are at least as
+	// wide as any package's counts.
+	cw := countWidths{
+		passed:  len(strconv.Itoa(summary.PassedTests)),
+		failed:  len(strconv.Itoa(summary.FailedTests)),
+		skipped: len(strconv.Itoa(summary.SkippedTests)),
+		total:   len(strconv.Itoa(summary.TotalTests)),
+	}
+
+	// status, name and extra info, counts, elapsed, replay label
+	t := &table{
+		seps:      []string{"    ", "  ", "  ", " "},
+		align:     []columnAlign{alignLeft, alignLeft, alignLeft, alignRight},
+		ruleWidth: f.width,
+	}
 
+	addPackage := func(pkg *results.PackageResult) {
+		var status string
 		switch {
-		case pkg.FailedBuild != "":
-			pl.statusWord = "FAIL"
-		case pkg.Status == results.StatusFailed:
-			pl.statusWord = "FAIL"
+		case pkg.FailedBuild != "", pkg.Status == results.StatusFailed:
+			status = f.boldFail.Render("FAIL")
 		case pkg.Status == results.StatusSkipped:
-			pl.statusWord = "?"
+			status = f.boldSkip.Render("?")
 		default:
-			pl.statusWord = "ok"
+			// "ok" is rendered without color (just bold) so the summary
+			// isn't a wall of green; FAIL/? still get a color highlight.
+			status = f.boldWhite.Render("ok")
 		}
 
-		pl.name = pkg.Name
+		var extra string
 		if pkg.FailedBuild != "" {
-			pl.extra = "[build failed]"
+			extra = "[build failed]"
 		} else if pkg.SummaryLine != "" {
 			output := expandTabs(pkg.SummaryLine, 8)
 			nameIdx := strings.Index(output, pkg.Name)
 			if nameIdx >= 0 {
-				rest := strings.TrimSpace(output[nameIdx+len(pkg.Name):])
-				if rest != "" {
-					pl.extra = rest
-				}
+				extra = strings.TrimSpace(output[nameIdx+len(pkg.Name):])
 			}
 		}
 
 		// Omit durations for packages that didn't actually run tests.
-		switch pl.extra {
-		case "[build failed]", "[no test files]", "(cached)":
-			pl.showDuration = false
-		default:
-			pl.showDuration = true
+		showDuration := true
+		switch {
+		case pkg.Cached:
+			showDuration = false
+			if !strings.Contains(extra, "(cached)") {
+				extra = strings.TrimSpace("(cached) " + extra)
+			}
+		case extra == "[build failed]", extra == "[no test files]", extra == "(cached)":
+			showDuration = false
 		}
 
-		passedStr := fmt.Sprintf("%d", pkg.Counts.Passed)
-		failedStr := fmt.Sprintf("%d", pkg.Counts.Failed)
-		skippedStr := fmt.Sprintf("%d", pkg.Counts.Skipped)
-		if len(passedStr) > maxPassedLen {
-			maxPassedLen = len(passedStr)
-		}
-		if len(failedStr) > maxFailedLen {
-			maxFailedLen = len(failedStr)
-		}
-		if len(skippedStr) > maxSkippedLen {
-			maxSkippedLen = len(skippedStr)
-		}
-		totalStr := fmt.Sprintf("%d", pkg.Counts.Passed+pkg.Counts.Failed+pkg.Counts.Skipped)
-		if len(totalStr) > maxTotalLen {
-			maxTotalLen = len(totalStr)
+		// Package name+info renders in the terminal's default foreground; the
+		// color-coded status word (FAIL/ok/?) alone signals package status.
+		nameExtra := f.options.PackageNames.Shorten(pkg.Name)
+		if extra != "" {
+			nameExtra += " " + extra
 		}
 
-		if len(pl.statusWord) > maxStatusLen {
-			maxStatusLen = len(pl.statusWord)
+		var counts string
+		if passed, failed, skipped := summary.PackageCounts(pkg); passed > 0 || failed > 0 || skipped > 0 {
+			counts = f.formatCounts(passed, failed, skipped, cw)
 		}
 
-		nameExtra := pl.name
-		if pl.extra != "" {
-			nameExtra += " " + pl.extra
-		}
-		if len(nameExtra) > maxNameExtraLen {
-			maxNameExtraLen = len(nameExtra)
+		var elapsed string
+		if showDuration {
+			elapsed = f.duration(pkg.Elapsed)
 		}
 
-		lines = append(lines, pl)
+		t.addRow(status, nameExtra, counts, elapsed)
 	}
 
-	maxElapsedLen := 0
-	for _, pl := range lines {
-		if pl.showDuration {
-			if el := len(formatDuration(pl.pkg.Elapsed)); el > maxElapsedLen {
-				maxElapsedLen = el
+	pkgs := summary.OrderedPackages(f.options.PackageOrder)
+	switch {
+	case f.options.RollupDepth > 0:
+		for _, group := range GroupByDirectory(pkgs, f.options.RollupDepth, f.options.Modules) {
+			if len(group.Packages) == 1 {
+				addPackage(group.Packages[0])
+				continue
+			}
+			f.addRollup(t, summary, group, cw)
+			if group.Failed() {
+				for _, pkg := range group.Packages {
+					addPackage(pkg)
+				}
 			}
 		}
+	case len(f.options.Modules) > 1:
+		for _, group := range GroupByModule(pkgs, f.options.Modules) {
+			for _, pkg := range group.Packages {
+				addPackage(pkg)
+			}
+			f.addModuleSubtotal(t, summary, group, cw)
+		}
+	default:
+		for _, pkg := range pkgs {
+			addPackage(pkg)
+		}
 	}
-	if el := len(formatDuration(summary.TotalTime)); el > maxElapsedLen {
-		maxElapsedLen = el
-	}
 
-	// (✓NN ✗NN ∅NN) NN
-	// parens=2, 3 symbols (multi-byte but 1 display col each), 2 inner spaces, 1 outer space
-	countsWidth := 2 + 3 + 2 + maxPassedLen + maxFailedLen + maxSkippedLen + 1 + maxTotalLen
-	lineWidth := maxStatusLen + 4 + maxNameExtraLen + 2 + countsWidth + 2 + maxElapsedLen
-	separatorLen := lineWidth
-	if f.width > separatorLen {
-		separatorLen = f.width
+	t.addRule()
+
+	pkgLabel := fmt.Sprintf("(%d packages)", summary.PackageCount)
+	if summary.CachedPackages > 0 {
+		pkgLabel = fmt.Sprintf("(%d packages, %d cached)", summary.PackageCount, summary.CachedPackages)
 	}
+	var replay string
+	if label := replayLabel(summary.Run); label != "" {
+		replay = f.dimStyle.Render(label)
+	}
+	if summary.Run != nil && summary.Run.ApproximateDurations() {
+		replay = strings.TrimSpace(replay + " " + f.dimStyle.Render("(durations approximate)"))
+	}
+	t.addSpanRow(2, pkgLabel,
+		f.formatCounts(summary.PassedTests, summary.FailedTests, summary.SkippedTests, cw),
+		f.duration(summary.TotalTime),
+		replay)
 
-	for _, pl := range lines {
-		var statusStr string
-		switch pl.statusWord {
-		case "FAIL":
-			statusStr = f.boldFail.Render(fmt.Sprintf("%-*s", maxStatusLen, pl.statusWord))
-		case "ok":
-			// "ok" is rendered without color (just bold) so the summary
-			// isn't a wall of green; FAIL/? still get a color highlight.
-			statusStr = f.boldWhite.Render(fmt.Sprintf("%-*s", maxStatusLen, pl.statusWord))
-		case "?":
-			statusStr = f.boldSkip.Render(fmt.Sprintf("%-*s", maxStatusLen, pl.statusWord))
-		}
+	t.render(sb)
 
-		nameExtra := pl.name
-		if pl.extra != "" {
-			nameExtra += " " + pl.extra
+	if len(summary.Categories) > 0 {
+		parts := make([]string, len(summary.Categories))
+		for i, c := range summary.Categories {
+			parts[i] = fmt.Sprintf("%s %d", c.Category, c.Count)
 		}
+		sb.WriteString(f.dimStyle.Render("failure categories: " + strings.Join(parts, ", ")))
+		sb.WriteString("\n")
+	}
 
-		// Package name+info renders in the terminal's default foreground; the
-		// color-coded status word (FAIL/ok/?) alone signals package status.
-		paddedNameExtra := fmt.Sprintf("%-*s", maxNameExtraLen, nameExtra)
-
-		hasCounts := pl.pkg.Counts.Passed > 0 || pl.pkg.Counts.Failed > 0 || pl.pkg.Counts.Skipped > 0
-		countsStr := ""
-		if hasCounts {
-			// Passing test count renders without color; only failures and
-			// skips get a color highlight.
-			passedStr := f.neutralStyle.Render(fmt.Sprintf("%*s", maxPassedLen+1, fmt.Sprintf("%s%d", SymbolPass, pl.pkg.Counts.Passed)))
-
-			failedStr := fmt.Sprintf("%*s", maxFailedLen+1, fmt.Sprintf("%s%d", SymbolFail, pl.pkg.Counts.Failed))
-			if pl.pkg.Counts.Failed > 0 {
-				failedStr = f.failStyle.Render(failedStr)
-			} else {
-				failedStr = f.neutralStyle.Render(failedStr)
-			}
+	if summary.InterruptedTests > 0 {
+		sb.WriteString(f.skipStyle.Render(fmt.Sprintf("%d interrupted", summary.InterruptedTests)))
+		sb.WriteString("\n")
+	}
 
-			skippedStr := fmt.Sprintf("%*s", maxSkippedLen+1, fmt.Sprintf("%s%d", SymbolSkip, pl.pkg.Counts.Skipped))
-			if pl.pkg.Counts.Skipped > 0 {
-				skippedStr = f.skipStyle.Render(skippedStr)
-			} else {
-				skippedStr = f.neutralStyle.Render(skippedStr)
-			}
+	if summary.ShortModeSkips > 0 {
+		sb.WriteString(f.dimStyle.Render(fmt.Sprintf("skips: %d in short mode, %d other",
+			summary.ShortModeSkips, len(summary.Skipped)-summary.ShortModeSkips)))
+		sb.WriteString("\n")
+	}
 
-			total := pl.pkg.Counts.Passed + pl.pkg.Counts.Failed + pl.pkg.Counts.Skipped
-			totalStr := f.neutralStyle.Render(fmt.Sprintf("%*d", maxTotalLen, total))
+	if summary.Run != nil && summary.Run.Resources.PeakRSS > 0 {
+		r := summary.Run.Resources
+		sb.WriteString(f.dimStyle.Render(fmt.Sprintf("peak memory %s, peak CPU %.0f%%", FormatBytes(r.PeakRSS), r.PeakCPU)))
+		sb.WriteString("\n")
+	}
+}
 
-			countsStr = fmt.Sprintf("(%s %s %s) %s", passedStr, failedStr, skippedStr, totalStr)
-		}
+// addModuleSubtotal adds a row totalling the tests of a module's packages
+// to the package table.
+func (f *SummaryFormatter) addModuleSubtotal(t *table, summary *Summary, group ModuleGroup, cw countWidths) {
+	name := group.Module
+	if name == "" {
+		name = "(other packages)"
+	}
+	label := fmt.Sprintf("%s (%d packages)", name, len(group.Packages))
+	if len(group.Packages) == 1 {
+		label = name + " (1 package)"
+	}
 
-		elapsed := ""
-		if pl.showDuration {
-			elapsed = fmt.Sprintf("  %*s", maxElapsedLen, formatDuration(pl.pkg.Elapsed))
-		}
+	passed, failed, skipped := summary.GroupCounts(group)
+	var elapsed string
+	if d := group.Elapsed(); d > 0 {
+		elapsed = f.duration(d)
+	}
+	t.addRow("", f.dimStyle.Render(label), f.formatCounts(passed, failed, skipped, cw), elapsed)
+}
 
-		if countsStr != "" {
-			fmt.Fprintf(sb, "%s    %s  %s%s\n",
-				statusStr, paddedNameExtra, countsStr, elapsed)
-		} else {
-			emptyCounts := strings.Repeat(" ", countsWidth)
-			fmt.Fprintf(sb, "%s    %s  %s%s\n",
-				statusStr, paddedNameExtra, emptyCounts, elapsed)
-		}
+// addRollup adds a row totalling the tests of a directory's packages to the
+// package table, in place of their own rows.
+func (f *SummaryFormatter) addRollup(t *table, summary *Summary, group ModuleGroup, cw countWidths) {
+	status := f.boldWhite.Render("ok")
+	if group.Failed() {
+		status = f.boldFail.Render("FAIL")
 	}
+	label := fmt.Sprintf("%s/... (%d packages)", f.options.PackageNames.Shorten(group.Module), len(group.Packages))
 
-	sb.WriteString(strings.Repeat("-", separatorLen))
-	sb.WriteString("\n")
+	passed, failed, skipped := summary.GroupCounts(group)
+	var elapsed string
+	if d := group.Elapsed(); d > 0 {
+		elapsed = f.duration(d)
+	}
+	t.addRow(status, label, f.formatCounts(passed, failed, skipped, cw), elapsed)
+}
 
-	pkgLabel := fmt.Sprintf("(%d packages)", summary.PackageCount)
+// countWidths are the digit widths of the count columns in the package
+// summary.
+type countWidths struct {
+	passed, failed, skipped, total int
+}
 
-	// Total passing test count renders without color.
-	passedStr := f.neutralStyle.Render(fmt.Sprintf("%*s", maxPassedLen+1, fmt.Sprintf("%s%d", SymbolPass, summary.PassedTests)))
+// formatCounts renders test counts as "(✓NN ✗NN ∅NN) NN", padding each count
+// so they line up across packages.
+func (f *SummaryFormatter) formatCounts(passed, failed, skipped int, cw countWidths) string {
+	// Passing test count renders without color; only failures and skips get
+	// a color highlight.
+	passedStr := f.neutralStyle.Render(padCell(f.options.Symbols.Pass()+strconv.Itoa(passed), cw.passed+1, alignRight))
 
-	failedStr := fmt.Sprintf("%*s", maxFailedLen+1, fmt.Sprintf("%s%d", SymbolFail, summary.FailedTests))
-	if summary.FailedTests > 0 {
+	failedStr := padCell(f.options.Symbols.Fail()+strconv.Itoa(failed), cw.failed+1, alignRight)
+	if failed > 0 {
 		failedStr = f.failStyle.Render(failedStr)
 	} else {
 		failedStr = f.neutralStyle.Render(failedStr)
 	}
 
-	skippedStr := fmt.Sprintf("%*s", maxSkippedLen+1, fmt.Sprintf("%s%d", SymbolSkip, summary.SkippedTests))
-	if summary.SkippedTests > 0 {
+	skippedStr := padCell(f.options.Symbols.Skip()+strconv.Itoa(skipped), cw.skipped+1, alignRight)
+	if skipped > 0 {
 		skippedStr = f.skipStyle.Render(skippedStr)
 	} else {
 		skippedStr = f.neutralStyle.Render(skippedStr)
 	}
 
-	totalStr := f.neutralStyle.Render(fmt.Sprintf("%*d", maxTotalLen, summary.TotalTests))
-	countsStr := fmt.Sprintf("(%s %s %s) %s", passedStr, failedStr, skippedStr, totalStr)
-	elapsed := fmt.Sprintf("%*s", maxElapsedLen, formatDuration(summary.TotalTime))
+	totalStr := f.neutralStyle.Render(padCell(strconv.Itoa(passed+failed+skipped), cw.total, alignRight))
+	return fmt.Sprintf("(%s %s %s) %s", passedStr, failedStr, skippedStr, totalStr)
+}
 
-	labelWidth := maxStatusLen + 4 + maxNameExtraLen
-	fmt.Fprintf(sb, "%-*s  %s  %s\n", labelWidth, pkgLabel, countsStr, elapsed)
+// replayLabel annotates the total time of a replayed run with the replay
+// speed, e.g. "(replayed at 2x)", since the time shown is that of the
+// original run rather than how long the replay took.
+func replayLabel(run *results.Run) string {
+	if run == nil || !run.Replay {
+		return ""
+	}
+	if run.ReplayRate <= 0 {
+		return "(replayed instantly)"
+	}
+	speed := math.Round(100/run.ReplayRate) / 100
+	return fmt.Sprintf("(replayed at %sx)", strconv.FormatFloat(speed, 'f', -1, 64))
 }
@@ -0,0 +1,180 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// SummaryJSON is the machine-readable form of a Summary, written by
+// -summary-json. It is also the format read back as a duration baseline.
+type SummaryJSON struct {
+	RunID        string            `json:"run_id,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Modes        []string          `json:"modes,omitempty"` // go test modes, e.g. "race"
+	StartTime    time.Time         `json:"start_time,omitzero"`
+	Status       string            `json:"status"`
+	Tests        int               `json:"tests"`
+	Passed       int               `json:"passed"`
+	Failed       int               `json:"failed"`
+	Skipped      int               `json:"skipped"`
+	Interrupted  int               `json:"interrupted,omitempty"`           // Tests still running when the run ended; not in Tests
+	Elapsed      float64           `json:"elapsed"`                         // seconds
+	Approximate  bool              `json:"durations_approximate,omitempty"` // Events had no timestamps; see results.Run.ApproximateDurations
+	Malformed    int               `json:"malformed_lines,omitempty"`
+	Inconsistent []string          `json:"inconsistencies,omitempty"` // Packages whose counts disagree with go test
+	Anomalies    []string          `json:"anomalies,omitempty"`       // Events out of order, found with -strict
+	Suspicious   []string          `json:"suspicious,omitempty"`      // Tests which ran in several shards with differing results; see MergeSummaryJSON
+	Repro        *ReproJSON        `json:"repro,omitempty"`           // How go test was run, in exec mode; see 'tang repro'
+	Packages     []PackageJSON     `json:"packages"`
+	Results      []TestResultJSON  `json:"results"`
+}
+
+// PackageJSON describes a single package in a SummaryJSON.
+type PackageJSON struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Elapsed  float64 `json:"elapsed"` // seconds
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Setup    float64 `json:"setup,omitempty"`    // seconds before the first test
+	Teardown float64 `json:"teardown,omitempty"` // seconds after the last test
+}
+
+// ReproJSON is how a run's go test was run, for rerunning it identically;
+// see results.Reproducibility. The packages run are the SummaryJSON's.
+type ReproJSON struct {
+	GoVersion      string            `json:"go_version,omitempty"`
+	Dir            string            `json:"dir,omitempty"`
+	Args           []string          `json:"args"`
+	ShuffleSeeds   map[string]string `json:"shuffle_seeds,omitempty"` // By package
+	Env            map[string]string `json:"env,omitempty"`
+	EnvFingerprint string            `json:"env_fingerprint,omitempty"`
+}
+
+// TestResultJSON describes a single test execution in a SummaryJSON.
+type TestResultJSON struct {
+	Package    string   `json:"package"`
+	Name       string   `json:"name"`
+	Iteration  int      `json:"iteration"`         // 1-based; >1 with -count=N or retries
+	Attempt    int      `json:"attempt,omitempty"` // Package attempt, set for retries merged with -merge-retries
+	Status     string   `json:"status"`
+	Elapsed    float64  `json:"elapsed"`              // seconds
+	Categories []string `json:"categories,omitempty"` // Failure categories, or "short-mode" for skips
+	Artifacts  []string `json:"artifacts,omitempty"`  // Files attached with results.ArtifactMarker lines
+	Dropped    int      `json:"dropped,omitempty"`    // Lines of output dropped by -max-line-rate
+}
+
+// NewSummaryJSON converts a Summary into its JSON form. Packages and tests
+// are listed in chronological start order.
+func NewSummaryJSON(summary *Summary) *SummaryJSON {
+	sj := &SummaryJSON{
+		Tests:       summary.TotalTests,
+		Passed:      summary.PassedTests,
+		Failed:      summary.FailedTests,
+		Skipped:     summary.SkippedTests,
+		Elapsed:     summary.TotalTime.Seconds(),
+		Interrupted: summary.InterruptedTests,
+		Packages:    make([]PackageJSON, 0, len(summary.Packages)),
+		Results:     make([]TestResultJSON, 0),
+	}
+	if summary.Run != nil {
+		sj.RunID = summary.Run.UID
+		sj.Approximate = summary.Run.ApproximateDurations()
+		sj.Labels = summary.Run.Labels.Map()
+		for _, mode := range summary.Run.Modes {
+			sj.Modes = append(sj.Modes, string(mode))
+		}
+		sj.StartTime = summary.Run.FirstEventTime
+		if r := summary.Run.Repro; r != nil {
+			sj.Repro = &ReproJSON{
+				GoVersion:      r.GoVersion,
+				Dir:            r.Dir,
+				Args:           r.Args,
+				ShuffleSeeds:   summary.Run.ShuffleSeeds(),
+				Env:            r.Env,
+				EnvFingerprint: r.EnvFingerprint,
+			}
+		}
+		sj.Status = summary.Run.Status.String()
+		sj.Malformed = summary.Run.MalformedLines
+		for _, inc := range summary.Run.Inconsistencies {
+			sj.Inconsistent = append(sj.Inconsistent, inc.String())
+		}
+		for _, a := range summary.Run.Anomalies {
+			sj.Anomalies = append(sj.Anomalies, a.String())
+		}
+	}
+
+	categories := summary.categoriesByExecution()
+	for _, pkg := range summary.Packages {
+		passed, failed, skipped := summary.PackageCounts(pkg)
+		sj.Packages = append(sj.Packages, PackageJSON{
+			Name:     pkg.Name,
+			Status:   pkg.Status.String(),
+			Elapsed:  pkg.Elapsed.Seconds(),
+			Passed:   passed,
+			Failed:   failed,
+			Skipped:  skipped,
+			Setup:    pkg.SetupTime.Seconds(),
+			Teardown: pkg.TeardownTime.Seconds(),
+		})
+
+		if summary.Run == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := summary.Run.TestResults[pkg.Name+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			retried := tr.Attempts() > 1
+			for i, exec := range tr.Executions {
+				if exec.Status == results.StatusRunning || exec.Status == results.StatusPaused {
+					continue
+				}
+				result := TestResultJSON{
+					Package:    pkg.Name,
+					Name:       tr.Name,
+					Iteration:  i + 1,
+					Status:     exec.Status.String(),
+					Elapsed:    exec.Elapsed.Seconds(),
+					Categories: categories[exec],
+					Artifacts:  exec.Artifacts,
+					Dropped:    exec.Dropped,
+				}
+				if retried {
+					result.Attempt = exec.Attempt
+				}
+				sj.Results = append(sj.Results, result)
+			}
+		}
+	}
+
+	return sj
+}
+
+// WriteSummaryJSON writes the summary to w as indented JSON.
+func WriteSummaryJSON(w io.Writer, summary *Summary) error {
+	return EncodeSummaryJSON(w, NewSummaryJSON(summary))
+}
+
+// EncodeSummaryJSON writes sj to w as indented JSON, as WriteSummaryJSON
+// does.
+func EncodeSummaryJSON(w io.Writer, sj *SummaryJSON) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sj)
+}
+
+// ReadSummaryJSON decodes a summary previously written by WriteSummaryJSON.
+func ReadSummaryJSON(r io.Reader) (*SummaryJSON, error) {
+	var sj SummaryJSON
+	if err := json.NewDecoder(r).Decode(&sj); err != nil {
+		return nil, err
+	}
+	return &sj, nil
+}
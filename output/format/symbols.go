@@ -0,0 +1,111 @@
+package format
+
+import "strings"
+
+// SymbolSet is the set of symbols marking test results and states in the
+// summary and the TUI. The zero SymbolSet is SymbolsUnicode.
+type SymbolSet string
+
+const (
+	SymbolsUnicode SymbolSet = ""      // ✓ ✗ ∅ ▶ ⏸ ⏱ ◆ █
+	SymbolsASCII   SymbolSet = "ascii" // + x - > | @ * #, for terminals and log viewers without the Unicode ones
+)
+
+// Pass returns the symbol of a passed test.
+func (s SymbolSet) Pass() string {
+	if s == SymbolsASCII {
+		return "+"
+	}
+	return SymbolPass
+}
+
+// Fail returns the symbol of a failed test.
+func (s SymbolSet) Fail() string {
+	if s == SymbolsASCII {
+		return "x"
+	}
+	return SymbolFail
+}
+
+// Skip returns the symbol of a skipped test.
+func (s SymbolSet) Skip() string {
+	if s == SymbolsASCII {
+		return "-"
+	}
+	return SymbolSkip
+}
+
+// Running returns the symbol of a running test.
+func (s SymbolSet) Running() string {
+	if s == SymbolsASCII {
+		return ">"
+	}
+	return "▶"
+}
+
+// Paused returns the symbol of a paused parallel test.
+func (s SymbolSet) Paused() string {
+	if s == SymbolsASCII {
+		return "|"
+	}
+	return "⏸"
+}
+
+// Timer returns the symbol of a long-running test's elapsed time.
+func (s SymbolSet) Timer() string {
+	if s == SymbolsASCII {
+		return "@"
+	}
+	return "⏱"
+}
+
+// Annotation returns the symbol of a test annotation.
+func (s SymbolSet) Annotation() string {
+	if s == SymbolsASCII {
+		return "*"
+	}
+	return "◆"
+}
+
+// Bar returns the block the timeline's bars are drawn with.
+func (s SymbolSet) Bar() string {
+	if s == SymbolsASCII {
+		return "#"
+	}
+	return "█"
+}
+
+// Arrow returns the arrow joining a parent test to the failed subtest its
+// failure was collapsed into (see TestExecutionEntry.DisplayName).
+func (s SymbolSet) Arrow() string {
+	if s == SymbolsASCII {
+		return "->"
+	}
+	return "→"
+}
+
+// DetectSymbols returns the symbol set the terminal described by the
+// environment, getenv, can be expected to display: SymbolsASCII if TERM is
+// "dumb" or "linux", the Linux console, whose font lacks most of the
+// Unicode symbols, or if the locale, from LC_ALL, LC_CTYPE or LANG in that
+// order, isn't UTF-8. Otherwise, including when no locale is set, as is
+// common in CI, it returns SymbolsUnicode.
+func DetectSymbols(getenv func(string) string) SymbolSet {
+	switch getenv("TERM") {
+	case "dumb", "linux":
+		return SymbolsASCII
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := getenv(name)
+		if locale == "" {
+			continue
+		}
+		// e.g. "en_US.UTF-8", "C.utf8"; "C" and "POSIX" are ASCII.
+		charset := strings.ToLower(locale)
+		if strings.Contains(charset, "utf-8") || strings.Contains(charset, "utf8") {
+			return SymbolsUnicode
+		}
+		return SymbolsASCII
+	}
+	return SymbolsUnicode
+}
@@ -0,0 +1,28 @@
+package format
+
+import "testing"
+
+func TestDetectSymbols(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want SymbolSet
+	}{
+		{"no locale", nil, SymbolsUnicode},
+		{"UTF-8 locale", map[string]string{"LANG": "en_US.UTF-8"}, SymbolsUnicode},
+		{"utf8 locale", map[string]string{"LANG": "C.utf8"}, SymbolsUnicode},
+		{"C locale", map[string]string{"LANG": "C"}, SymbolsASCII},
+		{"Latin-1 locale", map[string]string{"LANG": "de_DE.ISO-8859-1"}, SymbolsASCII},
+		{"LC_ALL wins", map[string]string{"LC_ALL": "POSIX", "LANG": "en_US.UTF-8"}, SymbolsASCII},
+		{"LC_CTYPE before LANG", map[string]string{"LC_CTYPE": "en_US.UTF-8", "LANG": "C"}, SymbolsUnicode},
+		{"dumb terminal", map[string]string{"TERM": "dumb", "LANG": "en_US.UTF-8"}, SymbolsASCII},
+		{"Linux console", map[string]string{"TERM": "linux"}, SymbolsASCII},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSymbols(func(name string) string { return tt.env[name] }); got != tt.want {
+				t.Errorf("DetectSymbols() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
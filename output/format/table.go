@@ -0,0 +1,156 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// columnAlign is the alignment of a table column.
+type columnAlign int
+
+const (
+	alignLeft columnAlign = iota
+	alignRight
+)
+
+// table renders rows of cells as aligned columns. Column widths are
+// measured in terminal cells with ANSI escape codes ignored, so cells may
+// be styled and contain wide or multi-byte characters.
+type table struct {
+	indent    string        // Written before every row
+	seps      []string      // Separator before column i+1; defaults to two spaces
+	align     []columnAlign // Per column; defaults to alignLeft
+	ruleWidth int           // Minimum width of rule rows
+	rows      []tableRow
+}
+
+type tableRow struct {
+	cells []string
+	span  int  // Columns spanned by the first cell; 0 or 1 for none
+	rule  bool // A horizontal rule across the table
+}
+
+// addRow appends a row. Rows may have fewer cells than others; missing
+// cells are empty.
+func (t *table) addRow(cells ...string) {
+	t.rows = append(t.rows, tableRow{cells: cells})
+}
+
+// addSpanRow appends a row whose first cell spans the first span columns,
+// e.g. a totals label. The spanning cell doesn't affect column widths.
+func (t *table) addSpanRow(span int, cells ...string) {
+	t.rows = append(t.rows, tableRow{cells: cells, span: span})
+}
+
+// addRule appends a horizontal rule as wide as the table, or ruleWidth if
+// that is wider.
+func (t *table) addRule() {
+	t.rows = append(t.rows, tableRow{rule: true})
+}
+
+// column returns the table column of a row's i'th cell.
+func (r tableRow) column(i int) int {
+	if i > 0 && r.span > 1 {
+		return i + r.span - 1
+	}
+	return i
+}
+
+// widths returns the display width of each column.
+func (t *table) widths() []int {
+	var widths []int
+	for _, row := range t.rows {
+		for i, cell := range row.cells {
+			col := row.column(i)
+			for col >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if i == 0 && row.span > 1 {
+				continue
+			}
+			widths[col] = max(widths[col], ansi.StringWidth(cell))
+		}
+	}
+	return widths
+}
+
+// width returns the display width of the whole table, excluding the indent.
+func (t *table) width() int {
+	total := 0
+	for i, w := range t.widths() {
+		if i > 0 {
+			total += ansi.StringWidth(t.sep(i))
+		}
+		total += w
+	}
+	return total
+}
+
+// sep returns the separator written before column i.
+func (t *table) sep(i int) string {
+	if i-1 < len(t.seps) {
+		return t.seps[i-1]
+	}
+	return "  "
+}
+
+// render writes the table to sb, one line per row. Trailing empty cells are
+// omitted, and the last cell of a row is not padded unless right-aligned,
+// so lines carry no trailing whitespace.
+func (t *table) render(sb *strings.Builder) {
+	widths := t.widths()
+	for _, row := range t.rows {
+		sb.WriteString(t.indent)
+		if row.rule {
+			sb.WriteString(strings.Repeat("-", max(t.width(), t.ruleWidth)))
+			sb.WriteString("\n")
+			continue
+		}
+
+		last := len(row.cells) - 1
+		for last >= 0 && row.cells[last] == "" {
+			last--
+		}
+		for i := 0; i <= last; i++ {
+			col := row.column(i)
+			if i > 0 {
+				sb.WriteString(t.sep(col))
+			}
+			width := widths[col]
+			if i == 0 && row.span > 1 {
+				// The spanning cell covers its columns and the separators
+				// between them.
+				width = 0
+				for c := 0; c < row.span; c++ {
+					if c > 0 {
+						width += ansi.StringWidth(t.sep(c))
+					}
+					width += widths[c]
+				}
+			}
+			align := alignLeft
+			if col < len(t.align) {
+				align = t.align[col]
+			}
+			if i == last && align == alignLeft {
+				sb.WriteString(row.cells[i])
+			} else {
+				sb.WriteString(padCell(row.cells[i], width, align))
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// padCell pads s with spaces to width display cells.
+func padCell(s string, width int, align columnAlign) string {
+	pad := width - ansi.StringWidth(s)
+	if pad <= 0 {
+		return s
+	}
+	if align == alignRight {
+		return strings.Repeat(" ", pad) + s
+	}
+	return s + strings.Repeat(" ", pad)
+}
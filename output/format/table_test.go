@@ -0,0 +1,48 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTableAlignsStyledAndWideCells(t *testing.T) {
+	bold := lipgloss.NewStyle().Bold(true)
+	tbl := &table{align: []columnAlign{alignLeft, alignLeft, alignRight}}
+	tbl.addRow(bold.Render("FAIL"), "…/storage/sql", "1.5s")
+	tbl.addRow(bold.Render("ok"), "example.com/pkg", "12.0s")
+	tbl.addRow("?", "example.com/empty")
+
+	var sb strings.Builder
+	tbl.render(&sb)
+
+	// Measure the rendered text with escape codes removed.
+	plain := ansi.Strip(sb.String())
+	expected := "FAIL  …/storage/sql       1.5s\n" +
+		"ok    example.com/pkg    12.0s\n" +
+		"?     example.com/empty\n"
+	if plain != expected {
+		t.Errorf("Unexpected table.\nExpected:\n%q\nGot:\n%q", expected, plain)
+	}
+}
+
+func TestTableSpanAndRuleRows(t *testing.T) {
+	tbl := &table{seps: []string{"    "}, ruleWidth: 10}
+	tbl.addRow("ok", "pkg/a", "(✓1)")
+	tbl.addRow("FAIL", "pkg/bb", "(✓2)")
+	tbl.addRule()
+	tbl.addSpanRow(2, "(2 packages)", "(✓3)")
+
+	var sb strings.Builder
+	tbl.render(&sb)
+
+	expected := "ok      pkg/a   (✓1)\n" +
+		"FAIL    pkg/bb  (✓2)\n" +
+		"--------------------\n" +
+		"(2 packages)    (✓3)\n"
+	if sb.String() != expected {
+		t.Errorf("Unexpected table.\nExpected:\n%q\nGot:\n%q", expected, sb.String())
+	}
+}
@@ -0,0 +1,192 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// TemplateData is the data a -template report is rendered with. It is a
+// stable model for user templates: fields may be added but are never
+// renamed or removed. Packages and tests are in chronological start order.
+type TemplateData struct {
+	RunID     string            // Unique run ID
+	Labels    map[string]string // Labels from -label
+	Modes     []string          // Badges of the go test modes the run was under, e.g. "RACE"
+	Status    string            // Run status: "passed", "failed" or "interrupted"
+	StartTime time.Time         // When the run started; zero if events had no timestamps
+	Elapsed   time.Duration     // Total run time
+	Counts    TemplateCounts
+	Packages  []*TemplatePackage
+	Failures  []*TemplateTest // Failed test executions
+	Skips     []*TemplateTest // Skipped test executions
+	SlowTests []*TemplateTest // Executions at or over the slow threshold, slowest first
+}
+
+// TemplateCounts are test execution counts.
+type TemplateCounts struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// TemplatePackage is a package in TemplateData.
+type TemplatePackage struct {
+	Name        string
+	Status      string // "passed", "failed", "skipped", "build failed" or "interrupted"
+	Elapsed     time.Duration
+	Cached      bool
+	Counts      TemplateCounts
+	SummaryLine string   // go test's result line, e.g. "ok  pkg  0.30s"
+	Output      []string // Package output outside any test, e.g. panics
+	BuildErrors []string // Compiler output, for packages that failed to build
+}
+
+// TemplateTest is a single test execution in TemplateData.
+type TemplateTest struct {
+	Package    string
+	Name       string // Full name, e.g. "TestA/sub"
+	Iteration  int    // 1-based; >1 only with -count=N
+	Status     string // "passed", "failed" or "skipped"
+	Elapsed    time.Duration
+	Output     []string // Captured output, excluding the === and --- lines
+	SkipReason string   // Message passed to t.Skip, for skipped tests
+	Categories []string // Failure categories, e.g. "assertion" (see -failure-rules), or "short-mode" for skips
+}
+
+// templateFuncs are the functions available to report templates.
+var templateFuncs = template.FuncMap{
+	"duration": formatDuration,  // Format a time.Duration like the built-in report, in its -duration-style
+	"join":     strings.Join,    // Join a []string with a separator
+	"repeat":   strings.Repeat,  // Repeat a string n times
+	"upper":    strings.ToUpper, // Upper-case a string
+}
+
+// ParseTemplateFile parses a report template from path, with tang's
+// template functions available. The template is test-executed with empty
+// data so references to unknown fields are reported up front.
+func ParseTemplateFile(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, &TemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// NewTemplateData converts a Summary into the data model for report
+// templates.
+func NewTemplateData(summary *Summary) *TemplateData {
+	data := &TemplateData{
+		Elapsed: summary.TotalTime,
+		Counts: TemplateCounts{
+			Total:   summary.TotalTests,
+			Passed:  summary.PassedTests,
+			Failed:  summary.FailedTests,
+			Skipped: summary.SkippedTests,
+		},
+	}
+	if summary.Run != nil {
+		data.RunID = summary.Run.UID
+		data.Labels = summary.Run.Labels.Map()
+		data.Modes = summary.Run.Badges()
+		data.Status = summary.Run.Status.String()
+		data.StartTime = summary.Run.FirstEventTime
+	}
+
+	categories := summary.categoriesByExecution()
+	for _, pkg := range summary.Packages {
+		passed, failed, skipped := summary.PackageCounts(pkg)
+		tp := &TemplatePackage{
+			Name:        pkg.Name,
+			Status:      pkg.Status.String(),
+			Elapsed:     pkg.Elapsed,
+			Cached:      pkg.Cached,
+			SummaryLine: pkg.SummaryLine,
+			Output:      pkg.OutputLines,
+			Counts: TemplateCounts{
+				Total:   passed + failed + skipped,
+				Passed:  passed,
+				Failed:  failed,
+				Skipped: skipped,
+			},
+		}
+		if pkg.FailedBuild != "" && summary.Run != nil {
+			for _, be := range summary.Run.GetBuildErrors(pkg.FailedBuild) {
+				if be.Output != "" {
+					tp.BuildErrors = append(tp.BuildErrors, strings.TrimRight(be.Output, "\n"))
+				}
+			}
+		}
+		data.Packages = append(data.Packages, tp)
+
+		if summary.Run == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := summary.Run.TestResults[pkg.Name+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			for i, exec := range tr.Executions {
+				tt := newTemplateTest(tr, i+1, exec)
+				switch exec.Status {
+				case results.StatusFailed:
+					tt.Categories = categories[exec]
+					data.Failures = append(data.Failures, tt)
+				case results.StatusSkipped:
+					tt.Categories = categories[exec]
+					data.Skips = append(data.Skips, tt)
+				}
+			}
+		}
+	}
+
+	for _, entry := range summary.SlowTests {
+		data.SlowTests = append(data.SlowTests, newTemplateTest(entry.TestResult, entry.Iteration, entry.TestExecution))
+	}
+	return data
+}
+
+func newTemplateTest(tr *results.TestResult, iteration int, exec *results.TestExecution) *TemplateTest {
+	return &TemplateTest{
+		Package:    tr.Package,
+		Name:       tr.Name,
+		Iteration:  iteration,
+		Status:     exec.Status.String(),
+		Elapsed:    exec.Elapsed,
+		Output:     exec.Output,
+		SkipReason: exec.SkipReason,
+	}
+}
+
+// formatTemplate renders the summary with the user's report template in
+// place of the built-in report. Execution errors are reported inline, since
+// the template was already checked by ParseTemplateFile.
+func (f *SummaryFormatter) formatTemplate(summary *Summary) string {
+	var sb strings.Builder
+	tmpl := f.options.Template
+	if f.options.DurationStyle != DurationStyleDefault {
+		// The duration function follows the report's duration style.
+		if clone, err := tmpl.Clone(); err == nil {
+			tmpl = clone.Funcs(template.FuncMap{"duration": f.options.DurationStyle.Format})
+		}
+	}
+	if err := tmpl.Execute(&sb, NewTemplateData(summary)); err != nil {
+		fmt.Fprintf(&sb, "\ntang: error rendering report template: %v\n", err)
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func writeTemplate(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTemplateFile(t *testing.T) {
+	if _, err := ParseTemplateFile(writeTemplate(t, "{{.Status}} {{duration .Elapsed}}")); err != nil {
+		t.Errorf("Expected valid template to parse, got %v", err)
+	}
+	if _, err := ParseTemplateFile(writeTemplate(t, "{{.Status")); err == nil {
+		t.Error("Expected a syntax error")
+	}
+	if _, err := ParseTemplateFile(writeTemplate(t, "{{.NoSuchField}}")); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+	if _, err := ParseTemplateFile(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestSummaryFormatterTemplate(t *testing.T) {
+	run := results.NewRun(1)
+	run.Status = results.StatusFailed
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, Elapsed: 2 * time.Second}
+	pkg.Counts.Passed = 1
+	pkg.Counts.Failed = 1
+	for _, tc := range []struct {
+		name   string
+		status results.Status
+		output []string
+	}{
+		{"TestPass", results.StatusPassed, nil},
+		{"TestFail", results.StatusFailed, []string{"    a_test.go:5: want 1", "    a_test.go:6: got 2"}},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = tc.status
+		tr.Latest().Output = tc.output
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+
+	tmpl, err := ParseTemplateFile(writeTemplate(t,
+		`{{.Status | upper}} {{.Counts.Passed}}/{{.Counts.Total}}`+
+			`{{range .Packages}} {{.Name}}={{.Status}}{{end}}`+
+			`{{range .Failures}} {{.Name}}: {{join .Output ";"}}{{end}}`))
+	if err != nil {
+		t.Fatalf("ParseTemplateFile: %v", err)
+	}
+
+	out := NewSummaryFormatter(80, true, NewSummaryOptions(WithTemplate(tmpl))).Format(ComputeSummary(run))
+	want := "FAILED 1/2 pkg1=failed TestFail:     a_test.go:5: want 1;    a_test.go:6: got 2"
+	if strings.TrimSpace(out) != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
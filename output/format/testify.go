@@ -0,0 +1,165 @@
+package format
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// testifyFieldRE matches a field of a testify assertion failure, e.g.
+	// "        \tError:      \tNot equal: ", capturing the indentation,
+	// label and value.
+	testifyFieldRE = regexp.MustCompile(`^(\s*)\t([A-Z][A-Za-z ]*):\s*\t(.*)$`)
+
+	// testifyContinuationRE matches a further line of a testify field's
+	// value, e.g. "        \t            \texpected: 1".
+	testifyContinuationRE = regexp.MustCompile(`^(\s*)\t +\t(.*)$`)
+
+	// testifyLocationRE matches the line go test starts a testify assertion
+	// failure with, e.g. "    foo_test.go:12: ", capturing the file and line.
+	testifyLocationRE = regexp.MustCompile(`^\s*([^\s:]+\.go):(\d+):\s*$`)
+)
+
+// compactTestifyFailures reformats the testify assertion failures in lines,
+// e.g.
+//
+//	foo_test.go:12:
+//	    	Error Trace:	/src/foo_test.go:12
+//	    	            	/src/helpers_test.go:30
+//	    	Error:      	Not equal:
+//	    	            	expected: 1
+//	    	            	actual  : 2
+//	    	Test:       	TestFoo
+//	    	Messages:   	wrong count
+//
+// to one line with the error and its message, followed by the rest of the
+// error and the trace without the frame the first line already names:
+//
+//	foo_test.go:12: Not equal: wrong count
+//	    expected: 1
+//	    actual  : 2
+//	    trace: /src/helpers_test.go:30
+//
+// Other lines are returned as they are.
+func compactTestifyFailures(lines []string) []string {
+	var out []string
+	compacted := false
+	for i := 0; i < len(lines); {
+		m := testifyLocationRE.FindStringSubmatch(lines[i])
+		if m == nil || i+1 == len(lines) || testifyLabel(lines[i+1]) != "Error Trace" {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		block, end := compactTestifyFailure(lines, i, m[1], m[2])
+		out = append(out, block...)
+		compacted = true
+		i = end
+	}
+	if !compacted {
+		return lines
+	}
+	return out
+}
+
+// compactTestifyFailure compacts the testify failure whose location line,
+// naming file and line, is lines[start], returning it and the index of the
+// first line after it.
+func compactTestifyFailure(lines []string, start int, file, line string) ([]string, int) {
+	// Collect each field's lines, in order, until a line which is neither
+	// a field nor a continuation of one.
+	type field struct {
+		label  string
+		values []string
+	}
+	var fields []*field
+	indent := ""
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if m := testifyFieldRE.FindStringSubmatch(lines[i]); m != nil {
+			if indent == "" {
+				indent = m[1]
+			}
+			fields = append(fields, &field{label: m[2], values: []string{m[3]}})
+			continue
+		}
+		if m := testifyContinuationRE.FindStringSubmatch(lines[i]); m != nil {
+			fields[len(fields)-1].values = append(fields[len(fields)-1].values, m[2])
+			continue
+		}
+		break
+	}
+
+	var errLines, msgLines, trace, others []string
+	for _, f := range fields {
+		values := trimTrailingBlank(f.values)
+		switch f.label {
+		case "Error":
+			errLines = values
+		case "Messages":
+			msgLines = values
+		case "Test":
+			// The test's name is already in the failure's heading.
+		case "Error Trace":
+			for _, frame := range values {
+				frame = strings.TrimSpace(frame)
+				if frame != "" && frame != file+":"+line && !strings.HasSuffix(frame, string(filepath.Separator)+file+":"+line) {
+					trace = append(trace, frame)
+				}
+			}
+		default:
+			for j, v := range values {
+				if j == 0 {
+					v = strings.ToLower(f.label) + ": " + v
+				}
+				others = append(others, v)
+			}
+		}
+	}
+
+	head := strings.TrimRight(lines[start], " ")
+	summary := ""
+	if len(errLines) > 0 {
+		summary = strings.TrimSpace(errLines[0])
+		errLines = errLines[1:]
+	}
+	if len(msgLines) > 0 {
+		summary = strings.TrimSuffix(summary, ":")
+		if summary != "" {
+			summary += ": "
+		}
+		summary += strings.TrimSpace(msgLines[0])
+		msgLines = msgLines[1:]
+	}
+	if summary != "" {
+		head += " " + summary
+	}
+
+	block := []string{head}
+	for _, group := range [][]string{errLines, msgLines, others} {
+		for _, v := range group {
+			block = append(block, strings.TrimRight(indent+v, " \t"))
+		}
+	}
+	if len(trace) > 0 {
+		block = append(block, indent+"trace: "+strings.Join(trace, ", "))
+	}
+	return block, i
+}
+
+// testifyLabel returns the label of a testify field line, or "".
+func testifyLabel(line string) string {
+	if m := testifyFieldRE.FindStringSubmatch(line); m != nil {
+		return m[2]
+	}
+	return ""
+}
+
+// trimTrailingBlank returns lines without trailing blank lines.
+func trimTrailingBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,68 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompactTestifyFailures(t *testing.T) {
+	lines := []string{
+		"=== RUN   TestEq",
+		"    tf_test.go:16: ",
+		"        \tError Trace:\t/src/tf_test.go:12",
+		"        \t            \t\t\t\t/src/tf_test.go:16",
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: 1",
+		"        \t            \tactual  : 2",
+		"        \tTest:       \tTestEq",
+		"        \tMessages:   \twrong count",
+		"    tf_test.go:18: ",
+		"        \tError Trace:\t/src/tf_test.go:18",
+		"        \tError:      \tReceived unexpected error:",
+		"        \t            \tboom",
+		"        \tTest:       \tTestEq",
+		"--- FAIL: TestEq (0.00s)",
+	}
+	want := []string{
+		"=== RUN   TestEq",
+		"    tf_test.go:16: Not equal: wrong count",
+		"        expected: 1",
+		"        actual  : 2",
+		"        trace: /src/tf_test.go:12",
+		"    tf_test.go:18: Received unexpected error:",
+		"        boom",
+		"--- FAIL: TestEq (0.00s)",
+	}
+	got := compactTestifyFailures(lines)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Compacted:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The diff after a compacted failure is still highlighted.
+	diff := []string{
+		"    tf_test.go:17: ",
+		"        \tError Trace:\t/src/tf_test.go:17",
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: \"a\"",
+		"        \t            \tactual  : \"b\"",
+		"        \t            \t",
+		"        \t            \tDiff:",
+		"        \t            \t-a",
+		"        \t            \t+b",
+		"        \tTest:       \tTestEq",
+	}
+	got = compactTestifyFailures(diff)
+	if len(got) != 7 || got[0] != "    tf_test.go:17: Not equal:" {
+		t.Fatalf("Compacted diff:\n%s", strings.Join(got, "\n"))
+	}
+	kinds := ClassifyDiffLines(got)
+	if kinds[1] != DiffRemoved || kinds[2] != DiffAdded || kinds[5] != DiffRemoved || kinds[6] != DiffAdded {
+		t.Errorf("Expected the compacted diff highlighted, got %v", kinds)
+	}
+
+	// Output without testify failures is returned as it is.
+	plain := []string{"    foo_test.go:12: ", "        got 1, want 2"}
+	if got := compactTestifyFailures(plain); strings.Join(got, "\n") != strings.Join(plain, "\n") {
+		t.Errorf("Expected plain output unchanged, got:\n%s", strings.Join(got, "\n"))
+	}
+}
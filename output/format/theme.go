@@ -0,0 +1,156 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+// Theme is the palette of the live display and the summary. Each color is
+// an ANSI color number ("1") or a hex color ("#ff5555"); an empty color
+// leaves the terminal's default foreground.
+//
+// The bright colors highlight running packages and tests in the live
+// display, and emphasize headers and counts in the summary.
+type Theme struct {
+	Fail string
+	Pass string
+	Skip string
+	Slow string
+
+	BrightFail string
+	BrightPass string
+	BrightSkip string
+	BrightSlow string
+
+	// Emphasis is used for bold headers and the names of running tests.
+	Emphasis string
+
+	// Muted de-emphasizes secondary text, such as the status of
+	// long-running tests in package headers.
+	Muted string
+}
+
+// DefaultTheme uses the terminal's own ANSI palette, so it follows the
+// terminal's color scheme.
+var DefaultTheme = Theme{
+	Fail: "1", Pass: "2", Skip: "3", Slow: "4",
+	BrightFail: "9", BrightPass: "10", BrightSkip: "11", BrightSlow: "12",
+	Emphasis: "15",
+	Muted:    "8",
+}
+
+// Themes are the built-in themes, by name.
+var Themes = map[string]Theme{
+	"default": DefaultTheme,
+	// dark uses fixed colors that stay legible on dark backgrounds,
+	// whatever the terminal's palette.
+	"dark": {
+		Fail: "#ff6b6b", Pass: "#69db7c", Skip: "#ffd43b", Slow: "#74c0fc",
+		BrightFail: "#ffa8a8", BrightPass: "#b2f2bb", BrightSkip: "#ffec99", BrightSlow: "#a5d8ff",
+		Emphasis: "#ffffff",
+		Muted:    "#868e96",
+	},
+	// light uses darker colors for light backgrounds, and leaves emphasized
+	// text in the default foreground rather than white.
+	"light": {
+		Fail: "#c92a2a", Pass: "#2b8a3e", Skip: "#a35f00", Slow: "#1864ab",
+		BrightFail: "#e03131", BrightPass: "#2f9e44", BrightSkip: "#e67700", BrightSlow: "#1971c2",
+		Muted: "#868e96",
+	},
+	// colorblind uses the Okabe-Ito palette, which stays distinguishable
+	// with the common forms of color blindness: pass and fail are blue and
+	// vermillion rather than green and red.
+	"colorblind": {
+		Fail: "#d55e00", Pass: "#0072b2", Skip: "#e69f00", Slow: "#cc79a7",
+		BrightFail: "#d55e00", BrightPass: "#56b4e9", BrightSkip: "#f0e442", BrightSlow: "#cc79a7",
+		Emphasis: "15",
+		Muted:    "8",
+	},
+}
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeColorRE matches the colors a Theme accepts.
+var themeColorRE = regexp.MustCompile(`^(?:#[0-9a-fA-F]{6}|#[0-9a-fA-F]{3}|[0-9]{1,3})$`)
+
+// ParseTheme returns the built-in theme with the given name, with colors
+// overridden by a comma-separated list of key=color pairs, e.g.
+// "fail=#ff5555,pass=#50fa7b". The keys are fail, pass, skip, slow, their
+// bright- variants (e.g. bright-fail), emphasis and muted. Overriding a
+// color also overrides its bright variant, unless that's given too.
+func ParseTheme(name, colors string) (Theme, error) {
+	if name == "" {
+		name = "default"
+	}
+	t, ok := Themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q: want one of %s", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	fields := map[string]*string{
+		"fail": &t.Fail, "pass": &t.Pass, "skip": &t.Skip, "slow": &t.Slow,
+		"bright-fail": &t.BrightFail, "bright-pass": &t.BrightPass,
+		"bright-skip": &t.BrightSkip, "bright-slow": &t.BrightSlow,
+		"emphasis": &t.Emphasis, "muted": &t.Muted,
+	}
+	set := make(map[string]string)
+	var order []string
+	for _, entry := range strings.Split(colors, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || fields[key] == nil {
+			return Theme{}, fmt.Errorf("invalid theme color %q: want key=color with key one of fail, pass, skip, slow, bright-<key>, emphasis, muted", entry)
+		}
+		if !themeColorRE.MatchString(value) {
+			return Theme{}, fmt.Errorf("invalid theme color %q: want a hex color like #ff5555 or an ANSI color number", entry)
+		}
+		if _, dup := set[key]; !dup {
+			order = append(order, key)
+		}
+		set[key] = value
+	}
+	for _, key := range order {
+		*fields[key] = set[key]
+		if bright := "bright-" + key; fields[bright] != nil {
+			if _, ok := set[bright]; !ok {
+				*fields[bright] = set[key]
+			}
+		}
+	}
+	return t, nil
+}
+
+// orDefault returns DefaultTheme for the zero Theme, e.g. in SummaryOptions
+// not built with NewSummaryOptions.
+func (t Theme) orDefault() Theme {
+	if t == (Theme{}) {
+		return DefaultTheme
+	}
+	return t
+}
+
+// ColorStyle returns a style with the theme color c as its foreground, or
+// no color if c is empty.
+func ColorStyle(c string) lipgloss.Style {
+	s := lipgloss.NewStyle()
+	if c != "" {
+		s = s.Foreground(lipgloss.Color(c))
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme("", "")
+	if err != nil {
+		t.Fatalf("ParseTheme: %v", err)
+	}
+	if theme != DefaultTheme {
+		t.Errorf("Expected the default theme, got %+v", theme)
+	}
+
+	theme, err = ParseTheme("light", "fail=#ff5555, bright-pass=10, pass=#50fa7b")
+	if err != nil {
+		t.Fatalf("ParseTheme: %v", err)
+	}
+	if theme.Fail != "#ff5555" || theme.BrightFail != "#ff5555" {
+		t.Errorf("Expected fail and bright-fail to be overridden, got %q and %q", theme.Fail, theme.BrightFail)
+	}
+	if theme.Pass != "#50fa7b" || theme.BrightPass != "10" {
+		t.Errorf("Expected an explicit bright-pass to be kept, got %q and %q", theme.Pass, theme.BrightPass)
+	}
+	if theme.Skip != Themes["light"].Skip {
+		t.Errorf("Expected skip to keep the light theme's color, got %q", theme.Skip)
+	}
+
+	for _, bad := range [][2]string{
+		{"solarized", ""},
+		{"default", "fail"},
+		{"default", "error=#ff0000"},
+		{"default", "fail=red"},
+		{"default", "fail=#ff00"},
+	} {
+		if _, err := ParseTheme(bad[0], bad[1]); err == nil {
+			t.Errorf("Expected an error for -theme %q -theme-colors %q", bad[0], bad[1])
+		}
+	}
+}
+
+func TestSummaryFormatterTheme(t *testing.T) {
+	pkg := &results.PackageResult{
+		Name:    "example.com/app",
+		Status:  results.StatusFailed,
+		Elapsed: time.Second,
+	}
+	pkg.Counts.Failed = 1
+
+	run := results.NewRun(1)
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	summary := ComputeSummary(run)
+
+	theme, err := ParseTheme("default", "fail=#ff5555")
+	if err != nil {
+		t.Fatalf("ParseTheme: %v", err)
+	}
+	out := NewSummaryFormatter(80, false, NewSummaryOptions(WithTheme(theme))).Format(summary)
+	if !strings.Contains(out, "38;2;255;85;85") {
+		t.Errorf("Expected failures in the theme's fail color, got:\n%q", out)
+	}
+
+	// Options not built with NewSummaryOptions use the default theme.
+	out = NewSummaryFormatter(80, false, SummaryOptions{}).Format(summary)
+	if !strings.Contains(out, "\x1b[31m") && !strings.Contains(out, ";31m") {
+		t.Errorf("Expected failures in ANSI red, got:\n%q", out)
+	}
+}
@@ -0,0 +1,131 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// minTimelineBarWidth is the narrowest timeline bar rendered,
+	// regardless of terminal width.
+	minTimelineBarWidth = 10
+
+	// minAnnotationLabelWidth is the width annotation labels are truncated
+	// to when package names are narrower.
+	minAnnotationLabelWidth = 24
+)
+
+// formatTimeline renders the TIMELINE section: an ASCII Gantt chart of when
+// each package started and finished, relative to the start of the run, so
+// packages dominating wall time stand out. The run's annotations follow as
+// markers at the time they were made.
+func (f *SummaryFormatter) formatTimeline(sb *strings.Builder, summary *Summary) {
+	if !f.options.Timeline || summary.Run == nil {
+		return
+	}
+
+	var pkgs []*results.PackageResult
+	for _, pkg := range summary.Packages {
+		if !pkg.StartTime.IsZero() && !pkg.EndTime.IsZero() {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+
+	start := summary.Run.FirstEventTime
+	end := summary.Run.LastEventTime
+	for _, pkg := range pkgs {
+		start = minTime(start, pkg.StartTime)
+		if pkg.EndTime.After(end) {
+			end = pkg.EndTime
+		}
+	}
+	for _, a := range summary.Run.Annotations {
+		start = minTime(start, a.Time)
+		if a.Time.After(end) {
+			end = a.Time
+		}
+	}
+	span := end.Sub(start)
+	if span <= 0 {
+		return
+	}
+
+	maxNameLen := 0
+	maxRangeLen := 0
+	ranges := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		maxNameLen = max(maxNameLen, len(pkg.Name))
+		ranges[i] = f.duration(pkg.StartTime.Sub(start)) + " -> " + f.duration(pkg.EndTime.Sub(start))
+		maxRangeLen = max(maxRangeLen, len(ranges[i]))
+	}
+	labels := make([]string, len(summary.Run.Annotations))
+	for i, a := range summary.Run.Annotations {
+		labels[i] = f.options.Symbols.Annotation() + " " + a.Text
+		if a.Package != "" {
+			labels[i] = f.options.Symbols.Annotation() + " " + a.Package + ": " + a.Text
+		}
+		labels[i] = ansi.Truncate(labels[i], max(maxNameLen, minAnnotationLabelWidth), "…")
+	}
+	for _, label := range labels {
+		maxNameLen = max(maxNameLen, ansi.StringWidth(label))
+	}
+
+	// indent + name + "  |" + bar + "|  " + range
+	barWidth := f.width - len(IndentLevel) - maxNameLen - 3 - 3 - maxRangeLen
+	barWidth = max(barWidth, minTimelineBarWidth)
+
+	sb.WriteString(f.boldWhite.Render(fmt.Sprintf("TIMELINE (%s)", f.duration(span))))
+	sb.WriteString("\n")
+	for i, pkg := range pkgs {
+		from := timelineColumn(pkg.StartTime.Sub(start), span, barWidth)
+		to := timelineColumn(pkg.EndTime.Sub(start), span, barWidth)
+		if to <= from {
+			to = from + 1
+		}
+		if to > barWidth {
+			from, to = barWidth-1, barWidth
+		}
+
+		bar := strings.Repeat(f.options.Symbols.Bar(), to-from)
+		switch pkg.Status {
+		case results.StatusFailed, results.StatusBuildFailed, results.StatusInterrupted:
+			bar = f.failStyle.Render(bar)
+		case results.StatusSkipped:
+			bar = f.skipStyle.Render(bar)
+		}
+
+		fmt.Fprintf(sb, "%s%-*s  |%s%s%s|  %s\n",
+			IndentLevel, maxNameLen, pkg.Name,
+			strings.Repeat(" ", from), bar, strings.Repeat(" ", barWidth-to),
+			f.dimStyle.Render(ranges[i]))
+	}
+	for i, a := range summary.Run.Annotations {
+		col := min(timelineColumn(a.Time.Sub(start), span, barWidth), barWidth-1)
+		fmt.Fprintf(sb, "%s%s%s  |%s%s%s|  %s\n",
+			IndentLevel, labels[i], strings.Repeat(" ", maxNameLen-ansi.StringWidth(labels[i])),
+			strings.Repeat(" ", col), f.boldWhite.Render(f.options.Symbols.Annotation()), strings.Repeat(" ", barWidth-col-1),
+			f.dimStyle.Render(f.duration(a.Time.Sub(start))))
+	}
+	sb.WriteString("\n")
+}
+
+// minTime returns the earlier of a and b, ignoring a zero a.
+func minTime(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// timelineColumn maps an offset from the start of the run onto a bar column.
+func timelineColumn(offset, span time.Duration, width int) int {
+	col := int(float64(offset) / float64(span) * float64(width))
+	return max(0, min(col, width))
+}
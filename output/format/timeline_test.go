@@ -0,0 +1,76 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/results"
+)
+
+func TestSummaryFormatterTimeline(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := results.NewRun(1)
+	run.FirstEventTime = start
+	run.LastEventTime = start.Add(10 * time.Second)
+
+	for _, p := range []struct {
+		name     string
+		from, to time.Duration
+	}{
+		{"pkg/a", 0, 5 * time.Second},
+		{"pkg/bb", 5 * time.Second, 10 * time.Second},
+	} {
+		run.Packages[p.name] = &results.PackageResult{
+			Name:      p.name,
+			Status:    results.StatusPassed,
+			StartTime: start.Add(p.from),
+			EndTime:   start.Add(p.to),
+		}
+		run.PackageOrder = append(run.PackageOrder, p.name)
+	}
+	summary := ComputeSummary(run)
+
+	// width 40: 40 - 4 indent - 6 name - 6 borders - 9 range = 15, clamped
+	// to whole columns of the 10s span.
+	out := NewSummaryFormatter(40, true, SummaryOptions{Timeline: true}).Format(summary)
+	want := "TIMELINE (10s)\n" +
+		"    pkg/a   |███████        |  0s -> 5s\n" +
+		"    pkg/bb  |       ████████|  5s -> 10s\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected timeline:\n%s\ngot:\n%s", want, out)
+	}
+
+	out = NewSummaryFormatter(40, true).Format(summary)
+	if strings.Contains(out, "TIMELINE") {
+		t.Errorf("Expected no timeline by default, got:\n%s", out)
+	}
+}
+
+func TestSummaryFormatterTimelineAnnotations(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := results.NewRun(1)
+	run.FirstEventTime = start
+	run.LastEventTime = start.Add(10 * time.Second)
+	run.Packages["pkg/a"] = &results.PackageResult{
+		Name:      "pkg/a",
+		Status:    results.StatusPassed,
+		StartTime: start,
+		EndTime:   start.Add(10 * time.Second),
+	}
+	run.PackageOrder = []string{"pkg/a"}
+	run.Annotations = []engine.Annotation{
+		{Time: start.Add(5 * time.Second), Text: "deploy"},
+		{Time: start.Add(10 * time.Second), Package: "pkg/a", Text: "checkpoint reached after a long wait"},
+	}
+
+	out := NewSummaryFormatter(60, true, SummaryOptions{Timeline: true}).Format(ComputeSummary(run))
+	want := "TIMELINE (10s)\n" +
+		"    pkg/a                     |█████████████████|  0s -> 10s\n" +
+		"    ◆ deploy                  |        ◆        |  5s\n" +
+		"    ◆ pkg/a: checkpoint rea…  |                ◆|  10s\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected timeline:\n%s\ngot:\n%s", want, out)
+	}
+}
@@ -0,0 +1,61 @@
+package format
+
+import "github.com/ansel1/tang/results"
+
+// SummaryView selects which test details a formatted summary shows, so an
+// interactive report can narrow a finished run without re-running it.
+type SummaryView int
+
+const (
+	SummaryViewAll      SummaryView = iota // Everything enabled by SummaryOptions
+	SummaryViewFailures                    // Failures and build failures only
+	SummaryViewSlow                        // Slow tests only
+)
+
+func (v SummaryView) String() string {
+	switch v {
+	case SummaryViewFailures:
+		return "failures"
+	case SummaryViewSlow:
+		return "slow"
+	default:
+		return "all"
+	}
+}
+
+// Next returns the view after v, wrapping from the last back to
+// SummaryViewAll.
+func (v SummaryView) Next() SummaryView {
+	return (v + 1) % (SummaryViewSlow + 1)
+}
+
+// Apply narrows a summary and its options to the view. The summary is
+// copied, never modified; the package summary table and totals are kept in
+// every view.
+func (v SummaryView) Apply(s *Summary, opts SummaryOptions) (*Summary, SummaryOptions) {
+	switch v {
+	case SummaryViewFailures:
+		filtered := *s
+		filtered.Skipped = nil
+		filtered.SlowTests = nil
+		return &filtered, SummaryOptions{SlowThreshold: opts.SlowThreshold, PackageSlowThresholds: opts.PackageSlowThresholds, LogDir: opts.LogDir, ArtifactDir: opts.ArtifactDir, SourceContext: opts.SourceContext, SourceDir: opts.SourceDir, RepoLinks: opts.RepoLinks, FullGoroutineDumps: opts.FullGoroutineDumps, RawTestify: opts.RawTestify, Theme: opts.Theme, DurationStyle: opts.DurationStyle, PackageOrder: opts.PackageOrder}
+
+	case SummaryViewSlow:
+		filtered := *s
+		filtered.Failures = nil
+		filtered.BuildFailures = nil
+		filtered.Skipped = nil
+		// Package output (e.g. panics outside a test) belongs with the
+		// failures, so drop it from copies of the packages.
+		filtered.Packages = make([]*results.PackageResult, len(s.Packages))
+		for i, pkg := range s.Packages {
+			p := *pkg
+			p.OutputLines = nil
+			filtered.Packages[i] = &p
+		}
+		return &filtered, SummaryOptions{SlowThreshold: opts.SlowThreshold, PackageSlowThresholds: opts.PackageSlowThresholds, IncludeSlow: true, SetupMinimum: opts.SetupMinimum, Theme: opts.Theme, DurationStyle: opts.DurationStyle, PackageOrder: opts.PackageOrder}
+
+	default:
+		return s, opts
+	}
+}
@@ -0,0 +1,63 @@
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+func TestSummaryViewApply(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, OutputLines: []string{"panic: boom"}}
+	for _, tc := range []struct {
+		name    string
+		status  results.Status
+		elapsed time.Duration
+	}{
+		{"TestFail", results.StatusFailed, 0},
+		{"TestSlow", results.StatusPassed, 20 * time.Second},
+		{"TestSkip", results.StatusSkipped, 0},
+	} {
+		tr := results.NewTestResult("pkg1", tc.name)
+		tr.Latest().Status = tc.status
+		tr.Latest().Elapsed = tc.elapsed
+		run.TestResults["pkg1/"+tc.name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, tc.name)
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+	summary := ComputeSummary(run)
+	opts := SummaryOptions{IncludeSkipped: true, IncludeSlow: true}
+
+	render := func(v SummaryView) string {
+		s, o := v.Apply(summary, opts)
+		return NewSummaryFormatter(80, true, o).Format(s)
+	}
+
+	all := render(SummaryViewAll)
+	for _, want := range []string{"TestFail", "TestSlow", "TestSkip", "panic: boom"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("all view: expected %q in:\n%s", want, all)
+		}
+	}
+
+	failures := render(SummaryViewFailures)
+	if !strings.Contains(failures, "TestFail") || strings.Contains(failures, "TestSlow") || strings.Contains(failures, "TestSkip") {
+		t.Errorf("failures view: expected only TestFail, got:\n%s", failures)
+	}
+
+	slow := render(SummaryViewSlow)
+	if !strings.Contains(slow, "TestSlow") || strings.Contains(slow, "TestFail") || strings.Contains(slow, "panic: boom") {
+		t.Errorf("slow view: expected only TestSlow, got:\n%s", slow)
+	}
+
+	if len(pkg.OutputLines) != 1 || len(summary.Failures) != 1 {
+		t.Error("Apply modified the original summary")
+	}
+
+	if got := SummaryViewSlow.Next(); got != SummaryViewAll {
+		t.Errorf("Expected the slow view to cycle back to all, got %v", got)
+	}
+}
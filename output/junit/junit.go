@@ -89,16 +89,14 @@ func WriteXML(w io.Writer, state *results.State) error {
 			}
 
 			suite := JUnitTestSuite{
-				Name:      pkgResult.Name,
-				Tests:     pkgResult.Counts.Passed + pkgResult.Counts.Failed + pkgResult.Counts.Skipped,
-				Failures:  pkgResult.Counts.Failed,
-				Skipped:   pkgResult.Counts.Skipped,
-				Time:      fmt.Sprintf("%.3f", pkgResult.Elapsed.Seconds()),
-				Timestamp: pkgResult.StartTime.Format(time.RFC3339),
-				Properties: []JUnitProperty{
-					{Name: "run_id", Value: fmt.Sprintf("%d", run.ID)},
-				},
-				TestCases: make([]JUnitTestCase, 0),
+				Name:       pkgResult.Name,
+				Tests:      pkgResult.Counts.Passed + pkgResult.Counts.Failed + pkgResult.Counts.Skipped,
+				Failures:   pkgResult.Counts.Failed,
+				Skipped:    pkgResult.Counts.Skipped,
+				Time:       fmt.Sprintf("%.3f", pkgResult.Elapsed.Seconds()),
+				Timestamp:  pkgResult.StartTime.Format(time.RFC3339),
+				Properties: runProperties(run),
+				TestCases:  make([]JUnitTestCase, 0),
 			}
 
 			suites.Tests += suite.Tests
@@ -210,3 +208,18 @@ func WriteXML(w io.Writer, state *results.State) error {
 	_, err := w.Write([]byte("\n"))
 	return err
 }
+
+// runProperties returns the properties recorded on each suite of a run: its
+// sequential and unique IDs, followed by the run's labels.
+func runProperties(run *results.Run) []JUnitProperty {
+	props := []JUnitProperty{
+		{Name: "run_id", Value: fmt.Sprintf("%d", run.ID)},
+	}
+	if run.UID != "" {
+		props = append(props, JUnitProperty{Name: "run_uid", Value: run.UID})
+	}
+	for _, label := range run.Labels {
+		props = append(props, JUnitProperty{Name: label.Key, Value: label.Value})
+	}
+	return props
+}
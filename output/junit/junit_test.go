@@ -462,3 +462,28 @@ func TestWriteXML_MultiExecutionSubtest(t *testing.T) {
 		t.Errorf("Expected second testcase name 'TestFoo#02/sub', got '%s'", val.TestSuites[0].TestCases[1].Name)
 	}
 }
+
+func TestWriteXMLRunProperties(t *testing.T) {
+	state := results.NewState()
+	run := results.NewRun(1)
+	run.UID = "20260102-150405-3f9a1c"
+	run.Labels = results.Labels{{Key: "ci_job", Value: "1234"}}
+	state.Runs = append(state.Runs, run)
+	run.Packages["pkg1"] = &results.PackageResult{Name: "pkg1", Status: results.StatusPassed}
+	run.PackageOrder = append(run.PackageOrder, "pkg1")
+
+	var buf bytes.Buffer
+	if err := WriteXML(&buf, state); err != nil {
+		t.Fatalf("WriteXML failed: %v", err)
+	}
+	output := buf.String()
+	for _, expected := range []string{
+		`<property name="run_id" value="1"></property>`,
+		`<property name="run_uid" value="20260102-150405-3f9a1c"></property>`,
+		`<property name="ci_job" value="1234"></property>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %s in output:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// ExtractLogs writes the full captured output of each failed test in the run
+// to its own file in dir (see format.LogFileName), creating dir if needed,
+// and returns the paths written. With -count=N, the output of every failed
+// execution is included, in order.
+func ExtractLogs(dir string, run *results.Run) ([]string, error) {
+	var paths []string
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := run.TestResults[pkgName+"/"+testName]
+			if tr == nil || !tr.Failed() {
+				continue
+			}
+			if len(paths) == 0 {
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					return nil, err
+				}
+			}
+			path := format.LogPath(dir, pkgName, testName)
+			if err := writeTestLog(path, tr); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func writeTestLog(path string, tr *results.TestResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for i, exec := range tr.Executions {
+		if exec.Status != results.StatusFailed {
+			continue
+		}
+		if len(tr.Executions) > 1 {
+			fmt.Fprintf(w, "=== %s\n", results.ExecutionDisplayName(tr.Name, i+1, len(tr.Executions)))
+		}
+		for _, line := range exec.Output {
+			fmt.Fprintln(w, line)
+		}
+		if exec.SummaryLine != "" {
+			fmt.Fprintln(w, exec.SummaryLine)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractLogs(t *testing.T) {
+	collector := results.NewCollector()
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	run := collector.State().MostRecentRun()
+	dir := filepath.Join(t.TempDir(), "logs")
+
+	paths, err := ExtractLogs(dir, run)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "example.com_a__TestFail.log")}, paths)
+
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, "    test_fail.go:10: assertion failed\n--- FAIL: TestFail (0.00s)\n", string(data))
+}
+
+func TestExtractLogs_NoFailures(t *testing.T) {
+	collector := results.NewCollector()
+	for _, evt := range passingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	dir := filepath.Join(t.TempDir(), "logs")
+
+	paths, err := ExtractLogs(dir, collector.State().MostRecentRun())
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+	assert.NoDirExists(t, dir)
+}
+
+func TestSimpleOutput_LogPathInFailures(t *testing.T) {
+	collector := results.NewCollector()
+	var buf bytes.Buffer
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(format.WithLogDir("logs")), false, 80, true)
+
+	require.NoError(t, simple.ProcessEvents(sendEvents(failingPackageEvents("example.com/pkg"))))
+	assert.Contains(t, buf.String(), "log: "+filepath.Join("logs", "example.com_pkg__TestFail.log"))
+}
+
+func TestLogFileName_Long(t *testing.T) {
+	long := "TestTable/" + strings.Repeat("case_with_a_long_descriptive_name/", 10)
+	name := format.LogFileName("example.com/a", long)
+	assert.LessOrEqual(t, len(name), 120)
+	assert.True(t, strings.HasPrefix(name, "example.com_a__TestTable_case"), name)
+	assert.True(t, strings.HasSuffix(name, ".log"), name)
+	assert.NotEqual(t, name, format.LogFileName("example.com/a", long+"x"), "truncated names should stay unique")
+	assert.Equal(t, "example.com_a__TestA.log", format.LogFileName("example.com/a", "TestA"))
+}
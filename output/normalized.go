@@ -0,0 +1,187 @@
+package output
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+// NormalizedJSON writes a cleaned-up go test -json stream, for
+// -normalized-json, sparing the tools reading archived streams the quirks
+// of the raw one:
+//
+//   - Each package starts with a "start" event, injected if missing.
+//   - Each test starts with a "run" event, injected if missing.
+//   - Tests still running when their package finishes are finished with
+//     the package's action, e.g. "pass", deepest subtests first.
+//   - Events without a Package get the package running, if only one is,
+//     and events without a Time get the time of the event before.
+//   - A package's events are written together when it finishes, with the
+//     events of each top-level test, and its subtests, together in their
+//     original order, top-level tests in the order they started.
+//
+// Build events are written as they arrive. Lines which aren't events are
+// dropped.
+type NormalizedJSON struct {
+	w io.Writer
+
+	mu       sync.Mutex
+	packages map[string]*normalizedPackage
+	order    []string  // The packages not finished, in start order
+	lastTime time.Time // The time of the last event
+	err      error     // First error writing
+}
+
+// normalizedPackage holds a package's events until it finishes.
+type normalizedPackage struct {
+	pre     []parser.TestEvent // Package events before the first test
+	tests   map[string][]parser.TestEvent
+	testSeq []string // Top-level tests, in start order
+	post    []parser.TestEvent
+	running map[string]time.Time // Tests started and not finished, by name
+	runSeq  []string             // The names in running, in start order
+}
+
+// NewNormalizedJSON returns a NormalizedJSON writing to w.
+func NewNormalizedJSON(w io.Writer) *NormalizedJSON {
+	return &NormalizedJSON{w: w, packages: make(map[string]*normalizedPackage)}
+}
+
+// Sink takes an event, for engine.Broadcaster.AddSink.
+func (n *NormalizedJSON) Sink(evt engine.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch evt.Type {
+	case engine.EventBuild:
+		n.writeLine(evt.Line)
+	case engine.EventTest:
+		n.add(evt.TestEvent)
+	}
+}
+
+// add adds a test event to its package's events, writing them once the
+// package finishes.
+func (n *NormalizedJSON) add(te parser.TestEvent) {
+	if te.Time.IsZero() {
+		te.Time = n.lastTime
+	}
+	n.lastTime = te.Time
+	if te.Package == "" && len(n.order) == 1 {
+		te.Package = n.order[0]
+	}
+
+	pkg := n.packages[te.Package]
+	if pkg == nil {
+		pkg = &normalizedPackage{
+			tests:   make(map[string][]parser.TestEvent),
+			running: make(map[string]time.Time),
+		}
+		n.packages[te.Package] = pkg
+		n.order = append(n.order, te.Package)
+		if te.Action != "start" {
+			pkg.pre = append(pkg.pre, parser.TestEvent{Time: te.Time, Action: "start", Package: te.Package})
+		}
+	}
+
+	if te.Test == "" {
+		switch te.Action {
+		case "pass", "fail", "skip":
+			n.finish(te, pkg)
+			return
+		}
+		if len(pkg.testSeq) == 0 {
+			pkg.pre = append(pkg.pre, te)
+		} else {
+			pkg.post = append(pkg.post, te)
+		}
+		return
+	}
+
+	if _, ok := pkg.running[te.Test]; !ok && te.Action != "run" {
+		pkg.addTest(parser.TestEvent{Time: te.Time, Action: "run", Package: te.Package, Test: te.Test})
+	}
+	pkg.addTest(te)
+}
+
+// addTest adds an event of a test to its top-level test's events, and
+// tracks which tests are running.
+func (p *normalizedPackage) addTest(te parser.TestEvent) {
+	top, _, _ := strings.Cut(te.Test, "/")
+	if _, ok := p.tests[top]; !ok {
+		p.testSeq = append(p.testSeq, top)
+	}
+	p.tests[top] = append(p.tests[top], te)
+
+	switch te.Action {
+	case "run":
+		if _, ok := p.running[te.Test]; !ok {
+			p.runSeq = append(p.runSeq, te.Test)
+		}
+		p.running[te.Test] = te.Time
+	case "pass", "fail", "skip":
+		delete(p.running, te.Test)
+		p.runSeq = slices.DeleteFunc(p.runSeq, func(name string) bool { return name == te.Test })
+	}
+}
+
+// finish writes a finished package's events, ending with end, its "pass",
+// "fail" or "skip" event.
+func (n *NormalizedJSON) finish(end parser.TestEvent, pkg *normalizedPackage) {
+	for _, name := range slices.Backward(slices.Clone(pkg.runSeq)) {
+		pkg.addTest(parser.TestEvent{
+			Time:    end.Time,
+			Action:  end.Action,
+			Package: end.Package,
+			Test:    name,
+			Elapsed: end.Time.Sub(pkg.running[name]).Seconds(),
+		})
+	}
+	pkg.post = append(pkg.post, end)
+	n.write(pkg)
+	delete(n.packages, end.Package)
+	n.order = slices.DeleteFunc(n.order, func(name string) bool { return name == end.Package })
+}
+
+// write writes a package's events.
+func (n *NormalizedJSON) write(pkg *normalizedPackage) {
+	events := slices.Clone(pkg.pre)
+	for _, top := range pkg.testSeq {
+		events = append(events, pkg.tests[top]...)
+	}
+	events = append(events, pkg.post...)
+	for _, te := range events {
+		line, err := json.Marshal(te)
+		if err != nil {
+			n.err = cmp.Or(n.err, err)
+			continue
+		}
+		n.writeLine(line)
+	}
+}
+
+// writeLine writes a line of JSON.
+func (n *NormalizedJSON) writeLine(line []byte) {
+	if _, err := n.w.Write(append(slices.Clip(line), '\n')); err != nil {
+		n.err = cmp.Or(n.err, err)
+	}
+}
+
+// Close writes the events of the packages which didn't finish, e.g. when
+// go test was killed, as they are, and returns the first error writing.
+func (n *NormalizedJSON) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, name := range n.order {
+		n.write(n.packages[name])
+	}
+	n.packages = make(map[string]*normalizedPackage)
+	n.order = nil
+	return n.err
+}
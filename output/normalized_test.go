@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizedJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewNormalizedJSON(&buf)
+
+	at := func(ms int) parser.TestEvent {
+		return parser.TestEvent{Time: baseTime.Add(time.Duration(ms) * time.Millisecond), Package: "example.com/a"}
+	}
+	event := func(te parser.TestEvent, action, test, output string) engine.Event {
+		te.Action, te.Test, te.Output = action, test, output
+		return engine.Event{Type: engine.EventTest, TestEvent: te}
+	}
+	events := []engine.Event{
+		// No start event, and TestA's output arrives without a run event.
+		event(at(0), "run", "TestB", ""),
+		event(at(1), "output", "TestA", "=== RUN   TestA\n"),
+		event(at(2), "run", "TestB/sub", ""),
+		event(at(3), "output", "TestB/sub", "working\n"),
+		{Type: engine.EventRawLine, RawLine: []byte("not an event")},
+		// Neither a package nor a time.
+		{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: "output", Test: "TestA", Output: "still running\n"}},
+		event(at(4), "pass", "TestA", ""),
+		// TestB and TestB/sub never finish.
+		event(at(10), "output", "", "FAIL\texample.com/a\t0.010s\n"),
+		event(at(10), "fail", "", ""),
+	}
+	for _, evt := range events {
+		n.Sink(evt)
+	}
+	require.NoError(t, n.Close())
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var te parser.TestEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &te))
+		require.Equal(t, "example.com/a", te.Package, line)
+		require.False(t, te.Time.IsZero(), line)
+		parts := slices.DeleteFunc([]string{te.Action, te.Test, strings.TrimSpace(te.Output)}, func(s string) bool { return s == "" })
+		got = append(got, strings.Join(parts, " "))
+	}
+	assert.Equal(t, []string{
+		"start",
+		"run TestB",
+		"run TestB/sub",
+		"output TestB/sub working",
+		"fail TestB/sub",
+		"fail TestB",
+		"run TestA",
+		"output TestA === RUN   TestA",
+		"output TestA still running",
+		"pass TestA",
+		"output FAIL\texample.com/a\t0.010s",
+		"fail",
+	}, got)
+}
+
+func TestNormalizedJSONUnfinished(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewNormalizedJSON(&buf)
+	n.Sink(engine.Event{Type: engine.EventBuild, Line: []byte(`{"ImportPath":"example.com/b","Action":"build-fail"}`)})
+	for _, evt := range passingPackageEvents("example.com/a")[:3] {
+		n.Sink(evt)
+	}
+	// Nothing of a package is written before it finishes, or tang exits.
+	assert.Equal(t, `{"ImportPath":"example.com/b","Action":"build-fail"}`+"\n", buf.String())
+
+	require.NoError(t, n.Close())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[3], `"Output":"=== RUN   TestFoo\n"`)
+}
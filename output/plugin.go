@@ -0,0 +1,151 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+const (
+	// PluginBuffer is how many events are queued for a plugin that isn't
+	// keeping up before further events are dropped.
+	PluginBuffer = 4096
+
+	// PluginExitTimeout is how long a plugin has to exit after its input
+	// ends before it's killed.
+	PluginExitTimeout = 10 * time.Second
+)
+
+// Plugin streams results.Events to the stdin of a subprocess as JSON Lines,
+// in the same form as -events-out, for custom integrations such as
+// uploading failures to an issue tracker.
+//
+// Events are queued and written by a separate goroutine, so a slow plugin
+// never holds up the collector: when the queue is full, events are dropped
+// and counted. The plugin's input ends when the run is over; it should
+// exit once it reads EOF.
+type Plugin struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	events chan results.Event
+	done   chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// StartPlugin starts the plugin command name with args. Its stdout and
+// stderr are written to output.
+func StartPlugin(name string, args []string, output io.Writer) (*Plugin, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		cmd:    cmd,
+		stdin:  stdin,
+		events: make(chan results.Event, PluginBuffer),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// run writes queued events to the plugin until the queue is closed,
+// flushing whenever it's caught up. After a write error, i.e. the plugin
+// stopped reading, remaining events are discarded; whether that's a
+// problem is up to the plugin's exit status.
+func (p *Plugin) run() {
+	defer close(p.done)
+	w := bufio.NewWriter(p.stdin)
+	enc := json.NewEncoder(w)
+	var err error
+	for evt := range p.events {
+		if err != nil {
+			continue
+		}
+		err = enc.Encode(NewEventJSON(evt))
+		if err == nil && len(p.events) == 0 {
+			err = w.Flush()
+		}
+	}
+	if err == nil {
+		_ = w.Flush()
+	}
+}
+
+// Write queues evt for the plugin without blocking. If the queue is full,
+// the event is dropped.
+func (p *Plugin) Write(evt results.Event) {
+	select {
+	case p.events <- evt:
+	default:
+		p.mu.Lock()
+		p.dropped++
+		p.mu.Unlock()
+	}
+}
+
+// Close ends the plugin's input once the queued events are written, and
+// waits for it to exit. A plugin that takes longer than timeout in all is
+// killed. It returns an error if the plugin failed or events were dropped.
+func (p *Plugin) Close(timeout time.Duration) error {
+	deadline := time.After(timeout)
+	killed := false
+	kill := func() {
+		_ = p.cmd.Process.Kill()
+		killed = true
+	}
+
+	close(p.events)
+	select {
+	case <-p.done:
+	case <-deadline:
+		// Killing the plugin fails the write it's blocked on.
+		kill()
+		<-p.done
+	}
+	_ = p.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+	var waitErr error
+	if killed {
+		<-exited
+	} else {
+		select {
+		case waitErr = <-exited:
+		case <-deadline:
+			kill()
+			<-exited
+		}
+	}
+	if killed {
+		waitErr = fmt.Errorf("killed after not exiting within %v", timeout)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	if waitErr != nil {
+		errs = append(errs, waitErr)
+	}
+	if p.dropped > 0 {
+		errs = append(errs, fmt.Errorf("dropped %d events the plugin didn't keep up with", p.dropped))
+	}
+	return errors.Join(errs...)
+}
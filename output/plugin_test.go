@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPluginHelperProcess isn't a real test: it's the plugin started by the
+// tests below, running as the test binary, behaving as TANG_PLUGIN_MODE says.
+func TestPluginHelperProcess(t *testing.T) {
+	switch os.Getenv("TANG_PLUGIN_MODE") {
+	case "":
+		return
+	case "copy":
+		// Copy the events to the file named by TANG_PLUGIN_OUT.
+		f, err := os.Create(os.Getenv("TANG_PLUGIN_OUT"))
+		if err != nil {
+			os.Exit(2)
+		}
+		_, _ = io.Copy(f, os.Stdin)
+		_ = f.Close()
+		os.Exit(0)
+	case "fail":
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		os.Exit(3)
+	case "hang":
+		// Never read, never exit.
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+func startHelperPlugin(t *testing.T, mode string, env ...string) *Plugin {
+	t.Helper()
+	t.Setenv("TANG_PLUGIN_MODE", mode)
+	for i := 0; i+1 < len(env); i += 2 {
+		t.Setenv(env[i], env[i+1])
+	}
+	p, err := StartPlugin(os.Args[0], []string{"-test.run=^TestPluginHelperProcess$"}, io.Discard)
+	require.NoError(t, err)
+	return p
+}
+
+func TestPlugin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events.jsonl")
+	p := startHelperPlugin(t, "copy", "TANG_PLUGIN_OUT", out)
+
+	collector := results.NewCollector()
+	collector.SetEventHandler(p.Write)
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	collector.Push(engine.Event{Type: engine.EventComplete})
+	require.NoError(t, p.Close(PluginExitTimeout))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var types []results.EventType
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var evt EventJSON
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &evt), scanner.Text())
+		types = append(types, evt.Type)
+	}
+	require.NotEmpty(t, types)
+	assert.Equal(t, results.EventRunStarted, types[0])
+	assert.Equal(t, results.EventRunFinished, types[len(types)-1])
+}
+
+func TestPlugin_Failure(t *testing.T) {
+	p := startHelperPlugin(t, "fail")
+	p.Write(results.NewRunStartedEvent(1))
+	err := p.Close(PluginExitTimeout)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+}
+
+func TestPlugin_SlowConsumer(t *testing.T) {
+	p := startHelperPlugin(t, "hang")
+
+	// Writes never block, even though the plugin isn't reading.
+	start := time.Now()
+	for range PluginBuffer * 4 {
+		p.Write(results.NewTestOutputEvent(1, "example.com/a", "TestA", "output line"))
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	err := p.Close(200 * time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "killed after not exiting within 200ms")
+	assert.Contains(t, err.Error(), "dropped")
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// ProgressInterval is how often -progress-fd reports progress.
+const ProgressInterval = time.Second
+
+// Progress is a snapshot of the most recent run, reported by -progress-fd
+// for wrapper scripts, e.g. to update a CI status while tests run.
+type Progress struct {
+	Run          int
+	Status       results.Status
+	Elapsed      time.Duration
+	Passed       int
+	Failed       int
+	Skipped      int
+	Running      int
+	PackagesDone int
+	Packages     int    // Packages expected to run; see format.Estimator.Packages
+	Package      string // Most recently started package still running
+	ETA          time.Duration
+	HasETA       bool // Whether the estimator could estimate the ETA yet
+}
+
+// CurrentProgress returns the progress of the collector's most recent run,
+// with an ETA from est, which may be nil. It returns false before the first
+// run starts. The caller must hold the collector's lock.
+func CurrentProgress(collector *results.Collector, est *format.Estimator) (Progress, bool) {
+	run := collector.State().MostRecentRun()
+	if run == nil {
+		return Progress{}, false
+	}
+
+	p := Progress{
+		Run:      run.ID,
+		Status:   run.Status,
+		Elapsed:  run.Elapsed(),
+		Passed:   run.Counts.Passed,
+		Failed:   run.Counts.Failed,
+		Skipped:  run.Counts.Skipped,
+		Running:  run.Counts.Running,
+		Packages: est.Packages(run),
+	}
+	if run.Status == results.StatusRunning {
+		p.Elapsed = run.ScaleWall(time.Since(run.WallStartTime))
+	}
+	for _, pkgName := range run.PackageOrder {
+		if run.Packages[pkgName].Status == results.StatusRunning {
+			p.Package = pkgName
+		} else {
+			p.PackagesDone++
+		}
+	}
+	p.ETA, p.HasETA = est.Remaining(run, p.Elapsed)
+	return p, true
+}
+
+// String formats the progress as a single line of space-separated
+// key=value fields, with durations in seconds, e.g.
+//
+//	progress run=1 status=running elapsed=12.0 passed=40 failed=1 skipped=2 running=3 packages=4/10 package=example.com/app eta=30.0
+//
+// The package and eta fields are omitted when unknown.
+func (p Progress) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "progress run=%d status=%s elapsed=%.1f passed=%d failed=%d skipped=%d running=%d packages=%d/%d",
+		p.Run, p.Status, p.Elapsed.Seconds(), p.Passed, p.Failed, p.Skipped, p.Running, p.PackagesDone, p.Packages)
+	if p.Package != "" {
+		fmt.Fprintf(&sb, " package=%s", p.Package)
+	}
+	if p.HasETA {
+		fmt.Fprintf(&sb, " eta=%.1f", p.ETA.Seconds())
+	}
+	return sb.String()
+}
+
+// ReportProgress writes a progress line for the collector's most recent run
+// to w every interval, and a final line when the returned stop function is
+// called.
+func ReportProgress(w io.Writer, collector *results.Collector, est *format.Estimator, interval time.Duration) (stop func()) {
+	write := func() {
+		collector.Lock()
+		p, ok := CurrentProgress(collector, est)
+		collector.Unlock()
+		if ok {
+			_, _ = fmt.Fprintln(w, p)
+		}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				write()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+			write()
+		})
+	}
+}
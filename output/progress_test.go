@@ -0,0 +1,66 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/parser"
+	"github.com/ansel1/tang/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentProgress(t *testing.T) {
+	collector := results.NewCollector()
+	_, ok := CurrentProgress(collector, nil)
+	assert.False(t, ok, "no progress before the first run")
+
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: baseTime, Action: "start", Package: "example.com/b"}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: baseTime, Action: "run", Package: "example.com/b", Test: "TestB"}})
+
+	p, ok := CurrentProgress(collector, nil)
+	require.True(t, ok)
+	assert.Equal(t, 1, p.Failed)
+	assert.Equal(t, 1, p.Running)
+	assert.Equal(t, 1, p.PackagesDone)
+	assert.Equal(t, 2, p.Packages)
+	assert.Equal(t, "example.com/b", p.Package)
+	assert.True(t, p.HasETA, "ETA from the package completion rate")
+
+	est := format.NewEstimator([]*format.SummaryJSON{{Packages: []format.PackageJSON{
+		{Name: "example.com/a", Elapsed: 1},
+		{Name: "example.com/b", Elapsed: 3},
+		{Name: "example.com/c", Elapsed: 6},
+	}}})
+	p, _ = CurrentProgress(collector, est)
+	assert.Equal(t, 3, p.Packages)
+	assert.True(t, p.HasETA)
+
+	p.Elapsed = 12 * time.Second
+	p.ETA = 30 * time.Second
+	assert.Equal(t, "progress run=1 status=running elapsed=12.0 passed=0 failed=1 skipped=0 running=1 packages=1/3 package=example.com/b eta=30.0", p.String())
+}
+
+func TestReportProgress(t *testing.T) {
+	var buf bytes.Buffer
+	collector := results.NewCollector()
+	for _, evt := range failingPackageEvents("example.com/a") {
+		collector.Push(evt)
+	}
+	collector.Push(engine.Event{Type: engine.EventComplete})
+
+	stop := ReportProgress(&buf, collector, nil, time.Hour)
+	stop()
+	stop()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "stop writes one final line")
+	assert.True(t, strings.HasPrefix(lines[0], "progress run=1 status=failed "), lines[0])
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"slices"
 	"strings"
-	"time"
 
 	"github.com/ansel1/tang/engine"
 	"github.com/ansel1/tang/output/format"
@@ -16,11 +15,16 @@ import (
 type SimpleOutput struct {
 	writer         io.Writer
 	collector      *results.Collector
-	slowThreshold  time.Duration
 	summaryOptions format.SummaryOptions
 	verbose        bool
 	width          int
+	widthFunc      func() int
 	noColor        bool
+	firstFailure   bool
+
+	// The summary's writer, when not writer (see SetSummaryWriter)
+	summaryWriter  io.Writer
+	summaryNoColor bool
 
 	// Per-event state (initialized by Init, used by ProcessEvent)
 	writers                   map[string]*packageWriter
@@ -30,6 +34,7 @@ type SimpleOutput struct {
 	pendingNonVerboseFailures map[string]map[string][]pendingNonVerboseFailure
 	focusedPkg                string
 	completedQueue            []string
+	firstFailureShown         bool
 }
 
 type pendingResult struct {
@@ -91,14 +96,13 @@ func pickFocus(writers map[string]*packageWriter) string {
 	return best
 }
 
-func NewSimpleOutput(w io.Writer, collector *results.Collector, slowThreshold time.Duration, summaryOptions format.SummaryOptions, verbose bool, width int, noColor bool) *SimpleOutput {
+func NewSimpleOutput(w io.Writer, collector *results.Collector, summaryOptions format.SummaryOptions, verbose bool, width int, noColor bool) *SimpleOutput {
 	if width <= 0 {
 		width = 80
 	}
 	return &SimpleOutput{
 		writer:         w,
 		collector:      collector,
-		slowThreshold:  slowThreshold,
 		summaryOptions: summaryOptions,
 		verbose:        verbose,
 		width:          width,
@@ -106,6 +110,41 @@ func NewSimpleOutput(w io.Writer, collector *results.Collector, slowThreshold ti
 	}
 }
 
+// SetWidthFunc sets a function returning the current terminal width. It's
+// queried when the summary is printed, so the summary fits a terminal that
+// was resized during the run. A non-positive result falls back to the
+// width given to NewSimpleOutput.
+func (s *SimpleOutput) SetWidthFunc(fn func() int) {
+	s.widthFunc = fn
+}
+
+// summaryWidth returns the width to format the summary for.
+func (s *SimpleOutput) summaryWidth() int {
+	if s.widthFunc != nil {
+		if w := s.widthFunc(); w > 0 {
+			return w
+		}
+	}
+	return s.width
+}
+
+// SetSummaryWriter sends the summary to w, formatted without colors if
+// noColor, rather than after the test output, e.g. so CI can keep the raw
+// log on stdout and the summary in a file of its own.
+func (s *SimpleOutput) SetSummaryWriter(w io.Writer, noColor bool) {
+	s.summaryWriter = w
+	s.summaryNoColor = noColor
+}
+
+// SetFirstFailure controls whether the output of the run's first failing
+// test is written at once, in a delimited FIRST FAILURE block, even while
+// its package's output is buffered. Someone watching a CI log sees the
+// problem without waiting for the run to finish. The failure is still
+// reported as usual.
+func (s *SimpleOutput) SetFirstFailure(enabled bool) {
+	s.firstFailure = enabled
+}
+
 // Init initializes the per-event processing state. Must be called before
 // ProcessEvent. It is called automatically by ProcessEvents.
 func (s *SimpleOutput) Init() {
@@ -116,6 +155,7 @@ func (s *SimpleOutput) Init() {
 	s.pendingNonVerboseFailures = make(map[string]map[string][]pendingNonVerboseFailure)
 	s.focusedPkg = ""
 	s.completedQueue = nil
+	s.firstFailureShown = false
 }
 
 // ProcessEvent handles a single engine event. It does NOT call
@@ -134,6 +174,9 @@ func (s *SimpleOutput) ProcessEvent(evt engine.Event) {
 
 	case engine.EventTest:
 		te := evt.TestEvent
+		if te.Test != "" && te.Action == "fail" && s.firstFailure && !s.firstFailureShown {
+			s.writeFirstFailure(te)
+		}
 		if te.Test != "" {
 			if s.verbose {
 				if te.Action == "output" && te.Output != "" {
@@ -341,6 +384,32 @@ func (s *SimpleOutput) emitNonVerboseChildren(
 	}
 }
 
+// writeFirstFailure writes the failed test's output in a FIRST FAILURE
+// block, straight to the writer.
+func (s *SimpleOutput) writeFirstFailure(te parser.TestEvent) {
+	state := s.collector.State()
+	run := state.MostRecentRun()
+	if run == nil {
+		return
+	}
+	tr, ok := run.TestResults[te.Package+"/"+te.Test]
+	if !ok {
+		return
+	}
+	s.firstFailureShown = true
+
+	title := " FIRST FAILURE: " + te.Package + " " + te.Test + " "
+	rule := max(s.width-len(title), 8)
+	_, _ = fmt.Fprintf(s.writer, "%s%s%s\n", strings.Repeat("=", rule/2), title, strings.Repeat("=", rule-rule/2))
+	if summaryLine := tr.SummaryLine(); summaryLine != "" {
+		_, _ = fmt.Fprintln(s.writer, summaryLine)
+	}
+	for _, line := range tr.Output() {
+		_, _ = fmt.Fprintln(s.writer, line)
+	}
+	_, _ = fmt.Fprintln(s.writer, strings.Repeat("=", max(s.width, len(title)+8)))
+}
+
 func (s *SimpleOutput) writeSummary() error {
 	if s.collector == nil {
 		return nil
@@ -352,12 +421,17 @@ func (s *SimpleOutput) writeSummary() error {
 	}
 
 	run := state.Runs[len(state.Runs)-1]
-	summary := format.ComputeSummary(run, s.slowThreshold)
+	summary := format.ComputeSummary(run, format.WithOptions(s.summaryOptions))
 	if summary == nil {
 		return nil
 	}
 
-	summaryText := format.NewSummaryFormatter(s.width, s.noColor, s.summaryOptions).Format(summary)
+	if s.summaryWriter != nil {
+		summaryText := format.NewSummaryFormatter(s.summaryWidth(), s.summaryNoColor, s.summaryOptions).Format(summary)
+		_, _ = fmt.Fprintln(s.summaryWriter, summaryText)
+		return nil
+	}
+	summaryText := format.NewSummaryFormatter(s.summaryWidth(), s.noColor, s.summaryOptions).Format(summary)
 	if summary.HasTestDetailsWithOptions(s.summaryOptions) {
 		_, _ = fmt.Fprintln(s.writer)
 	}
@@ -452,17 +526,5 @@ func (s *SimpleOutput) HasFailures() bool {
 		return false
 	}
 
-	state := s.collector.State()
-	for _, run := range state.Runs {
-		if run.Counts.Failed > 0 {
-			return true
-		}
-		for _, pkg := range run.Packages {
-			if pkg.Status == results.StatusFailed {
-				return true
-			}
-		}
-	}
-
-	return false
+	return s.collector.State().HasFailures()
 }
@@ -56,7 +56,7 @@ func sendEvents(events []engine.Event) <-chan engine.Event {
 func TestSimpleOutput_Verbose_PassingTest(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, true, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), true, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(passingPackageEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -71,7 +71,7 @@ func TestSimpleOutput_Verbose_PassingTest(t *testing.T) {
 func TestSimpleOutput_Verbose_FailedTest(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, true, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), true, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(failingPackageEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -87,7 +87,7 @@ func TestSimpleOutput_Verbose_FailedTest(t *testing.T) {
 func TestSimpleOutput_NonVerbose_PassingTest(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(passingPackageEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -102,7 +102,7 @@ func TestSimpleOutput_NonVerbose_PassingTest(t *testing.T) {
 func TestSimpleOutput_NonVerbose_FailedTest(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(failingPackageEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -123,7 +123,7 @@ func TestSimpleOutput_NonVerbose_FailedTest(t *testing.T) {
 func TestSimpleOutput_NonVerbose_BuildError(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	events := []engine.Event{
 		{Type: engine.EventBuild, BuildEvent: parser.BuildEvent{ImportPath: "example.com/broken", Action: "build-output", Output: "# example.com/broken\n"}},
@@ -144,7 +144,7 @@ func TestSimpleOutput_NonVerbose_BuildError(t *testing.T) {
 func TestSimpleOutput_RawLines(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	events := []engine.Event{
 		{Type: engine.EventRawLine, RawLine: []byte("This is a raw line")},
@@ -191,7 +191,7 @@ func testMainPanicEvents(pkg string) []engine.Event {
 func TestSimpleOutput_NonVerbose_BadFlag(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(badFlagEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -206,7 +206,7 @@ func TestSimpleOutput_NonVerbose_BadFlag(t *testing.T) {
 func TestSimpleOutput_NonVerbose_TestMainPanic(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(testMainPanicEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -221,7 +221,7 @@ func TestSimpleOutput_NonVerbose_TestMainPanic(t *testing.T) {
 func TestSimpleOutput_Verbose_BadFlag(t *testing.T) {
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, true, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), true, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(badFlagEvents("example.com/pkg")))
 	require.NoError(t, err)
@@ -239,7 +239,7 @@ func TestSimpleOutput_HasFailures(t *testing.T) {
 	state.Runs = append(state.Runs, run)
 
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, false, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, false)
 
 	assert.False(t, simple.HasFailures())
 
@@ -283,7 +283,7 @@ func TestSimpleOutput_MultiExecutionBothFail(t *testing.T) {
 
 	collector := results.NewCollector()
 	var buf bytes.Buffer
-	simple := NewSimpleOutput(&buf, collector, 10*time.Second, format.SummaryOptions{}, true, 80, false)
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), true, 80, false)
 
 	err := simple.ProcessEvents(sendEvents(events))
 	require.NoError(t, err)
@@ -329,3 +329,66 @@ func TestSimpleOutput_MultiExecutionBothFail(t *testing.T) {
 	// Verify HasFailures returns true
 	assert.True(t, simple.HasFailures(), "HasFailures should return true")
 }
+
+func TestSimpleOutput_SummaryUsesCurrentWidth(t *testing.T) {
+	collector := results.NewCollector()
+	var buf bytes.Buffer
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, true)
+	// The terminal was resized to 100 columns during the run.
+	simple.SetWidthFunc(func() int { return 100 })
+
+	require.NoError(t, simple.ProcessEvents(sendEvents(passingPackageEvents("example.com/pkg"))))
+	assert.Contains(t, buf.String(), "\n"+strings.Repeat("-", 100)+"\n")
+	assert.NotContains(t, buf.String(), strings.Repeat("-", 101))
+}
+
+func TestSimpleOutput_SummaryWriter(t *testing.T) {
+	collector := results.NewCollector()
+	var buf, summaryBuf bytes.Buffer
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 80, true)
+	simple.SetSummaryWriter(&summaryBuf, true)
+
+	require.NoError(t, simple.ProcessEvents(sendEvents(failingPackageEvents("example.com/pkg"))))
+	assert.Contains(t, buf.String(), "FAIL\texample.com/pkg\t0.100s\n")
+	assert.NotContains(t, buf.String(), "(1 packages)")
+	assert.Contains(t, summaryBuf.String(), "--- FAIL: TestFail")
+	assert.Contains(t, summaryBuf.String(), "(1 packages)")
+}
+
+func TestSimpleOutput_FirstFailure(t *testing.T) {
+	collector := results.NewCollector()
+	var buf bytes.Buffer
+	simple := NewSimpleOutput(&buf, collector, format.NewSummaryOptions(), false, 60, true)
+	simple.SetFirstFailure(true)
+
+	// A second package starts first and stays running, so the failing
+	// package's output is buffered rather than streamed.
+	events := []engine.Event{
+		{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: baseTime, Action: "start", Package: "example.com/slow"}},
+		{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: baseTime, Action: "output", Package: "example.com/slow", Output: "setting up\n"}},
+	}
+	events = append(events, failingPackageEvents("example.com/a")...)
+	events = append(events, failingPackageEvents("example.com/b")...)
+
+	simple.Init()
+	var afterFirst string
+	for _, evt := range events {
+		collector.Push(evt)
+		simple.ProcessEvent(evt)
+		if evt.TestEvent.Action == "fail" && evt.TestEvent.Package == "example.com/a" && evt.TestEvent.Test != "" {
+			afterFirst = buf.String()
+		}
+	}
+
+	want := "========== FIRST FAILURE: example.com/a TestFail ===========\n" +
+		"--- FAIL: TestFail (0.00s)\n" +
+		"    test_fail.go:10: assertion failed\n" +
+		strings.Repeat("=", 60) + "\n"
+	assert.Contains(t, afterFirst, want, "Expected the first failure at once")
+	assert.Equal(t, 1, strings.Count(buf.String(), "FIRST FAILURE"), "Expected only the first failure in a block")
+
+	buf.Reset()
+	simple = NewSimpleOutput(&buf, results.NewCollector(), format.NewSummaryOptions(), false, 60, true)
+	require.NoError(t, simple.ProcessEvents(sendEvents(failingPackageEvents("example.com/a"))))
+	assert.NotContains(t, buf.String(), "FIRST FAILURE")
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+)
+
+// SplitLogs streams the output of each package to its own file in a
+// directory as events arrive (see format.PackageLogFileName), for
+// -split-logs, so a long run can be followed per package with tail -f.
+// Each file is created when its package first writes output, replacing any
+// earlier file, and kept open across the runs of an interactive session.
+type SplitLogs struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+	err   error // First error opening or writing a file
+}
+
+// NewSplitLogs returns a SplitLogs writing to dir, creating it if needed.
+func NewSplitLogs(dir string) (*SplitLogs, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &SplitLogs{dir: dir, files: make(map[string]*os.File)}, nil
+}
+
+// Sink writes the output of an event to its package's file, for
+// engine.Broadcaster.AddSink: the Output of test and package events, and
+// the build output of the package's test binary. Lines are written
+// unbuffered, so they can be followed as they arrive.
+func (s *SplitLogs) Sink(evt engine.Event) {
+	var pkg, out string
+	switch evt.Type {
+	case engine.EventTest:
+		pkg, out = evt.TestEvent.Package, evt.TestEvent.Output
+	case engine.EventBuild:
+		if evt.BuildEvent.Action == "build-output" {
+			// Build events name the package being compiled, e.g.
+			// "example.com/pkg [example.com/pkg.test]".
+			pkg, _, _ = strings.Cut(evt.BuildEvent.ImportPath, " ")
+			out = evt.BuildEvent.Output
+		}
+	}
+	if pkg == "" || out == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		return // Closed
+	}
+	f := s.files[pkg]
+	if f == nil {
+		var err error
+		f, err = os.Create(filepath.Join(s.dir, format.PackageLogFileName(pkg)))
+		if err != nil {
+			s.err = cmp.Or(s.err, err)
+			return
+		}
+		s.files[pkg] = f
+	}
+	if _, err := f.WriteString(out); err != nil {
+		s.err = cmp.Or(s.err, err)
+	}
+}
+
+// Close closes the files, returning the first error of any write.
+func (s *SplitLogs) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.files {
+		if err := f.Close(); err != nil {
+			s.err = cmp.Or(s.err, err)
+		}
+	}
+	s.files = nil
+	return s.err
+}
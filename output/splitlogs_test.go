@@ -0,0 +1,51 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitLogs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	logs, err := NewSplitLogs(dir)
+	require.NoError(t, err)
+
+	events := []engine.Event{
+		{Type: engine.EventBuild, BuildEvent: parser.BuildEvent{ImportPath: "example.com/b [example.com/b.test]", Action: "build-output", Output: "# example.com/b\n"}},
+		{Type: engine.EventBuild, BuildEvent: parser.BuildEvent{ImportPath: "example.com/b [example.com/b.test]", Action: "build-fail"}},
+		{Type: engine.EventRawLine, RawLine: []byte("not attributed")},
+	}
+	events = append(events, failingPackageEvents("example.com/a")...)
+	for _, evt := range events {
+		logs.Sink(evt)
+		// Output is written as it arrives, before the run finishes.
+		if evt.TestEvent.Output == "    test_fail.go:10: assertion failed\n" {
+			data, err := os.ReadFile(filepath.Join(dir, "example.com_a.log"))
+			require.NoError(t, err)
+			assert.Equal(t, "=== RUN   TestFail\n    test_fail.go:10: assertion failed\n", string(data))
+		}
+	}
+	require.NoError(t, logs.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "example.com_a.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "=== RUN   TestFail\n    test_fail.go:10: assertion failed\n--- FAIL: TestFail (0.00s)\nFAIL\nFAIL\texample.com/a\t0.100s\n", string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "example.com_b.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "# example.com/b\n", string(data))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Events after closing are dropped.
+	logs.Sink(failingPackageEvents("example.com/c")[2])
+	assert.NoFileExists(t, filepath.Join(dir, "example.com_c.log"))
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// SchemaVersion is the version of the recording format tang writes. It's
+// bumped when recordings gain something older versions of tang would
+// misread.
+const SchemaVersion = 1
+
+// Header is the first line tang writes to a recording of go test -json
+// events, e.g. with -jsonfile, marking the format of the events that follow
+// so later versions of tang can replay older recordings. It's optional:
+// recordings without one, e.g. plain go test -json output, are schema 0.
+type Header struct {
+	Schema  int    `json:"TangSchema"`
+	Version string `json:"TangVersion,omitempty"` // Version of tang which wrote the recording
+}
+
+// NewHeader returns the header of a recording written by this version of
+// tang, whose version is given.
+func NewHeader(version string) Header {
+	return Header{Schema: SchemaVersion, Version: version}
+}
+
+// Marshal returns the header's line, without a newline.
+func (h Header) Marshal() []byte {
+	line, _ := json.Marshal(h)
+	return line
+}
+
+// ParseHeader parses a header line, returning false if line isn't one. A
+// header is recognized by its TangSchema field anywhere in a recording,
+// e.g. where recordings were concatenated, and whatever its schema, so a
+// recording from a newer tang is still replayed as far as it can be.
+func ParseHeader(line []byte) (Header, bool) {
+	if !bytes.Contains(line, []byte(`"TangSchema"`)) {
+		return Header{}, false
+	}
+	var h Header
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil || fields["Action"] != nil {
+		return Header{}, false
+	}
+	if err := json.Unmarshal(line, &h); err != nil || h.Schema <= 0 {
+		return Header{}, false
+	}
+	return h, true
+}
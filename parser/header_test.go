@@ -0,0 +1,33 @@
+package parser
+
+import "testing"
+
+func TestHeader(t *testing.T) {
+	h := NewHeader("v1.2.3")
+	line := h.Marshal()
+	if got, want := string(line), `{"TangSchema":1,"TangVersion":"v1.2.3"}`; got != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+	if got, ok := ParseHeader(line); !ok || got != h {
+		t.Errorf("ParseHeader(%s) = %+v, %v", line, got, ok)
+	}
+
+	// Headers from newer versions, with fields this one doesn't know, are
+	// still recognized.
+	if got, ok := ParseHeader([]byte(`{"TangSchema":7,"TangVersion":"v9","Compressed":true}`)); !ok || got.Schema != 7 {
+		t.Errorf("ParseHeader() of a newer header = %+v, %v", got, ok)
+	}
+
+	for _, line := range []string{
+		`{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"p","Output":"\"TangSchema\":1\n"}`,
+		`{"Action":"output","TangSchema":1}`,
+		`{"TangSchema":0}`,
+		`{"TangSchema":"1"}`,
+		`"TangSchema"`,
+		`ok  	example.com/pkg	0.1s`,
+	} {
+		if h, ok := ParseHeader([]byte(line)); ok {
+			t.Errorf("ParseHeader(%s) = %+v, want no header", line, h)
+		}
+	}
+}
@@ -28,9 +28,11 @@ func (e *Event) IsBuildEvent() bool {
 	return e.ImportPath != "" && e.Time.IsZero()
 }
 
-// IsTestEvent returns true if this is a test event (has Time and Package)
+// IsTestEvent returns true if this is a test event (has Time and Package).
+// Events from `go tool test2json` run without -t and on a bare test binary
+// have neither, so any other event with an Action is a test event too.
 func (e *Event) IsTestEvent() bool {
-	return !e.Time.IsZero() || e.Package != ""
+	return !e.Time.IsZero() || e.Package != "" || (e.Action != "" && !e.IsBuildEvent())
 }
 
 // ToBuildEvent converts to a BuildEvent (only call if IsBuildEvent() is true)
@@ -51,6 +53,7 @@ func (e *Event) ToTestEvent() TestEvent {
 		Test:        e.Test,
 		Output:      e.Output,
 		Elapsed:     e.Elapsed,
+		ImportPath:  e.ImportPath,
 		FailedBuild: e.FailedBuild,
 	}
 }
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// LooksLikeEvent reports whether line starts like a JSON event, so failing
+// to parse it means it's corrupted rather than plain output.
+func LooksLikeEvent(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(line, " \t"), []byte(`{"`))
+}
+
+// Resync finds an event embedded in a corrupted line, e.g. when a writer
+// crashed mid-event and the next event was appended to the partial line.
+// It returns the index the embedded event starts at, and the event, or -1
+// if there's none.
+func Resync(line []byte) (int, Event) {
+	for i := 1; i < len(line); i++ {
+		j := bytes.Index(line[i:], []byte(`{"`))
+		if j < 0 {
+			break
+		}
+		i += j
+		if event, err := ParseEvent(line[i:]); err == nil && event.Action != "" {
+			return i, event
+		}
+	}
+	return -1, Event{}
+}
+
+var (
+	// recoverFieldRE matches a complete string or number field of a
+	// corrupted event.
+	recoverFieldRE = regexp.MustCompile(`"(\w+)":("(?:[^"\\]|\\.)*"|-?[0-9][0-9.eE+-]*)`)
+
+	// recoverTrailingRE matches a string field cut off at the end of a
+	// corrupted event.
+	recoverTrailingRE = regexp.MustCompile(`"(\w+)":"((?:[^"\\]|\\.)*)\\?$`)
+)
+
+// RecoverEvent extracts what fields it can from a corrupted event, e.g. one
+// truncated by a crashed writer, returning false if the line doesn't hold
+// enough to trust the event.
+//
+// go test -json writes the fields in a fixed order: Time, Action, Package,
+// Test, Elapsed, Output. An event is only recovered if it has an Action and
+// got as far as the Test field or a later one, so a test event cut off
+// before its Test field isn't mistaken for a package event. A cut off
+// Output is kept as far as it goes.
+func RecoverEvent(line []byte) (Event, bool) {
+	var event Event
+	seen := make(map[string]bool)
+	set := func(key, value string) {
+		seen[key] = true
+		switch key {
+		case "Time":
+			event.Time, _ = time.Parse(time.RFC3339Nano, value)
+		case "Action":
+			event.Action = value
+		case "Package":
+			event.Package = value
+		case "Test":
+			event.Test = value
+		case "Output":
+			event.Output = value
+		case "ImportPath":
+			event.ImportPath = value
+		case "FailedBuild":
+			event.FailedBuild = value
+		case "Elapsed":
+			event.Elapsed, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	for _, m := range recoverFieldRE.FindAllSubmatch(line, -1) {
+		value := string(m[2])
+		if m[2][0] == '"' {
+			if err := json.Unmarshal(m[2], &value); err != nil {
+				continue
+			}
+		}
+		set(string(m[1]), value)
+	}
+	if m := recoverTrailingRE.FindSubmatch(line); m != nil && string(m[1]) == "Output" && !seen["Output"] {
+		var value string
+		if err := json.Unmarshal(append(append([]byte{'"'}, m[2]...), '"'), &value); err == nil {
+			set("Output", value)
+		}
+	}
+
+	if event.Action == "" {
+		return Event{}, false
+	}
+	if !seen["Test"] && !seen["Elapsed"] && !seen["Output"] && !seen["FailedBuild"] {
+		return Event{}, false
+	}
+	return event, true
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Severity is how bad a Problem found by Validate is.
+type Severity int
+
+const (
+	// SeverityWarning is something unusual which tang copes with, e.g. an
+	// action added by a newer version of go.
+	SeverityWarning Severity = iota
+	// SeverityError is a structural problem with the stream, which tang
+	// misreads or drops, e.g. a truncated event.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Problem is something wrong with a line of a go test -json stream.
+type Problem struct {
+	Line     int // 1-based
+	Severity Severity
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Severity, p.Message)
+}
+
+// Validation is the result of validating a go test -json stream.
+type Validation struct {
+	Lines    int // Lines read
+	Events   int // Test and build events
+	RawLines int // Lines which aren't JSON, e.g. output of go vet
+	Packages int // Distinct packages with test events
+	Tests    int // Distinct tests, by package and name
+	Problems []Problem
+	Errors   int // Problems of SeverityError
+}
+
+// knownActions are the actions of go test -json events, as of Go 1.25.
+var knownActions = map[string]bool{
+	"start": true, "run": true, "pause": true, "cont": true, "pass": true, "fail": true,
+	"skip": true, "output": true, "bench": true,
+	"build-output": true, "build-fail": true,
+}
+
+// validatePackage tracks the state of a package while validating.
+type validatePackage struct {
+	events   bool      // Test events were seen since the package (re)started
+	finished bool      // The package passed, failed or was skipped
+	last     time.Time // Time of its latest event
+	tests    map[string]bool
+}
+
+// Validate reads a recorded go test -json stream and reports problems with
+// it without acting on it: malformed events, unknown actions, events out of
+// order, and events of tests which never ran. It's for debugging pipelines
+// which mangle the stream. Only read errors are returned.
+func Validate(r io.Reader) (*Validation, error) {
+	v := &Validation{}
+	report := func(severity Severity, format string, args ...any) {
+		v.Problems = append(v.Problems, Problem{Line: v.Lines, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		if severity == SeverityError {
+			v.Errors++
+		}
+	}
+	packages := make(map[string]*validatePackage)
+	seen := make(map[string]bool) // Packages and "package/test"s, for counting
+	count := func(key string, n *int) {
+		if !seen[key] {
+			seen[key] = true
+			*n++
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		v.Lines++
+		line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+		if _, ok := ParseHeader(line); ok {
+			continue
+		}
+		event, err := ParseEvent(line)
+		if err != nil {
+			if LooksLikeEvent(line) {
+				report(SeverityError, "malformed event: %v", err)
+			} else {
+				// tang ends the run at a raw line, so whatever follows is
+				// a new run, e.g. of recordings concatenated with notes.
+				v.RawLines++
+				clear(packages)
+			}
+			continue
+		}
+		switch {
+		case event.Action == "":
+			report(SeverityWarning, "JSON line without an Action")
+			continue
+		case !knownActions[event.Action]:
+			report(SeverityWarning, "unknown action %q", event.Action)
+		}
+		v.Events++
+		if event.IsBuildEvent() {
+			continue
+		}
+
+		pkg := packages[event.Package]
+		if pkg == nil {
+			pkg = &validatePackage{tests: make(map[string]bool)}
+			packages[event.Package] = pkg
+		}
+		if event.Package != "" {
+			count(event.Package, &v.Packages)
+		}
+		if event.Action == "start" && event.Test == "" && pkg.finished {
+			// A package may start again, e.g. when retried.
+			*pkg = validatePackage{tests: make(map[string]bool)}
+		}
+		if !event.Time.IsZero() {
+			if event.Time.Before(pkg.last) {
+				report(SeverityError, "out of order: %s event of %s is %s older than the previous one",
+					event.Action, describe(event), pkg.last.Sub(event.Time))
+			} else {
+				pkg.last = event.Time
+			}
+		}
+
+		if event.Test == "" {
+			switch event.Action {
+			case "start":
+				if pkg.events {
+					report(SeverityError, "out of order: package %s started after its tests", event.Package)
+				}
+			case "pass", "fail", "skip":
+				if pkg.finished {
+					report(SeverityError, "out of order: package %s finished twice", event.Package)
+				}
+				pkg.finished = true
+			case "output":
+			default:
+				if pkg.finished {
+					report(SeverityError, "out of order: %s event of package %s after it finished", event.Action, event.Package)
+				}
+			}
+			continue
+		}
+
+		if pkg.finished {
+			report(SeverityError, "out of order: %s event of %s after its package finished", event.Action, describe(event))
+		}
+		pkg.events = true
+		count(event.Package+"/"+event.Test, &v.Tests)
+		if event.Action != "run" && !pkg.tests[event.Test] {
+			report(SeverityError, "orphan: %s event of %s, which never ran", event.Action, describe(event))
+		}
+		// An orphan's later events are blamed on its first.
+		pkg.tests[event.Test] = true
+	}
+	return v, scanner.Err()
+}
+
+// describe names the test of an event with its package, or the package of
+// a package event.
+func describe(event Event) string {
+	switch {
+	case event.Test == "":
+		return "package " + event.Package
+	case event.Package == "":
+		return event.Test
+	}
+	return event.Package + " " + event.Test
+}
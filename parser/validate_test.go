@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	input := strings.Join([]string{
+		`{"TangSchema":1,"TangVersion":"v1.0.0"}`,
+		`{"ImportPath":"example.com/broken","Action":"build-fail"}`,
+		`{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}`,
+		`{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:02Z","Action":"output","Package":"example.com/pkg","Test":"TestB","Output":"x\n"}`,
+		`{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"example.com/pkg","Test":"TestB"}`,
+		`{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:03Z","Action":"frobnicate","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:04Z","Action":"outp`,
+		`{"Time":"2024-01-01T00:00:04Z","Action":"pass","Package":"example.com/pkg"}`,
+		`{"Time":"2024-01-01T00:00:05Z","Action":"output","Package":"example.com/pkg","Test":"TestA","Output":"late\n"}`,
+		`{"Foo":"bar"}`,
+		// A raw line ends the run, so the package may start over.
+		`FAIL`,
+		`{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"example.com/pkg"}`,
+		`{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"example.com/pkg","Test":"TestA"}`,
+		`{"Time":"2024-01-01T00:00:03Z","Action":"pass","Package":"example.com/pkg"}`,
+		// A retried package starts again after finishing.
+		`{"Time":"2024-01-01T00:00:04Z","Action":"start","Package":"example.com/pkg"}`,
+		`{"Time":"2024-01-01T00:00:05Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}`,
+	}, "\n")
+
+	v, err := Validate(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Lines != 19 || v.Events != 15 || v.RawLines != 1 || v.Packages != 1 || v.Tests != 2 {
+		t.Errorf("Unexpected counts: %d lines, %d events, %d raw, %d packages, %d tests", v.Lines, v.Events, v.RawLines, v.Packages, v.Tests)
+	}
+
+	var got []string
+	for _, p := range v.Problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"line 5: error: orphan: output event of example.com/pkg TestB, which never ran",
+		"line 7: error: out of order: pass event of example.com/pkg TestA is 1s older than the previous one",
+		`line 8: warning: unknown action "frobnicate"`,
+		"line 9: error: malformed event: unexpected end of JSON input",
+		"line 11: error: out of order: output event of example.com/pkg TestA after its package finished",
+		"line 12: warning: JSON line without an Action",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if v.Errors != 4 {
+		t.Errorf("Errors = %d, want 4", v.Errors)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// prepareInterval is how often the preparing line is redrawn.
+	prepareInterval = 100 * time.Millisecond
+
+	// downloadQuiet is how long after its last "go: downloading" line go
+	// test is taken to have moved on to compiling.
+	downloadQuiet = 2 * time.Second
+)
+
+// preparePhase shows what go test is doing before its first event, in a
+// line redrawn in place on a terminal, e.g.
+//
+//	preparing: downloading golang.org/x/sys v0.20.0 (3 modules)  4.2s
+//
+// so the screen isn't blank while modules download and packages compile,
+// which can take minutes. It's go test's stderr: download progress lines
+// feed the preparing line, and other lines, e.g. errors, are passed on to
+// stderr. Once the first event arrives (see watch), the line is cleared
+// and stderr is passed on as is.
+type preparePhase struct {
+	out      io.Writer // The terminal the line is drawn on
+	stderr   io.Writer
+	format   func(time.Duration) string
+	start    time.Time
+	interval time.Duration
+
+	mu           sync.Mutex
+	partial      []byte    // An incomplete line of stderr
+	module       string    // The module last downloaded
+	downloads    int       // Modules downloaded
+	lastDownload time.Time // When the last download started
+	drawn        bool      // Whether the line is on screen
+	done         bool
+	stopped      chan struct{}
+}
+
+// newPreparePhase starts drawing the preparing line on out, timed with
+// format.
+func newPreparePhase(out, stderr io.Writer, format func(time.Duration) string) *preparePhase {
+	p := &preparePhase{
+		out:      out,
+		stderr:   stderr,
+		format:   format,
+		start:    time.Now(),
+		interval: prepareInterval,
+		stopped:  make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// loop redraws the line until stop is called.
+func (p *preparePhase) loop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			p.mu.Lock()
+			if !p.done {
+				p.draw(now)
+			}
+			p.mu.Unlock()
+		case <-p.stopped:
+			return
+		}
+	}
+}
+
+// status returns the text of the preparing line at now.
+func (p *preparePhase) status(now time.Time) string {
+	elapsed := p.format(now.Sub(p.start))
+	if p.downloads > 0 && now.Sub(p.lastDownload) < downloadQuiet {
+		modules := "1 module"
+		if p.downloads > 1 {
+			modules = fmt.Sprintf("%d modules", p.downloads)
+		}
+		return fmt.Sprintf("preparing: downloading %s (%s)  %s", p.module, modules, elapsed)
+	}
+	return fmt.Sprintf("preparing: building  %s", elapsed)
+}
+
+// draw redraws the line. The caller holds p.mu.
+func (p *preparePhase) draw(now time.Time) {
+	_, _ = fmt.Fprintf(p.out, "\r%s%s", ansi.EraseEntireLine, p.status(now))
+	p.drawn = true
+}
+
+// clear erases the line. The caller holds p.mu.
+func (p *preparePhase) clear() {
+	if p.drawn {
+		_, _ = fmt.Fprintf(p.out, "\r%s", ansi.EraseEntireLine)
+		p.drawn = false
+	}
+}
+
+// Write consumes go test's stderr.
+func (p *preparePhase) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return p.stderr.Write(b)
+	}
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := p.partial[:i+1]
+		p.partial = p.partial[i+1:]
+		if module, ok := downloadedModule(string(line)); ok {
+			p.module = module
+			p.downloads++
+			p.lastDownload = time.Now()
+			continue
+		}
+		p.clear()
+		if _, err := p.stderr.Write(line); err != nil {
+			return len(b), err
+		}
+	}
+	return len(b), nil
+}
+
+// downloadedModule returns the module of a "go: downloading" line, e.g.
+// "golang.org/x/sys v0.20.0", and whether line is one.
+func downloadedModule(line string) (string, bool) {
+	module, ok := strings.CutPrefix(strings.TrimSpace(line), "go: downloading ")
+	return module, ok && module != ""
+}
+
+// stop clears the line, and passes the rest of stderr on as is.
+func (p *preparePhase) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return
+	}
+	p.done = true
+	close(p.stopped)
+	p.clear()
+	if len(p.partial) > 0 {
+		_, _ = p.stderr.Write(p.partial)
+		p.partial = nil
+	}
+}
+
+// watch returns a reader of r, go test's stdout, stopping the phase when
+// the first output arrives, or r ends.
+func (p *preparePhase) watch(r io.ReadCloser) io.ReadCloser {
+	return &prepareWatcher{ReadCloser: r, phase: p}
+}
+
+// prepareWatcher is the reader returned by preparePhase.watch.
+type prepareWatcher struct {
+	io.ReadCloser
+	phase *preparePhase
+}
+
+func (w *prepareWatcher) Read(b []byte) (int, error) {
+	n, err := w.ReadCloser.Read(b)
+	if n > 0 || err != nil {
+		w.phase.stop()
+	}
+	return n, err
+}
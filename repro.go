@@ -0,0 +1,234 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// goTestValueFlags are the go test flags, build and test, which take a
+// value, so a value given as the next argument isn't taken for a package.
+var goTestValueFlags = map[string]bool{
+	"C": true, "asmflags": true, "bench": true, "benchtime": true, "blockprofile": true, "blockprofilerate": true,
+	"count": true, "covermode": true, "coverpkg": true, "coverprofile": true, "cpu": true, "cpuprofile": true,
+	"exec": true, "fuzz": true, "fuzzminimizetime": true, "fuzztime": true, "gccgoflags": true, "gcflags": true,
+	"installsuffix": true, "ldflags": true, "list": true, "memprofile": true, "memprofilerate": true, "mod": true,
+	"modfile": true, "mutexprofile": true, "mutexprofilefraction": true, "o": true, "outputdir": true, "overlay": true,
+	"p": true, "parallel": true, "pgo": true, "pkgdir": true, "run": true, "shuffle": true, "skip": true,
+	"tags": true, "timeout": true, "toolexec": true, "trace": true, "vet": true,
+}
+
+// runRepro implements the `tang repro` subcommand, which reruns a run of
+// exec mode recorded with -history or -db as identically as it can: with
+// the same go test arguments, in the same directory, with the go
+// environment variables set as they were, and each package shuffled with
+// the seed it was.
+func runRepro(args []string) int {
+	fs := flag.NewFlagSet("repro", flag.ContinueOnError)
+	historyFile := fs.String("history", "", "History file recorded with -history")
+	dbFile := fs.String("db", "", "SQLite history database recorded with -db, instead of -history")
+	dryRun := fs.Bool("n", false, "Print the commands without running them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang repro -history <file> | -db <file> [flags] <run-id>\n\n")
+		fmt.Fprintf(os.Stderr, "Rerun a run recorded in exec mode ('tang test') identically: with the same go test\n")
+		fmt.Fprintf(os.Stderr, "arguments, directory, go environment variables and shuffle seeds. A run ID may be\n")
+		fmt.Fprintf(os.Stderr, "abbreviated to a unique prefix.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if (*historyFile == "") == (*dbFile == "") {
+		fmt.Fprintf(os.Stderr, "Error: repro requires one of -history <filename> and -db <filename>\n")
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: repro requires one run ID\n")
+		return 1
+	}
+
+	var runs []*format.SummaryJSON
+	var err error
+	if *dbFile != "" {
+		runs, err = loadDB(*dbFile, 0)
+	} else {
+		runs, err = history.Load(*historyFile, 0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		return 1
+	}
+	sj, err := findRun(runs, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	r := sj.Repro
+	if r == nil {
+		fmt.Fprintf(os.Stderr, "Error: run %s wasn't recorded in exec mode, so there's no go test command to rerun\n", sj.RunID)
+		return 1
+	}
+
+	if version := goVersion(); r.GoVersion != "" && version != r.GoVersion {
+		fmt.Fprintf(os.Stderr, "Warning: the run was with %s, not %s\n", r.GoVersion, version)
+	}
+	env := os.Environ()
+	if fp := results.EnvFingerprint(env); r.EnvFingerprint != "" && fp != r.EnvFingerprint {
+		fmt.Fprintf(os.Stderr, "Warning: the environment differs from the run's (fingerprint %s, not %s)\n", fp, r.EnvFingerprint)
+	}
+	for name, value := range r.Env {
+		env = append(env, name+"="+value)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, goTestArgs := range reproArgs(sj) {
+		fmt.Fprintf(os.Stderr, "tang test %s\n", strings.Join(goTestArgs, " "))
+		if *dryRun {
+			continue
+		}
+		cmd := exec.Command(self, append([]string{"test"}, goTestArgs...)...)
+		cmd.Dir = r.Dir
+		cmd.Env = env
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			code = cmp.Or(code, exitErr.ExitCode())
+		}
+	}
+	return code
+}
+
+// findRun returns the run of runs with the ID id, or an unambiguous
+// prefix of it.
+func findRun(runs []*format.SummaryJSON, id string) (*format.SummaryJSON, error) {
+	var found *format.SummaryJSON
+	for _, sj := range runs {
+		if sj.RunID == id {
+			return sj, nil
+		}
+		if id != "" && strings.HasPrefix(sj.RunID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("run ID %s is ambiguous: %s, %s, ...", id, found.RunID, sj.RunID)
+			}
+			found = sj
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no run %s in the history", id)
+	}
+	return found, nil
+}
+
+// reproArgs returns the go test arguments of each command rerunning the
+// run. Shuffled packages are shuffled with their seed: when they all had
+// the same one, e.g. from -shuffle=N, the run's command is rerun with it,
+// and otherwise the packages sharing a seed are rerun together, each group
+// by its own command.
+func reproArgs(sj *format.SummaryJSON) [][]string {
+	args := sj.Repro.Args
+	seeds := sj.Repro.ShuffleSeeds
+	var order []string // Distinct seeds, in package order
+	for _, pkg := range sj.Packages {
+		if seed, ok := seeds[pkg.Name]; ok && !slices.Contains(order, seed) {
+			order = append(order, seed)
+		}
+	}
+	switch len(order) {
+	case 0:
+		return [][]string{args}
+	case 1:
+		return [][]string{withShuffle(args, order[0])}
+	}
+
+	flags := goTestFlags(args)
+	commands := make([][]string, 0, len(order))
+	for _, seed := range order {
+		command := withShuffle(flags, seed)
+		i := argsIndex(command)
+		var pkgs []string
+		for _, pkg := range sj.Packages {
+			if seeds[pkg.Name] == seed {
+				pkgs = append(pkgs, pkg.Name)
+			}
+		}
+		commands = append(commands, slices.Insert(command, i, pkgs...))
+	}
+	return commands
+}
+
+// withShuffle returns args with go test's -shuffle set to seed.
+func withShuffle(args []string, seed string) []string {
+	end := argsIndex(args)
+	out := make([]string, 0, len(args)+1)
+	for i := 0; i < end; i++ {
+		name, value, isFlag := parseFlagArg(args[i])
+		if !isFlag || name != "shuffle" {
+			out = append(out, args[i])
+			continue
+		}
+		if value == "" && !strings.Contains(args[i], "=") {
+			i++ // Its value is the next argument
+		}
+	}
+	out = append(out, "-shuffle="+seed)
+	return append(out, args[end:]...)
+}
+
+// goTestFlags returns args without their packages.
+func goTestFlags(args []string) []string {
+	end := argsIndex(args)
+	out := make([]string, 0, len(args))
+	for i := 0; i < end; i++ {
+		name, _, isFlag := parseFlagArg(args[i])
+		if !isFlag {
+			continue
+		}
+		out = append(out, args[i])
+		if goTestValueFlags[name] && !strings.Contains(args[i], "=") && i+1 < end {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return append(out, args[end:]...)
+}
+
+// argsIndex returns the index of -args in go test's arguments, after which
+// they're the test binary's, or len(args).
+func argsIndex(args []string) int {
+	if i := slices.IndexFunc(args, func(arg string) bool { return arg == "-args" || arg == "--args" }); i >= 0 {
+		return i
+	}
+	return len(args)
+}
+
+// goVersion returns the version of the go command, as `go version` reports
+// it, e.g. "go1.25.1 linux/amd64", or "" if it can't be run.
+func goVersion() string {
+	out, err := exec.Command("go", "version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "go version ")
+}
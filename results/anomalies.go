@@ -0,0 +1,65 @@
+package results
+
+import (
+	"fmt"
+
+	"github.com/ansel1/tang/parser"
+)
+
+// Anomaly is a go test event breaking the stream's ordering invariants,
+// e.g. a test passing which never ran. It points at a bug in tang or in the
+// tooling producing the stream. Anomalies are only recorded by a strict
+// collector; see SetStrict.
+type Anomaly struct {
+	Package string
+	Test    string // Empty for a package's anomalies
+	Problem string
+}
+
+func (a Anomaly) String() string {
+	if a.Test == "" {
+		return a.Package + ": " + a.Problem
+	}
+	return a.Package + " " + a.Test + ": " + a.Problem
+}
+
+// SetStrict makes the collector record anomalies in the stream's events
+// in their run's Anomalies, as warnings: they don't change how the events
+// are handled.
+func (c *Collector) SetStrict(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strict = strict
+}
+
+// recordAnomaly records an anomaly of event in the run, if the collector
+// is strict.
+func (c *Collector) recordAnomaly(run *Run, event parser.TestEvent, format string, args ...any) {
+	if !c.strict {
+		return
+	}
+	run.Anomalies = append(run.Anomalies, Anomaly{Package: event.Package, Test: event.Test, Problem: fmt.Sprintf(format, args...)})
+}
+
+// checkTestEvent records the anomalies of a test-level event: an event
+// other than run for a test which never ran, or a second result for one
+// execution. exists is whether the test had run before the event.
+func (c *Collector) checkTestEvent(run *Run, event parser.TestEvent, exists bool) {
+	if !c.strict {
+		return
+	}
+	if !exists {
+		if event.Action != "run" {
+			c.recordAnomaly(run, event, "%s event before its run event", event.Action)
+		}
+		return
+	}
+	switch event.Action {
+	case "pass", "fail", "skip":
+		tr := run.TestResults[event.Package+"/"+event.Test]
+		switch status := tr.Status(); status {
+		case StatusPassed, StatusFailed, StatusSkipped:
+			c.recordAnomaly(run, event, "%s after it already %s", event.Action, status)
+		}
+	}
+}
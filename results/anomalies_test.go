@@ -0,0 +1,71 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorAnomalies(t *testing.T) {
+	now := time.Now()
+	events := []parser.TestEvent{
+		{Time: now, Action: "start", Package: "pkg"},
+		{Time: now, Action: "output", Package: "pkg", Test: "TestGhost", Output: "hello\n"},
+		{Time: now, Action: "pass", Package: "pkg", Test: "TestNeverRan"},
+		{Time: now, Action: "run", Package: "pkg", Test: "TestA"},
+		{Time: now, Action: "pass", Package: "pkg", Test: "TestA"},
+		{Time: now, Action: "fail", Package: "pkg", Test: "TestA"},
+		// A rerun, e.g. with -count=2, isn't a duplicate.
+		{Time: now, Action: "run", Package: "pkg", Test: "TestA"},
+		{Time: now, Action: "pass", Package: "pkg", Test: "TestA"},
+		{Time: now, Action: "run", Package: "pkg", Test: "TestB"},
+		{Time: now, Action: "fail", Package: "pkg"},
+	}
+	push := func(strict bool) *Run {
+		collector := NewCollector()
+		collector.SetStrict(strict)
+		for _, evt := range events {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: evt})
+		}
+		collector.Push(engine.Event{Type: engine.EventComplete})
+		return collector.State().MostRecentRun()
+	}
+
+	want := []string{
+		"pkg TestGhost: output event before its run event",
+		"pkg TestNeverRan: pass event before its run event",
+		"pkg TestA: fail after it already passed",
+		"pkg: failed with 1 tests still running",
+	}
+	var got []string
+	for _, a := range push(true).Anomalies {
+		got = append(got, a.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected anomalies %q, got %q", want, got)
+	}
+
+	if run := push(false); len(run.Anomalies) != 0 {
+		t.Errorf("Expected no anomalies without strict, got %v", run.Anomalies)
+	}
+}
+
+func TestCollectorAnomaliesTimeoutPanic(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.SetStrict(true)
+	for _, evt := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "pkg"},
+		{Time: now, Action: "run", Package: "pkg", Test: "TestSlow"},
+		{Time: now, Action: "output", Package: "pkg", Test: "TestSlow", Output: "panic: test timed out after 1s\n"},
+		{Time: now, Action: "fail", Package: "pkg"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: evt})
+	}
+	if run := collector.State().MostRecentRun(); len(run.Anomalies) != 0 {
+		t.Errorf("Expected tests killed by a panic not to be anomalies, got %v", run.Anomalies)
+	}
+}
@@ -0,0 +1,35 @@
+package results
+
+import (
+	"slices"
+	"strings"
+)
+
+// ArtifactMarker starts a line of test output attaching a file to the test,
+// e.g. a screenshot or a dump, which is listed with the test's failure:
+//
+//	t.Log("TANG_ARTIFACT: /tmp/screenshot.png")
+//
+// Relative paths are relative to the test's package directory, the working
+// directory of go test's test binaries.
+const ArtifactMarker = "TANG_ARTIFACT:"
+
+// artifactPath returns the path a line of test output attaches, if it's an
+// ArtifactMarker line, with or without the "file_test.go:12: " prefix
+// testing.T adds to logged lines.
+func artifactPath(line string) (string, bool) {
+	if loc := skipLocationRE.FindStringIndex(line); loc != nil {
+		line = line[loc[1]:]
+	}
+	path, ok := strings.CutPrefix(strings.TrimSpace(line), ArtifactMarker)
+	path = strings.TrimSpace(path)
+	return path, ok && path != ""
+}
+
+// recordArtifact records the artifact a line of the test's output attaches,
+// if any. A path attached more than once is recorded once.
+func (e *TestExecution) recordArtifact(line string) {
+	if path, ok := artifactPath(line); ok && !slices.Contains(e.Artifacts, path) {
+		e.Artifacts = append(e.Artifacts, path)
+	}
+}
@@ -0,0 +1,34 @@
+package results
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorRecordsArtifacts(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "pkg"},
+		{Action: "run", Package: "pkg", Test: "TestA"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "    a_test.go:10: TANG_ARTIFACT: /tmp/shot.png\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "TANG_ARTIFACT: testdata/dump.txt\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "    a_test.go:12: TANG_ARTIFACT: /tmp/shot.png\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "    a_test.go:13: TANG_ARTIFACT:\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "    a_test.go:14: no TANG_ARTIFACT: here\n"},
+		{Action: "fail", Package: "pkg", Test: "TestA"},
+		{Action: "fail", Package: "pkg"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	exec := collector.State().MostRecentRun().TestResults["pkg/TestA"].Latest()
+	if want := []string{"/tmp/shot.png", "testdata/dump.txt"}; !slices.Equal(exec.Artifacts, want) {
+		t.Errorf("Artifacts = %q, want %q", exec.Artifacts, want)
+	}
+}
@@ -0,0 +1,53 @@
+package results
+
+import (
+	"regexp"
+	"strings"
+)
+
+// framingRE matches the lines go test prints to say whose output follows,
+// e.g. "=== CONT  TestX/sub" or "    --- FAIL: TestX/sub (0.00s)", capturing
+// the marker verb and the test name.
+var framingRE = regexp.MustCompile(`^\s*(?:=== (RUN|CONT|PAUSE|NAME)\s+|--- (?:PASS|FAIL|SKIP): )(\S+)`)
+
+// attributeOutput returns the test an output line reported against test
+// belongs to.
+//
+// go test attributes the output of parallel subtests to their parent until
+// the subtest is seen to resume, so interleaved lines can land on the
+// parent. The package tracks the test framed by the latest run/cont action
+// or "===" / "---" line; output reported against an ancestor of that test
+// is moved to it. Output is only ever moved from a test to one of its own
+// subtests.
+func (c *Collector) attributeOutput(run *Run, pkg *PackageResult, test, output string) string {
+	if m := framingRE.FindStringSubmatch(output); m != nil {
+		named := m[2]
+		if m[1] == "PAUSE" {
+			pkg.unframe(named)
+		} else {
+			pkg.framedTest = named
+		}
+		if isSubtestOf(named, test) && run.TestResults[pkg.Name+"/"+named] != nil {
+			return named
+		}
+		return test
+	}
+
+	if isSubtestOf(pkg.framedTest, test) && run.TestResults[pkg.Name+"/"+pkg.framedTest] != nil {
+		return pkg.framedTest
+	}
+	return test
+}
+
+// unframe clears the framed test if it's test; a paused test prints nothing
+// until it resumes.
+func (p *PackageResult) unframe(test string) {
+	if p.framedTest == test {
+		p.framedTest = ""
+	}
+}
+
+// isSubtestOf reports whether name is a subtest, at any depth, of parent.
+func isSubtestOf(name, parent string) bool {
+	return strings.HasPrefix(name, parent+"/")
+}
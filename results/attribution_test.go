@@ -0,0 +1,83 @@
+package results
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ansel1/tang/engine"
+)
+
+func TestCollectorAttributesInterleavedSubtestOutput(t *testing.T) {
+	f, err := os.Open("testdata/interleaved_subtests.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	collector := NewCollector()
+	for evt := range engine.NewEngine().Stream(f) {
+		collector.Push(evt)
+	}
+	run := collector.State().MostRecentRun()
+
+	tests := []struct {
+		name    string
+		output  []string
+		summary string
+	}{
+		{"TestP", []string{"    p_test.go:9: parent setup done", "    p_test.go:22: parent teardown"}, "--- FAIL: TestP (0.01s)"},
+		{"TestP/a", []string{"    p_test.go:15: a: connecting", "    p_test.go:17: a: got 1, want 2"}, "--- FAIL: TestP/a (0.00s)"},
+		{"TestP/b", []string{"    p_test.go:15: b: connecting"}, "--- PASS: TestP/b (0.00s)"},
+	}
+	for _, tt := range tests {
+		tr := run.TestResults["example.com/pkg/"+tt.name]
+		if tr == nil {
+			t.Errorf("Expected test %s", tt.name)
+			continue
+		}
+		if got := tr.Latest().Output; !reflect.DeepEqual(got, tt.output) {
+			t.Errorf("%s: expected output %q, got %q", tt.name, tt.output, got)
+		}
+		if got := tr.Latest().SummaryLine; got != tt.summary {
+			t.Errorf("%s: expected summary line %q, got %q", tt.name, tt.summary, got)
+		}
+	}
+}
+
+func TestAttributeOutputOnlyMovesToSubtests(t *testing.T) {
+	run := NewRun(1)
+	pkg := &PackageResult{Name: "pkg"}
+	for _, name := range []string{"TestA", "TestB", "TestA/sub"} {
+		run.TestResults["pkg/"+name] = NewTestResult("pkg", name)
+	}
+	c := NewCollector()
+
+	pkg.framedTest = "TestB"
+	if got := c.attributeOutput(run, pkg, "TestA", "hello"); got != "TestA" {
+		t.Errorf("Expected output to stay on TestA when a sibling is framed, got %s", got)
+	}
+
+	pkg.framedTest = "TestA/sub"
+	if got := c.attributeOutput(run, pkg, "TestA", "hello"); got != "TestA/sub" {
+		t.Errorf("Expected output to move to the framed subtest, got %s", got)
+	}
+	if got := c.attributeOutput(run, pkg, "TestA/sub", "hello"); got != "TestA/sub" {
+		t.Errorf("Expected subtest output to stay put, got %s", got)
+	}
+
+	// A framing line naming an unknown test changes nothing.
+	if got := c.attributeOutput(run, pkg, "TestA", "=== CONT  TestA/other"); got != "TestA" {
+		t.Errorf("Expected output to stay on TestA for an unknown subtest, got %s", got)
+	}
+	if got := c.attributeOutput(run, pkg, "TestA", "hello"); got != "TestA" {
+		t.Errorf("Expected output to stay on TestA once an unknown subtest is framed, got %s", got)
+	}
+
+	// Pausing the framed test hands output back to its parent.
+	c.attributeOutput(run, pkg, "TestA/sub", "=== CONT  TestA/sub")
+	c.attributeOutput(run, pkg, "TestA/sub", "=== PAUSE TestA/sub")
+	if got := c.attributeOutput(run, pkg, "TestA", "hello"); got != "TestA" {
+		t.Errorf("Expected output to stay on TestA after the subtest paused, got %s", got)
+	}
+}
@@ -1,6 +1,8 @@
 package results
 
 import (
+	"cmp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -17,20 +19,60 @@ import (
 // - Run starts: Any test or build event when no current run exists
 // - Run finishes: Running package count drops to 0
 type Collector struct {
-	mu            sync.Mutex
-	state         *State
-	lastEventTime time.Time
-	isReplay      bool
-	replayRate    float64
+	mu         sync.Mutex
+	state      *State
+	isReplay   bool
+	replayRate float64
+	labels     Labels
+	modes      []Mode
+	repro      *Reproducibility
+	handler    func(Event)
+	defaultPkg string
+	clock      engine.Clock
+
+	keepRepeats  bool
+	mergeRetries bool
+	maxLineRate  int
+	pkgFilter    PackageFilter
+	strict       bool
+
+	// pendingMalformed counts malformed lines seen before the first run,
+	// which are attributed to it.
+	pendingMalformed int
+
+	// pendingAnnotations are annotations pushed between runs, which
+	// concern the next one, e.g. a fixture deployed before go test starts.
+	pendingAnnotations []engine.Annotation
+
+	events uint64 // Events pushed, for Stats
+}
+
+// CollectorStats measures the collector's workload, for diagnosing tang's
+// own resource usage on very large streams.
+type CollectorStats struct {
+	Events      uint64 // Events pushed
+	Runs        int
+	Packages    int // Packages across all runs
+	TestResults int // Test results across all runs
 }
 
 // NewCollector creates a new result collector.
 func NewCollector() *Collector {
 	return &Collector{
 		state: NewState(),
+		clock: engine.SystemClock,
 	}
 }
 
+// SetClock sets the clock wall times are taken from, e.g. when tests
+// started, for timing runs without event times and interrupted tests. It's
+// engine.SystemClock by default; tests set an engine.ManualClock.
+func (c *Collector) SetClock(clock engine.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
 // SetReplay configures whether the collector is running in replay mode and the rate.
 func (c *Collector) SetReplay(replay bool, rate float64) {
 	c.mu.Lock()
@@ -39,6 +81,91 @@ func (c *Collector) SetReplay(replay bool, rate float64) {
 	c.replayRate = rate
 }
 
+// SetLabels sets the labels attached to each subsequent run.
+func (c *Collector) SetLabels(labels Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labels = labels
+}
+
+// SetModes sets the modes each subsequent run is known to be under, e.g.
+// from the go test arguments in exec mode. Modes shown by the output, such
+// as a data race report, are added as they're seen.
+func (c *Collector) SetModes(modes []Mode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.modes = modes
+}
+
+// SetReproducibility sets how each subsequent run's go test is run, in
+// exec mode; see Run.Repro.
+func (c *Collector) SetReproducibility(r *Reproducibility) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repro = r
+}
+
+// SetDefaultPackage sets the package that test events without a Package
+// are attributed to. `go tool test2json` driving a prebuilt test binary
+// omits it, along with the package "start" event, which isn't required.
+// With no default package, such events are ignored.
+func (c *Collector) SetDefaultPackage(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultPkg = name
+}
+
+// SetKeepRepeatedLines controls whether consecutive duplicate lines of test
+// output are all stored. By default they're collapsed into a "previous line
+// repeated N times" line, saving memory when a test logs the same warning
+// thousands of times. Events carry every line either way.
+func (c *Collector) SetKeepRepeatedLines(keep bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepRepeats = keep
+}
+
+// SetMaxLineRate sets how many lines of output per second each test may
+// log before further lines in the second are dropped, counted by a marker
+// line in the test's output, protecting tang from a runaway test. Dropped
+// lines aren't stored or passed to the event handler; test and package
+// events are never dropped, nor is the output of a test which panicked.
+// Zero, the default, keeps every line.
+func (c *Collector) SetMaxLineRate(rate int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxLineRate = rate
+}
+
+// SetPackageFilter sets the packages tracked: events of other packages,
+// including their build output, are ignored, as if they weren't in the
+// stream.
+func (c *Collector) SetPackageFilter(filter PackageFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pkgFilter = filter
+}
+
+// SetMergeRetries controls how a package that starts again after finishing
+// in the same run is handled. By default it's a fresh start, e.g. in watch
+// mode, and replaces the package's earlier results. With merging, it's a
+// retry, e.g. by gotestsum --rerun-fails: earlier results are kept, and
+// each test run again gets a new execution recorded as a further attempt.
+func (c *Collector) SetMergeRetries(merge bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mergeRetries = merge
+}
+
+// SetEventHandler registers a function called with each Event derived from
+// the go test stream, as the state changes. It's called while the collector
+// is locked, so it must not call back into the collector.
+func (c *Collector) SetEventHandler(handler func(Event)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
 // State returns the current state.
 // Note: The returned pointer provides direct access to the internal state.
 // It is NOT thread-safe, so the caller should hold the lock if accessing it directly
@@ -61,13 +188,27 @@ func (c *Collector) Unlock() {
 func (c *Collector) Push(evt engine.Event) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.events++
 
 	switch evt.Type {
 	case engine.EventTest:
 		c.handleTestEvent(evt.TestEvent)
+		if evt.Malformed {
+			c.recordMalformed(true)
+		}
 
 	case engine.EventBuild:
+		if !c.tracks(buildPackage(evt.BuildEvent.ImportPath)) {
+			return
+		}
 		c.handleBuildEvent(evt.BuildEvent)
+		if evt.Malformed {
+			c.recordMalformed(true)
+		}
+
+	case engine.EventMalformed:
+		// Unlike raw lines, corrupted events don't end the run.
+		c.recordMalformed(false)
 
 	case engine.EventRawLine:
 		// Raw lines act as a hard boundary to force the run to finish
@@ -80,6 +221,9 @@ func (c *Collector) Push(evt engine.Event) {
 			c.state.CurrentRun.NonTestOutput = append(c.state.CurrentRun.NonTestOutput, string(evt.RawLine))
 		}
 
+	case engine.EventAnnotation:
+		c.annotate(evt.Annotation)
+
 	case engine.EventComplete:
 		// Finish current run if any
 		c.Finish()
@@ -90,6 +234,18 @@ func (c *Collector) Push(evt engine.Event) {
 	}
 }
 
+// tracks reports whether the package passes the collector's filter.
+func (c *Collector) tracks(pkg string) bool {
+	return pkg == "" || c.pkgFilter.Allows(pkg)
+}
+
+// buildPackage returns the package of a build event's ImportPath, e.g.
+// "example.com/pkg" of "example.com/pkg [example.com/pkg.test]".
+func buildPackage(importPath string) string {
+	pkg, _, _ := strings.Cut(importPath, " ")
+	return pkg
+}
+
 // handleBuildEvent processes a build event.
 func (c *Collector) handleBuildEvent(event parser.BuildEvent) {
 	if c.state.CurrentRun == nil {
@@ -100,8 +256,24 @@ func (c *Collector) handleBuildEvent(event parser.BuildEvent) {
 
 // handleTestEvent processes a test event and updates the state.
 func (c *Collector) handleTestEvent(event parser.TestEvent) {
-	// Update last event time
-	c.lastEventTime = event.Time
+	if event.Package == "" && event.ImportPath == "" && !strings.HasPrefix(event.Action, "build-") {
+		if c.defaultPkg == "" {
+			return
+		}
+		event.Package = c.defaultPkg
+	}
+	if pkg := cmp.Or(event.Package, buildPackage(event.ImportPath)); pkg != "" && !c.tracks(pkg) {
+		return
+	}
+
+	// A note printed by a test harness, e.g. from TestMain, annotates the
+	// run rather than being output.
+	if event.Action == "output" {
+		if text, ok := engine.ParseNote(event.Output); ok {
+			c.annotate(engine.Annotation{Time: event.Time, Package: event.Package, Text: text})
+			return
+		}
+	}
 
 	// Start a new run if needed
 	if c.state.CurrentRun == nil {
@@ -121,9 +293,21 @@ func (c *Collector) handleTestEvent(event parser.TestEvent) {
 	if event.Package == "" {
 		switch event.Action {
 		case "build-output", "build-fail", "build-pass":
+			// Build events carrying an ImportPath are associated with the
+			// package whose "fail" event later references it via
+			// FailedBuild, so the compiler output renders under it.
+			if event.ImportPath != "" {
+				run.BuildEvents = append(run.BuildEvents, parser.BuildEvent{
+					ImportPath: event.ImportPath,
+					Action:     event.Action,
+					Output:     event.Output,
+				})
+				return
+			}
 			if event.Output != "" {
-				output := strings.TrimRight(event.Output, "\n")
+				output := strings.TrimRight(event.Output, "\r\n")
 				run.NonTestOutput = append(run.NonTestOutput, output)
+				c.emit(NewNonTestOutputEvent(run.ID, output))
 			}
 		}
 		return
@@ -136,6 +320,12 @@ func (c *Collector) handleTestEvent(event parser.TestEvent) {
 	// If we see an event for a package that has already completed in the
 	// current run, it means the test suite is being re-run (e.g., watch mode).
 	if exists && pkgResult.Status != StatusRunning && event.Action == "start" {
+		if c.mergeRetries {
+			c.retryPackage(run, pkgResult, event)
+			return
+		}
+		prevStatus := pkgResult.Status
+
 		// 1. Subtract the old package counts from the global run counts
 		run.Counts.Passed -= pkgResult.Counts.Passed
 		run.Counts.Failed -= pkgResult.Counts.Failed
@@ -158,14 +348,23 @@ func (c *Collector) handleTestEvent(event parser.TestEvent) {
 		// 4. Reset package status and metadata
 		pkgResult.Status = StatusRunning
 		pkgResult.StartTime = event.Time
-		pkgResult.WallStartTime = time.Now()
+		pkgResult.WallStartTime = c.clock.Now()
+		pkgResult.EndTime = time.Time{}
 		pkgResult.Elapsed = 0
 		pkgResult.SummaryLine = ""
 		pkgResult.OutputLines = nil
+		pkgResult.framedTest = ""
 		pkgResult.FailedBuild = ""
 		pkgResult.PanicTestKey = ""
+		pkgResult.RunningSamples = nil
+		pkgResult.Cached = false
+		pkgResult.SetupTime = 0
+		pkgResult.TeardownTime = 0
+		pkgResult.firstTestStart = time.Time{}
+		pkgResult.lastTestEnd = time.Time{}
 
 		run.RunningPkgs++
+		c.emitPackageUpdated(run, pkgResult, prevStatus, event.Time)
 		return
 	}
 
@@ -173,14 +372,16 @@ func (c *Collector) handleTestEvent(event parser.TestEvent) {
 		pkgResult = &PackageResult{
 			Name:          event.Package,
 			StartTime:     event.Time,
-			WallStartTime: time.Now(),
+			WallStartTime: c.clock.Now(),
 			TestOrder:     make([]string, 0),
 			DisplayOrder:  make([]string, 0),
 			Status:        StatusRunning,
+			Attempts:      1,
 		}
 		run.Packages[event.Package] = pkgResult
 		run.PackageOrder = append(run.PackageOrder, event.Package)
 		run.RunningPkgs++
+		c.emitPackageUpdated(run, pkgResult, StatusUnknown, event.Time)
 	}
 
 	// Handle package-level events
@@ -190,13 +391,17 @@ func (c *Collector) handleTestEvent(event parser.TestEvent) {
 	}
 
 	// Handle test-level events
+	if event.Action == "output" {
+		event.Test = c.attributeOutput(run, pkgResult, event.Test, event.Output)
+	}
 	c.handleTestLevelEvent(run, pkgResult, event)
 }
 
 // classifyPackageOutput routes a package-level output line into the right
 // bucket on the PackageResult:
 //   - The "ok\tpkg\ttime" / "FAIL\tpkg\ttime" / "?\tpkg\ttime" summary line
-//     is stored in SummaryLine (overwriting any previous value).
+//     is stored in SummaryLine (overwriting any previous value). A
+//     "(cached)" marker in it sets Cached.
 //   - Bare "PASS" or "FAIL" lines (which `go test` emits before the summary
 //     line) are dropped.
 //   - Bare "coverage: X% of statements" lines are dropped because the same
@@ -211,6 +416,7 @@ func classifyPackageOutput(pkg *PackageResult, output string) {
 			strings.HasPrefix(trimmed, "FAIL") ||
 			strings.HasPrefix(trimmed, "?")) {
 		pkg.SummaryLine = output
+		pkg.Cached = strings.Contains(trimmed, "(cached)")
 		return
 	}
 	if trimmed == "PASS" || trimmed == "FAIL" {
@@ -219,11 +425,17 @@ func classifyPackageOutput(pkg *PackageResult, output string) {
 	if strings.HasPrefix(trimmed, "coverage:") && strings.HasSuffix(trimmed, "of statements") {
 		return
 	}
+	if seed, ok := strings.CutPrefix(trimmed, "-test.shuffle "); ok {
+		pkg.ShuffleSeed = seed
+	}
 	pkg.OutputLines = append(pkg.OutputLines, output)
 }
 
 // handlePackageEvent handles package-level events.
 func (c *Collector) handlePackageEvent(run *Run, pkg *PackageResult, event parser.TestEvent) {
+	prevStatus := pkg.Status
+	defer func() { c.emitPackageUpdated(run, pkg, prevStatus, event.Time) }()
+
 	switch event.Action {
 	case "output":
 		if event.Output != "" {
@@ -233,26 +445,44 @@ func (c *Collector) handlePackageEvent(run *Run, pkg *PackageResult, event parse
 			}
 			if output != "" {
 				classifyPackageOutput(pkg, output)
+				run.detectOutputModes(output)
 			}
 		}
 
 	case "pass":
 		pkg.Status = StatusPassed
 		pkg.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
+		pkg.clearRunning()
+		pkg.recordRunning(event.Time)
+		pkg.EndTime = event.Time
+		pkg.recordSetupTeardown()
 		run.RunningPkgs--
 
 	case "fail":
 		pkg.Status = StatusFailed
 		pkg.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
 		if event.FailedBuild != "" {
+			pkg.Status = StatusBuildFailed
 			pkg.FailedBuild = event.FailedBuild
 		}
+		// A panic, e.g. a timeout, kills the tests still running.
+		if n := pkg.Counts.Running + pkg.Counts.Paused; n > 0 && pkg.PanicTestKey == "" {
+			c.recordAnomaly(run, event, "failed with %d tests still running", n)
+		}
 		c.failInterruptedTests(run, pkg)
+		pkg.clearRunning()
+		pkg.recordRunning(event.Time)
+		pkg.EndTime = event.Time
+		pkg.recordSetupTeardown()
 		run.RunningPkgs--
 
 	case "skip":
 		pkg.Status = StatusSkipped
 		pkg.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
+		pkg.clearRunning()
+		pkg.recordRunning(event.Time)
+		pkg.EndTime = event.Time
+		pkg.recordSetupTeardown()
 		run.RunningPkgs--
 	}
 }
@@ -261,13 +491,34 @@ func (c *Collector) handlePackageEvent(run *Run, pkg *PackageResult, event parse
 func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event parser.TestEvent) {
 	testKey := event.Package + "/" + event.Test
 
+	pkg.recordTestTime(event.Action, event.Time)
+
 	testResult, exists := run.TestResults[testKey]
+	defer func() {
+		pkg.trackRunning(event.Test, testResult != nil && testResult.Status() == StatusRunning)
+		pkg.recordRunning(event.Time)
+	}()
+	prevStatus, prevExecutions := StatusUnknown, 0
+	if exists {
+		prevStatus, prevExecutions = testResult.Status(), len(testResult.Executions)
+	}
+	defer func() {
+		if len(testResult.Executions) != prevExecutions {
+			prevStatus = StatusUnknown
+		}
+		c.emitTestUpdated(run, testResult, prevStatus, event.Time)
+	}()
+
+	c.checkTestEvent(run, event, exists)
 	if !exists {
-		now := time.Now()
+		now := c.clock.Now()
 		testResult = NewTestResult(event.Package, event.Test)
 		testResult.Latest().StartTime = event.Time
 		testResult.Latest().WallStartTime = now
 		testResult.Latest().LastResumeTime = now
+		testResult.Latest().LastOutputTime = event.Time
+		testResult.Latest().WallLastOutputTime = now
+		testResult.Latest().Attempt = pkg.Attempts
 		run.TestResults[testKey] = testResult
 		pkg.TestOrder = append(pkg.TestOrder, event.Test)
 		pkg.DisplayOrder = append(pkg.DisplayOrder, event.Test)
@@ -275,17 +526,31 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 		run.Counts.Running++
 	}
 
+	switch event.Action {
+	case "run", "cont":
+		pkg.framedTest = event.Test
+	case "pause":
+		pkg.unframe(event.Test)
+	}
+
 	switch event.Action {
 	case "run":
 		// Detect rerun: if the latest execution is terminal and we get a new "run",
 		// this is a -count=N rerun. Append a new execution.
 		latest := testResult.Latest()
 		if latest.Status == StatusPassed || latest.Status == StatusFailed || latest.Status == StatusSkipped {
+			if latest.Attempt < pkg.Attempts {
+				// A retry supersedes the earlier attempt's result.
+				uncountResult(run, pkg, latest.Status)
+			}
 			latest = testResult.AppendExecution()
-			now := time.Now()
+			now := c.clock.Now()
 			latest.StartTime = event.Time
 			latest.WallStartTime = now
 			latest.LastResumeTime = now
+			latest.LastOutputTime = event.Time
+			latest.WallLastOutputTime = now
+			latest.Attempt = pkg.Attempts
 			pkg.Counts.Running++
 			run.Counts.Running++
 		} else {
@@ -295,25 +560,40 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 	case "output":
 		latest := testResult.Latest()
 		if event.Output != "" {
-			output := strings.TrimRight(event.Output, "\n")
+			c.recordActivity(run, testResult, event.Time)
+			output := strings.TrimRight(event.Output, "\r\n")
+			summaryLine := strings.HasPrefix(output, "===") || strings.HasPrefix(output, "---")
+
+			// Detect fatal crashes: go test emits the panic/fatal
+			// stacktrace as output on one arbitrary running test.
+			// Timeout panics and runtime fatals (e.g. concurrent
+			// map writes) both kill the process, leaving other
+			// tests without terminal actions.
+			if !summaryLine && pkg.PanicTestKey == "" {
+				if strings.HasPrefix(output, "panic: ") ||
+					strings.HasPrefix(output, "fatal error: ") {
+					pkg.PanicTestKey = testKey
+				}
+			}
+
+			if !summaryLine && c.maxLineRate > 0 && pkg.PanicTestKey != testKey && latest.throttle(cmp.Or(event.Time, c.clock.Now()), c.maxLineRate) {
+				run.detectOutputModes(output)
+				break
+			}
+			if c.handler != nil {
+				evt := NewTestOutputEvent(run.ID, pkg.Name, testResult.Name, output)
+				evt.Time = event.Time
+				evt.Iteration = len(testResult.Executions)
+				c.emit(evt)
+			}
 
 			// Extract summary line (lines starting with "===" or "---")
-			if strings.HasPrefix(output, "===") || strings.HasPrefix(output, "---") {
+			if summaryLine {
 				latest.SummaryLine = output
 			} else {
-				latest.Output = append(latest.Output, output)
-
-				// Detect fatal crashes: go test emits the panic/fatal
-				// stacktrace as output on one arbitrary running test.
-				// Timeout panics and runtime fatals (e.g. concurrent
-				// map writes) both kill the process, leaving other
-				// tests without terminal actions.
-				if pkg.PanicTestKey == "" {
-					if strings.HasPrefix(output, "panic: ") ||
-						strings.HasPrefix(output, "fatal error: ") {
-						pkg.PanicTestKey = testKey
-					}
-				}
+				latest.appendOutput(output, !c.keepRepeats)
+				latest.recordArtifact(output)
+				run.detectOutputModes(output)
 			}
 		}
 
@@ -321,8 +601,8 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 		latest := testResult.Latest()
 		wasPaused := latest.Status == StatusPaused
 		latest.Status = StatusPassed
-		latest.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
-		latest.ActiveDuration += time.Since(latest.LastResumeTime)
+		latest.finishElapsed(testResult.Name, event.Elapsed)
+		latest.ActiveDuration += c.clock.Now().Sub(latest.LastResumeTime)
 		pkg.Counts.Passed++
 		run.Counts.Passed++
 		if wasPaused {
@@ -337,8 +617,8 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 		latest := testResult.Latest()
 		wasPaused := latest.Status == StatusPaused
 		latest.Status = StatusFailed
-		latest.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
-		latest.ActiveDuration += time.Since(latest.LastResumeTime)
+		latest.finishElapsed(testResult.Name, event.Elapsed)
+		latest.ActiveDuration += c.clock.Now().Sub(latest.LastResumeTime)
 		pkg.Counts.Failed++
 		run.Counts.Failed++
 		if wasPaused {
@@ -353,8 +633,12 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 		latest := testResult.Latest()
 		wasPaused := latest.Status == StatusPaused
 		latest.Status = StatusSkipped
-		latest.Elapsed = time.Duration(event.Elapsed * float64(time.Second))
-		latest.ActiveDuration += time.Since(latest.LastResumeTime)
+		latest.SkipReason = skipReason(latest.Output)
+		if IsShortModeSkip(latest.SkipReason) {
+			run.Modes = addMode(run.Modes, ModeShort)
+		}
+		latest.finishElapsed(testResult.Name, event.Elapsed)
+		latest.ActiveDuration += c.clock.Now().Sub(latest.LastResumeTime)
 		pkg.Counts.Skipped++
 		run.Counts.Skipped++
 		if wasPaused {
@@ -368,7 +652,7 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 	case "pause":
 		latest := testResult.Latest()
 		latest.Status = StatusPaused
-		latest.ActiveDuration += time.Since(latest.LastResumeTime)
+		latest.ActiveDuration += c.clock.Now().Sub(latest.LastResumeTime)
 		pkg.Counts.Running--
 		pkg.Counts.Paused++
 		run.Counts.Running--
@@ -377,10 +661,12 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 	case "cont":
 		latest := testResult.Latest()
 		latest.Status = StatusRunning
-		now := time.Now()
+		now := c.clock.Now()
 		latest.LastResumeTime = now
 		latest.WallStartTime = now
 		latest.StartTime = event.Time
+		latest.LastOutputTime = event.Time
+		latest.WallLastOutputTime = now
 		pkg.Counts.Running++
 		pkg.Counts.Paused--
 		run.Counts.Running++
@@ -389,6 +675,26 @@ func (c *Collector) handleTestLevelEvent(run *Run, pkg *PackageResult, event par
 	}
 }
 
+// recordActivity records that the test wrote output at event time t, and
+// so its parents, which are waiting on it rather than stalled; see
+// TestExecution.Silence.
+func (c *Collector) recordActivity(run *Run, tr *TestResult, t time.Time) {
+	now := c.clock.Now()
+	name := tr.Name
+	for {
+		if parent := run.TestResults[tr.Package+"/"+name]; parent != nil {
+			latest := parent.Latest()
+			latest.LastOutputTime = t
+			latest.WallLastOutputTime = now
+		}
+		i := strings.LastIndexByte(name, '/')
+		if i < 0 {
+			return
+		}
+		name = name[:i]
+	}
+}
+
 // failInterruptedTests transitions still-running tests in a failed package to
 // StatusFailed. When a panic/fatal source test is identified (PanicTestKey),
 // its output is preserved and other interrupted tests have their output
@@ -404,8 +710,10 @@ func (c *Collector) failInterruptedTests(run *Run, pkg *PackageResult) {
 
 		latest := tr.Latest()
 		wasPaused := latest.Status == StatusPaused
+		prevStatus := latest.Status
 		latest.Status = StatusFailed
 		latest.Interrupted = true
+		c.emitTestUpdated(run, tr, prevStatus, time.Time{})
 		pkg.Counts.Failed++
 		run.Counts.Failed++
 		if wasPaused {
@@ -422,14 +730,161 @@ func (c *Collector) failInterruptedTests(run *Run, pkg *PackageResult) {
 	}
 }
 
+// interruptTests transitions the still-running tests of a package
+// interrupted by the end of the run to StatusInterrupted, timing them up to
+// endTime, so they aren't left running in the report.
+func (c *Collector) interruptTests(run *Run, pkg *PackageResult, endTime time.Time) {
+	for _, testName := range pkg.TestOrder {
+		tr := run.TestResults[pkg.Name+"/"+testName]
+		if tr == nil || !tr.Running() {
+			continue
+		}
+
+		latest := tr.Latest()
+		prevStatus := latest.Status
+		latest.Status = StatusInterrupted
+		if run.TimeSource == TimeSourceEvent && !latest.StartTime.IsZero() {
+			latest.Elapsed = endTime.Sub(latest.StartTime)
+		} else {
+			latest.Elapsed = run.ScaleWall(c.clock.Now().Sub(latest.WallStartTime))
+		}
+		if prevStatus == StatusPaused {
+			pkg.Counts.Paused--
+			run.Counts.Paused--
+		} else {
+			if run.TimeSource == TimeSourceEvent && !latest.LastOutputTime.IsZero() {
+				latest.SilentFor = endTime.Sub(latest.LastOutputTime)
+			} else {
+				latest.SilentFor = run.ScaleWall(c.clock.Now().Sub(latest.WallLastOutputTime))
+			}
+			latest.ActiveDuration += c.clock.Now().Sub(latest.LastResumeTime)
+			pkg.Counts.Running--
+			run.Counts.Running--
+		}
+		pkg.Counts.Interrupted++
+		run.Counts.Interrupted++
+		c.emitTestUpdated(run, tr, prevStatus, endTime)
+	}
+}
+
 // startNewRun creates a new run.
 func (c *Collector) startNewRun() {
 	runID := len(c.state.Runs) + 1
 	run := NewRun(runID)
+	run.WallStartTime = c.clock.Now()
 	run.Status = StatusRunning
+	run.Replay = c.isReplay
+	run.ReplayRate = c.replayRate
+	run.UID = newRunUID(run.WallStartTime)
+	run.Labels = c.labels
+	run.Modes = slices.Clone(c.modes)
+	run.Repro = c.repro
+	run.MalformedLines, c.pendingMalformed = c.pendingMalformed, 0
+	run.Annotations, c.pendingAnnotations = c.pendingAnnotations, nil
 
 	c.state.Runs = append(c.state.Runs, run)
 	c.state.CurrentRun = run
+	c.emit(NewRunStartedEvent(runID))
+}
+
+// annotate adds an annotation to the current run, or keeps it for the next
+// one between runs. Annotations of packages the collector doesn't track are
+// dropped.
+func (c *Collector) annotate(a engine.Annotation) {
+	if a.Package != "" && !c.tracks(a.Package) {
+		return
+	}
+	if a.Time.IsZero() {
+		a.Time = c.clock.Now()
+	}
+	if run := c.state.CurrentRun; run != nil {
+		run.Annotations = append(run.Annotations, a)
+		return
+	}
+	c.pendingAnnotations = append(c.pendingAnnotations, a)
+}
+
+// recordMalformed counts a malformed line against the current run, or the
+// most recent one between runs.
+func (c *Collector) recordMalformed(recovered bool) {
+	run := c.state.CurrentRun
+	if run == nil {
+		run = c.state.MostRecentRun()
+	}
+	if run == nil {
+		c.pendingMalformed++
+		return
+	}
+	run.MalformedLines++
+	if recovered {
+		run.RecoveredLines++
+	}
+}
+
+// emit passes evt to the event handler, if any.
+func (c *Collector) emit(evt Event) {
+	if c.handler != nil {
+		c.handler(evt)
+	}
+}
+
+// emitPackageUpdated emits an EventPackageUpdated if the package's status
+// changed from prevStatus.
+func (c *Collector) emitPackageUpdated(run *Run, pkg *PackageResult, prevStatus Status, t time.Time) {
+	if c.handler == nil || pkg.Status == prevStatus {
+		return
+	}
+	evt := NewPackageUpdatedEvent(run.ID, pkg.Name)
+	evt.Time = t
+	evt.Status = pkg.Status
+	evt.PrevStatus = prevStatus
+	evt.Elapsed = pkg.Elapsed
+	c.emit(evt)
+}
+
+// emitTestUpdated emits an EventTestUpdated if the latest execution's status
+// changed from prevStatus.
+func (c *Collector) emitTestUpdated(run *Run, tr *TestResult, prevStatus Status, t time.Time) {
+	if c.handler == nil || tr.Status() == prevStatus {
+		return
+	}
+	evt := NewTestUpdatedEvent(run.ID, tr.Package, tr.Name)
+	evt.Time = t
+	evt.Status = tr.Status()
+	evt.PrevStatus = prevStatus
+	evt.Iteration = len(tr.Executions)
+	evt.Elapsed = tr.Elapsed()
+	c.emit(evt)
+}
+
+// Stats returns the number of events pushed so far, and the sizes of the
+// state they built.
+func (c *Collector) Stats() CollectorStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CollectorStats{Events: c.events, Runs: len(c.state.Runs)}
+	for _, run := range c.state.Runs {
+		stats.Packages += len(run.Packages)
+		stats.TestResults += len(run.TestResults)
+	}
+	return stats
+}
+
+// RecordResources records a resource usage sample of the go test process
+// tree against the current run. Samples taken between runs are dropped.
+func (c *Collector) RecordResources(rss uint64, cpuPercent float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	run := c.state.CurrentRun
+	if run == nil {
+		return
+	}
+	r := &run.Resources
+	r.RSS, r.CPU = rss, cpuPercent
+	r.PeakRSS = max(r.PeakRSS, rss)
+	r.PeakCPU = max(r.PeakCPU, cpuPercent)
 }
 
 // Finish finishes the current run if any.
@@ -441,50 +896,66 @@ func (c *Collector) Finish() {
 
 	run := c.state.CurrentRun
 
-	// Determine end time: use last event time if available, otherwise now
-	endTime := c.lastEventTime
-	if c.lastEventTime.IsZero() {
-		endTime = time.Now()
-		if c.isReplay {
-			// Calculate simulated end time based on wall clock duration and replay rate
-			// This ensures that the summary matches the live UI's "perceived" time
-			wallDuration := time.Since(run.WallStartTime)
-
-			// Apply rate (rate is inverse speed, e.g. 0.5 means 2x speed)
-			// If rate is 0 (instant), we fall back to lastEventTime
-			if c.replayRate > 0 {
-				wallDuration = time.Duration(float64(wallDuration) / c.replayRate)
-			}
-			endTime = run.FirstEventTime.Add(wallDuration)
+	// Settle the run's time source. Event timestamps are preferred; runs
+	// without them are timed by the wall clock, scaled to match the live
+	// UI's "perceived" time when replaying, with FirstEventTime anchored
+	// at the wall start so Elapsed never mixes the two clocks.
+	// The wall clock only times a stream as it's received, e.g. not at all
+	// in an instant replay, so go's own elapsed times of the packages bound
+	// the run's from below.
+	if run.FirstEventTime.IsZero() {
+		run.TimeSource = TimeSourceWall
+		elapsed := run.ScaleWall(c.clock.Now().Sub(run.WallStartTime))
+		for _, pkg := range run.Packages {
+			elapsed = max(elapsed, pkg.Elapsed)
 		}
+		run.FirstEventTime = run.WallStartTime
+		run.LastEventTime = run.FirstEventTime.Add(elapsed)
 	}
-	run.LastEventTime = endTime
+	endTime := run.LastEventTime
 
-	var interrupted bool
+	var interrupted, buildFailed bool
 
 	// Mark any still-running packages as interrupted and compute their elapsed time
 	for _, pkg := range run.Packages {
+		if pkg.Status == StatusBuildFailed {
+			buildFailed = true
+		}
 		if pkg.Status == StatusRunning {
 			interrupted = true
 			pkg.Status = StatusInterrupted
 
-			// Calculate elapsed time based on run duration and package start offset
-			// This ensures consistency with live UI even if ReplayReader doesn't sleep exactly as expected
-			wallRunDuration := time.Since(pkg.WallStartTime)
-			if c.isReplay && c.replayRate > 0 {
-				wallRunDuration = time.Duration(float64(wallRunDuration) / c.replayRate)
+			// Measure elapsed time with the run's time source, so it's
+			// consistent with the run's total.
+			if run.TimeSource == TimeSourceEvent && !pkg.StartTime.IsZero() {
+				pkg.Elapsed = endTime.Sub(pkg.StartTime)
+			} else {
+				pkg.Elapsed = run.ScaleWall(c.clock.Now().Sub(pkg.WallStartTime))
 			}
-			pkg.Elapsed = wallRunDuration
+			pkg.EndTime = endTime
+			c.interruptTests(run, pkg, endTime)
+			c.emitPackageUpdated(run, pkg, StatusRunning, endTime)
 		}
 	}
 
+	run.Inconsistencies = checkConsistency(run)
+
 	if interrupted {
 		run.Status = StatusInterrupted
-	} else if run.Counts.Failed > 0 {
+	} else if run.Counts.Failed > 0 || buildFailed {
 		run.Status = StatusFailed
 	} else {
 		run.Status = StatusPassed
 	}
 
 	c.state.CurrentRun = nil
+
+	if c.handler != nil {
+		evt := NewRunFinishedEvent(run.ID)
+		evt.Time = run.LastEventTime
+		evt.Status = run.Status
+		evt.PrevStatus = StatusRunning
+		evt.Elapsed = run.Elapsed()
+		c.emit(evt)
+	}
 }
@@ -1,6 +1,9 @@
 package results
 
 import (
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -336,6 +339,96 @@ func TestCollectorFinishInterruptedRun(t *testing.T) {
 		if pkg3.Elapsed == 0 {
 			t.Error("Expected pkg3 to have non-zero elapsed time")
 		}
+
+		// Their tests should be interrupted, not left running
+		for _, key := range []string{"github.com/test/pkg2/TestB", "github.com/test/pkg3/TestC"} {
+			tr := run.TestResults[key]
+			if tr.Status() != StatusInterrupted {
+				t.Errorf("Expected %s status 'interrupted', got '%s'", key, tr.Status())
+			}
+		}
+		if got := run.TestResults["github.com/test/pkg2/TestB"].Latest().Elapsed; got != 450*time.Millisecond {
+			t.Errorf("Expected TestB to be timed up to the last event, got %v", got)
+		}
+		if run.Counts.Running != 0 || run.Counts.Interrupted != 2 {
+			t.Errorf("Expected 0 running and 2 interrupted tests, got %d and %d", run.Counts.Running, run.Counts.Interrupted)
+		}
+		if pkg2.Counts.Interrupted != 1 {
+			t.Errorf("Expected pkg2 to count 1 interrupted test, got %d", pkg2.Counts.Interrupted)
+		}
+	}
+}
+
+// TestCollectorFinishInterruptedWallClock tests that tests and packages
+// interrupted in a stream without event times, timed by the wall clock,
+// get the time they ran for on the collector's clock.
+func TestCollectorFinishInterruptedWallClock(t *testing.T) {
+	clock := engine.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	collector := NewCollector()
+	collector.SetClock(clock)
+
+	push := func(action, test string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Action: action, Package: "example.com/pkg", Test: test}})
+	}
+	push("start", "")
+	clock.Advance(time.Second)
+	push("run", "TestA")
+	clock.Advance(2 * time.Second)
+	push("pause", "TestA")
+	clock.Advance(time.Second)
+	push("cont", "TestA")
+	clock.Advance(500 * time.Millisecond)
+	collector.Finish()
+
+	run := collector.State().Runs[0]
+	if got, want := run.LastEventTime.Sub(run.FirstEventTime), 4500*time.Millisecond; got != want {
+		t.Errorf("Expected the run to last %v, got %v", want, got)
+	}
+	pkg := run.Packages["example.com/pkg"]
+	if pkg.Status != StatusInterrupted || pkg.Elapsed != 4500*time.Millisecond {
+		t.Errorf("Expected the package interrupted after 4.5s, got %s after %v", pkg.Status, pkg.Elapsed)
+	}
+	latest := run.TestResults["example.com/pkg/TestA"].Latest()
+	if latest.Status != StatusInterrupted || latest.Elapsed != 500*time.Millisecond {
+		t.Errorf("Expected TestA interrupted 0.5s after it continued, got %s after %v", latest.Status, latest.Elapsed)
+	}
+	if latest.ActiveDuration != 2500*time.Millisecond {
+		t.Errorf("Expected TestA active for 2.5s, got %v", latest.ActiveDuration)
+	}
+}
+
+func TestCollectorSilence(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := engine.NewManualClock(start)
+	collector := NewCollector()
+	collector.SetClock(clock)
+
+	push := func(action, test, output string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: clock.Now(), Action: action, Package: "example.com/pkg", Test: test, Output: output,
+		}})
+	}
+	push("start", "", "")
+	push("run", "TestStuck", "")
+	push("run", "TestBusy", "")
+	push("run", "TestBusy/sub", "")
+	clock.Advance(time.Minute)
+	push("output", "TestStuck", "    waiting\n")
+	clock.Advance(3 * time.Minute)
+	push("output", "TestBusy/sub", "    working\n")
+	clock.Advance(time.Minute)
+
+	run := collector.State().CurrentRun
+	for name, want := range map[string]time.Duration{"TestStuck": 4 * time.Minute, "TestBusy": time.Minute, "TestBusy/sub": time.Minute} {
+		if got := run.TestResults["example.com/pkg/"+name].Latest().Silence(clock.Now()); got != want {
+			t.Errorf("Expected %s silent for %v, got %v", name, want, got)
+		}
+	}
+
+	push("output", "", "") // The run's last event, at 5m
+	collector.Finish()
+	if got := run.TestResults["example.com/pkg/TestStuck"].Latest().SilentFor; got != 4*time.Minute {
+		t.Errorf("Expected TestStuck silent for 4m when the run ended, got %v", got)
 	}
 }
 
@@ -984,3 +1077,426 @@ func TestCollectorWatchModeRerunClearsPanicKey(t *testing.T) {
 		t.Errorf("Expected 1 test result in new run, got %d", len(run2.TestResults))
 	}
 }
+
+func TestCollectorBuildFailedPackage(t *testing.T) {
+	collector := NewCollector()
+	now := time.Now()
+	importPath := "example.com/broken [example.com/broken.test]"
+
+	// Go 1.24 emits build output as ImportPath-tagged events ahead of the
+	// package's "fail" event, which references them via FailedBuild.
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "build-output", ImportPath: importPath, Output: "# example.com/broken\n",
+	}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "build-output", ImportPath: importPath, Output: "./broken.go:7:1: syntax error\n",
+	}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "build-fail", ImportPath: importPath,
+	}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "start", Package: "example.com/broken",
+	}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "fail", Package: "example.com/broken", FailedBuild: importPath,
+	}})
+	collector.Push(engine.Event{Type: engine.EventComplete})
+
+	run := collector.State().Runs[0]
+	if len(run.NonTestOutput) != 0 {
+		t.Errorf("Expected build output not to land in NonTestOutput, got %v", run.NonTestOutput)
+	}
+	if got := len(run.GetBuildErrors(importPath)); got != 3 {
+		t.Errorf("Expected 3 build events for %s, got %d", importPath, got)
+	}
+
+	pkg := run.Packages["example.com/broken"]
+	if pkg.Status != StatusBuildFailed {
+		t.Errorf("Expected package status %s, got %s", StatusBuildFailed, pkg.Status)
+	}
+	if pkg.FailedBuild != importPath {
+		t.Errorf("Expected FailedBuild %q, got %q", importPath, pkg.FailedBuild)
+	}
+	if run.Status != StatusFailed {
+		t.Errorf("Expected run status %s, got %s", StatusFailed, run.Status)
+	}
+}
+
+func TestCollectorRunningSamples(t *testing.T) {
+	collector := NewCollector()
+	start := time.Now()
+	pkg := "example.com/pkg"
+	for _, te := range []parser.TestEvent{
+		{Time: start, Action: "start", Package: pkg},
+		{Time: start, Action: "run", Package: pkg, Test: "TestA"},
+		{Time: start.Add(1 * time.Second), Action: "pause", Package: pkg, Test: "TestA"},
+		{Time: start.Add(1 * time.Second), Action: "run", Package: pkg, Test: "TestB"},
+		{Time: start.Add(2 * time.Second), Action: "cont", Package: pkg, Test: "TestA"},
+		{Time: start.Add(3 * time.Second), Action: "pass", Package: pkg, Test: "TestA"},
+		{Time: start.Add(4 * time.Second), Action: "pass", Package: pkg, Test: "TestB"},
+		{Time: start.Add(5 * time.Second), Action: "pass", Package: pkg},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	samples := collector.State().Runs[0].Packages[pkg].RunningSamples
+	var got []int
+	for _, s := range samples {
+		got = append(got, s.Running)
+	}
+	want := []int{1, 0, 1, 2, 1, 0}
+	if len(got) != len(want) {
+		t.Fatalf("Expected running samples %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected running samples %v, got %v", want, got)
+		}
+	}
+}
+
+func TestCollectorRunningSamplesSubtests(t *testing.T) {
+	start := time.Now()
+	pkg := "example.com/pkg"
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	tests := []struct {
+		name   string
+		events []parser.TestEvent
+		want   []int
+	}{
+		{
+			// A parent waiting on its subtests, one at a time, isn't
+			// counted as running alongside them.
+			name: "sequential",
+			events: []parser.TestEvent{
+				{Time: at(0), Action: "run", Package: pkg, Test: "TestA"},
+				{Time: at(1), Action: "run", Package: pkg, Test: "TestA/one"},
+				{Time: at(2), Action: "pass", Package: pkg, Test: "TestA/one"},
+				{Time: at(2), Action: "run", Package: pkg, Test: "TestA/two"},
+				{Time: at(3), Action: "run", Package: pkg, Test: "TestA/two/nested"},
+				{Time: at(4), Action: "pass", Package: pkg, Test: "TestA/two/nested"},
+				{Time: at(4), Action: "pass", Package: pkg, Test: "TestA/two"},
+				{Time: at(5), Action: "pass", Package: pkg, Test: "TestA"},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "parallel",
+			events: []parser.TestEvent{
+				{Time: at(0), Action: "run", Package: pkg, Test: "TestA"},
+				{Time: at(1), Action: "run", Package: pkg, Test: "TestA/one"},
+				{Time: at(1), Action: "run", Package: pkg, Test: "TestA/two"},
+				{Time: at(2), Action: "pass", Package: pkg, Test: "TestA/one"},
+				{Time: at(3), Action: "pass", Package: pkg, Test: "TestA/two"},
+				{Time: at(4), Action: "pass", Package: pkg, Test: "TestA"},
+			},
+			want: []int{1, 2, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewCollector()
+			events := append([]parser.TestEvent{{Time: start, Action: "start", Package: pkg}}, tt.events...)
+			events = append(events, parser.TestEvent{Time: at(6), Action: "pass", Package: pkg})
+			for _, te := range events {
+				collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+			}
+			var got []int
+			for _, s := range collector.State().Runs[0].Packages[pkg].RunningSamples {
+				got = append(got, s.Running)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected running samples %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCollectorCachedPackage(t *testing.T) {
+	collector := NewCollector()
+	now := time.Now()
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Output: "ok  \texample.com/pkg\t(cached)\n"},
+		{Time: now, Action: "pass", Package: "example.com/pkg"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	if pkg := collector.State().Runs[0].Packages["example.com/pkg"]; !pkg.Cached {
+		t.Error("Expected package to be marked cached")
+	}
+}
+
+func TestCollectorTimeSource(t *testing.T) {
+	t.Run("event", func(t *testing.T) {
+		collector := NewCollector()
+		collector.SetReplay(true, 0.5)
+		start := time.Now().Add(-time.Hour)
+		for _, te := range []parser.TestEvent{
+			{Time: start, Action: "start", Package: "example.com/pkg"},
+			{Time: start.Add(3 * time.Second), Action: "run", Package: "example.com/pkg", Test: "TestA"},
+		} {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		collector.Finish()
+
+		run := collector.State().Runs[0]
+		if run.TimeSource != TimeSourceEvent {
+			t.Errorf("Expected event time source, got %v", run.TimeSource)
+		}
+		if !run.Replay || run.ReplayRate != 0.5 {
+			t.Errorf("Expected replay at rate 0.5, got %v %v", run.Replay, run.ReplayRate)
+		}
+		if run.Elapsed() != 3*time.Second {
+			t.Errorf("Expected elapsed 3s, got %v", run.Elapsed())
+		}
+		// The interrupted package is timed by events, not the wall clock.
+		if pkg := run.Packages["example.com/pkg"]; pkg.Elapsed != 3*time.Second {
+			t.Errorf("Expected interrupted package elapsed 3s, got %v", pkg.Elapsed)
+		}
+	})
+
+	t.Run("wall", func(t *testing.T) {
+		collector := NewCollector()
+		for _, te := range []parser.TestEvent{
+			{Action: "start", Package: "example.com/pkg"},
+			{Action: "pass", Package: "example.com/pkg"},
+		} {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		collector.Finish()
+
+		run := collector.State().Runs[0]
+		if run.TimeSource != TimeSourceWall {
+			t.Errorf("Expected wall time source, got %v", run.TimeSource)
+		}
+		if run.Elapsed() < 0 || run.Elapsed() > time.Minute {
+			t.Errorf("Expected a small wall-clock elapsed time, got %v", run.Elapsed())
+		}
+		if !run.ApproximateDurations() {
+			t.Error("Expected approximate durations")
+		}
+	})
+
+	t.Run("wall with go's elapsed", func(t *testing.T) {
+		clock := engine.NewManualClock(time.Now())
+		collector := NewCollector()
+		collector.SetClock(clock)
+		for _, te := range []parser.TestEvent{
+			{Action: "start", Package: "example.com/a"},
+			{Action: "start", Package: "example.com/b"},
+			{Action: "pass", Package: "example.com/a", Elapsed: 2},
+			{Action: "pass", Package: "example.com/b", Elapsed: 5},
+		} {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		clock.Advance(time.Second)
+		collector.Finish()
+
+		// Received in a second, but go timed a package at 5s.
+		if run := collector.State().Runs[0]; run.Elapsed() != 5*time.Second {
+			t.Errorf("Expected elapsed 5s, got %v", run.Elapsed())
+		}
+	})
+}
+
+func TestCollectorRecordResources(t *testing.T) {
+	collector := NewCollector()
+	collector.RecordResources(100, 50)
+
+	now := time.Now()
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "start", Package: "example.com/pkg"}})
+	collector.RecordResources(300, 150)
+	collector.RecordResources(200, 250)
+
+	r := collector.State().Runs[0].Resources
+	if r.RSS != 200 || r.CPU != 250 {
+		t.Errorf("Expected latest sample 200 bytes at 250%%, got %d at %v%%", r.RSS, r.CPU)
+	}
+	if r.PeakRSS != 300 || r.PeakCPU != 250 {
+		t.Errorf("Expected peaks 300 bytes and 250%%, got %d and %v%%", r.PeakRSS, r.PeakCPU)
+	}
+}
+
+func TestCollectorRunIDAndLabels(t *testing.T) {
+	collector := NewCollector()
+	collector.SetLabels(Labels{{Key: "go", Value: "1.25"}})
+
+	now := time.Now()
+	for range 2 {
+		for _, te := range []parser.TestEvent{
+			{Time: now, Action: "start", Package: "example.com/pkg"},
+			{Time: now, Action: "pass", Package: "example.com/pkg"},
+		} {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		collector.Finish()
+	}
+
+	runs := collector.State().Runs
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d", len(runs))
+	}
+	if runs[0].UID == "" || runs[0].UID == runs[1].UID {
+		t.Errorf("Expected distinct run UIDs, got %q and %q", runs[0].UID, runs[1].UID)
+	}
+	if got := runs[1].Labels.String(); got != "go=1.25" {
+		t.Errorf("Expected labels go=1.25, got %q", got)
+	}
+}
+
+func TestCollectorDefaultPackage(t *testing.T) {
+	// go tool test2json on a test binary: no package start, no Package field.
+	events := []parser.TestEvent{
+		{Action: "run", Test: "TestA"},
+		{Action: "output", Test: "TestA", Output: "=== RUN   TestA\n"},
+		{Action: "pass", Test: "TestA", Elapsed: 0.5},
+		{Action: "run", Test: "TestB"},
+		{Action: "fail", Test: "TestB", Elapsed: 0.25},
+		{Action: "output", Output: "FAIL\n"},
+		{Action: "fail", Elapsed: 1},
+	}
+
+	t.Run("ignored without a default", func(t *testing.T) {
+		collector := NewCollector()
+		for _, te := range events {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		if run := collector.State().MostRecentRun(); run != nil {
+			t.Errorf("Expected no run, got packages %v", run.PackageOrder)
+		}
+	})
+
+	t.Run("attributed to the default", func(t *testing.T) {
+		collector := NewCollector()
+		collector.SetDefaultPackage("example.com/pkg")
+		for _, te := range events {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		collector.Finish()
+
+		run := collector.State().MostRecentRun()
+		pkg := run.Packages["example.com/pkg"]
+		if pkg == nil {
+			t.Fatalf("Expected package example.com/pkg, got %v", run.PackageOrder)
+		}
+		if pkg.Status != StatusFailed || pkg.Elapsed != time.Second {
+			t.Errorf("Expected failed package in 1s, got %v in %v", pkg.Status, pkg.Elapsed)
+		}
+		if pkg.Counts.Passed != 1 || pkg.Counts.Failed != 1 {
+			t.Errorf("Expected 1 passed and 1 failed test, got %+v", pkg.Counts)
+		}
+		if run.Status != StatusFailed {
+			t.Errorf("Expected failed run, got %v", run.Status)
+		}
+	})
+}
+
+func TestCollectorCRLFOutput(t *testing.T) {
+	f, err := os.Open("../engine/testdata/crlf.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	collector := NewCollector()
+	for evt := range engine.NewEngine().Stream(f) {
+		collector.Push(evt)
+	}
+
+	run := collector.State().MostRecentRun()
+	test := run.TestResults["example.com/pkg/TestFoo"]
+	if test == nil {
+		t.Fatal("Expected TestFoo to be collected")
+	}
+	want := []string{"    foo_test.go:10: written with CRLF"}
+	if got := test.Latest().Output; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected output %q, got %q", want, got)
+	}
+}
+
+func TestCollectorMalformedLines(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.Push(engine.Event{Type: engine.EventMalformed})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "run", Package: "pkg", Test: "TestA",
+	}})
+	collector.Push(engine.Event{Type: engine.EventMalformed})
+	collector.Push(engine.Event{Type: engine.EventTest, Malformed: true, TestEvent: parser.TestEvent{
+		Time: now, Action: "pass", Package: "pkg", Test: "TestA",
+	}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "pass", Package: "pkg",
+	}})
+	collector.Push(engine.Event{Type: engine.EventComplete})
+
+	if n := len(collector.State().Runs); n != 1 {
+		t.Fatalf("Expected malformed lines not to split the run, got %d runs", n)
+	}
+	run := collector.State().MostRecentRun()
+	if run.MalformedLines != 3 || run.RecoveredLines != 1 {
+		t.Errorf("Expected 3 malformed lines, 1 recovered, got %d and %d", run.MalformedLines, run.RecoveredLines)
+	}
+	if run.Status != StatusPassed || run.Counts.Passed != 1 {
+		t.Errorf("Expected the recovered pass to count, got %s with %+v", run.Status, run.Counts)
+	}
+}
+
+func TestCollectorAnnotations(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.SetPackageFilter(PackageFilter{Exclude: PackagePatterns{"skipped"}})
+	collector.Push(engine.NewSyntheticEvent("deploying fixture DB"))
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "start", Package: "pkg",
+	}})
+	collector.Push(engine.NewPackageSyntheticEvent("pkg", "fixture ready"))
+	collector.Push(engine.NewPackageSyntheticEvent("skipped", "ignored"))
+	collector.Push(engine.Event{Type: engine.EventAnnotation, Annotation: engine.Annotation{Text: "untimed"}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+		Time: now, Action: "pass", Package: "pkg",
+	}})
+	collector.Push(engine.Event{Type: engine.EventComplete})
+
+	if n := len(collector.State().Runs); n != 1 {
+		t.Fatalf("Expected annotations not to start or split runs, got %d runs", n)
+	}
+	var texts []string
+	for _, a := range collector.State().MostRecentRun().Annotations {
+		if a.Time.IsZero() {
+			t.Errorf("Expected annotation %q to be timed", a.Text)
+		}
+		texts = append(texts, a.Package+":"+a.Text)
+	}
+	want := []string{":deploying fixture DB", "pkg:fixture ready", ":untimed"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("Expected annotations %q, got %q", want, texts)
+	}
+}
+
+func TestCollectorNoteOutput(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	for _, evt := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "pkg"},
+		{Time: now.Add(time.Second), Action: "output", Package: "pkg", Output: "##tang:note environment rebuilt\n"},
+		{Time: now.Add(2 * time.Second), Action: "output", Package: "pkg", Output: "ok  \tpkg\t2.0s\n"},
+		{Time: now.Add(2 * time.Second), Action: "pass", Package: "pkg"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: evt})
+	}
+	collector.Push(engine.Event{Type: engine.EventComplete})
+
+	run := collector.State().MostRecentRun()
+	want := []engine.Annotation{{Time: now.Add(time.Second), Package: "pkg", Text: "environment rebuilt"}}
+	if !reflect.DeepEqual(run.Annotations, want) {
+		t.Errorf("Expected annotations %+v, got %+v", want, run.Annotations)
+	}
+	for _, line := range run.Packages["pkg"].OutputLines {
+		if strings.Contains(line, "##tang:note") {
+			t.Errorf("Expected the note not to be package output, got %q", line)
+		}
+	}
+}
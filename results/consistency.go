@@ -0,0 +1,83 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Inconsistency is a disagreement between tang's accounting of a package
+// and go test's own verdict on it. It points at a bug in parsing or
+// attributing the go test stream, rather than at the tests.
+type Inconsistency struct {
+	Package string
+	Problem string
+}
+
+func (i Inconsistency) String() string {
+	return i.Package + ": " + i.Problem
+}
+
+// checkConsistency reconciles each finished package of the run with go
+// test's verdict, its summary line and pass or fail event, and with the
+// results of its tests. It's run when the run finishes; packages which
+// didn't finish are skipped.
+func checkConsistency(run *Run) []Inconsistency {
+	var found []Inconsistency
+	report := func(pkg *PackageResult, format string, args ...any) {
+		found = append(found, Inconsistency{Package: pkg.Name, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if pkg == nil || (pkg.Status != StatusPassed && pkg.Status != StatusFailed) {
+			continue
+		}
+
+		// go test's summary line, e.g. "ok  \tpkg\t0.1s", agrees with
+		// its pass or fail event.
+		verdict, _, _ := strings.Cut(strings.TrimSpace(pkg.SummaryLine), "\t")
+		switch {
+		case strings.TrimSpace(verdict) == "ok" && pkg.Status != StatusPassed:
+			report(pkg, "summary line says ok, but the package %s", pkg.Status)
+		case strings.TrimSpace(verdict) == "FAIL" && pkg.Status != StatusFailed:
+			report(pkg, "summary line says FAIL, but the package %s", pkg.Status)
+		}
+
+		if pkg.Status == StatusPassed {
+			if pkg.Counts.Failed > 0 {
+				report(pkg, "passed, but %d tests failed", pkg.Counts.Failed)
+			}
+			if n := pkg.Counts.Running + pkg.Counts.Paused; n > 0 {
+				report(pkg, "passed, but %d tests never finished", n)
+			}
+		}
+
+		// The package's counts agree with its tests' results. Retries
+		// uncount earlier results, so their counts can't be rebuilt.
+		if pkg.Attempts > 1 {
+			continue
+		}
+		var passed, failed, skipped int
+		for _, testName := range pkg.TestOrder {
+			tr := run.TestResults[pkgName+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			for _, exec := range tr.Executions {
+				switch exec.Status {
+				case StatusPassed:
+					passed++
+				case StatusFailed:
+					failed++
+				case StatusSkipped:
+					skipped++
+				}
+			}
+		}
+		if passed != pkg.Counts.Passed || failed != pkg.Counts.Failed || skipped != pkg.Counts.Skipped {
+			report(pkg, "counted %d passed, %d failed, %d skipped, but its tests have %d passed, %d failed, %d skipped",
+				pkg.Counts.Passed, pkg.Counts.Failed, pkg.Counts.Skipped, passed, failed, skipped)
+		}
+	}
+	return found
+}
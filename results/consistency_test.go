@@ -0,0 +1,69 @@
+package results
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorConsistencyCheck(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	for _, te := range []parser.TestEvent{
+		// Consistent
+		{Action: "start", Package: "good"},
+		{Action: "run", Package: "good", Test: "TestA"},
+		{Action: "fail", Package: "good", Test: "TestA"},
+		{Action: "output", Package: "good", Output: "FAIL\tgood\t0.1s\n"},
+		{Action: "fail", Package: "good"},
+		// Passed despite a failed test, and with a FAIL summary line
+		{Action: "start", Package: "bad"},
+		{Action: "run", Package: "bad", Test: "TestA"},
+		{Action: "fail", Package: "bad", Test: "TestA"},
+		{Action: "output", Package: "bad", Output: "FAIL\tbad\t0.1s\n"},
+		{Action: "pass", Package: "bad"},
+		// Unfinished packages aren't checked
+		{Action: "start", Package: "running"},
+		{Action: "run", Package: "running", Test: "TestA"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+	collector.Finish()
+
+	want := []Inconsistency{
+		{Package: "bad", Problem: "summary line says FAIL, but the package passed"},
+		{Package: "bad", Problem: "passed, but 1 tests failed"},
+	}
+	if got := collector.State().MostRecentRun().Inconsistencies; !slices.Equal(got, want) {
+		t.Errorf("Inconsistencies = %v, want %v", got, want)
+	}
+}
+
+func TestCheckConsistencyCounts(t *testing.T) {
+	run := NewRun(1)
+	pkg := &PackageResult{Name: "pkg", Status: StatusPassed, TestOrder: []string{"TestA", "TestB"}}
+	pkg.Counts.Passed = 1
+	run.Packages[pkg.Name] = pkg
+	run.PackageOrder = []string{pkg.Name}
+	for _, name := range pkg.TestOrder {
+		tr := NewTestResult(pkg.Name, name)
+		tr.Latest().Status = StatusPassed
+		run.TestResults[pkg.Name+"/"+name] = tr
+	}
+
+	got := checkConsistency(run)
+	want := []Inconsistency{{Package: "pkg", Problem: "counted 1 passed, 0 failed, 0 skipped, but its tests have 2 passed, 0 failed, 0 skipped"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("checkConsistency() = %v, want %v", got, want)
+	}
+
+	// Retries uncount earlier results, so the counts aren't rebuilt.
+	pkg.Attempts = 2
+	if got := checkConsistency(run); len(got) != 0 {
+		t.Errorf("Expected no inconsistencies for a retried package, got %v", got)
+	}
+}
@@ -0,0 +1,50 @@
+package results
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// summaryElapsedRE matches the line go test ends a test with, e.g.
+// "--- PASS: TestA (1.23s)", capturing the test's name and elapsed seconds.
+var summaryElapsedRE = regexp.MustCompile(`^\s*--- (?:PASS|FAIL|SKIP): (.+) \((\d+(?:\.\d+)?)s\)\s*$`)
+
+// summaryElapsedLines bounds how many trailing output lines are searched
+// for a subtest's summary line, which go test indents, so it's kept in the
+// output rather than as the SummaryLine.
+const summaryElapsedLines = 5
+
+// summaryElapsed returns the elapsed time go test reported in the test's
+// summary line, for streams whose pass, fail and skip events carry no
+// Elapsed, e.g. test2json driving a prebuilt test binary.
+func (e *TestExecution) summaryElapsed(name string) (time.Duration, bool) {
+	lines := []string{e.SummaryLine}
+	for i := len(e.Output) - 1; i >= max(len(e.Output)-summaryElapsedLines, 0); i-- {
+		lines = append(lines, e.Output[i])
+	}
+	for _, line := range lines {
+		m := summaryElapsedRE.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[1]) != name {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// finishElapsed sets the execution's elapsed time from its finishing
+// event's, or from its summary line if the event has none.
+func (e *TestExecution) finishElapsed(name string, elapsed float64) {
+	e.Elapsed = time.Duration(elapsed * float64(time.Second))
+	if elapsed == 0 {
+		if d, ok := e.summaryElapsed(name); ok {
+			e.Elapsed = d
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorElapsedFromSummaryLine(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "pkg"},
+		{Action: "run", Package: "pkg", Test: "TestA"},
+		{Action: "run", Package: "pkg", Test: "TestA/sub"},
+		{Action: "output", Package: "pkg", Test: "TestA/sub", Output: "    --- FAIL: TestA/sub (12.50s)\n"},
+		{Action: "fail", Package: "pkg", Test: "TestA/sub"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "--- FAIL: TestA (12.75s)\n"},
+		{Action: "fail", Package: "pkg", Test: "TestA"},
+		{Action: "run", Package: "pkg", Test: "TestB"},
+		{Action: "output", Package: "pkg", Test: "TestB", Output: "--- PASS: TestB (0.00s)\n"},
+		{Action: "pass", Package: "pkg", Test: "TestB"},
+		{Action: "run", Package: "pkg", Test: "TestC"},
+		{Action: "output", Package: "pkg", Test: "TestC", Output: "--- SKIP: TestC (3.00s)\n"},
+		{Action: "skip", Package: "pkg", Test: "TestC", Elapsed: 1},
+		{Action: "fail", Package: "pkg"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	run := collector.State().MostRecentRun()
+	for test, want := range map[string]time.Duration{
+		"TestA/sub": 12500 * time.Millisecond,
+		"TestA":     12750 * time.Millisecond,
+		"TestB":     0,
+		"TestC":     time.Second, // The event's Elapsed wins
+	} {
+		if got := run.TestResults["pkg/"+test].Latest().Elapsed; got != want {
+			t.Errorf("%s elapsed = %v, want %v", test, got, want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package results
 
+import "time"
+
 // EventType identifies the type of event emitted by the Collector.
 type EventType string
 
@@ -16,11 +18,20 @@ const (
 // Event represents a high-level event emitted by the Collector.
 type Event struct {
 	Type        EventType
-	RunID       int    // Which run this event belongs to
-	PackageName string // For EventPackageUpdated, EventTestUpdated
-	TestName    string // For EventTestUpdated
-	RawLine     []byte // For EventRawOutput
-	Output      string // For EventNonTestOutput
+	RunID       int       // Which run this event belongs to
+	Time        time.Time // Time of the go test event which caused this event, if any
+	PackageName string    // For EventPackageUpdated, EventTestUpdated
+	TestName    string    // For EventTestUpdated
+	RawLine     []byte    // For EventRawOutput
+	Output      string    // For EventNonTestOutput, EventTestOutput
+
+	// Status transition, for EventRunFinished, EventPackageUpdated and
+	// EventTestUpdated. PrevStatus is StatusUnknown for a new package or
+	// test execution.
+	Status     Status
+	PrevStatus Status
+	Iteration  int           // 1-based test execution, for EventTestUpdated and EventTestOutput
+	Elapsed    time.Duration // Once Status is terminal
 }
 
 // NewRunStartedEvent creates a new RunStarted event.
@@ -0,0 +1,60 @@
+package results
+
+// ExitPolicy decides which outcomes, besides failures, give tang a non-zero
+// exit code. Failed tests and packages, including build failures, always
+// do.
+type ExitPolicy struct {
+	FailOnSkip        bool // Any skipped test fails the run
+	FailOnInterrupted bool // A run which ended with packages still running fails
+}
+
+// HasFailures reports whether any test or package in the run failed,
+// including packages which failed to build.
+func (r *Run) HasFailures() bool {
+	if r.Counts.Failed > 0 || r.Status == StatusFailed {
+		return true
+	}
+	for _, pkg := range r.Packages {
+		if pkg.Status == StatusFailed || pkg.Status == StatusBuildFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasInterruptions reports whether the run ended before all of its
+// packages finished, e.g. because go test was killed or its output was
+// truncated.
+func (r *Run) HasInterruptions() bool {
+	if r.Status == StatusInterrupted {
+		return true
+	}
+	for _, pkg := range r.Packages {
+		if pkg.Status == StatusInterrupted {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFailures reports whether any run has failures.
+func (s *State) HasFailures() bool {
+	for _, run := range s.Runs {
+		if run.HasFailures() {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns 1 if any run fails under the policy, else 0.
+func (s *State) ExitCode(p ExitPolicy) int {
+	for _, run := range s.Runs {
+		if run.HasFailures() ||
+			(p.FailOnSkip && run.Counts.Skipped > 0) ||
+			(p.FailOnInterrupted && run.HasInterruptions()) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestStateExitCode(t *testing.T) {
+	push := func(c *Collector, events ...parser.TestEvent) {
+		for _, te := range events {
+			c.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+	}
+	now := time.Now()
+
+	t.Run("passed", func(t *testing.T) {
+		c := NewCollector()
+		push(c,
+			parser.TestEvent{Time: now, Action: "start", Package: "pkg"},
+			parser.TestEvent{Time: now, Action: "run", Package: "pkg", Test: "TestA"},
+			parser.TestEvent{Time: now, Action: "skip", Package: "pkg", Test: "TestA"},
+			parser.TestEvent{Time: now, Action: "pass", Package: "pkg"},
+		)
+		state := c.State()
+		if state.HasFailures() {
+			t.Error("Expected no failures")
+		}
+		if code := state.ExitCode(ExitPolicy{}); code != 0 {
+			t.Errorf("Expected exit code 0, got %d", code)
+		}
+		if code := state.ExitCode(ExitPolicy{FailOnSkip: true}); code != 1 {
+			t.Errorf("Expected exit code 1 with FailOnSkip, got %d", code)
+		}
+	})
+
+	t.Run("build failed", func(t *testing.T) {
+		c := NewCollector()
+		push(c,
+			parser.TestEvent{Time: now, Action: "start", Package: "pkg"},
+			parser.TestEvent{Time: now, Action: "fail", Package: "pkg", FailedBuild: "pkg"},
+		)
+		run := c.State().Runs[0]
+		if !run.HasFailures() {
+			t.Error("Expected a build failure to count as a failure")
+		}
+		if code := c.State().ExitCode(ExitPolicy{}); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		c := NewCollector()
+		push(c,
+			parser.TestEvent{Time: now, Action: "start", Package: "pkg"},
+			parser.TestEvent{Time: now, Action: "run", Package: "pkg", Test: "TestA"},
+		)
+		c.Finish()
+		run := c.State().Runs[0]
+		if !run.HasInterruptions() || run.HasFailures() {
+			t.Errorf("Expected an interruption and no failures, got %v", run.Status)
+		}
+		if code := c.State().ExitCode(ExitPolicy{}); code != 0 {
+			t.Errorf("Expected exit code 0, got %d", code)
+		}
+		if code := c.State().ExitCode(ExitPolicy{FailOnInterrupted: true}); code != 1 {
+			t.Errorf("Expected exit code 1 with FailOnInterrupted, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package results
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Label is a key=value annotation attached to a run, e.g. a CI job ID or a
+// matrix parameter like the Go version, for correlating reports.
+type Label struct {
+	Key   string
+	Value string
+}
+
+func (l Label) String() string {
+	return l.Key + "=" + l.Value
+}
+
+// ParseLabel parses a "key=value" label. The key must be non-empty; the
+// value may be empty.
+func ParseLabel(s string) (Label, error) {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return Label{}, fmt.Errorf("invalid label %q: want key=value", s)
+	}
+	return Label{Key: key, Value: value}, nil
+}
+
+// Labels is an ordered list of run labels. It implements flag.Value, so a
+// -label flag can be repeated.
+type Labels []Label
+
+func (l *Labels) String() string {
+	if l == nil {
+		return ""
+	}
+	strs := make([]string, len(*l))
+	for i, label := range *l {
+		strs[i] = label.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// Set appends a label parsed by ParseLabel.
+func (l *Labels) Set(s string) error {
+	label, err := ParseLabel(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, label)
+	return nil
+}
+
+// Map returns the labels keyed by name. Later labels override earlier ones
+// with the same key.
+func (l Labels) Map() map[string]string {
+	if len(l) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(l))
+	for _, label := range l {
+		m[label.Key] = label.Value
+	}
+	return m
+}
+
+// newRunUID generates an identifier for a run which is unique across
+// invocations of tang, e.g. "20260102-150405-3f9a1c". It sorts by start
+// time.
+func newRunUID(start time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return start.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
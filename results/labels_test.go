@@ -0,0 +1,26 @@
+package results
+
+import "testing"
+
+func TestLabelsSet(t *testing.T) {
+	var labels Labels
+	for _, s := range []string{"ci_job=1234", "go=1.25", "empty=", "go=1.26"} {
+		if err := labels.Set(s); err != nil {
+			t.Fatalf("Set(%q): %v", s, err)
+		}
+	}
+	if got := labels.String(); got != "ci_job=1234,go=1.25,empty=,go=1.26" {
+		t.Errorf("Unexpected labels %q", got)
+	}
+
+	m := labels.Map()
+	if m["go"] != "1.26" || m["ci_job"] != "1234" || len(m) != 3 {
+		t.Errorf("Unexpected label map %v", m)
+	}
+
+	for _, s := range []string{"novalue", "=value", ""} {
+		if err := labels.Set(s); err == nil {
+			t.Errorf("Expected an error for label %q", s)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package results
 
 import (
+	"strings"
 	"time"
 
+	"github.com/ansel1/tang/engine"
 	"github.com/ansel1/tang/parser"
 )
 
@@ -18,6 +20,7 @@ const (
 	StatusSkipped
 	StatusInterrupted
 	StatusPaused
+	StatusBuildFailed // Package whose test binary failed to compile
 )
 
 func (s Status) String() string {
@@ -29,6 +32,7 @@ func (s Status) String() string {
 		"skipped",
 		"interrupted",
 		"paused",
+		"build failed",
 	}
 	if s < 0 || s >= Status(len(strs)) {
 		return "unknown"
@@ -41,25 +45,57 @@ func (s Status) String() string {
 // A run starts when any test or build event is received and there is no current run in progress.
 // A run finishes when the number of running packages drops to 0.
 type Run struct {
-	ID             int                       // Sequential run ID (1, 2, 3...)
-	Packages       map[string]*PackageResult // Package name -> PackageResult
-	PackageOrder   []string                  // Chronological order of package starts
-	TestResults    map[string]*TestResult    // "package/testname" -> TestResult
-	FirstEventTime time.Time                 // When the run started
-	WallStartTime  time.Time                 // When the run started (wall clock)
-	LastEventTime  time.Time                 // When the run ended
-	RunningPkgs    int                       // Number of currently running packages
-	NonTestOutput  []string                  // Build errors, compilation output
-	BuildEvents    []parser.BuildEvent       // Structured build events
-	Counts         struct {
-		Passed  int // Number of passed tests
-		Failed  int // Number of failed tests
-		Skipped int // Number of skipped tests
-		Running int // Number of actively running tests (excludes paused)
-		Paused  int // Number of paused tests
+	ID              int                       // Sequential run ID (1, 2, 3...)
+	UID             string                    // Unique run ID for correlating reports across invocations
+	Labels          Labels                    // User-supplied labels, e.g. CI job ID or Go version
+	Modes           []Mode                    // go test modes the run was under, e.g. -race
+	Packages        map[string]*PackageResult // Package name -> PackageResult
+	PackageOrder    []string                  // Chronological order of package starts
+	TestResults     map[string]*TestResult    // "package/testname" -> TestResult
+	FirstEventTime  time.Time                 // When the run started
+	WallStartTime   time.Time                 // When the run started (wall clock)
+	LastEventTime   time.Time                 // When the run ended
+	RunningPkgs     int                       // Number of currently running packages
+	NonTestOutput   []string                  // Build errors, compilation output
+	MalformedLines  int                       // Corrupted JSON events in the input, with engine.WithResync
+	RecoveredLines  int                       // MalformedLines a test or build event was recovered from
+	Inconsistencies []Inconsistency           // Packages whose counts disagree with go test's verdict; see checkConsistency
+	Anomalies       []Anomaly                 // Events breaking the stream's ordering invariants, with SetStrict
+	BuildEvents     []parser.BuildEvent       // Structured build events
+	Annotations     []engine.Annotation       // Checkpoints injected by a wrapping tool, in arrival order
+	Counts          struct {
+		Passed      int // Number of passed tests
+		Failed      int // Number of failed tests
+		Skipped     int // Number of skipped tests
+		Running     int // Number of actively running tests (excludes paused)
+		Paused      int // Number of paused tests
+		Interrupted int // Number of tests still running when the run ended; see Collector.Finish
 	}
 	Status  Status
 	Running bool
+
+	// TimeSource is the clock FirstEventTime and LastEventTime (and so
+	// Elapsed) are measured with. It's settled when the run finishes.
+	TimeSource TimeSource
+	Replay     bool    // Whether the run was replayed from a file
+	ReplayRate float64 // Replay rate (inverse speed) when Replay is set; 0 is instant
+
+	// Resources is the memory and CPU usage of the go test process tree,
+	// sampled while the run is in progress. Only set in exec mode.
+	Resources ResourceUsage
+
+	// Repro is how go test was run, for rerunning the run identically.
+	// Only set in exec mode; see Collector.SetReproducibility.
+	Repro *Reproducibility
+}
+
+// ResourceUsage is the latest and peak memory and CPU usage of a process
+// tree. CPU is a percentage of one core, so 400 is four busy cores.
+type ResourceUsage struct {
+	RSS     uint64 // Resident set size, in bytes
+	CPU     float64
+	PeakRSS uint64
+	PeakCPU float64
 }
 
 // GetBuildErrors returns all build events for the given import path
@@ -79,13 +115,15 @@ type PackageResult struct {
 	Status        Status
 	StartTime     time.Time // When the package testing started
 	WallStartTime time.Time // When the package testing started (wall clock)
+	EndTime       time.Time // When the package testing finished (event time)
 	Elapsed       time.Duration
 	Counts        struct {
-		Passed  int // Number of passed tests
-		Failed  int // Number of failed tests
-		Skipped int // Number of skipped tests
-		Running int // Number of actively running tests (excludes paused)
-		Paused  int // Number of paused tests
+		Passed      int // Number of passed tests
+		Failed      int // Number of failed tests
+		Skipped     int // Number of skipped tests
+		Running     int // Number of actively running tests (excludes paused)
+		Paused      int // Number of paused tests
+		Interrupted int // Number of tests still running when the run ended; see Collector.Finish
 	}
 	SummaryLine  string   // Final package result line (e.g. "ok\tpkg\t0.30s\tcoverage: 87.5%")
 	OutputLines  []string // Package-level output that isn't the summary line or a bare PASS/FAIL
@@ -93,6 +131,108 @@ type PackageResult struct {
 	DisplayOrder []string // Render order for TUI; reordered when paused tests resume
 	FailedBuild  string   // ImportPath of failed build (if any)
 	PanicTestKey string   // "package/test" key of the test carrying the timeout panic output
+	Cached       bool     // Result was replayed from go's test cache ("(cached)" in the summary line)
+	Attempts     int      // Times the package started in the run; see Collector.SetMergeRetries
+	ShuffleSeed  string   // The seed of go test -shuffle, from the "-test.shuffle" line the package printed
+
+	// SetupTime is the time from the package starting to its first test
+	// starting, and TeardownTime from its last test finishing to the
+	// package finishing: time spent outside tests, e.g. in TestMain. Both
+	// are measured with event times, for the package's first attempt.
+	SetupTime    time.Duration
+	TeardownTime time.Duration
+
+	// RunningSamples records the number of actively running tests each time
+	// it changes, keyed by event time. Used to report parallelism.
+	RunningSamples []RunningSample
+
+	// running holds the package's running tests, and runningSubtests how
+	// many running subtests each test has at any depth; waiting is the
+	// number of running tests with running subtests. See trackRunning.
+	running         map[string]bool
+	runningSubtests map[string]int
+	waiting         int
+
+	// framedTest is the test whose output go test is currently printing;
+	// see Collector.attributeOutput.
+	framedTest string
+
+	// firstTestStart and lastTestEnd bound the package's tests; see
+	// recordSetupTeardown.
+	firstTestStart time.Time
+	lastTestEnd    time.Time
+}
+
+// RunningSample is the number of actively running tests in a package from
+// Time until the next sample.
+type RunningSample struct {
+	Time    time.Time
+	Running int
+}
+
+// recordRunning appends a RunningSample if the number of running tests
+// doing work of their own changed since the last sample. Events without a
+// timestamp are ignored.
+func (p *PackageResult) recordRunning(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	running := p.runningLeaves()
+	n := len(p.RunningSamples)
+	if n == 0 && running == 0 {
+		return
+	}
+	if n > 0 && p.RunningSamples[n-1].Running == running {
+		return
+	}
+	p.RunningSamples = append(p.RunningSamples, RunningSample{Time: t, Running: running})
+}
+
+// runningLeaves returns the number of running tests without running
+// subtests. A test running subtests only waits on them, so it isn't
+// counted, lest a test with sequential subtests look like two running.
+func (p *PackageResult) runningLeaves() int {
+	return len(p.running) - p.waiting
+}
+
+// trackRunning records whether the test named name is running.
+func (p *PackageResult) trackRunning(name string, running bool) {
+	if p.running[name] == running {
+		return
+	}
+	if p.running == nil {
+		p.running = make(map[string]bool)
+		p.runningSubtests = make(map[string]int)
+	}
+	delta := -1
+	if running {
+		delta = 1
+		p.running[name] = true
+	} else {
+		delete(p.running, name)
+	}
+	if p.runningSubtests[name] > 0 {
+		p.waiting += delta
+	}
+	for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name, "/") {
+		name = name[:i]
+		before := p.runningSubtests[name]
+		after := before + delta
+		if after == 0 {
+			delete(p.runningSubtests, name)
+		} else {
+			p.runningSubtests[name] = after
+		}
+		if p.running[name] && (before == 0) != (after == 0) {
+			p.waiting += delta
+		}
+	}
+}
+
+// clearRunning forgets the running tests, once the package finishes and
+// any left running were interrupted.
+func (p *PackageResult) clearRunning() {
+	p.running, p.runningSubtests, p.waiting = nil, nil, 0
 }
 
 func (p *PackageResult) moveToEndOfDisplayOrder(name string) {
@@ -113,9 +253,43 @@ type TestExecution struct {
 	Elapsed        time.Duration
 	Output         []string      // Failure/skip messages
 	SummaryLine    string        // The "===" or "---" line
+	SkipReason     string        // Message passed to t.Skip, for skipped tests
 	Interrupted    bool          // True if the test was interrupted by a panic or runtime fatal
 	ActiveDuration time.Duration // Accumulated time spent actively running (excludes paused time)
 	LastResumeTime time.Time     // Wall clock time when the test last entered running state
+	Attempt        int           // The package's Attempts when the execution started
+	Artifacts      []string      // Files attached with ArtifactMarker lines, in order
+	Dropped        int           // Lines of output dropped for going over the collector's line rate
+
+	// LastOutputTime is when the test last wrote output, a subtest
+	// included, or started or resumed, and WallLastOutputTime the same by
+	// the wall clock; see Silence. SilentFor is, for an execution
+	// interrupted by the end of the run, how long it had been silent then.
+	LastOutputTime     time.Time
+	WallLastOutputTime time.Time
+	SilentFor          time.Duration
+
+	// The line collapsed into the last line of Output, and how many times
+	// it was repeated; see appendOutput.
+	repeated string
+	repeats  int
+
+	// The current one second window of output, and the lines seen and
+	// dropped in it; see throttle.
+	rateWindow  time.Time
+	windowLines int
+	windowDrops int
+}
+
+// Silence returns how long a running execution has gone without output at
+// wall time now: since it, or a subtest, last wrote any, or it started or
+// resumed. It's 0 unless the execution is running; a paused test waits
+// for others rather than stalling.
+func (e *TestExecution) Silence(now time.Time) time.Duration {
+	if e.Status != StatusRunning {
+		return 0
+	}
+	return max(0, now.Sub(e.WallLastOutputTime))
 }
 
 // TestResult represents the result of a single test (possibly with multiple executions).
@@ -0,0 +1,88 @@
+package results
+
+import (
+	"slices"
+	"strings"
+)
+
+// Mode is a go test flag which changes what a run tests, e.g. -race. A
+// report shows the modes its run was under as badges, so a run under -short
+// isn't mistaken for the full suite.
+type Mode string
+
+const (
+	ModeRace  Mode = "race"  // -race
+	ModeShort Mode = "short" // -short
+	ModeCover Mode = "cover" // -cover, or any flag implying it, e.g. -coverprofile
+)
+
+// modeOrder is the order a run's modes are listed in.
+var modeOrder = []Mode{ModeRace, ModeShort, ModeCover}
+
+// Badge returns the mode's badge, e.g. "RACE".
+func (m Mode) Badge() string {
+	return strings.ToUpper(string(m))
+}
+
+// ModesFromArgs returns the modes enabled by go test arguments, e.g. those
+// of the test subcommand. Arguments after -args are passed to the test
+// binary, so they're ignored.
+func ModesFromArgs(args []string) []Mode {
+	var modes []Mode
+	for _, arg := range args {
+		if arg == "-args" || arg == "--args" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (hasValue && value == "false") {
+			continue
+		}
+		switch name {
+		case "race":
+			modes = addMode(modes, ModeRace)
+		case "short":
+			modes = addMode(modes, ModeShort)
+		case "cover", "covermode", "coverpkg", "coverprofile":
+			modes = addMode(modes, ModeCover)
+		}
+	}
+	return modes
+}
+
+// HasMode reports whether the run was under mode.
+func (r *Run) HasMode(mode Mode) bool {
+	return slices.Contains(r.Modes, mode)
+}
+
+// Badges returns the badges of the run's modes, e.g. ["RACE", "COVER"].
+func (r *Run) Badges() []string {
+	badges := make([]string, len(r.Modes))
+	for i, mode := range r.Modes {
+		badges[i] = mode.Badge()
+	}
+	return badges
+}
+
+// addMode adds mode to modes, keeping them in modeOrder.
+func addMode(modes []Mode, mode Mode) []Mode {
+	if slices.Contains(modes, mode) {
+		return modes
+	}
+	modes = append(modes, mode)
+	slices.SortFunc(modes, func(a, b Mode) int {
+		return slices.Index(modeOrder, a) - slices.Index(modeOrder, b)
+	})
+	return modes
+}
+
+// detectOutputModes records the modes a line of test or package output
+// shows the run was under: a data race report only comes from the race
+// detector, and a coverage figure only from a coverage run.
+func (r *Run) detectOutputModes(line string) {
+	switch {
+	case strings.Contains(line, "WARNING: DATA RACE"), strings.Contains(line, "race detected during execution of test"):
+		r.Modes = addMode(r.Modes, ModeRace)
+	case strings.Contains(line, "coverage: ") && (strings.Contains(line, "% of statements") || strings.Contains(line, "[no statements]")):
+		r.Modes = addMode(r.Modes, ModeCover)
+	}
+}
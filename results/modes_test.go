@@ -0,0 +1,64 @@
+package results
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestModesFromArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want []Mode
+	}{
+		{nil, nil},
+		{[]string{"-v", "./..."}, nil},
+		{[]string{"-cover", "-short", "--race", "./..."}, []Mode{ModeRace, ModeShort, ModeCover}},
+		{[]string{"-coverprofile=c.out", "-race=false", "./..."}, []Mode{ModeCover}},
+		{[]string{"-covermode", "atomic", "-short=true"}, []Mode{ModeShort, ModeCover}},
+		{[]string{"./...", "-args", "-race"}, nil},
+		{[]string{"race"}, nil},
+	} {
+		if got := ModesFromArgs(tc.args); !slices.Equal(got, tc.want) {
+			t.Errorf("ModesFromArgs(%q) = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestCollectorDetectsModes(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.SetModes([]Mode{ModeCover})
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "pkg"},
+		{Action: "run", Package: "pkg", Test: "TestA"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "    a_test.go:10: skipping in short mode\n"},
+		{Action: "skip", Package: "pkg", Test: "TestA"},
+		{Action: "run", Package: "pkg", Test: "TestB"},
+		{Action: "output", Package: "pkg", Test: "TestB", Output: "WARNING: DATA RACE\n"},
+		{Action: "fail", Package: "pkg", Test: "TestB"},
+		{Action: "fail", Package: "pkg"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	run := collector.State().MostRecentRun()
+	if want := []Mode{ModeRace, ModeShort, ModeCover}; !slices.Equal(run.Modes, want) {
+		t.Errorf("Modes = %v, want %v", run.Modes, want)
+	}
+	if got := run.Badges(); !slices.Equal(got, []string{"RACE", "SHORT", "COVER"}) {
+		t.Errorf("Badges() = %v", got)
+	}
+
+	// Modes seen in one run's output don't carry over to the next.
+	collector.Push(engine.Event{Type: engine.EventComplete})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "start", Package: "pkg2"}})
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "output", Package: "pkg2", Output: "ok  \tpkg2\t0.01s\tcoverage: 50.0% of statements\n"}})
+	if run := collector.State().MostRecentRun(); !slices.Equal(run.Modes, []Mode{ModeCover}) {
+		t.Errorf("Expected only the configured mode in a new run, got %v", run.Modes)
+	}
+}
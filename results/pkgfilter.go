@@ -0,0 +1,62 @@
+package results
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchPackage reports whether the package import path matches pattern: a
+// path.Match glob (e.g. "*/integration"), or a package path ending in
+// "/..." to match that package and everything beneath it, like go's package
+// patterns.
+func MatchPackage(pattern, pkg string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+			return true
+		}
+	}
+	matched, _ := path.Match(pattern, pkg)
+	return matched
+}
+
+// PackagePatterns is a list of MatchPackage patterns, set by a repeatable
+// flag, e.g. -pkg.
+type PackagePatterns []string
+
+func (p *PackagePatterns) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, ",")
+}
+
+// Set appends a pattern, rejecting malformed globs.
+func (p *PackagePatterns) Set(s string) error {
+	if _, err := path.Match(s, ""); err != nil || s == "" {
+		return fmt.Errorf("invalid package pattern %q", s)
+	}
+	*p = append(*p, s)
+	return nil
+}
+
+// Match reports whether the package matches any of the patterns.
+func (p PackagePatterns) Match(pkg string) bool {
+	for _, pattern := range p {
+		if MatchPackage(pattern, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageFilter selects the packages a Collector tracks.
+type PackageFilter struct {
+	Include PackagePatterns // If set, only matching packages are tracked
+	Exclude PackagePatterns // Matching packages aren't tracked
+}
+
+// Allows reports whether the filter tracks the package.
+func (f PackageFilter) Allows(pkg string) bool {
+	return (len(f.Include) == 0 || f.Include.Match(pkg)) && !f.Exclude.Match(pkg)
+}
@@ -0,0 +1,68 @@
+package results
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestPackageFilterAllows(t *testing.T) {
+	filter := PackageFilter{
+		Include: PackagePatterns{"example.com/svc/...", "*/tools"},
+		Exclude: PackagePatterns{"example.com/svc/legacy/..."},
+	}
+	for pkg, want := range map[string]bool{
+		"example.com/svc":            true,
+		"example.com/svc/orders":     true,
+		"example.com/svc/legacy":     false,
+		"example.com/svc/legacy/db":  false,
+		"example.com/tools":          true,
+		"example.com/other":          false,
+		"example.com/service/orders": false,
+	} {
+		if got := filter.Allows(pkg); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", pkg, got, want)
+		}
+	}
+	if !(PackageFilter{}).Allows("anything") {
+		t.Error("Expected an empty filter to allow every package")
+	}
+
+	var patterns PackagePatterns
+	if err := patterns.Set("["); err == nil {
+		t.Error("Expected a malformed glob to be rejected")
+	}
+}
+
+func TestCollectorPackageFilter(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.SetPackageFilter(PackageFilter{Exclude: PackagePatterns{"skipped"}})
+	collector.Push(engine.Event{Type: engine.EventBuild, BuildEvent: parser.BuildEvent{ImportPath: "skipped [skipped.test]", Action: "build-output", Output: "# skipped\n"}})
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "skipped"},
+		{Action: "start", Package: "kept"},
+		{Action: "run", Package: "skipped", Test: "TestA"},
+		{Action: "run", Package: "kept", Test: "TestB"},
+		{Action: "build-output", ImportPath: "skipped [skipped.test]", Output: "oops\n"},
+		{Action: "fail", Package: "skipped", Test: "TestA"},
+		{Action: "pass", Package: "kept", Test: "TestB"},
+		{Action: "fail", Package: "skipped"},
+		{Action: "pass", Package: "kept"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+	collector.Finish()
+
+	run := collector.State().MostRecentRun()
+	if !slices.Equal(run.PackageOrder, []string{"kept"}) || len(run.BuildEvents) != 0 {
+		t.Errorf("Expected only the kept package, got %v and build events %v", run.PackageOrder, run.BuildEvents)
+	}
+	if run.Counts.Failed != 0 || run.Counts.Passed != 1 || run.Status != StatusPassed {
+		t.Errorf("Expected the run to pass with 1 test, got %+v, %v", run.Counts, run.Status)
+	}
+}
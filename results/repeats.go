@@ -0,0 +1,41 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+)
+
+// appendOutput appends a line of the test's output. With collapse, a line
+// repeating the previous one is counted instead of stored: the first
+// repeat appends a marker line, which later repeats update, e.g.
+//
+//	warning: connection reset
+//	(previous line repeated 2999 times)
+//
+// Blank lines are always stored.
+func (e *TestExecution) appendOutput(line string, collapse bool) {
+	if collapse && e.repeats > 0 && line == e.repeated {
+		e.repeats++
+		e.Output[len(e.Output)-1] = repeatMarker(line, e.repeats)
+		return
+	}
+	if collapse && len(e.Output) > 0 && e.repeats == 0 && line == e.Output[len(e.Output)-1] && strings.TrimSpace(line) != "" {
+		e.repeated = line
+		e.repeats = 1
+		e.Output = append(e.Output, repeatMarker(line, 1))
+		return
+	}
+	e.repeated, e.repeats = "", 0
+	e.Output = append(e.Output, line)
+}
+
+// repeatMarker returns the line standing in for n repeats of line, indented
+// like it.
+func repeatMarker(line string, n int) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	times := "times"
+	if n == 1 {
+		times = "time"
+	}
+	return fmt.Sprintf("%s(previous line repeated %d %s)", indent, n, times)
+}
@@ -0,0 +1,67 @@
+package results
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func pushRepeatedOutput(c *Collector, lines ...string) {
+	now := time.Now()
+	push := func(action, test, output string) {
+		c.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now, Action: action, Package: "example.com/pkg", Test: test, Output: output,
+		}})
+	}
+	push("start", "", "")
+	push("run", "TestNoisy", "")
+	for _, line := range lines {
+		push("output", "TestNoisy", line+"\n")
+	}
+	push("fail", "TestNoisy", "")
+}
+
+func TestCollectorCollapsesRepeatedOutput(t *testing.T) {
+	var lines []string
+	lines = append(lines, "    noisy_test.go:10: starting")
+	for range 3000 {
+		lines = append(lines, "    noisy_test.go:12: warning: connection reset")
+	}
+	lines = append(lines, "    noisy_test.go:14: done", "    noisy_test.go:14: done", "", "")
+
+	c := NewCollector()
+	var events int
+	c.SetEventHandler(func(evt Event) {
+		if evt.Type == EventTestOutput {
+			events++
+		}
+	})
+	pushRepeatedOutput(c, lines...)
+
+	want := []string{
+		"    noisy_test.go:10: starting",
+		"    noisy_test.go:12: warning: connection reset",
+		"    (previous line repeated 2999 times)",
+		"    noisy_test.go:14: done",
+		"    (previous line repeated 1 time)",
+		"",
+		"",
+	}
+	got := c.State().MostRecentRun().TestResults["example.com/pkg/TestNoisy"].Latest().Output
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected collapsed output:\n%q\ngot:\n%q", want, got)
+	}
+	if events != len(lines) {
+		t.Errorf("Expected an output event for each of the %d lines, got %d", len(lines), events)
+	}
+
+	c = NewCollector()
+	c.SetKeepRepeatedLines(true)
+	pushRepeatedOutput(c, lines...)
+	if got := c.State().MostRecentRun().TestResults["example.com/pkg/TestNoisy"].Latest().Output; len(got) != len(lines) {
+		t.Errorf("Expected all %d lines kept, got %d", len(lines), len(got))
+	}
+}
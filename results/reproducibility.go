@@ -0,0 +1,94 @@
+package results
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// reproEnv are the environment variables which change how go builds and
+// runs tests, recorded with their values in a Reproducibility.
+var reproEnv = []string{
+	"CGO_ENABLED", "GOARCH", "GOOS", "GOAMD64", "GOARM", "GOARM64",
+	"GOEXPERIMENT", "GOFLAGS", "GODEBUG", "GOMAXPROCS", "GOTOOLCHAIN",
+	"GOWORK", "GOPROXY", "GOCOVERDIR", "TZ",
+}
+
+// volatileEnv are the environment variables left out of an environment's
+// fingerprint, which shells change as they go without changing tests.
+var volatileEnv = []string{"_", "OLDPWD", "PWD", "SHLVL"}
+
+// Reproducibility is what's needed to rerun a run identically in exec
+// mode, as `tang repro` does: the go version, go test's arguments and
+// working directory, and the environment. The shuffle seeds and the
+// packages run are the run's own; see PackageResult.ShuffleSeed.
+type Reproducibility struct {
+	GoVersion string            // e.g. "go1.25.1 linux/amd64"
+	Dir       string            // go test's working directory
+	Args      []string          // go test's arguments, without -json
+	Env       map[string]string // The variables of reproEnv which were set
+
+	// EnvFingerprint is a short hash of the whole environment, so a rerun
+	// in a different one shows, without recording values which may be
+	// secrets.
+	EnvFingerprint string
+}
+
+// NewReproducibility records how go test was run: goVersion is what `go
+// version` reports, args are go test's arguments, and environ the
+// environment, as from os.Environ.
+func NewReproducibility(goVersion, dir string, args, environ []string) *Reproducibility {
+	r := &Reproducibility{
+		GoVersion:      goVersion,
+		Dir:            dir,
+		Args:           slices.Clone(args),
+		EnvFingerprint: EnvFingerprint(environ),
+	}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if slices.Contains(reproEnv, name) {
+			if r.Env == nil {
+				r.Env = make(map[string]string)
+			}
+			r.Env[name] = value
+		}
+	}
+	return r
+}
+
+// WithArgs returns a copy of r with go test's arguments replaced, e.g.
+// for a rerun of failed tests.
+func (r *Reproducibility) WithArgs(args []string) *Reproducibility {
+	c := *r
+	c.Args = slices.Clone(args)
+	return &c
+}
+
+// EnvFingerprint returns a short hash of the environment environ, the same
+// for any order of its variables, and whatever the shell's working
+// directory (see volatileEnv).
+func EnvFingerprint(environ []string) string {
+	sorted := slices.Sorted(slices.Values(environ))
+	sorted = slices.DeleteFunc(sorted, func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+		return slices.Contains(volatileEnv, name)
+	})
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// ShuffleSeeds returns the shuffle seeds of the run's packages tested with
+// -shuffle, by package.
+func (r *Run) ShuffleSeeds() map[string]string {
+	var seeds map[string]string
+	for _, name := range r.PackageOrder {
+		if pkg := r.Packages[name]; pkg != nil && pkg.ShuffleSeed != "" {
+			if seeds == nil {
+				seeds = make(map[string]string)
+			}
+			seeds[name] = pkg.ShuffleSeed
+		}
+	}
+	return seeds
+}
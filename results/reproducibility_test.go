@@ -0,0 +1,52 @@
+package results
+
+import (
+	"testing"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestNewReproducibility(t *testing.T) {
+	environ := []string{"HOME=/home/me", "GOFLAGS=-mod=mod", "CGO_ENABLED=0", "PWD=/src"}
+	r := NewReproducibility("go1.25.1 linux/amd64", "/src", []string{"-race", "./..."}, environ)
+	if len(r.Env) != 2 || r.Env["GOFLAGS"] != "-mod=mod" || r.Env["CGO_ENABLED"] != "0" {
+		t.Errorf("Expected only the go variables recorded, got %v", r.Env)
+	}
+	// Neither the order of the environment nor the working directory
+	// changes the fingerprint; any other variable does.
+	if fp := EnvFingerprint([]string{"PWD=/elsewhere", "CGO_ENABLED=0", "GOFLAGS=-mod=mod", "HOME=/home/me"}); fp != r.EnvFingerprint {
+		t.Errorf("Expected fingerprint %s, got %s", r.EnvFingerprint, fp)
+	}
+	if fp := EnvFingerprint([]string{"HOME=/home/you", "GOFLAGS=-mod=mod", "CGO_ENABLED=0"}); fp == r.EnvFingerprint {
+		t.Errorf("Expected a different fingerprint than %s", fp)
+	}
+	if c := r.WithArgs([]string{"-run", "TestA"}); len(c.Args) != 2 || r.Args[0] != "-race" || c.Env["GOFLAGS"] != "-mod=mod" {
+		t.Errorf("Expected a copy with new arguments, got %+v of %+v", c, r)
+	}
+}
+
+func TestCollectorReproducibility(t *testing.T) {
+	collector := NewCollector()
+	r := NewReproducibility("go1.25.1 linux/amd64", "/src", []string{"-shuffle=on", "./..."}, nil)
+	collector.SetReproducibility(r)
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "example.com/a"},
+		{Action: "output", Package: "example.com/a", Output: "-test.shuffle 1700000000\n"},
+		{Action: "start", Package: "example.com/b"},
+		{Action: "pass", Package: "example.com/a"},
+		{Action: "pass", Package: "example.com/b"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+	collector.Finish()
+
+	run := collector.State().Runs[0]
+	if run.Repro != r {
+		t.Errorf("Expected the run's reproducibility set, got %+v", run.Repro)
+	}
+	seeds := run.ShuffleSeeds()
+	if len(seeds) != 1 || seeds["example.com/a"] != "1700000000" {
+		t.Errorf("Expected the seed of example.com/a, got %v", seeds)
+	}
+}
@@ -0,0 +1,146 @@
+package results
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RunPattern builds a `go test -run` regexp selecting exactly the given
+// tests. Each level of a subtest name (split on "/") becomes one anchored
+// alternation, matching how go test applies -run per level, e.g.
+// TestA and TestB/sub produce "^(TestA|TestB)$/^sub$". Names are quoted
+// with regexp.QuoteMeta so special characters in subtest names match
+// literally.
+//
+// Because -run levels are matched independently, mixing names of different
+// depths may select a few extra subtests; it never omits a listed test.
+func RunPattern(testNames []string) string {
+	var levels [][]string
+	seen := make([]map[string]bool, 0)
+	for _, name := range testNames {
+		for i, part := range strings.Split(name, "/") {
+			if i == len(levels) {
+				levels = append(levels, nil)
+				seen = append(seen, make(map[string]bool))
+			}
+			if !seen[i][part] {
+				seen[i][part] = true
+				levels[i] = append(levels[i], part)
+			}
+		}
+	}
+
+	patterns := make([]string, len(levels))
+	for i, parts := range levels {
+		sort.Strings(parts)
+		quoted := make([]string, len(parts))
+		for j, p := range parts {
+			quoted[j] = regexp.QuoteMeta(p)
+		}
+		if len(quoted) == 1 {
+			patterns[i] = "^" + quoted[0] + "$"
+		} else {
+			patterns[i] = "^(" + strings.Join(quoted, "|") + ")$"
+		}
+	}
+	return strings.Join(patterns, "/")
+}
+
+// FailedTests returns the names of the failed tests in the run, grouped by
+// package in PackageOrder. A failed parent test is omitted when one of its
+// subtests also failed, since rerunning the subtest reruns the parent.
+func (r *Run) FailedTests() map[string][]string {
+	failed := make(map[string][]string)
+	for _, pkgName := range r.PackageOrder {
+		pkg := r.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		var names []string
+		for _, testName := range pkg.TestOrder {
+			tr := r.TestResults[pkgName+"/"+testName]
+			if tr == nil || !tr.Failed() {
+				continue
+			}
+			names = append(names, testName)
+		}
+		var leaves []string
+		for _, name := range names {
+			isParent := false
+			for _, other := range names {
+				if strings.HasPrefix(other, name+"/") {
+					isParent = true
+					break
+				}
+			}
+			if !isParent {
+				leaves = append(leaves, name)
+			}
+		}
+		if len(leaves) > 0 {
+			failed[pkgName] = leaves
+		}
+	}
+	return failed
+}
+
+// Failed reports whether any execution of the test failed.
+func (t *TestResult) Failed() bool {
+	for _, exec := range t.Executions {
+		if exec.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// RerunCommand returns a ready-to-run `go test` command line which reruns
+// only the failed tests of the run, or "" when nothing failed.
+func (r *Run) RerunCommand() string {
+	failed := r.FailedTests()
+	if len(failed) == 0 {
+		return ""
+	}
+	var pkgs, names []string
+	for _, pkgName := range r.PackageOrder {
+		if tests, ok := failed[pkgName]; ok {
+			pkgs = append(pkgs, pkgName)
+			names = append(names, tests...)
+		}
+	}
+	return "go test -run " + shellQuote(RunPattern(names)) + " " + strings.Join(pkgs, " ")
+}
+
+// RerunCommand returns a ready-to-run `go test` command line which reruns
+// only the test, e.g. go test -run '^TestFoo$' example.com/pkg.
+func (t *TestResult) RerunCommand() string {
+	return "go test -run " + shellQuote(RunPattern([]string{t.Name})) + " " + t.Package
+}
+
+// ReproduceCommands returns the narrowest `go test` commands reproducing
+// the run's failures, one per failed package in PackageOrder: its failed
+// tests selected with -run, and -count=1 so go's test cache can't stand in
+// for running them. A package which failed without a failed test, e.g. a
+// build failure or a TestMain exit, is run whole.
+func (r *Run) ReproduceCommands() []string {
+	failed := r.FailedTests()
+	var commands []string
+	for _, pkgName := range r.PackageOrder {
+		pkg := r.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		if tests, ok := failed[pkgName]; ok {
+			commands = append(commands, "go test -count=1 -run "+shellQuote(RunPattern(tests))+" "+pkgName)
+		} else if pkg.Status == StatusFailed || pkg.Status == StatusBuildFailed {
+			commands = append(commands, "go test -count=1 "+pkgName)
+		}
+	}
+	return commands
+}
+
+// shellQuote single-quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,106 @@
+package results
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestRunPattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		tests []string
+		want  string
+	}{
+		{"single", []string{"TestFoo"}, "^TestFoo$"},
+		{"multiple", []string{"TestB", "TestA"}, "^(TestA|TestB)$"},
+		{"subtest", []string{"TestFoo/bar_baz"}, "^TestFoo$/^bar_baz$"},
+		{"mixed depth", []string{"TestA", "TestB/sub"}, "^(TestA|TestB)$/^sub$"},
+		{"escaped", []string{"TestFoo/a.b(c)"}, `^TestFoo$/^a\.b\(c\)$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RunPattern(tt.tests); got != tt.want {
+				t.Errorf("RunPattern(%v) = %q, want %q", tt.tests, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRerunCommand(t *testing.T) {
+	run := NewRun(1)
+	addTest := func(pkgName, name string, status Status) {
+		pkg, ok := run.Packages[pkgName]
+		if !ok {
+			pkg = &PackageResult{Name: pkgName}
+			run.Packages[pkgName] = pkg
+			run.PackageOrder = append(run.PackageOrder, pkgName)
+		}
+		tr := NewTestResult(pkgName, name)
+		tr.Latest().Status = status
+		run.TestResults[pkgName+"/"+name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, name)
+	}
+
+	if got := run.RerunCommand(); got != "" {
+		t.Errorf("Expected no command for an empty run, got %q", got)
+	}
+
+	addTest("example.com/a", "TestPass", StatusPassed)
+	addTest("example.com/a", "TestParent", StatusFailed)
+	addTest("example.com/a", "TestParent/it's_broken", StatusFailed)
+	addTest("example.com/b", "TestOther", StatusFailed)
+
+	failed := run.FailedTests()
+	if len(failed["example.com/a"]) != 1 || failed["example.com/a"][0] != "TestParent/it's_broken" {
+		t.Errorf("Expected failed parent to be replaced by its failed subtest, got %v", failed["example.com/a"])
+	}
+
+	want := `go test -run '^(TestOther|TestParent)$/^it'\''s_broken$' example.com/a example.com/b`
+	if got := run.RerunCommand(); got != want {
+		t.Errorf("RerunCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestTestResultRerunCommand(t *testing.T) {
+	tr := NewTestResult("example.com/a", "TestParent/it's_broken")
+	want := `go test -run '^TestParent$/^it'\''s_broken$' example.com/a`
+	if got := tr.RerunCommand(); got != want {
+		t.Errorf("RerunCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestRunReproduceCommands(t *testing.T) {
+	run := NewRun(1)
+	addPkg := func(name string, status Status, tests map[string]Status) {
+		pkg := &PackageResult{Name: name, Status: status}
+		run.Packages[name] = pkg
+		run.PackageOrder = append(run.PackageOrder, name)
+		for _, testName := range slices.Sorted(maps.Keys(tests)) {
+			tr := NewTestResult(name, testName)
+			tr.Latest().Status = tests[testName]
+			run.TestResults[name+"/"+testName] = tr
+			pkg.TestOrder = append(pkg.TestOrder, testName)
+		}
+	}
+
+	if got := run.ReproduceCommands(); len(got) != 0 {
+		t.Errorf("Expected no commands for an empty run, got %q", got)
+	}
+
+	addPkg("example.com/a", StatusFailed, map[string]Status{
+		"TestPass": StatusPassed, "TestParent": StatusFailed, "TestParent/it's_broken": StatusFailed, "TestParent/a+b": StatusFailed,
+	})
+	addPkg("example.com/ok", StatusPassed, map[string]Status{"TestPass": StatusPassed})
+	addPkg("example.com/build", StatusBuildFailed, nil)
+	addPkg("example.com/main", StatusFailed, map[string]Status{"TestPass": StatusPassed})
+
+	want := []string{
+		`go test -count=1 -run '^TestParent$/^(a\+b|it'\''s_broken)$' example.com/a`,
+		`go test -count=1 example.com/build`,
+		`go test -count=1 example.com/main`,
+	}
+	if got := run.ReproduceCommands(); !slices.Equal(got, want) {
+		t.Errorf("ReproduceCommands() = %q, want %q", got, want)
+	}
+}
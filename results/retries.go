@@ -0,0 +1,57 @@
+package results
+
+import (
+	"time"
+
+	"github.com/ansel1/tang/parser"
+)
+
+// retryPackage restarts a finished package as a further attempt within the
+// run; see Collector.SetMergeRetries. Test results and counts are kept
+// until the tests run again, so tests that aren't retried keep their
+// earlier result.
+func (c *Collector) retryPackage(run *Run, pkg *PackageResult, event parser.TestEvent) {
+	prevStatus := pkg.Status
+
+	pkg.Attempts++
+	pkg.Status = StatusRunning
+	pkg.WallStartTime = c.clock.Now()
+	pkg.EndTime = time.Time{}
+	pkg.SummaryLine = ""
+	pkg.framedTest = ""
+	pkg.FailedBuild = ""
+	pkg.PanicTestKey = ""
+	pkg.Cached = false
+
+	run.RunningPkgs++
+	c.emitPackageUpdated(run, pkg, prevStatus, event.Time)
+}
+
+// uncountResult removes a finished execution with the given status from the
+// package's and run's counts, when a retry supersedes it.
+func uncountResult(run *Run, pkg *PackageResult, status Status) {
+	switch status {
+	case StatusPassed:
+		pkg.Counts.Passed--
+		run.Counts.Passed--
+	case StatusFailed:
+		pkg.Counts.Failed--
+		run.Counts.Failed--
+	case StatusSkipped:
+		pkg.Counts.Skipped--
+		run.Counts.Skipped--
+	}
+}
+
+// Attempts returns how many attempts of the package ran the test: more
+// than 1 when it was retried.
+func (t *TestResult) Attempts() int {
+	n, last := 0, -1
+	for _, exec := range t.Executions {
+		if exec.Attempt != last {
+			n++
+			last = exec.Attempt
+		}
+	}
+	return max(n, 1)
+}
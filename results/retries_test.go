@@ -0,0 +1,92 @@
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+// pushRetriedPackage pushes a package in which TestFlaky fails and TestOK
+// passes, followed by a retry of TestFlaky which passes.
+func pushRetriedPackage(c *Collector) {
+	now := time.Now()
+	push := func(action, test string) {
+		now = now.Add(10 * time.Millisecond)
+		c.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now, Action: action, Package: "pkg", Test: test,
+		}})
+	}
+	push("start", "")
+	push("run", "TestFlaky")
+	push("fail", "TestFlaky")
+	push("run", "TestOK")
+	push("pass", "TestOK")
+	push("fail", "")
+
+	push("start", "")
+	push("run", "TestFlaky")
+	push("pass", "TestFlaky")
+	push("pass", "")
+	c.Push(engine.Event{Type: engine.EventComplete})
+}
+
+func TestCollectorMergeRetries(t *testing.T) {
+	c := NewCollector()
+	c.SetMergeRetries(true)
+	pushRetriedPackage(c)
+
+	run := c.State().MostRecentRun()
+	if len(c.State().Runs) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(c.State().Runs))
+	}
+	if run.Status != StatusPassed {
+		t.Errorf("Expected run to pass after the retry, got %s", run.Status)
+	}
+	pkg := run.Packages["pkg"]
+	if pkg.Attempts != 2 {
+		t.Errorf("Expected 2 package attempts, got %d", pkg.Attempts)
+	}
+	if pkg.Status != StatusPassed {
+		t.Errorf("Expected package to pass, got %s", pkg.Status)
+	}
+	if pkg.Counts.Passed != 2 || pkg.Counts.Failed != 0 || run.Counts.Passed != 2 || run.Counts.Failed != 0 {
+		t.Errorf("Expected the retry to supersede the failure, got package %+v, run %+v", pkg.Counts, run.Counts)
+	}
+
+	flaky := run.TestResults["pkg/TestFlaky"]
+	if len(flaky.Executions) != 2 {
+		t.Fatalf("Expected 2 executions of TestFlaky, got %d", len(flaky.Executions))
+	}
+	if e := flaky.Executions[0]; e.Status != StatusFailed || e.Attempt != 1 {
+		t.Errorf("Expected a failed first attempt, got %s in attempt %d", e.Status, e.Attempt)
+	}
+	if e := flaky.Executions[1]; e.Status != StatusPassed || e.Attempt != 2 {
+		t.Errorf("Expected a passed second attempt, got %s in attempt %d", e.Status, e.Attempt)
+	}
+	if flaky.Attempts() != 2 {
+		t.Errorf("Expected TestFlaky to have 2 attempts, got %d", flaky.Attempts())
+	}
+
+	ok := run.TestResults["pkg/TestOK"]
+	if ok == nil || ok.Status() != StatusPassed || ok.Attempts() != 1 {
+		t.Errorf("Expected TestOK's result to be kept from the first attempt, got %+v", ok)
+	}
+}
+
+func TestCollectorRestartReplacesResultsByDefault(t *testing.T) {
+	c := NewCollector()
+	pushRetriedPackage(c)
+
+	run := c.State().MostRecentRun()
+	if _, ok := run.TestResults["pkg/TestOK"]; ok {
+		t.Error("Expected the restart to replace TestOK's result")
+	}
+	if flaky := run.TestResults["pkg/TestFlaky"]; len(flaky.Executions) != 1 || flaky.Attempts() != 1 {
+		t.Errorf("Expected a single execution of TestFlaky, got %d", len(flaky.Executions))
+	}
+	if run.Packages["pkg"].Attempts != 1 {
+		t.Errorf("Expected 1 package attempt, got %d", run.Packages["pkg"].Attempts)
+	}
+}
@@ -0,0 +1,34 @@
+package results
+
+import "time"
+
+// recordTestTime notes when the package's first test started and its last
+// test finished, given a test event's action and time. Events without a
+// timestamp are ignored.
+func (p *PackageResult) recordTestTime(action string, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	switch action {
+	case "run":
+		if p.firstTestStart.IsZero() {
+			p.firstTestStart = t
+		}
+	case "pass", "fail", "skip":
+		if t.After(p.lastTestEnd) {
+			p.lastTestEnd = t
+		}
+	}
+}
+
+// recordSetupTeardown sets SetupTime and TeardownTime once the package has
+// finished. They're left zero for packages without tests or timestamps,
+// cached packages, whose events are replayed all at once, and retries.
+func (p *PackageResult) recordSetupTeardown() {
+	if p.Cached || p.Attempts > 1 || p.StartTime.IsZero() || p.EndTime.IsZero() ||
+		p.firstTestStart.IsZero() || p.lastTestEnd.IsZero() {
+		return
+	}
+	p.SetupTime = max(p.firstTestStart.Sub(p.StartTime), 0)
+	p.TeardownTime = max(p.EndTime.Sub(p.lastTestEnd), 0)
+}
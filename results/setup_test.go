@@ -0,0 +1,38 @@
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorSetupTeardown(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	push := func(offset time.Duration, action, pkg, test string) {
+		c.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: start.Add(offset), Action: action, Package: pkg, Test: test,
+		}})
+	}
+	push(0, "start", "pkg/slow", "")
+	push(0, "start", "pkg/none", "")
+	push(3*time.Second, "run", "pkg/slow", "TestA")
+	push(3500*time.Millisecond, "run", "pkg/slow", "TestB")
+	push(4*time.Second, "pass", "pkg/slow", "TestB")
+	push(4500*time.Millisecond, "pass", "pkg/slow", "TestA")
+	push(5*time.Second, "pass", "pkg/slow", "")
+	push(5*time.Second, "pass", "pkg/none", "")
+	c.Push(engine.Event{Type: engine.EventComplete})
+
+	run := c.State().MostRecentRun()
+	slow := run.Packages["pkg/slow"]
+	if slow.SetupTime != 3*time.Second || slow.TeardownTime != 500*time.Millisecond {
+		t.Errorf("Expected 3s setup and 500ms teardown, got %v and %v", slow.SetupTime, slow.TeardownTime)
+	}
+	none := run.Packages["pkg/none"]
+	if none.SetupTime != 0 || none.TeardownTime != 0 {
+		t.Errorf("Expected no setup or teardown without tests, got %v and %v", none.SetupTime, none.TeardownTime)
+	}
+}
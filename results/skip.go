@@ -0,0 +1,86 @@
+package results
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// skipLocationRE matches the "file_test.go:12: " prefix testing.T adds to
+// t.Skip messages.
+var skipLocationRE = regexp.MustCompile(`^\s*[\w.\-]+\.go:\d+: `)
+
+// shortModeRE matches skip reasons caused by go test's -short flag, e.g.
+// "skipping in short mode" or "requires !testing.Short()".
+var shortModeRE = regexp.MustCompile(`(?i)short mode|testing\.Short\b|(^|\s)-short\b`)
+
+// IsShortModeSkip reports whether a skip reason indicates the test was
+// skipped because go test ran with -short.
+func IsShortModeSkip(reason string) bool {
+	return shortModeRE.MatchString(reason)
+}
+
+// skipReason extracts the message passed to t.Skip from a skipped test's
+// output: the last line carrying a file:line prefix, with the prefix
+// removed. If no line has a prefix, the last non-blank line is used.
+func skipReason(output []string) string {
+	last := ""
+	for i := len(output) - 1; i >= 0; i-- {
+		line := output[i]
+		if loc := skipLocationRE.FindStringIndex(line); loc != nil {
+			return strings.TrimSpace(line[loc[1]:])
+		}
+		if last == "" {
+			last = strings.TrimSpace(line)
+		}
+	}
+	return last
+}
+
+// SkipPolicy fails a run based on its skipped tests.
+type SkipPolicy struct {
+	MaxSkips int            // Most skipped tests allowed; negative means unlimited
+	Pattern  *regexp.Regexp // Skip reasons matching this fail the run; nil disables
+
+	// NoShortSkips fails the run if any test was skipped because of -short,
+	// for CI jobs expected to run the full suite.
+	NoShortSkips bool
+}
+
+// Check returns a description of each way the run violates the policy, or
+// nil if it complies.
+func (p SkipPolicy) Check(run *Run) []string {
+	var violations []string
+	if p.MaxSkips >= 0 && run.Counts.Skipped > p.MaxSkips {
+		violations = append(violations, fmt.Sprintf("%d tests skipped, more than the maximum of %d", run.Counts.Skipped, p.MaxSkips))
+	}
+	if p.Pattern == nil && !p.NoShortSkips {
+		return violations
+	}
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := run.TestResults[pkgName+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			for _, exec := range tr.Executions {
+				if exec.Status != StatusSkipped {
+					continue
+				}
+				if p.NoShortSkips && IsShortModeSkip(exec.SkipReason) {
+					violations = append(violations, fmt.Sprintf("%s %s skipped in short mode: %s", pkgName, testName, exec.SkipReason))
+					break
+				}
+				if p.Pattern != nil && p.Pattern.MatchString(exec.SkipReason) {
+					violations = append(violations, fmt.Sprintf("%s %s skipped: %s", pkgName, testName, exec.SkipReason))
+					break
+				}
+			}
+		}
+	}
+	return violations
+}
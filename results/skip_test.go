@@ -0,0 +1,90 @@
+package results
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		output []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"    db_test.go:12: requires docker"}, "requires docker"},
+		{[]string{"    db_test.go:8: setup", "    db_test.go:12: requires docker  "}, "requires docker"},
+		{[]string{"skipping: no network"}, "skipping: no network"},
+	}
+	for _, tt := range tests {
+		if got := skipReason(tt.output); got != tt.want {
+			t.Errorf("skipReason(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestSkipPolicy(t *testing.T) {
+	collector := NewCollector()
+	now := time.Now()
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestDB"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Test: "TestDB", Output: "    db_test.go:12: requires docker\n"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Test: "TestDB", Output: "--- SKIP: TestDB (0.00s)\n"},
+		{Time: now, Action: "skip", Package: "example.com/pkg", Test: "TestDB"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestShort"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Test: "TestShort", Output: "    short_test.go:5: skipped in short mode\n"},
+		{Time: now, Action: "skip", Package: "example.com/pkg", Test: "TestShort"},
+		{Time: now, Action: "pass", Package: "example.com/pkg"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+	run := collector.State().Runs[0]
+
+	if got := run.TestResults["example.com/pkg/TestDB"].Latest().SkipReason; got != "requires docker" {
+		t.Errorf("Expected skip reason 'requires docker', got %q", got)
+	}
+
+	if v := (SkipPolicy{MaxSkips: -1}).Check(run); len(v) != 0 {
+		t.Errorf("Expected no violations with an empty policy, got %v", v)
+	}
+	if v := (SkipPolicy{MaxSkips: 2}).Check(run); len(v) != 0 {
+		t.Errorf("Expected no violations at the skip limit, got %v", v)
+	}
+	if v := (SkipPolicy{MaxSkips: 1}).Check(run); len(v) != 1 {
+		t.Errorf("Expected 1 violation over the skip limit, got %v", v)
+	}
+
+	v := SkipPolicy{MaxSkips: -1, Pattern: regexp.MustCompile("docker")}.Check(run)
+	if len(v) != 1 || v[0] != "example.com/pkg TestDB skipped: requires docker" {
+		t.Errorf("Expected TestDB to match the skip pattern, got %v", v)
+	}
+
+	v = SkipPolicy{MaxSkips: -1, NoShortSkips: true}.Check(run)
+	if len(v) != 1 || v[0] != "example.com/pkg TestShort skipped in short mode: skipped in short mode" {
+		t.Errorf("Expected TestShort to violate -no-short-skips, got %v", v)
+	}
+}
+
+func TestIsShortModeSkip(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   bool
+	}{
+		{"skipping in short mode", true},
+		{"Skipping test in Short Mode.", true},
+		{"requires !testing.Short()", true},
+		{"not run with -short", true},
+		{"requires docker", false},
+		{"FOO_SHORTCUT not set", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsShortModeSkip(tt.reason); got != tt.want {
+			t.Errorf("IsShortModeSkip(%q) = %v, want %v", tt.reason, got, tt.want)
+		}
+	}
+}
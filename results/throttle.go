@@ -0,0 +1,46 @@
+package results
+
+import (
+	"fmt"
+	"time"
+)
+
+// throttle reports whether a line of the test's output at t is over rate
+// lines per second, so it's dropped rather than stored. Lines are counted in
+// one second windows, starting at the first line of each; the lines dropped
+// in a window are counted by a marker line in their place, e.g.
+//
+//	(25000 lines dropped: over 10000 lines/s)
+//
+// which later drops in the window update. Lines without a time, e.g. from
+// test2json driving a test binary directly, are given the time they arrive
+// by the caller.
+func (e *TestExecution) throttle(t time.Time, rate int) bool {
+	if e.rateWindow.IsZero() || t.Before(e.rateWindow) || t.Sub(e.rateWindow) >= time.Second {
+		e.rateWindow, e.windowLines, e.windowDrops = t, 0, 0
+	}
+	e.windowLines++
+	if e.windowLines <= rate {
+		return false
+	}
+	e.Dropped++
+	e.windowDrops++
+	marker := dropMarker(e.windowDrops, rate)
+	if e.windowDrops == 1 {
+		e.repeated, e.repeats = "", 0
+		e.Output = append(e.Output, marker)
+	} else {
+		e.Output[len(e.Output)-1] = marker
+	}
+	return true
+}
+
+// dropMarker returns the line standing in for n lines dropped for going over
+// rate lines per second.
+func dropMarker(n, rate int) string {
+	lines := "lines"
+	if n == 1 {
+		lines = "line"
+	}
+	return fmt.Sprintf("(%d %s dropped: over %d lines/s)", n, lines, rate)
+}
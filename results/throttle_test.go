@@ -0,0 +1,75 @@
+package results
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+)
+
+func TestCollectorMaxLineRate(t *testing.T) {
+	start := time.Now()
+	collector := NewCollector()
+	collector.SetMaxLineRate(3)
+	var emitted int
+	collector.SetEventHandler(func(evt Event) {
+		if evt.Type == EventTestOutput {
+			emitted++
+		}
+	})
+	push := func(at time.Duration, te parser.TestEvent) {
+		te.Time = start.Add(at)
+		te.Package = "pkg"
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	push(0, parser.TestEvent{Action: "start"})
+	push(0, parser.TestEvent{Action: "run", Test: "TestLoop"})
+	for i := range 6 {
+		push(time.Duration(i)*time.Millisecond, parser.TestEvent{Action: "output", Test: "TestLoop", Output: fmt.Sprintf("line %d\n", i)})
+	}
+	push(time.Second, parser.TestEvent{Action: "output", Test: "TestLoop", Output: "line 6\n"})
+	push(time.Second, parser.TestEvent{Action: "output", Test: "TestLoop", Output: "--- FAIL: TestLoop (1.00s)\n"})
+	push(time.Second, parser.TestEvent{Action: "fail", Test: "TestLoop", Elapsed: 1})
+	push(time.Second, parser.TestEvent{Action: "fail"})
+
+	run := collector.State().MostRecentRun()
+	exec := run.TestResults["pkg/TestLoop"].Latest()
+	want := []string{"line 0", "line 1", "line 2", "(3 lines dropped: over 3 lines/s)", "line 6"}
+	if !slices.Equal(exec.Output, want) {
+		t.Errorf("Output = %q, want %q", exec.Output, want)
+	}
+	if exec.Dropped != 3 || exec.Status != StatusFailed || exec.SummaryLine != "--- FAIL: TestLoop (1.00s)" {
+		t.Errorf("Expected 3 dropped lines and the failure kept, got %d, %v, %q", exec.Dropped, exec.Status, exec.SummaryLine)
+	}
+	if emitted != 5 {
+		t.Errorf("Expected 5 output events, without the dropped lines, got %d", emitted)
+	}
+}
+
+func TestCollectorMaxLineRateKeepsPanics(t *testing.T) {
+	now := time.Now()
+	collector := NewCollector()
+	collector.SetMaxLineRate(1)
+	for _, te := range []parser.TestEvent{
+		{Action: "start", Package: "pkg"},
+		{Action: "run", Package: "pkg", Test: "TestA"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "spam\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "spam\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "panic: boom\n"},
+		{Action: "output", Package: "pkg", Test: "TestA", Output: "goroutine 7 [running]:\n"},
+		{Action: "fail", Package: "pkg"},
+	} {
+		te.Time = now
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	exec := collector.State().MostRecentRun().TestResults["pkg/TestA"].Latest()
+	want := []string{"spam", "(1 line dropped: over 1 lines/s)", "panic: boom", "goroutine 7 [running]:"}
+	if !slices.Equal(exec.Output, want) {
+		t.Errorf("Output = %q, want %q", exec.Output, want)
+	}
+}
@@ -0,0 +1,51 @@
+package results
+
+import "time"
+
+// TimeSource identifies the clock a Run's durations are measured with.
+type TimeSource int
+
+const (
+	// TimeSourceEvent measures durations between test2json event
+	// timestamps. It's used whenever events carry timestamps, including
+	// replays, so durations match the original run at any replay rate.
+	TimeSourceEvent TimeSource = iota
+
+	// TimeSourceWall measures wall-clock time, scaled by the replay rate
+	// when replaying, and at least the longest elapsed time go reported for
+	// a package. It's used for runs whose events have no timestamps, e.g.
+	// streams piped through tools which strip them, so its durations are
+	// approximate; see Run.ApproximateDurations.
+	TimeSourceWall
+)
+
+func (s TimeSource) String() string {
+	if s == TimeSourceWall {
+		return "wall"
+	}
+	return "event"
+}
+
+// Elapsed returns the duration of the run, measured by its TimeSource.
+// While the run is in progress this is the time up to its latest event.
+func (r *Run) Elapsed() time.Duration {
+	return r.LastEventTime.Sub(r.FirstEventTime)
+}
+
+// ApproximateDurations reports whether the run's durations are
+// approximate, measured as its events were received rather than from their
+// timestamps, which they lacked. Tests' and packages' durations reported
+// by go itself are exact either way.
+func (r *Run) ApproximateDurations() bool {
+	return r.TimeSource == TimeSourceWall
+}
+
+// ScaleWall converts a wall-clock duration into the run's time, dividing
+// by the replay rate when replaying (a rate of 0.5 replays at 2x speed). An
+// instant replay (rate 0) leaves the duration unscaled.
+func (r *Run) ScaleWall(d time.Duration) time.Duration {
+	if r.Replay && r.ReplayRate > 0 {
+		return time.Duration(float64(d) / r.ReplayRate)
+	}
+	return d
+}
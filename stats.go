@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/output/format"
+	"github.com/charmbracelet/colorprofile"
+)
+
+// runStats implements the `tang stats` subcommand, which reports failure
+// rates and flaky tests from a history file recorded with -history, or a
+// database recorded with -db.
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	historyFile := fs.String("history", "", "History file recorded with -history")
+	dbFile := fs.String("db", "", "SQLite history database recorded with -db, instead of -history")
+	n := fs.Int("n", 20, "Number of most recent runs to include (0 for all)")
+	byTest := fs.Bool("by-test", false, "Group results by test name across packages, to find tests which are slow or fail in many packages")
+	noColorFlag := fs.Bool("no-color", false, "Disable all ANSI color and style escape codes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang stats -history <file> | -db <file> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Report per-package failure rates and the flakiest tests across recorded runs.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if (*historyFile == "") == (*dbFile == "") {
+		fmt.Fprintf(os.Stderr, "Error: stats requires one of -history <filename> and -db <filename>\n")
+		return 1
+	}
+
+	var (
+		stats  *history.Stats
+		byName []*history.NameStats
+		runs   int
+		err    error
+	)
+	if *dbFile != "" {
+		stats, byName, runs, err = queryDB(*dbFile, *n, *byTest)
+	} else {
+		var loaded []*format.SummaryJSON
+		loaded, err = history.Load(*historyFile, *n)
+		if *byTest {
+			byName, runs = history.ComputeByTest(loaded), len(loaded)
+		} else {
+			stats = history.ComputeStats(loaded)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		return 1
+	}
+
+	profile := colorprofile.Detect(os.Stdout, os.Environ())
+	noColor := *noColorFlag || profile == colorprofile.NoTTY
+
+	if *byTest {
+		err = history.FormatByTest(os.Stdout, byName, runs, noColor)
+	} else {
+		err = history.FormatStats(os.Stdout, stats, noColor)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing stats: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// queryDB returns the stats of the last n runs of the history database at
+// path, aggregated by its queries rather than loaded: by test name with
+// the number of runs if byTest is set, by package otherwise.
+func queryDB(path string, n int, byTest bool) (*history.Stats, []*history.NameStats, int, error) {
+	db, err := history.OpenDB(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func() { _ = db.Close() }()
+	if byTest {
+		byName, runs, err := db.ByTest(n)
+		return nil, byName, runs, err
+	}
+	stats, err := db.Stats(n)
+	return stats, nil, 0, err
+}
+
+// loadDB returns the last n runs of the history database at path.
+func loadDB(path string, n int) ([]*format.SummaryJSON, error) {
+	db, err := history.OpenDB(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	return db.Load(n)
+}
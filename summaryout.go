@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ansel1/tang/internal/console"
+)
+
+// summaryOutput is where the summary printed at the end of a run goes: a
+// file named by -summary-file, an inherited file descriptor given by
+// -summary-fd, or stdout, with the test output.
+type summaryOutput struct {
+	w       io.Writer
+	noColor bool // Whether to format the summary without colors
+	close   func() error
+}
+
+// openSummaryOutput opens the summary's output for -summary-file path or
+// -summary-fd fd; with neither, the summary goes to stdout, colored unless
+// noColor. A file or descriptor other than a terminal gets plain text.
+func openSummaryOutput(path string, fd int, noColor bool) (*summaryOutput, error) {
+	var f *os.File
+	switch {
+	case path != "" && fd != 0:
+		return nil, errors.New("-summary-file and -summary-fd are mutually exclusive")
+	case path != "":
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return nil, fmt.Errorf("creating summary file: %w", err)
+		}
+	case fd < 0:
+		return nil, errors.New("-summary-fd must be >= 0")
+	case fd == 1:
+		f = os.Stdout
+	case fd == 2:
+		f = os.Stderr
+	case fd != 0:
+		if f = os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd)); f == nil {
+			return nil, fmt.Errorf("-summary-fd %d isn't a file descriptor", fd)
+		}
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("-summary-fd %d: %w", fd, err)
+		}
+	default:
+		return &summaryOutput{w: os.Stdout, noColor: noColor, close: func() error { return nil }}, nil
+	}
+	out := &summaryOutput{
+		w:       f,
+		noColor: noColor || !console.IsTerminal(f),
+		close:   func() error { return nil },
+	}
+	if f != os.Stdout && f != os.Stderr {
+		out.close = f.Close
+	}
+	return out, nil
+}
+
+// separate reports whether the summary goes somewhere other than stdout.
+func (o *summaryOutput) separate() bool {
+	return o.w != os.Stdout
+}
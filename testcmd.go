@@ -7,12 +7,25 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ansel1/tang/internal/procstats"
+	"github.com/ansel1/tang/results"
 )
 
 var valueTangFlags = map[string]bool{
-	"f": true, "outfile": true, "jsonfile": true, "junitfile": true,
-	"slow-threshold": true, "rate": true,
+	"f": true, "outfile": true, "jsonfile": true, "junitfile": true, "events-out": true,
+	"slow-threshold": true, "stall-threshold": true, "pkg-slow-threshold": true, "long-running": true,
+	"trim-pkg-prefix": true, "pkg-segments": true, "pkg-width": true, "rollup": true, "width": true, "rate": true, "summary-json": true, "checkpoint-every": true, "checkpoint-packages": true, "summary-file": true, "summary-fd": true, "max-details-bytes": true, "normalized-json": true, "baseline": true,
+	"regression-pct": true, "regression-abs": true, "failed-out": true, "failed-out-format": true,
+	"history": true, "db": true, "empty-threshold": true, "package-name": true,
+	"max-skips": true, "extract-logs": true, "artifacts": true, "max-line-rate": true, "split-logs": true, "failure-rules": true, "template": true, "skip-pattern-fail": true,
+	"label": true, "failures-pane": true, "pin-failures": true, "pkg": true, "skip-pkg": true, "show-output": true, "progress-fd": true, "pprof": true, "record-cast": true,
+	"theme": true, "theme-colors": true, "duration-style": true, "package-order": true, "plugin": true,
+	"suite-change-pct": true, "markdown": true, "source-context": true, "repo-url": true, "file-links": true, "setup-min": true,
 }
 
 func parseFlagArg(arg string) (name, value string, isFlag bool) {
@@ -106,12 +119,35 @@ func splitTestArgs(allArgs []string) (tangArgs []string, goTestArgs []string, ha
 	return tangArgs, goTestArgs, hasVerbose
 }
 
+// rerunFailedArgs returns goTestArgs with a -run pattern matching the
+// run's failed tests. go test uses the last -run given, so it overrides
+// any earlier one; it's placed before -args, which passes everything after
+// it to the test binary.
+func rerunFailedArgs(goTestArgs []string, run *results.Run) []string {
+	failed := run.FailedTests()
+	var names []string
+	for _, pkgName := range run.PackageOrder {
+		names = append(names, failed[pkgName]...)
+	}
+
+	args := make([]string, 0, len(goTestArgs)+2)
+	i := slices.Index(goTestArgs, "-args")
+	if i < 0 {
+		i = len(goTestArgs)
+	}
+	args = append(args, goTestArgs[:i]...)
+	args = append(args, "-run", results.RunPattern(names))
+	return append(args, goTestArgs[i:]...)
+}
+
 type goTestProcess struct {
 	cmd    *exec.Cmd
 	stdout io.ReadCloser
 }
 
-func startGoTest(goTestArgs []string) (*goTestProcess, error) {
+// startGoTest starts go test -json with goTestArgs, writing its stderr to
+// stderr.
+func startGoTest(goTestArgs []string, stderr io.Writer) (*goTestProcess, error) {
 	args := []string{"test"}
 
 	hasJSON := false
@@ -131,7 +167,7 @@ func startGoTest(goTestArgs []string) (*goTestProcess, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating stdout pipe: %w", err)
 	}
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = stderr
 
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("error starting go test: %w", err)
@@ -157,3 +193,35 @@ func (p *goTestProcess) signal(sig os.Signal) error {
 func (p *goTestProcess) cleanup() {
 	killProcessGroup(p.cmd)
 }
+
+// resourceSampleInterval is how often the memory and CPU usage of the go
+// test process tree is sampled.
+const resourceSampleInterval = time.Second
+
+// sampleResources records the memory and CPU usage of go test and the test
+// binaries it runs against the collector's current run, every interval,
+// until the returned stop function is called. go test leads its own
+// process group (see configureProcessGroup), so the group is the tree.
+// Sampling ends early if stats are unavailable on this platform.
+func (p *goTestProcess) sampleResources(collector *results.Collector, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	sampler := procstats.NewSampler(p.cmd.Process.Pid)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				rss, cpu, err := sampler.Sample()
+				if err != nil {
+					return
+				}
+				collector.RecordResources(rss, cpu)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
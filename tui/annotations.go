@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"github.com/ansel1/tang/results"
+)
+
+// maxAnnotationLines is how many of a run's latest annotations the live
+// display shows.
+const maxAnnotationLines = 3
+
+// annotationLines returns the lines marking the run's latest annotations,
+// e.g. "◆ 1.2s deploying fixture DB", each with its time from the start of
+// the run.
+func (m *Model) annotationLines(run *results.Run) []string {
+	annotations := run.Annotations[max(0, len(run.Annotations)-maxAnnotationLines):]
+	lines := make([]string, 0, len(annotations))
+	for _, a := range annotations {
+		var offset string
+		if !run.FirstEventTime.IsZero() {
+			offset = m.formatElapsed(max(0, a.Time.Sub(run.FirstEventTime))) + " "
+		}
+		text := a.Text
+		if a.Package != "" {
+			text = m.SummaryOptions.PackageNames.Shorten(a.Package) + ": " + text
+		}
+		lines = append(lines, m.brightStyle.Render(m.SummaryOptions.Symbols.Annotation())+" "+m.darkStyle.Render(truncateLine(offset+text, m.TerminalWidth-2)))
+	}
+	return lines
+}
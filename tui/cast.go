@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// CastWriter records the live display into an asciinema v2 cast file as
+// it's drawn, for sharing a test run in docs and bug reports. Use it as the
+// program's output (tea.WithOutput): writes go to the terminal and are
+// recorded with their timing.
+//
+// It reads from and reports the file descriptor of the terminal, so
+// bubbletea still recognizes the terminal and sizes the display to it.
+type CastWriter struct {
+	term  *os.File
+	cast  io.Writer
+	start time.Time
+
+	mu  sync.Mutex
+	err error
+}
+
+// castHeader is the first line of an asciinema v2 cast file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// NewCastWriter returns a CastWriter drawing to term and recording to cast,
+// starting with the header for a terminal of the given size.
+func NewCastWriter(term *os.File, cast io.Writer, width, height int) (*CastWriter, error) {
+	c := &CastWriter{term: term, cast: cast, start: time.Now()}
+	header := castHeader{Version: 2, Width: width, Height: height, Timestamp: c.start.Unix()}
+	if t := os.Getenv("TERM"); t != "" {
+		header.Env = map[string]string{"TERM": t}
+	}
+	if err := json.NewEncoder(cast).Encode(header); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Write draws p on the terminal and records it as an output event.
+func (c *CastWriter) Write(p []byte) (int, error) {
+	n, err := c.term.Write(p)
+	c.record("o", string(p[:n]))
+	return n, err
+}
+
+// Resize records a change in the terminal's size.
+func (c *CastWriter) Resize(width, height int) {
+	c.record("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// record appends an event to the cast. After a write error, further events
+// are dropped; the error is returned by Err.
+func (c *CastWriter) record(code, data string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	event, err := json.Marshal([]any{time.Since(c.start).Seconds(), code, data})
+	if err != nil {
+		c.err = err
+		return
+	}
+	_, c.err = c.cast.Write(append(event, '\n'))
+}
+
+// Err returns the first error encountered recording the cast.
+func (c *CastWriter) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Read reads from the terminal.
+func (c *CastWriter) Read(p []byte) (int, error) {
+	return c.term.Read(p)
+}
+
+// Close does nothing: the terminal outlives the program, and the cast file
+// is closed by its owner.
+func (c *CastWriter) Close() error {
+	return nil
+}
+
+// Fd returns the terminal's file descriptor.
+func (c *CastWriter) Fd() uintptr {
+	return c.term.Fd()
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCastWriter(t *testing.T) {
+	term, err := os.Create(filepath.Join(t.TempDir(), "term"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = term.Close() }()
+
+	var cast bytes.Buffer
+	c, err := NewCastWriter(term, &cast, 100, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Write([]byte("\x1b[1mframe 1\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	c.Resize(120, 40)
+	if err := c.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	drawn, _ := os.ReadFile(term.Name())
+	if string(drawn) != "\x1b[1mframe 1\r\n" {
+		t.Errorf("Expected the frame to be drawn on the terminal, got %q", drawn)
+	}
+
+	lines := strings.Split(strings.TrimSpace(cast.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 events, got:\n%s", cast.String())
+	}
+
+	var header castHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 100 || header.Height != 30 || header.Timestamp == 0 {
+		t.Errorf("Unexpected header %+v", header)
+	}
+
+	for i, want := range [][2]string{{"o", "\x1b[1mframe 1\r\n"}, {"r", "120x40"}} {
+		var event []any
+		if err := json.Unmarshal([]byte(lines[i+1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if len(event) != 3 || event[1] != want[0] || event[2] != want[1] {
+			t.Errorf("Expected event %q, got %v", want, event)
+		}
+		if ts, ok := event[0].(float64); !ok || ts < 0 {
+			t.Errorf("Expected a non-negative timestamp, got %v", event[0])
+		}
+	}
+	if c.Fd() != term.Fd() {
+		t.Error("Expected the terminal's file descriptor")
+	}
+}
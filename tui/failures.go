@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ansel1/tang/results"
+)
+
+// failedTests returns the run's failed tests, most recently failed first.
+// Tests which failed only because a subtest did are left out, as the
+// subtest names the failure.
+func failedTests(run *results.Run) []*results.TestResult {
+	var failed []*results.TestResult
+	for _, tr := range run.TestResults {
+		if tr.Status() == results.StatusFailed {
+			failed = append(failed, tr)
+		}
+	}
+	failed = slices.DeleteFunc(failed, func(tr *results.TestResult) bool {
+		return slices.ContainsFunc(failed, func(other *results.TestResult) bool {
+			return other.Package == tr.Package && strings.HasPrefix(other.Name, tr.Name+"/")
+		})
+	})
+	failedAt := func(tr *results.TestResult) time.Time {
+		return tr.StartTime().Add(tr.Elapsed())
+	}
+	slices.SortFunc(failed, func(a, b *results.TestResult) int {
+		if c := failedAt(b).Compare(failedAt(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Package+"/"+a.Name, b.Package+"/"+b.Name)
+	})
+	return failed
+}
+
+// selectedFailure returns the failed test c reruns, of the run's failed
+// tests as returned by failedTests: the one selected with tab and
+// shift+tab, while it's among them, else the most recent failure. It
+// returns nil when no test has failed.
+func (m *Model) selectedFailure(failed []*results.TestResult) *results.TestResult {
+	if m.selected != nil && slices.Contains(failed, m.selected) {
+		return m.selected
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return failed[0]
+}
+
+// selectFailure moves the selection by delta through the failed tests of
+// the current run, most recent first, wrapping around at either end.
+func (m *Model) selectFailure(delta int) {
+	m.collector.Lock()
+	defer m.collector.Unlock()
+
+	run := m.collector.State().MostRecentRun()
+	if run == nil {
+		return
+	}
+	failed := failedTests(run)
+	if len(failed) == 0 {
+		return
+	}
+	i := slices.Index(failed, m.selectedFailure(failed))
+	m.selected = failed[(i+delta+len(failed))%len(failed)]
+}
+
+// failureLines returns the lines of the failures pane: a header, then the
+// failed tests, most recent first, in at most FailuresPane lines, with the
+// selected one highlighted. It's empty until a test fails, or when
+// FailuresPane is 0.
+func (m *Model) failureLines(run *results.Run) []string {
+	if m.FailuresPane <= 0 {
+		return nil
+	}
+	failed := failedTests(run)
+	if len(failed) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("FAILED (%d) ", len(failed))
+	lines := []string{m.failStyle.Render(header + strings.Repeat("─", max(0, m.TerminalWidth-len(header))))}
+	shown := min(len(failed), m.FailuresPane)
+	if shown < len(failed) {
+		shown-- // Room for the count of the rest
+	}
+	selected := m.selectedFailure(failed)
+	for _, tr := range failed[:shown] {
+		line := fmt.Sprintf("%s %s  %s", m.SummaryOptions.Symbols.Fail(), tr.Name, m.SummaryOptions.PackageNames.Shorten(tr.Package))
+		style := m.failStyle
+		if tr == selected {
+			style = m.brightFail
+		}
+		lines = append(lines, style.Render(truncateLine(line, m.TerminalWidth)))
+	}
+	if shown < len(failed) {
+		lines = append(lines, m.darkStyle.Render(fmt.Sprintf("… and %d more", len(failed)-shown)))
+	}
+	return lines
+}
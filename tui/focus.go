@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// folded reports whether FocusActive folds pkg into the line of finished
+// packages: it passed, or had no tests to run.
+func (m *Model) folded(pkg *results.PackageResult) bool {
+	if !m.FocusActive || pkg.FailedBuild != "" {
+		return false
+	}
+	return pkg.Status == results.StatusPassed || pkg.Status == results.StatusSkipped
+}
+
+// shownPackages returns the names of the run's packages shown with a row of
+// their own, in start order.
+func (m *Model) shownPackages(run *results.Run) []string {
+	if !m.FocusActive {
+		return run.PackageOrder
+	}
+	shown := make([]string, 0, len(run.PackageOrder))
+	for _, pkgName := range run.PackageOrder {
+		if !m.folded(run.Packages[pkgName]) {
+			shown = append(shown, pkgName)
+		}
+	}
+	return shown
+}
+
+// collapsedLine returns the line standing for the packages FocusActive
+// folds, e.g. "✓ 37 packages passed, 3 with no tests", or "" if there are
+// none.
+func (m *Model) collapsedLine(run *results.Run) string {
+	if !m.FocusActive {
+		return ""
+	}
+	var passed, skipped int
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if !m.folded(pkg) {
+			continue
+		}
+		if pkg.Status == results.StatusPassed {
+			passed++
+		} else {
+			skipped++
+		}
+	}
+	if passed+skipped == 0 {
+		return ""
+	}
+	var parts []string
+	if passed > 0 {
+		noun := "packages"
+		if passed == 1 {
+			noun = "package"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s passed", passed, noun))
+	}
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d with no tests", skipped))
+	}
+	line := m.SummaryOptions.Symbols.Pass() + " " + strings.Join(parts, ", ")
+	return m.darkStyle.Render(truncateLine(line, m.TerminalWidth))
+}
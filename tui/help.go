@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyBinding describes a key of the live display, for its help.
+type keyBinding struct {
+	keys string // As shown, e.g. "q, esc, ctrl+c"
+	hint string // Short action for the hint bar; empty to leave it out
+	help string // Description for the help panel
+}
+
+// keyBindings lists the keys of the live display, in the order they're
+// shown in its help.
+var keyBindings = []keyBinding{
+	{keys: "c", hint: "copy rerun", help: "Copy a go test -run command rerunning the selected failed test (also printed)"},
+	{keys: "tab, shift+tab", help: "Select the next or previous failed test, most recent first, highlighted in the failures pane"},
+	{keys: "e", help: "Print the full report of the previous run, then of each run before it (with -compact-runs)"},
+	{keys: "?", hint: "help", help: "Show or hide this help (esc also hides it)"},
+	{keys: "q, esc, ctrl+c", hint: "quit", help: "Interrupt the run and quit"},
+}
+
+// hintLine returns the one-line summary of the keys shown at the bottom of
+// the live display, e.g. "c: copy rerun  ?: help  q: quit".
+func hintLine() string {
+	hints := make([]string, 0, len(keyBindings))
+	for _, kb := range keyBindings {
+		if kb.hint != "" {
+			key, _, _ := strings.Cut(kb.keys, ",")
+			hints = append(hints, key+": "+kb.hint)
+		}
+	}
+	return strings.Join(hints, "  ")
+}
+
+// helpLines returns the lines of the help panel listing every key.
+func helpLines() []string {
+	keyWidth := 0
+	for _, kb := range keyBindings {
+		keyWidth = max(keyWidth, len(kb.keys))
+	}
+	lines := []string{"KEYS"}
+	for _, kb := range keyBindings {
+		lines = append(lines, fmt.Sprintf("  %-*s  %s", keyWidth, kb.keys, kb.help))
+	}
+	return lines
+}
+
+// renderHelp renders the help panel over the package area of the display,
+// in at most height lines.
+func (m *Model) renderHelp(b *strings.Builder, height int) {
+	for i, line := range helpLines() {
+		if i == height {
+			break
+		}
+		style := m.neutralStyle
+		if i == 0 {
+			style = m.brightStyle
+		}
+		b.WriteString(style.Render(truncateLine(line, m.TerminalWidth)))
+		b.WriteString("\n")
+	}
+}
+
+// renderHints renders the hint bar.
+func (m *Model) renderHints(b *strings.Builder) {
+	b.WriteString(m.darkStyle.Render(truncateLine(hintLine(), m.TerminalWidth)))
+	b.WriteString("\n")
+}
@@ -9,13 +9,12 @@ import (
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
 	"github.com/ansel1/tang/results"
 	"github.com/charmbracelet/x/ansi"
 )
 
-// DefaultSlowThreshold is the fallback threshold used when none is specified.
-const DefaultSlowThreshold = 10 * time.Second
-
 // RepaintMsg forces a redraw
 type RepaintMsg struct{}
 
@@ -23,11 +22,24 @@ type RepaintMsg struct{}
 // so the terminal is left clean for summary output.
 type QuitMsg struct{}
 
-// TickMsg is used for timer updates to refresh elapsed times
-type TickMsg struct{}
-
 const MaxOutputLines = 6
 
+// MinWidth is the narrowest terminal the full layout is rendered in. In a
+// narrower one, or one too short for the summary line and a header per
+// package, only the run's counts are shown.
+const MinWidth = 40
+
+// DefaultLongRunningThreshold is the default Model.LongRunningThreshold.
+const DefaultLongRunningThreshold = 30 * time.Second
+
+// longRunningRotation is how long each of several long-running tests in a
+// package is shown in the package header before the next one.
+const longRunningRotation = 5 * time.Second
+
+// diffScanLines bounds how many trailing output lines are scanned for
+// assertion diff context when rendering a running test's last line.
+const diffScanLines = 50
+
 // Model represents the TUI state for the enhanced hierarchical test output display.
 //
 // The Model implements the Bubbletea Model interface.
@@ -61,16 +73,67 @@ type Model struct {
 
 	darkStyle lipgloss.Style
 
-	SlowThreshold time.Duration
+	// SummaryOptions is shared with the final summary, so tests are
+	// highlighted as slow by the same threshold the summary uses.
+	SummaryOptions format.SummaryOptions
+
+	// LongRunningThreshold is how long a test runs before its status
+	// (elapsed time and last output line) is promoted to its package's
+	// header while the test itself is elided for lack of space. Zero
+	// disables promotion.
+	LongRunningThreshold time.Duration
+
+	// Estimator estimates the time remaining in the run, shown in the
+	// summary line. Nil estimates from the package completion rate alone.
+	Estimator *format.Estimator
+
+	// ShowHints shows a one-line summary of the keys, e.g. "?: help", at
+	// the bottom of the display.
+	ShowHints bool
+
+	// FailuresPane is the height of a pane listing the run's failed tests,
+	// most recent first, below the packages, which is shown once a test
+	// fails so a failure isn't missed while output scrolls. Zero hides it.
+	FailuresPane int
+
+	// PinFailures is how long a test which fails is pinned below the
+	// packages with its last output lines, before it collapses back into
+	// its package's counts; see pinnedLines. Zero disables pinning.
+	PinFailures time.Duration
+
+	// FocusActive folds the packages which finished without failing into
+	// one line, e.g. "✓ 37 packages passed", so only running and failed
+	// packages get a row of their own, keeping the display short for runs
+	// of hundreds of packages.
+	FocusActive bool
+
+	// HideSubtests gives only top-level tests a line of their own, each
+	// with its subtests' counts and the latest output line of it or its
+	// subtests, so table-driven tests with hundreds of subtests don't
+	// flood the display. Failed subtests still show in the failures pane.
+	HideSubtests bool
+
+	// Clock is the time running tests' elapsed times are measured against,
+	// engine.SystemClock by default. It must be the collector's clock (see
+	// results.Collector.SetClock), e.g. an engine.ManualClock in tests.
+	Clock engine.Clock
+
+	// ReportRun, if set, renders the full report of a finished run, which
+	// e prints above the display: the run before the current one, then
+	// each run before that on further presses. Used when earlier runs of a
+	// session are summarized in one line each.
+	ReportRun func(run *results.Run) string
 
 	// Replay state
 	ReplayRate float64
 
-	spinner       spinner.Model // Bubbles spinner component ⏺
+	spinner       spinner.Model // Bubbles spinner component ⏺, advanced by timer
 	frozenSpinner spinner.Model // Bubbles frozen spinner component
+	timer         refreshTimer
 
 	interrupted bool
 	quitting    bool
+	showHelp    bool // Whether the help panel replaces the packages
 
 	// OnInterrupt, if set, is invoked when the user presses ctrl+c (or
 	// otherwise interrupts the TUI). It runs before tea.Quit is returned so
@@ -81,6 +144,27 @@ type Model struct {
 	OnInterrupt func()
 
 	NonTestOutput []string
+
+	// elided holds the keys ("package/test") of the tests whose output the
+	// display couldn't show in full, in the order they were first elided,
+	// so the output can be replayed after the run.
+	elided     []string
+	elidedSeen map[string]bool
+
+	// pinned is the failed test pinned until pinnedUntil, and pinSeen the
+	// failed tests already pinned or passed over.
+	pinned      *results.TestResult
+	pinnedUntil time.Time
+	pinSeen     map[*results.TestResult]bool
+
+	// expanded is how many runs e has printed the report of since the
+	// last run started, to walk back through them.
+	expanded     int
+	expandedRuns int // The number of runs when expanded was counted
+
+	// selected is the failed test chosen with tab and shift+tab, which c
+	// copies a rerun command for; see selectedFailure.
+	selected *results.TestResult
 }
 
 // NewModel creates a new TUI model
@@ -88,42 +172,70 @@ func NewModel(replayMode bool, replayRate float64, collector *results.Collector)
 	s := spinner.New(spinner.WithSpinner(spinner.MiniDot))
 	sf := spinner.New(spinner.WithSpinner(spinner.MiniDot))
 
-	return &Model{
-		collector:      collector,
-		TerminalWidth:  80,                                                  // Default width, will be updated by Bubbletea
-		TerminalHeight: 24,                                                  // Default height, will be updated by Bubbletea
-		passStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color("2")), // green
-		failStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
-		skipStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
-		slowStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color("4")), // blue
-		neutralStyle:   lipgloss.NewStyle(),
-		brightStyle:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
-		brightFail:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9")),
-		brightPass:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")),
-		brightSkip:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")),
-		brightSlow:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")),
-		brightNeutral:  lipgloss.NewStyle().Bold(true),
-		dimStyle:       lipgloss.NewStyle().Faint(true),
-		darkStyle:      lipgloss.NewStyle().Foreground(lipgloss.BrightBlack),
-		SlowThreshold:  DefaultSlowThreshold,
-		spinner:        s,
-		frozenSpinner:  sf,
-		ReplayRate:     replayRate,
+	m := &Model{
+		collector:            collector,
+		TerminalWidth:        80, // Default width, will be updated by Bubbletea
+		TerminalHeight:       24, // Default height, will be updated by Bubbletea
+		SummaryOptions:       format.NewSummaryOptions(),
+		LongRunningThreshold: DefaultLongRunningThreshold,
+		spinner:              s,
+		frozenSpinner:        sf,
+		timer:                refreshTimer{interval: RefreshInterval},
+		Clock:                engine.SystemClock,
+		ReplayRate:           replayRate,
 	}
+	m.SetTheme(format.DefaultTheme)
+	return m
+}
+
+// SetTheme colors the display with t, usually the Theme of the
+// SummaryOptions, so the live display matches the summary.
+func (m *Model) SetTheme(t format.Theme) {
+	m.passStyle = format.ColorStyle(t.Pass)
+	m.failStyle = format.ColorStyle(t.Fail)
+	m.skipStyle = format.ColorStyle(t.Skip)
+	m.slowStyle = format.ColorStyle(t.Slow)
+	m.neutralStyle = lipgloss.NewStyle()
+	m.brightStyle = format.ColorStyle(t.Emphasis).Bold(true)
+	m.brightFail = format.ColorStyle(t.BrightFail).Bold(true)
+	m.brightPass = format.ColorStyle(t.BrightPass).Bold(true)
+	m.brightSkip = format.ColorStyle(t.BrightSkip).Bold(true)
+	m.brightSlow = format.ColorStyle(t.BrightSlow).Bold(true)
+	m.brightNeutral = lipgloss.NewStyle().Bold(true)
+	m.dimStyle = lipgloss.NewStyle().Faint(true)
+	m.darkStyle = format.ColorStyle(t.Muted)
+}
+
+// SetSymbols sets the symbols marking test results and states, and the
+// spinner to go with them: with format.SymbolsASCII, one drawn in ASCII.
+func (m *Model) SetSymbols(s format.SymbolSet) {
+	m.SummaryOptions.Symbols = s
+	sp := spinner.MiniDot
+	if s == format.SymbolsASCII {
+		sp = spinner.Line
+	}
+	m.spinner = spinner.New(spinner.WithSpinner(sp))
+	m.frozenSpinner = spinner.New(spinner.WithSpinner(sp))
 }
 
 // Init initializes the model and returns the initial command
 func (m *Model) Init() tea.Cmd {
-	// Return a tick command to update elapsed times for running tests
-	// and the spinner tick
-	return m.spinner.Tick
+	// Start ticking to update elapsed times for running tests and the
+	// spinner
+	return m.timer.start()
 }
 
 // Update handles messages
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case RepaintMsg:
-		return m, nil
+		// Events arrived, so restart the timer if it stopped while idle.
+		return m, m.timer.wake()
+
+	case tea.ResumeMsg:
+		// Redraw at once after being suspended, rather than waiting out the
+		// tick in flight.
+		return m, m.timer.start()
 
 	case tea.WindowSizeMsg:
 		// Update terminal width and height
@@ -136,24 +248,116 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyPressMsg:
 		switch msg.String() {
-		case "q", "esc", "ctrl+c":
+		case "?":
+			m.showHelp = !m.showHelp
+			return m, nil
+		case "esc":
+			if m.showHelp {
+				m.showHelp = false
+				return m, nil
+			}
+			fallthrough
+		case "q", "ctrl+c":
 			m.interrupted = true
 			m.quitting = true
 			if m.OnInterrupt != nil {
 				m.OnInterrupt()
 			}
 			return m, tea.Quit
+		case "c":
+			return m, m.copyRerunCommand()
+		case "tab":
+			m.selectFailure(1)
+			return m, nil
+		case "shift+tab":
+			m.selectFailure(-1)
+			return m, nil
+		case "e":
+			return m, m.expandRun()
 		}
 
-	case spinner.TickMsg:
-		var cmd tea.Cmd
-		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
+	case TickMsg:
+		if !m.timer.accept(msg) {
+			return m, nil
+		}
+		// Drive the spinner from the timer; its own tick command is
+		// discarded so there's a single source of ticks.
+		m.spinner, _ = m.spinner.Update(m.spinner.Tick())
+		if m.idle() {
+			m.timer.stop()
+			return m, nil
+		}
+		return m, m.timer.schedule()
 	}
 
 	return m, nil
 }
 
+// idle reports whether nothing is running, so elapsed times are static.
+func (m *Model) idle() bool {
+	m.collector.Lock()
+	defer m.collector.Unlock()
+
+	run := m.collector.State().MostRecentRun()
+	return run == nil || run.Status != results.StatusRunning
+}
+
+// copyRerunCommand copies a `go test` command which reruns the selected
+// failed test of the current run to the clipboard (via OSC 52) and prints
+// it above the live display, for terminals without clipboard support.
+func (m *Model) copyRerunCommand() tea.Cmd {
+	command := m.rerunCommand()
+	if command == "" {
+		return nil
+	}
+	return tea.Batch(tea.SetClipboard(command), tea.Println(command))
+}
+
+// rerunCommand returns the `go test` command which reruns the selected
+// failed test of the current run, or "" when no test has failed.
+func (m *Model) rerunCommand() string {
+	m.collector.Lock()
+	defer m.collector.Unlock()
+
+	run := m.collector.State().MostRecentRun()
+	if run == nil {
+		return ""
+	}
+	test := m.selectedFailure(failedTests(run))
+	if test == nil {
+		return ""
+	}
+	return test.RerunCommand()
+}
+
+// expandRun prints the full report of the most recent finished run not yet
+// printed since the last run started, wrapping around to the most recent
+// once every run has been printed.
+func (m *Model) expandRun() tea.Cmd {
+	if m.ReportRun == nil {
+		return nil
+	}
+	m.collector.Lock()
+	defer m.collector.Unlock()
+
+	state := m.collector.State()
+	var finished []*results.Run
+	for _, run := range state.Runs {
+		if run != state.CurrentRun {
+			finished = append(finished, run)
+		}
+	}
+	if len(finished) == 0 {
+		return nil
+	}
+	if m.expandedRuns != len(state.Runs) {
+		m.expanded, m.expandedRuns = 0, len(state.Runs)
+	}
+	run := finished[len(finished)-1-m.expanded%len(finished)]
+	m.expanded++
+	return tea.Println(m.ReportRun(run))
+}
+
 // View renders the TUI
 func (m *Model) View() tea.View {
 	return tea.NewView(m.renderView())
@@ -204,7 +408,7 @@ func (m *Model) String() string {
 
 func (m *Model) packageElapsed(pkg *results.PackageResult) time.Duration {
 	if pkg.Status == results.StatusRunning {
-		return m.scaledElapsedDuration(time.Since(pkg.WallStartTime))
+		return m.scaledElapsedDuration(m.Clock.Now().Sub(pkg.WallStartTime))
 	}
 	return pkg.Elapsed
 }
@@ -216,7 +420,7 @@ func (m *Model) testElapsed(test *results.TestResult) time.Duration {
 	}
 	switch latest.Status {
 	case results.StatusRunning:
-		return m.scaledElapsedDuration(latest.ActiveDuration + time.Since(latest.LastResumeTime))
+		return m.scaledElapsedDuration(latest.ActiveDuration + m.Clock.Now().Sub(latest.LastResumeTime))
 	case results.StatusPaused:
 		return m.scaledElapsedDuration(latest.ActiveDuration)
 	default:
@@ -226,9 +430,9 @@ func (m *Model) testElapsed(test *results.TestResult) time.Duration {
 
 func (m *Model) runElapsed(run *results.Run) time.Duration {
 	if run.Status == results.StatusRunning {
-		return m.scaledElapsedDuration(time.Since(run.WallStartTime))
+		return m.scaledElapsedDuration(m.Clock.Now().Sub(run.WallStartTime))
 	}
-	return run.LastEventTime.Sub(run.FirstEventTime)
+	return run.Elapsed()
 }
 
 func (m *Model) scaledElapsedDuration(duration time.Duration) time.Duration {
@@ -240,17 +444,10 @@ func (m *Model) scaledElapsedDuration(duration time.Duration) time.Duration {
 	return time.Duration(float64(duration) / replayRate)
 }
 
-// formatElapsedTime formats elapsed time according to spec
-func formatElapsedTime(d time.Duration) string {
-	if d < 50*time.Millisecond {
-		return "0.0s"
-	}
-	if d >= time.Minute {
-		minutes := d.Seconds() / 60
-		s := fmt.Sprintf("%.1fm", minutes)
-		return s
-	}
-	return fmt.Sprintf("%.1fs", d.Seconds())
+// formatElapsed formats an elapsed time in the summary options' duration
+// style, compact by default so it stays short as the display ticks.
+func (m *Model) formatElapsed(d time.Duration) string {
+	return m.SummaryOptions.DurationStyle.Or(format.DurationStyleCompact).Format(d)
 }
 
 // truncateLine truncates a line to fit within width
@@ -263,18 +460,26 @@ func truncateLine(line string, width int) string {
 
 // renderRun renders the TUI for a specific run
 func (m *Model) renderRun(run *results.Run) string {
+	if m.TerminalWidth < MinWidth {
+		return m.renderCompact(run)
+	}
+
 	var b strings.Builder
 
 	// Render non-test output first (build errors, etc.)
 
 	for _, line := range run.NonTestOutput {
-		// b.WriteString("  ") // Add padding
-		b.WriteString(line)
+		b.WriteString(m.nonTestStyle(line).Render(line))
 		b.WriteString("\n")
 	}
 	if len(run.NonTestOutput) > 0 {
 		b.WriteString("\n")
 	}
+	annotations := m.annotationLines(run)
+	for _, line := range annotations {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
 
 	// Calculate max widths for each column (including run-level counts for the summary line)
 	var maxRunning, maxPaused, maxPassed, maxFailed, maxSkipped, maxTotal, maxElapsed int
@@ -287,7 +492,7 @@ func (m *Model) renderRun(run *results.Run) string {
 	maxFailed = len(fmt.Sprintf("%d", run.Counts.Failed))
 	maxSkipped = len(fmt.Sprintf("%d", run.Counts.Skipped))
 	maxTotal = len(fmt.Sprintf("%d", runTotal))
-	maxElapsed = len(formatElapsedTime(m.runElapsed(run)))
+	maxElapsed = len(m.formatElapsed(m.runElapsed(run)))
 
 	for _, pkg := range run.Packages {
 		if runningLen := len(fmt.Sprintf("%d", pkg.Counts.Running)); runningLen > maxRunning {
@@ -310,21 +515,54 @@ func (m *Model) renderRun(run *results.Run) string {
 			maxTotal = totalLen
 		}
 
-		if elapsedLen := len(formatElapsedTime(m.packageElapsed(pkg))); elapsedLen > maxElapsed {
+		if elapsedLen := len(m.formatElapsed(m.packageElapsed(pkg))); elapsedLen > maxElapsed {
 			maxElapsed = elapsedLen
 		}
 	}
 
-	fixedLines := len(run.NonTestOutput)
+	nonTestLines := len(run.NonTestOutput)
 	if len(run.NonTestOutput) > 0 {
-		fixedLines++ // Newline
+		nonTestLines++ // Newline
 	}
+	fixedLines := nonTestLines
+	fixedLines += len(annotations)
 	fixedLines += 1 // Summary line
 	if len(run.PackageOrder) > 0 {
 		fixedLines += 1 // Separator line
 	}
-	fixedLines += len(run.PackageOrder) // One header per package
+	shown := m.shownPackages(run)
+	fixedLines += len(shown) // One header per package
+	collapsed := m.collapsedLine(run)
+	if collapsed != "" {
+		fixedLines++
+	}
+	if m.ShowHints {
+		fixedLines++ // Hint bar
+	}
+	groups := m.moduleGroups(run)
+	fixedLines += len(groups) // One header per module
+	for _, pkgName := range shown {
+		fixedLines += len(buildOutputLines(run, run.Packages[pkgName]))
+	}
+
+	// Non-test output scrolls off the top, but the rest of the layout
+	// must fit.
+	if fixedLines-nonTestLines > m.TerminalHeight {
+		return m.renderCompact(run)
+	}
 
+	// The pinned failure and the failures pane are left out rather than
+	// squeeze the packages.
+	pinned := m.pinnedLines(run)
+	if fixedLines-nonTestLines+len(pinned) > m.TerminalHeight {
+		pinned = nil
+	}
+	fixedLines += len(pinned)
+	failures := m.failureLines(run)
+	if fixedLines-nonTestLines+len(failures) > m.TerminalHeight {
+		failures = nil
+	}
+	fixedLines += len(failures)
 	availableLines := m.TerminalHeight - fixedLines
 	if availableLines < 0 {
 		availableLines = 0
@@ -347,6 +585,10 @@ func (m *Model) renderRun(run *results.Run) string {
 			for _, testName := range pkg.TestOrder {
 				testKey := pkgName + "/" + testName
 				test := run.TestResults[testKey]
+				if m.hidden(testName) {
+					m.recordElided(run, pkgName, testName, false)
+					continue
+				}
 
 				// Each test now only needs 1 line (output is inline)
 				lineCount := 1
@@ -424,6 +666,10 @@ func (m *Model) renderRun(run *results.Run) string {
 	allocate(p2)
 	allocate(p3)
 
+	for _, item := range items {
+		m.recordElided(run, item.pkgName, item.testName, linesToShow[item.pkgName][item.testName] > 0)
+	}
+
 	// Summary line at top
 	m.renderSummaryLine(&b, run, maxRunning, maxPaused, maxPassed, maxFailed, maxSkipped, maxTotal, maxElapsed)
 
@@ -433,19 +679,127 @@ func (m *Model) renderRun(run *results.Run) string {
 		b.WriteString("\n")
 	}
 
+	if m.showHelp {
+		height := m.TerminalHeight - 1 // Summary line
+		if len(run.PackageOrder) > 0 {
+			height-- // Separator line
+		}
+		if m.ShowHints {
+			height--
+		}
+		m.renderHelp(&b, height)
+		if m.ShowHints {
+			m.renderHints(&b)
+		}
+		return b.String()
+	}
+
 	// Render packages
-	for _, pkgName := range run.PackageOrder {
-		pkgState := run.Packages[pkgName]
-		m.renderPackage(&b, run, pkgState, maxRunning, maxPaused, maxPassed, maxFailed, maxSkipped, maxTotal, maxElapsed, linesToShow[pkgName])
+	if collapsed != "" {
+		b.WriteString(collapsed)
+		b.WriteString("\n")
+	}
+	if groups != nil {
+		for _, group := range groups {
+			name := group.Module
+			if name == "" {
+				name = "(other packages)"
+			}
+			b.WriteString(m.darkStyle.Render(truncateLine("module "+name, m.TerminalWidth)))
+			b.WriteString("\n")
+			for _, pkgState := range group.Packages {
+				m.renderPackage(&b, run, pkgState, maxRunning, maxPaused, maxPassed, maxFailed, maxSkipped, maxTotal, maxElapsed, linesToShow[pkgState.Name])
+			}
+		}
+	} else {
+		for _, pkgName := range shown {
+			pkgState := run.Packages[pkgName]
+			m.renderPackage(&b, run, pkgState, maxRunning, maxPaused, maxPassed, maxFailed, maxSkipped, maxTotal, maxElapsed, linesToShow[pkgName])
+		}
+	}
+
+	for _, line := range pinned {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, line := range failures {
+		b.WriteString(line)
+		b.WriteString("\n")
 	}
 
+	if m.ShowHints {
+		m.renderHints(&b)
+	}
 	return b.String()
 }
 
+// recordElided records the test if the display elides some of its output:
+// a running test shows only its last output line, and other tests none.
+func (m *Model) recordElided(run *results.Run, pkgName, testName string, shown bool) {
+	key := pkgName + "/" + testName
+	if m.elidedSeen[key] {
+		return
+	}
+	test := run.TestResults[key]
+	visible := 0
+	if shown && test.Status() == results.StatusRunning {
+		visible = 1
+	}
+	if len(test.Output()) <= visible {
+		return
+	}
+	if m.elidedSeen == nil {
+		m.elidedSeen = make(map[string]bool)
+	}
+	m.elidedSeen[key] = true
+	m.elided = append(m.elided, key)
+}
+
+// ElidedTests returns the keys ("package/test") of the tests whose output
+// the display elided for lack of space, in the order they were first
+// elided. It must not be called while the model is being rendered.
+func (m *Model) ElidedTests() []string {
+	return m.elided
+}
+
+// renderCompact renders the run's counts alone, on one line, for a
+// terminal too small for the full layout.
+func (m *Model) renderCompact(run *results.Run) string {
+	sym := m.SummaryOptions.Symbols
+	failed := fmt.Sprintf("%s%d", sym.Fail(), run.Counts.Failed)
+	if run.Counts.Failed > 0 {
+		failed = m.failStyle.Render(failed)
+	}
+	line := fmt.Sprintf("%s%s%d %s%d %s %s%d %s",
+		m.getStatusPrefix(run.Status, run.Counts.Failed > 0),
+		sym.Running(), run.Counts.Running, sym.Pass(), run.Counts.Passed, failed, sym.Skip(), run.Counts.Skipped,
+		m.formatElapsed(m.runElapsed(run)))
+	return truncateLine(line, m.TerminalWidth)
+}
+
+// moduleGroups groups the run's shown packages by the workspace modules in
+// SummaryOptions, or returns nil when there aren't several modules.
+func (m *Model) moduleGroups(run *results.Run) []format.ModuleGroup {
+	if len(m.SummaryOptions.Modules) < 2 {
+		return nil
+	}
+	shown := m.shownPackages(run)
+	pkgs := make([]*results.PackageResult, 0, len(shown))
+	for _, pkgName := range shown {
+		pkgs = append(pkgs, run.Packages[pkgName])
+	}
+	return format.GroupByModule(pkgs, m.SummaryOptions.Modules)
+}
+
 // renderPackage renders a single package and its tests
 func (m *Model) renderPackage(b *strings.Builder, run *results.Run, pkg *results.PackageResult, wRunning, wPaused, wPassed, wFailed, wSkipped, wTotal, wElapsed int, testLines map[string]int) {
 	// Render package header
-	m.renderPackageHeader(b, pkg, wRunning, wPaused, wPassed, wFailed, wSkipped, wTotal, wElapsed)
+	m.renderPackageHeader(b, pkg, m.longRunningStatus(run, pkg, testLines), wRunning, wPaused, wPassed, wFailed, wSkipped, wTotal, wElapsed)
+
+	// Compiler output for packages that failed to build
+	for _, line := range buildOutputLines(run, pkg) {
+		m.renderAlignedLine(b, m.failStyle.Render(line), "", "    ")
+	}
 
 	// Render tests if allocated
 	if pkg.Status == results.StatusRunning || pkg.Status == results.StatusInterrupted {
@@ -454,14 +808,75 @@ func (m *Model) renderPackage(b *strings.Builder, run *results.Run, pkg *results
 			if ok && count > 0 {
 				testKey := pkg.Name + "/" + testName
 				testState := run.TestResults[testKey]
-				m.renderTest(b, testState, count)
+				m.renderTest(b, run, testState, count)
+			}
+		}
+	}
+}
+
+// longRunningStatus returns a condensed status line, e.g.
+// "⏱ TestSlow 2.5m: waiting for db", for a test in pkg which has been
+// running longer than LongRunningThreshold but has no line of its own in
+// the display. When several tests qualify, they take turns.
+func (m *Model) longRunningStatus(run *results.Run, pkg *results.PackageResult, testLines map[string]int) string {
+	if m.LongRunningThreshold <= 0 || pkg.Status != results.StatusRunning {
+		return ""
+	}
+
+	var candidates []*results.TestResult
+	for _, testName := range pkg.TestOrder {
+		test := run.TestResults[pkg.Name+"/"+testName]
+		if test == nil || test.Status() != results.StatusRunning || testLines[testName] > 0 {
+			continue
+		}
+		if m.testElapsed(test) >= m.LongRunningThreshold {
+			candidates = append(candidates, test)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	test := candidates[int(m.Clock.Now().UnixNano()/int64(longRunningRotation))%len(candidates)]
+	status := m.SummaryOptions.Symbols.Timer() + " " + test.Name + " " + m.formatElapsed(m.testElapsed(test))
+	output := test.Output()
+	for i := len(output) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(output[i]); line != "" {
+			status += ": " + line
+			break
+		}
+	}
+	return status
+}
+
+// buildOutputLines returns the compiler output for a package that failed to
+// build, capped at MaxOutputLines. The full output is shown in the final
+// summary.
+func buildOutputLines(run *results.Run, pkg *results.PackageResult) []string {
+	if pkg.Status != results.StatusBuildFailed || pkg.FailedBuild == "" {
+		return nil
+	}
+	var lines []string
+	for _, be := range run.GetBuildErrors(pkg.FailedBuild) {
+		if be.Action != "build-output" || be.Output == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(be.Output, "\r\n"), "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if line == "" {
+				continue
 			}
+			if len(lines) == MaxOutputLines {
+				return lines
+			}
+			lines = append(lines, line)
 		}
 	}
+	return lines
 }
 
 // renderPackageHeader renders the package summary line
-func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResult, wRunning, wPaused, wPassed, wFailed, wSkipped, wTotal, wElapsed int) {
+func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResult, longRunning string, wRunning, wPaused, wPassed, wFailed, wSkipped, wTotal, wElapsed int) {
 	var leftPart string
 	var rightPart string
 
@@ -474,16 +889,16 @@ func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResu
 		failColor, skipColor, neutralColor = m.brightFail, m.brightSkip, m.brightNeutral
 	}
 
-	passedStr := neutralColor.Render(fmt.Sprintf("%*s", wPassed+1, fmt.Sprintf("✓%d", pkg.Counts.Passed)))
+	passedStr := neutralColor.Render(fmt.Sprintf("%*s", wPassed+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Pass(), pkg.Counts.Passed)))
 
-	failedStr := fmt.Sprintf("%*s", wFailed+1, fmt.Sprintf("✗%d", pkg.Counts.Failed))
+	failedStr := fmt.Sprintf("%*s", wFailed+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Fail(), pkg.Counts.Failed))
 	if pkg.Counts.Failed > 0 {
 		failedStr = failColor.Render(failedStr)
 	} else {
 		failedStr = neutralColor.Render(failedStr)
 	}
 
-	skippedStr := fmt.Sprintf("%*s", wSkipped+1, fmt.Sprintf("∅%d", pkg.Counts.Skipped))
+	skippedStr := fmt.Sprintf("%*s", wSkipped+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Skip(), pkg.Counts.Skipped))
 	if pkg.Counts.Skipped > 0 {
 		skippedStr = skipColor.Render(skippedStr)
 	} else {
@@ -495,7 +910,7 @@ func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResu
 
 	var elapsedVal string
 	currentElapsed := m.packageElapsed(pkg)
-	elapsedVal = formatElapsedTime(currentElapsed)
+	elapsedVal = m.formatElapsed(currentElapsed)
 	elapsedStr := fmt.Sprintf("%*s", wElapsed, elapsedVal)
 	if running {
 		elapsedStr = m.brightStyle.Render(elapsedStr)
@@ -506,17 +921,19 @@ func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResu
 	runPauseWidth := 1 + wRunning + 1 + 1 + wPaused + 1
 	var runPausePart string
 	if running {
-		runningStr := neutralColor.Render(fmt.Sprintf("%*s", wRunning+1, fmt.Sprintf("▶%d", pkg.Counts.Running)))
-		pausedStr := neutralColor.Render(fmt.Sprintf("%*s", wPaused+1, fmt.Sprintf("⏸%d", pkg.Counts.Paused)))
+		runningStr := neutralColor.Render(fmt.Sprintf("%*s", wRunning+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Running(), pkg.Counts.Running)))
+		pausedStr := neutralColor.Render(fmt.Sprintf("%*s", wPaused+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Paused(), pkg.Counts.Paused)))
 		runPausePart = fmt.Sprintf("%s %s ", runningStr, pausedStr)
 	} else {
 		runPausePart = strings.Repeat(" ", runPauseWidth)
 	}
 
 	rightPart = fmt.Sprintf("%s(%s %s %s) %s %s", runPausePart, passedStr, failedStr, skippedStr, totalStr, elapsedStr)
-	leftPart = pkg.Name
+	shortName := m.SummaryOptions.PackageNames.Shorten(pkg.Name)
+	leftPart = shortName
 	if !running && pkg.SummaryLine != "" {
-		leftPart = expandTabs(stripSummaryStatusWord(pkg.SummaryLine), 8)
+		summaryLine := strings.Replace(pkg.SummaryLine, pkg.Name, shortName, 1)
+		leftPart = expandTabs(stripSummaryStatusWord(summaryLine), 8)
 	}
 
 	// Running/interrupted packages keep their bright highlight so the active
@@ -530,6 +947,9 @@ func (m *Model) renderPackageHeader(b *strings.Builder, pkg *results.PackageResu
 		leftPart = m.brightStyle.Render(leftPart)
 		rightPart = m.brightStyle.Render(rightPart)
 	}
+	if longRunning != "" {
+		leftPart += "  " + m.darkStyle.Render(longRunning)
+	}
 
 	// Prefix uses a colored gutter icon for both running and finished packages so
 	// the package name aligns at column 3 across all states.
@@ -564,13 +984,14 @@ func stripSummaryStatusWord(summary string) string {
 }
 
 // renderTest renders a test and its output lines
-func (m *Model) renderTest(b *strings.Builder, test *results.TestResult, _ int) {
+func (m *Model) renderTest(b *strings.Builder, run *results.Run, test *results.TestResult, _ int) {
 	// Render test summary line
 	summary := m.formatTestSummary(test)
+	subtests, latest := m.subtestStatus(run, test)
 
 	var elapsedVal string
 	currentElapsed := m.testElapsed(test)
-	elapsedVal = formatElapsedTime(currentElapsed)
+	elapsedVal = m.formatElapsed(currentElapsed)
 
 	prefix := "  "
 
@@ -578,11 +999,32 @@ func (m *Model) renderTest(b *strings.Builder, test *results.TestResult, _ int)
 	if test.Status() == results.StatusRunning {
 		summary = m.brightStyle.Render(summary)
 
-		output := test.Output()
+		// A test silent for StallThreshold is likely deadlocked.
+		if threshold := m.SummaryOptions.StallThreshold; threshold > 0 {
+			if silence := m.scaledElapsedDuration(test.Latest().Silence(m.Clock.Now())); silence >= threshold {
+				summary += " " + m.failStyle.Render("stalled "+m.formatElapsed(silence))
+			}
+		}
+		if subtests != "" {
+			summary += " " + subtests
+		}
+
+		output := latest.Output()
 		if len(output) > 0 {
 			lastLine := output[len(output)-1]
 			lastLine = strings.TrimSpace(lastLine)
-			summary += " " + m.darkStyle.Render(lastLine)
+			// Assertion diff lines keep their removed/added highlight so a
+			// failing comparison is recognizable at a glance. Only the tail
+			// of the output is scanned to keep rendering cheap.
+			lineStyle := m.darkStyle
+			tail := output[max(0, len(output)-diffScanLines):]
+			switch kinds := format.ClassifyDiffLines(tail); kinds[len(kinds)-1] {
+			case format.DiffRemoved:
+				lineStyle = m.failStyle
+			case format.DiffAdded:
+				lineStyle = m.passStyle
+			}
+			summary += " " + lineStyle.Render(lastLine)
 		}
 
 		elapsedVal = m.brightStyle.Render(elapsedVal)
@@ -592,11 +1034,27 @@ func (m *Model) renderTest(b *strings.Builder, test *results.TestResult, _ int)
 			summary = style.Render(summary)
 			elapsedVal = style.Render(elapsedVal)
 		}
+		if subtests != "" {
+			summary += " " + subtests
+		}
 	}
 
 	m.renderAlignedLine(b, summary, elapsedVal, prefix)
 }
 
+// nonTestStyle returns the style of a line of non-test output, by its
+// severity: errors stand out, and informational lines recede.
+func (m *Model) nonTestStyle(line string) *lipgloss.Style {
+	switch format.ClassifyOutputLine(line) {
+	case format.OutputError:
+		return &m.failStyle
+	case format.OutputWarning:
+		return &m.skipStyle
+	default:
+		return &m.darkStyle
+	}
+}
+
 func (m *Model) testStyle(test *results.TestResult) *lipgloss.Style {
 	switch test.Status() {
 	case results.StatusFailed:
@@ -604,7 +1062,7 @@ func (m *Model) testStyle(test *results.TestResult) *lipgloss.Style {
 	case results.StatusSkipped:
 		return &m.skipStyle
 	case results.StatusPassed:
-		if m.SlowThreshold > 0 && test.Elapsed() >= m.SlowThreshold {
+		if th := m.SummaryOptions.SlowThresholdFor(test.Package); th > 0 && test.Elapsed() >= th {
 			return &m.slowStyle
 		}
 	}
@@ -637,11 +1095,11 @@ func (m *Model) getStatusPrefix(status results.Status, hasFailures bool) string
 		// The finished-package gutter icon for passing packages renders in
 		// the terminal default color so a successful run isn't a wall of
 		// green; failures and skips keep their color highlight.
-		return "✓ "
-	case results.StatusFailed:
-		return m.failStyle.Render("✗") + " "
+		return m.SummaryOptions.Symbols.Pass() + " "
+	case results.StatusFailed, results.StatusBuildFailed:
+		return m.failStyle.Render(m.SummaryOptions.Symbols.Fail()) + " "
 	case results.StatusSkipped:
-		return m.skipStyle.Render("∅") + " "
+		return m.skipStyle.Render(m.SummaryOptions.Symbols.Skip()) + " "
 	case results.StatusPaused:
 		// For interrupted, we just show the last spinner frame (frozen)
 		// logic is same as running for now from visual perspective in loop
@@ -669,6 +1127,14 @@ func (m *Model) renderAlignedLine(b *strings.Builder, left, right, prefix string
 	rightWidth := ansi.StringWidth(right)
 	leftWidth := ansi.StringWidth(fullLeft)
 
+	// Without room for both parts, the right part (counts and elapsed
+	// time) is kept, truncated to fit.
+	if rightWidth+2 >= m.TerminalWidth {
+		b.WriteString(truncateLine(right, m.TerminalWidth))
+		b.WriteString("\n")
+		return
+	}
+
 	availableWidth := m.TerminalWidth - rightWidth - 2
 	if availableWidth < 0 {
 		availableWidth = 0
@@ -702,6 +1168,12 @@ func (m *Model) renderSummaryLine(b *strings.Builder, run *results.Run, wRunning
 	donePkgs := totalPkgs - run.RunningPkgs
 	if running {
 		leftPart = fmt.Sprintf("(%d packages: %d running, %d done)", totalPkgs, run.RunningPkgs, donePkgs)
+		if eta, ok := m.Estimator.Remaining(run, m.runElapsed(run)); ok {
+			leftPart += " ~" + m.formatElapsed(eta) + " left"
+		}
+		if r := run.Resources; r.RSS > 0 {
+			leftPart += fmt.Sprintf(" %s %.0f%% cpu", format.FormatBytes(r.RSS), r.CPU)
+		}
 	} else {
 		var statusLabel string
 		switch run.Status {
@@ -716,6 +1188,9 @@ func (m *Model) renderSummaryLine(b *strings.Builder, run *results.Run, wRunning
 		}
 		leftPart = statusLabel
 	}
+	if badges := run.Badges(); len(badges) > 0 {
+		leftPart += " " + strings.Join(badges, " ")
+	}
 
 	// Passing test count is rendered without color (only failures and skips
 	// get a color highlight) so the pass color is no longer needed here.
@@ -724,16 +1199,16 @@ func (m *Model) renderSummaryLine(b *strings.Builder, run *results.Run, wRunning
 		failColor, skipColor, neutralColor = m.brightFail, m.brightSkip, m.brightNeutral
 	}
 
-	passedStr := neutralColor.Render(fmt.Sprintf("%*s", wPassed+1, fmt.Sprintf("✓%d", run.Counts.Passed)))
+	passedStr := neutralColor.Render(fmt.Sprintf("%*s", wPassed+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Pass(), run.Counts.Passed)))
 
-	failedStr := fmt.Sprintf("%*s", wFailed+1, fmt.Sprintf("✗%d", run.Counts.Failed))
+	failedStr := fmt.Sprintf("%*s", wFailed+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Fail(), run.Counts.Failed))
 	if run.Counts.Failed > 0 {
 		failedStr = failColor.Render(failedStr)
 	} else {
 		failedStr = neutralColor.Render(failedStr)
 	}
 
-	skippedStr := fmt.Sprintf("%*s", wSkipped+1, fmt.Sprintf("∅%d", run.Counts.Skipped))
+	skippedStr := fmt.Sprintf("%*s", wSkipped+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Skip(), run.Counts.Skipped))
 	if run.Counts.Skipped > 0 {
 		skippedStr = skipColor.Render(skippedStr)
 	} else {
@@ -743,10 +1218,10 @@ func (m *Model) renderSummaryLine(b *strings.Builder, run *results.Run, wRunning
 	total := run.Counts.Passed + run.Counts.Failed + run.Counts.Skipped
 	totalStr := neutralColor.Render(fmt.Sprintf("%*d", wTotal, total))
 
-	runningStr := neutralColor.Render(fmt.Sprintf("%*s", wRunning+1, fmt.Sprintf("▶%d", run.Counts.Running)))
-	pausedStr := neutralColor.Render(fmt.Sprintf("%*s", wPaused+1, fmt.Sprintf("⏸%d", run.Counts.Paused)))
+	runningStr := neutralColor.Render(fmt.Sprintf("%*s", wRunning+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Running(), run.Counts.Running)))
+	pausedStr := neutralColor.Render(fmt.Sprintf("%*s", wPaused+1, fmt.Sprintf("%s%d", m.SummaryOptions.Symbols.Paused(), run.Counts.Paused)))
 
-	elapsedVal := formatElapsedTime(m.runElapsed(run))
+	elapsedVal := m.formatElapsed(m.runElapsed(run))
 	elapsedStr := fmt.Sprintf("%*s", wElapsed, elapsedVal)
 
 	rightPart = fmt.Sprintf("%s %s (%s %s %s) %s %s", runningStr, pausedStr, passedStr, failedStr, skippedStr, totalStr, elapsedStr)
@@ -1,13 +1,19 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+	"unicode"
 
+	tea "charm.land/bubbletea/v2"
 	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
 	"github.com/ansel1/tang/parser"
 	"github.com/ansel1/tang/results"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestPackageSummaryLastOutput(t *testing.T) {
@@ -65,3 +71,693 @@ func TestPackageSummaryLastOutput(t *testing.T) {
 		t.Errorf("Finished package line should not include the 'ok' status word; gutter icon replaces it.\nGot:\n%s", output)
 	}
 }
+
+func TestCopyRerunCommandKey(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+
+	_, cmd := m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if cmd != nil {
+		t.Error("Expected no command when there is no run")
+	}
+
+	now := time.Now()
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "start", Package: "example.com/other"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestFoo"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestBar"},
+		{Time: now, Action: "run", Package: "example.com/other", Test: "TestBaz"},
+		{Time: now.Add(time.Second), Action: "fail", Package: "example.com/pkg", Test: "TestFoo", Elapsed: 1},
+		{Time: now.Add(2 * time.Second), Action: "fail", Package: "example.com/pkg", Test: "TestBar", Elapsed: 2},
+		{Time: now.Add(3 * time.Second), Action: "fail", Package: "example.com/other", Test: "TestBaz", Elapsed: 3},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	_, cmd = m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if cmd == nil {
+		t.Fatal("Expected a command to copy the rerun command line")
+	}
+
+	// The most recent failure is selected until tab selects another, and
+	// only the selected test is rerun.
+	if got, want := m.rerunCommand(), "go test -run '^TestBaz$' example.com/other"; got != want {
+		t.Errorf("rerunCommand() = %q, want %q", got, want)
+	}
+	tab, shiftTab := tea.KeyPressMsg{Code: tea.KeyTab}, tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift}
+	for _, tt := range []struct {
+		key  tea.KeyPressMsg
+		want string
+	}{
+		{tab, "go test -run '^TestBar$' example.com/pkg"},
+		{tab, "go test -run '^TestFoo$' example.com/pkg"},
+		{tab, "go test -run '^TestBaz$' example.com/other"},
+		{shiftTab, "go test -run '^TestFoo$' example.com/pkg"},
+	} {
+		m.Update(tt.key)
+		if got := m.rerunCommand(); got != tt.want {
+			t.Errorf("After %s, rerunCommand() = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLongRunningStatusPromotedToHeader(t *testing.T) {
+	now := time.Now()
+	clock := engine.NewManualClock(now)
+	collector := results.NewCollector()
+	collector.SetClock(clock)
+	m := NewModel(false, 1.0, collector)
+	m.Clock = clock
+	m.TerminalWidth = 100
+	m.LongRunningThreshold = time.Millisecond
+
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestSlow"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Test: "TestSlow", Output: "    waiting for db\n"},
+		{Time: now, Action: "output", Package: "example.com/pkg", Test: "TestSlow", Output: "\n"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+	clock.Advance(5 * time.Millisecond)
+
+	// With room for the test's own line, nothing is promoted.
+	m.TerminalHeight = 20
+	if output := viewLatest(m); strings.Contains(output, "⏱") {
+		t.Errorf("Expected no promoted status when the test is visible.\nGot:\n%s", output)
+	}
+
+	// Leave room only for the package header.
+	m.TerminalHeight = 3
+	output := viewLatest(m)
+	if !strings.Contains(output, "⏱ TestSlow") || !strings.Contains(output, ": waiting for db") {
+		t.Errorf("Expected promoted status for TestSlow in the package header.\nGot:\n%s", output)
+	}
+
+	m.LongRunningThreshold = 0
+	if output := viewLatest(m); strings.Contains(output, "⏱") {
+		t.Errorf("Expected no promoted status when disabled.\nGot:\n%s", output)
+	}
+}
+
+func TestPackagesGroupedByModule(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.SummaryOptions.Modules = []string{"example.com/app", "example.com/lib"}
+
+	now := time.Now()
+	for _, pkg := range []string{"example.com/app/a", "example.com/lib/x", "example.com/app/b"} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "start", Package: pkg}})
+	}
+
+	output := viewLatest(m)
+	pos := 0
+	for _, want := range []string{"module example.com/app", "example.com/app/a", "example.com/app/b", "module example.com/lib", "example.com/lib/x"} {
+		i := strings.Index(output[pos:], want)
+		if i < 0 {
+			t.Fatalf("Expected %q after offset %d in:\n%s", want, pos, output)
+		}
+		pos += i + len(want)
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+
+	now := time.Now()
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestBroken"},
+		{Time: now, Action: "fail", Package: "example.com/pkg", Test: "TestBroken"},
+		{Time: now, Action: "fail", Package: "example.com/pkg"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	m.SetTheme(format.Themes["colorblind"])
+	// Vermillion, #d55e00.
+	if output := viewLatest(m); !strings.Contains(output, "38;2;213;94;0") {
+		t.Errorf("Expected the failed package in the theme's fail color.\nGot:\n%q", output)
+	}
+}
+
+func TestElidedTests(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+
+	now := time.Now()
+	push := func(events ...parser.TestEvent) {
+		for _, te := range events {
+			te.Time, te.Package = now, "example.com/pkg"
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+	}
+	push(
+		parser.TestEvent{Action: "start"},
+		parser.TestEvent{Action: "run", Test: "TestQuiet"},
+		parser.TestEvent{Action: "run", Test: "TestChatty"},
+		parser.TestEvent{Action: "output", Test: "TestChatty", Output: "    first\n"},
+	)
+
+	// The last output line of a running test is shown.
+	viewLatest(m)
+	if got := m.ElidedTests(); len(got) != 0 {
+		t.Errorf("Expected no elided tests, got %q", got)
+	}
+
+	push(parser.TestEvent{Action: "output", Test: "TestChatty", Output: "    second\n"})
+	viewLatest(m)
+	push(parser.TestEvent{Action: "output", Test: "TestChatty", Output: "    third\n"})
+	viewLatest(m)
+	if got, want := m.ElidedTests(), []string{"example.com/pkg/TestChatty"}; !slices.Equal(got, want) {
+		t.Errorf("ElidedTests() = %q, want %q", got, want)
+	}
+}
+
+func TestTinyTerminal(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+
+	now := time.Now()
+	for _, pkg := range []string{"example.com/a", "example.com/b", "example.com/c"} {
+		for _, te := range []parser.TestEvent{
+			{Action: "start"},
+			{Action: "run", Test: "TestSomethingWithALongName"},
+			{Action: "output", Test: "TestSomethingWithALongName", Output: "    still working on it\n"},
+		} {
+			te.Time, te.Package = now, pkg
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+	}
+
+	for _, size := range []tea.WindowSizeMsg{{Width: 0, Height: 0}, {Width: 1, Height: 1}, {Width: 12, Height: 3}, {Width: 39, Height: 24}, {Width: 80, Height: 4}} {
+		m.Update(size)
+		output := viewLatest(m)
+		lines := strings.Split(output, "\n")
+		if len(lines) > max(size.Height, 1) {
+			t.Errorf("%dx%d: expected at most %d lines, got:\n%s", size.Width, size.Height, max(size.Height, 1), output)
+		}
+		for _, line := range lines {
+			if w := ansi.StringWidth(line); w > size.Width {
+				t.Errorf("%dx%d: line %q is %d columns wide", size.Width, size.Height, line, w)
+			}
+		}
+	}
+
+	// The counts-only layout is used below the minimum width.
+	m.Update(tea.WindowSizeMsg{Width: 39, Height: 24})
+	if output := viewLatest(m); !strings.Contains(output, "▶3 ✓0 ✗0 ∅0") {
+		t.Errorf("Expected the compact layout, got:\n%s", output)
+	}
+
+	// With room for the full layout, it's used.
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if output := viewLatest(m); !strings.Contains(output, "TestSomethingWithALongName") {
+		t.Errorf("Expected the full layout, got:\n%s", output)
+	}
+}
+
+func TestHelpAndHints(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 10
+
+	now := time.Now()
+	for _, te := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestFoo"},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+	}
+
+	if output := viewLatest(m); strings.Contains(output, "?: help") {
+		t.Errorf("Expected no hints by default, got:\n%s", output)
+	}
+
+	m.ShowHints = true
+	output := viewLatest(m)
+	lines := strings.Split(output, "\n")
+	if last := ansi.Strip(lines[len(lines)-1]); last != "c: copy rerun  ?: help  q: quit" {
+		t.Errorf("Expected the hint bar last, got:\n%s", output)
+	}
+
+	m.Update(tea.KeyPressMsg{Code: '?', Text: "?"})
+	output = viewLatest(m)
+	if !strings.Contains(output, "KEYS") || !strings.Contains(output, "Interrupt the run and quit") || strings.Contains(output, "TestFoo") {
+		t.Errorf("Expected the help panel in place of the tests, got:\n%s", output)
+	}
+	if n := len(strings.Split(output, "\n")); n > m.TerminalHeight {
+		t.Errorf("Expected the help to fit %d lines, got %d:\n%s", m.TerminalHeight, n, output)
+	}
+
+	// esc hides the help rather than quitting.
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEscape}); cmd != nil || m.quitting {
+		t.Error("Expected esc to hide the help without quitting")
+	}
+	if output := viewLatest(m); strings.Contains(output, "KEYS") || !strings.Contains(output, "TestFoo") {
+		t.Errorf("Expected the tests after esc, got:\n%s", output)
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEscape}); cmd == nil {
+		t.Error("Expected esc to quit without the help")
+	}
+}
+
+func TestDurationStyle(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+
+	now := time.Now()
+	for _, evt := range []parser.TestEvent{
+		{Time: now, Action: "start", Package: "example.com/pkg"},
+		{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestA"},
+		{Time: now.Add(90500 * time.Millisecond), Action: "pass", Package: "example.com/pkg", Test: "TestA", Elapsed: 90.5},
+		{Time: now.Add(90500 * time.Millisecond), Action: "pass", Package: "example.com/pkg", Elapsed: 90.5},
+	} {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: evt})
+	}
+
+	if output := ansi.Strip(viewLatest(m)); !strings.Contains(output, "1.5m") {
+		t.Errorf("Expected compact durations by default, got:\n%s", output)
+	}
+	m.SummaryOptions = format.NewSummaryOptions(format.WithDurationStyle(format.DurationStyleClock))
+	if output := ansi.Strip(viewLatest(m)); !strings.Contains(output, "00:01:30.500") {
+		t.Errorf("Expected clock durations, got:\n%s", output)
+	}
+}
+
+func TestSlowStyle(t *testing.T) {
+	m := NewModel(false, 1.0, results.NewCollector())
+	tr := results.NewTestResult("example.com/pkg", "TestA")
+	tr.Latest().Status = results.StatusPassed
+	tr.Latest().Elapsed = time.Minute
+
+	if style := m.testStyle(tr); style != &m.slowStyle {
+		t.Error("Expected a test over the default threshold to be styled slow")
+	}
+	m.SummaryOptions = format.NewSummaryOptions(format.WithSlowThreshold(0))
+	if style := m.testStyle(tr); style != nil {
+		t.Error("Expected a zero threshold to disable the slow style")
+	}
+}
+
+func TestExpandRunKey(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	var printed []int
+	m.ReportRun = func(run *results.Run) string {
+		printed = append(printed, run.ID)
+		return "report"
+	}
+
+	now := time.Now()
+	pushRun := func(finish bool) {
+		for _, te := range []parser.TestEvent{
+			{Time: now, Action: "start", Package: "example.com/pkg"},
+			{Time: now, Action: "run", Package: "example.com/pkg", Test: "TestFoo"},
+			{Time: now, Action: "pass", Package: "example.com/pkg", Test: "TestFoo"},
+		} {
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: te})
+		}
+		if finish {
+			// A watcher's line between runs ends the run.
+			collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "pass", Package: "example.com/pkg"}})
+			collector.Push(engine.Event{Type: engine.EventRawLine, RawLine: []byte("change detected, rerunning")})
+		}
+	}
+	press := func() tea.Cmd {
+		_, cmd := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+		return cmd
+	}
+
+	pushRun(false)
+	if press() != nil {
+		t.Error("Expected no report while the only run is running")
+	}
+	pushRun(true)
+	pushRun(true)
+	pushRun(false)
+
+	// The runs before the running one, most recent first, then around again.
+	for range 3 {
+		if press() == nil {
+			t.Fatal("Expected a command printing a report")
+		}
+	}
+	if !slices.Equal(printed, []int{2, 1, 2}) {
+		t.Errorf("Printed the reports of runs %v, want [2 1 2]", printed)
+	}
+
+	m.ReportRun = nil
+	if press() != nil {
+		t.Error("Expected no report without ReportRun")
+	}
+}
+
+func TestNonTestOutputColors(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+
+	run := results.NewRun(1)
+	run.Status = results.StatusRunning
+	run.NonTestOutput = []string{
+		"go: downloading example.com/dep v1.0.0",
+		"go: warning: ignoring symlink /src/link",
+		"./foo.go:12:3: undefined: bar",
+	}
+	state := collector.State()
+	state.Runs = append(state.Runs, run)
+	state.CurrentRun = run
+
+	output := m.String()
+	for _, want := range []string{
+		m.darkStyle.Render(run.NonTestOutput[0]),
+		m.skipStyle.Render(run.NonTestOutput[1]),
+		m.failStyle.Render(run.NonTestOutput[2]),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output doesn't contain %q:\n%q", want, output)
+		}
+	}
+}
+
+func TestAnnotationLines(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(true, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+
+	start := time.Now()
+	run := results.NewRun(1)
+	run.Status = results.StatusRunning
+	run.FirstEventTime = start
+	for i, text := range []string{"first", "second", "third", "fourth"} {
+		run.Annotations = append(run.Annotations, engine.Annotation{Time: start.Add(time.Duration(i) * time.Second), Text: text})
+	}
+	run.Annotations[3].Package = "example.com/pkg"
+	state := collector.State()
+	state.Runs = append(state.Runs, run)
+	state.CurrentRun = run
+
+	output := ansi.Strip(m.String())
+	if strings.Contains(output, "first") {
+		t.Errorf("Expected only the last %d annotations, got:\n%s", maxAnnotationLines, output)
+	}
+	for _, want := range []string{"◆ 1.0s second", "◆ 2.0s third", "◆ 3.0s example.com/pkg: fourth"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestFailuresPane(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(true, 1.0, collector)
+	m.TerminalWidth = 60
+	m.TerminalHeight = 30
+	m.FailuresPane = 3
+
+	now := time.Now()
+	push := func(action, test string, elapsed float64) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now.Add(time.Duration(elapsed * float64(time.Second))), Action: action, Package: "example.com/pkg", Test: test, Elapsed: elapsed,
+		}})
+	}
+	push("start", "", 0)
+	for _, name := range []string{"TestA", "TestB", "TestB/sub", "TestC", "TestD"} {
+		push("run", name, 0)
+	}
+	output := ansi.Strip(m.String())
+	if strings.Contains(output, "FAILED") {
+		t.Fatalf("Expected no failures pane before a test fails, got:\n%s", output)
+	}
+
+	push("fail", "TestA", 1)
+	push("fail", "TestB/sub", 2)
+	push("fail", "TestB", 2)
+	output = ansi.Strip(m.String())
+	want := "FAILED (2) " + strings.Repeat("─", 49) + "\n" +
+		"✗ TestB/sub  example.com/pkg\n" +
+		"✗ TestA  example.com/pkg"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected failures pane:\n%s\ngot:\n%s", want, output)
+	}
+
+	push("fail", "TestC", 3)
+	push("fail", "TestD", 4)
+	output = ansi.Strip(m.String())
+	want = "FAILED (4) " + strings.Repeat("─", 49) + "\n" +
+		"✗ TestD  example.com/pkg\n" +
+		"✗ TestC  example.com/pkg\n" +
+		"… and 2 more"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected failures pane:\n%s\ngot:\n%s", want, output)
+	}
+}
+
+func TestFixedWidthTruncates(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(true, 1.0, collector)
+	m.Update(tea.WindowSizeMsg{Width: 40, Height: 30})
+
+	now := time.Now()
+	pkg := "github.com/example/averyveryverylongpackagename/subpackage"
+	push := func(action, test, output string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now, Action: action, Package: pkg, Test: test, Output: output,
+		}})
+	}
+	push("start", "", "")
+	push("run", "TestWithAVeryLongNameThatDoesNotFitInForty", "")
+	push("output", "TestWithAVeryLongNameThatDoesNotFitInForty", "    the quick brown fox jumps over the lazy dog\n")
+
+	output := ansi.Strip(m.String())
+	if !strings.Contains(output, "…") {
+		t.Errorf("Expected long lines truncated with an ellipsis, got:\n%s", output)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if w := ansi.StringWidth(line); w > 40 {
+			t.Errorf("Expected lines at most 40 columns wide, got %d: %q", w, line)
+		}
+	}
+}
+
+func TestPinFailures(t *testing.T) {
+	now := time.Now()
+	clock := engine.NewManualClock(now)
+	collector := results.NewCollector()
+	collector.SetClock(clock)
+	m := NewModel(true, 1.0, collector)
+	m.Clock = clock
+	m.TerminalWidth = 60
+	m.TerminalHeight = 30
+	m.PinFailures = 5 * time.Second
+
+	push := func(action, test, output string, elapsed float64) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now.Add(time.Duration(elapsed * float64(time.Second))), Action: action, Package: "example.com/pkg", Test: test, Output: output, Elapsed: elapsed,
+		}})
+	}
+	push("start", "", "", 0)
+	for _, name := range []string{"TestA", "TestB", "TestC", "TestRunning"} {
+		push("run", name, "", 0)
+	}
+	for i := range 12 {
+		push("output", "TestA", fmt.Sprintf("    a_test.go:%d: line %d\n", i, i), 0)
+	}
+	push("fail", "TestA", "", 1)
+	output := ansi.Strip(m.String())
+	if !strings.Contains(output, "✗ TestA  example.com/pkg ─") || !strings.Contains(output, "line 11") || strings.Contains(output, "line 1\n") {
+		t.Fatalf("Expected TestA pinned with its last %d lines, got:\n%s", pinnedOutputLines, output)
+	}
+
+	// Tests failing while TestA is pinned wait, and only the most recent
+	// is pinned next.
+	push("fail", "TestB", "", 2)
+	push("fail", "TestC", "", 3)
+	clock.Advance(time.Second)
+	if output := ansi.Strip(m.String()); !strings.Contains(output, "✗ TestA  example.com/pkg ─") {
+		t.Errorf("Expected TestA still pinned, got:\n%s", output)
+	}
+	clock.Advance(5 * time.Second)
+	output = ansi.Strip(m.String())
+	if !strings.Contains(output, "✗ TestC  example.com/pkg ─") || strings.Contains(output, "line 11") {
+		t.Errorf("Expected TestC pinned, got:\n%s", output)
+	}
+
+	clock.Advance(5 * time.Second)
+	if output := ansi.Strip(m.String()); strings.Contains(output, "example.com/pkg ─") {
+		t.Errorf("Expected no pinned failure, got:\n%s", output)
+	}
+}
+
+func TestStalledBadge(t *testing.T) {
+	now := time.Now()
+	clock := engine.NewManualClock(now)
+	collector := results.NewCollector()
+	collector.SetClock(clock)
+	m := NewModel(false, 1.0, collector)
+	m.Clock = clock
+	m.TerminalWidth = 80
+	m.TerminalHeight = 20
+	m.SummaryOptions.StallThreshold = 2 * time.Minute
+
+	push := func(action, test, output string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: clock.Now(), Action: action, Package: "example.com/pkg", Test: test, Output: output,
+		}})
+	}
+	push("start", "", "")
+	push("run", "TestStuck", "")
+	push("run", "TestBusy", "")
+	clock.Advance(time.Minute)
+	if output := ansi.Strip(m.String()); strings.Contains(output, "stalled") {
+		t.Errorf("Expected no stalled tests yet, got:\n%s", output)
+	}
+
+	clock.Advance(2 * time.Minute)
+	push("output", "TestBusy", "    working\n")
+	output := ansi.Strip(m.String())
+	if !strings.Contains(output, "TestStuck stalled 3") {
+		t.Errorf("Expected TestStuck stalled, got:\n%s", output)
+	}
+	if strings.Contains(output, "TestBusy stalled") {
+		t.Errorf("Expected TestBusy not stalled, got:\n%s", output)
+	}
+}
+
+func TestHideSubtests(t *testing.T) {
+	now := time.Now()
+	clock := engine.NewManualClock(now)
+	collector := results.NewCollector()
+	collector.SetClock(clock)
+	m := NewModel(false, 1.0, collector)
+	m.Clock = clock
+	m.TerminalWidth = 100
+	m.TerminalHeight = 30
+	m.HideSubtests = true
+
+	push := func(action, test, output string) {
+		clock.Advance(time.Millisecond)
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: clock.Now(), Action: action, Package: "example.com/pkg", Test: test, Output: output,
+		}})
+	}
+	push("start", "", "")
+	push("run", "TestTable", "")
+	for i := range 5 {
+		name := fmt.Sprintf("TestTable/case_%d", i)
+		push("run", name, "")
+		switch i {
+		case 0, 1:
+			push("pass", name, "")
+		case 2:
+			push("fail", name, "")
+		default:
+			push("output", name, fmt.Sprintf("    checking case %d\n", i))
+		}
+	}
+
+	output := ansi.Strip(m.String())
+	if strings.Contains(output, "case_") {
+		t.Errorf("Expected no subtest lines, got:\n%s", output)
+	}
+	if !strings.Contains(output, "TestTable 5 subtests: ✓ 2 ✗ 1 ▶ 2 checking case 4") {
+		t.Errorf("Expected TestTable with its subtests' counts and latest line, got:\n%s", output)
+	}
+
+	m.HideSubtests = false
+	if output := ansi.Strip(m.String()); !strings.Contains(output, "case_4") {
+		t.Errorf("Expected subtest lines without HideSubtests, got:\n%s", output)
+	}
+}
+
+func TestFocusActive(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(true, 1.0, collector)
+	m.TerminalWidth = 60
+	m.TerminalHeight = 30
+	m.FocusActive = true
+
+	now := time.Now()
+	push := func(action, pkg, test string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now, Action: action, Package: pkg, Test: test,
+		}})
+	}
+	for _, pkg := range []string{"example.com/a", "example.com/b", "example.com/c", "example.com/d", "example.com/running"} {
+		push("start", pkg, "")
+		push("run", pkg, "TestX")
+	}
+	output := ansi.Strip(m.String())
+	if strings.Contains(output, "passed") {
+		t.Fatalf("Expected no folded packages while all run, got:\n%s", output)
+	}
+
+	for _, pkg := range []string{"example.com/a", "example.com/b"} {
+		push("pass", pkg, "TestX")
+		push("pass", pkg, "")
+	}
+	push("fail", "example.com/c", "TestX")
+	push("fail", "example.com/c", "")
+	push("skip", "example.com/d", "TestX")
+	push("skip", "example.com/d", "")
+	output = ansi.Strip(m.String())
+	if !strings.Contains(output, "✓ 2 packages passed, 1 with no tests\n") {
+		t.Errorf("Expected a line for the finished packages, got:\n%s", output)
+	}
+	for _, pkg := range []string{"example.com/a", "example.com/b", "example.com/d"} {
+		if strings.Contains(output, pkg) {
+			t.Errorf("Expected %s to be folded, got:\n%s", pkg, output)
+		}
+	}
+	for _, pkg := range []string{"example.com/c", "example.com/running"} {
+		if !strings.Contains(output, pkg) {
+			t.Errorf("Expected a row for %s, got:\n%s", pkg, output)
+		}
+	}
+}
+
+func TestASCIISymbols(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(true, 1.0, collector)
+	m.TerminalWidth = 80
+	m.TerminalHeight = 30
+	m.SetSymbols(format.SymbolsASCII)
+
+	now := time.Now()
+	push := func(action, pkg, test string) {
+		collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{
+			Time: now, Action: action, Package: pkg, Test: test,
+		}})
+	}
+	push("start", "example.com/a", "")
+	push("run", "example.com/a", "TestPass")
+	push("pass", "example.com/a", "TestPass")
+	push("run", "example.com/a", "TestFail")
+	push("fail", "example.com/a", "TestFail")
+	push("run", "example.com/a", "TestRunning")
+
+	output := ansi.Strip(m.String())
+	for _, want := range []string{"+1", "x1", "-0", ">1"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+	for _, r := range output {
+		if r > unicode.MaxASCII {
+			t.Fatalf("Expected only ASCII, got %q in:\n%s", r, output)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// pinnedOutputLines is how many of a pinned failure's last output lines
+// are shown.
+const pinnedOutputLines = 10
+
+// pinnedLines returns the lines of the pinned failure: a test which just
+// failed, named in a header and followed by its last output lines, shown
+// for PinFailures before it collapses back into its package's counts, so
+// the failure can be read before running tests push it off screen.
+//
+// Pins are rate limited: one is shown for the whole of PinFailures, and of
+// the tests which fail meanwhile, only the most recent is pinned next. The
+// others are still listed in the failures pane, and the summary.
+func (m *Model) pinnedLines(run *results.Run) []string {
+	if m.PinFailures <= 0 {
+		return nil
+	}
+	now := m.Clock.Now()
+	if m.pinned == nil || !now.Before(m.pinnedUntil) {
+		m.pinned = nil
+		if m.pinSeen == nil {
+			m.pinSeen = make(map[*results.TestResult]bool)
+		}
+		for _, tr := range failedTests(run) { // Most recent first
+			if m.pinSeen[tr] {
+				continue
+			}
+			if m.pinned == nil {
+				m.pinned = tr
+				m.pinnedUntil = now.Add(m.PinFailures)
+			}
+			m.pinSeen[tr] = true
+		}
+	}
+	if m.pinned == nil {
+		return nil
+	}
+
+	tr := m.pinned
+	header := fmt.Sprintf("%s %s  %s ", m.SummaryOptions.Symbols.Fail(), tr.Name, m.SummaryOptions.PackageNames.Shorten(tr.Package))
+	lines := []string{m.failStyle.Render(truncateLine(header+strings.Repeat("─", max(0, m.TerminalWidth-len([]rune(header)))), m.TerminalWidth))}
+	output := tr.Output()
+	for len(output) > 0 && strings.TrimSpace(output[len(output)-1]) == "" {
+		output = output[:len(output)-1]
+	}
+	for _, line := range output[max(0, len(output)-pinnedOutputLines):] {
+		lines = append(lines, m.dimStyle.Render(truncateLine(expandTabs(line, 8), m.TerminalWidth)))
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// MaxFrameRate caps how many times per second incoming events repaint the
+// live display.
+const MaxFrameRate = 30
+
+const (
+	minRepaintBatch = 50
+	maxRepaintBatch = 4096
+)
+
+// Repainter coalesces a stream of events into RepaintMsgs, so a burst of
+// events (e.g. replaying a recording with -rate 0) costs at most one render
+// per frame instead of one per batch of events.
+//
+// Events are counted in batches, and the clock is only consulted at the end
+// of a batch. A batch ending before the frame budget has passed doubles the
+// batch size, so the check gets cheaper under load; a batch ending well
+// after it halves the size again. Events left over at the end of a burst are
+// picked up by the model's refresh timer.
+type Repainter struct {
+	send     func(tea.Msg)
+	interval time.Duration
+	batch    int
+	pending  int
+	last     time.Time
+}
+
+// NewRepainter returns a Repainter which sends RepaintMsgs with send,
+// usually a tea.Program's Send method.
+func NewRepainter(send func(tea.Msg)) *Repainter {
+	return &Repainter{
+		send:     send,
+		interval: time.Second / MaxFrameRate,
+		batch:    minRepaintBatch,
+	}
+}
+
+// Event records an event which arrived at now, sending a RepaintMsg if the
+// batch is complete and the frame budget allows.
+func (r *Repainter) Event(now time.Time) {
+	r.pending++
+	if r.pending < r.batch {
+		return
+	}
+
+	since := now.Sub(r.last)
+	if since < r.interval {
+		r.batch = min(r.batch*2, maxRepaintBatch)
+		return
+	}
+	if since > 2*r.interval {
+		r.batch = max(r.batch/2, minRepaintBatch)
+	}
+	r.pending = 0
+	r.last = now
+	r.send(RepaintMsg{})
+}
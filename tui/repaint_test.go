@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/results"
+)
+
+func TestRepainterCoalescesBursts(t *testing.T) {
+	var repaints int
+	r := NewRepainter(func(tea.Msg) { repaints++ })
+
+	// A burst of 10k events within a single frame repaints once, and grows
+	// the batch size.
+	start := time.Now()
+	for range 10000 {
+		r.Event(start)
+	}
+	if repaints != 1 {
+		t.Errorf("Expected 1 repaint for a burst within one frame, got %d", repaints)
+	}
+	if r.batch <= minRepaintBatch {
+		t.Errorf("Expected batch size to grow under load, got %d", r.batch)
+	}
+
+	// Once the load eases, the batch size shrinks back.
+	now := start
+	for range 10000 {
+		now = now.Add(r.interval)
+		r.Event(now)
+	}
+	if r.batch != minRepaintBatch {
+		t.Errorf("Expected batch size to shrink to %d, got %d", minRepaintBatch, r.batch)
+	}
+}
+
+// BenchmarkReplayBurst feeds a recorded test run through the collector as
+// fast as possible, rendering the model on every repaint, as when replaying
+// with -rate 0.
+func BenchmarkReplayBurst(b *testing.B) {
+	data, err := os.ReadFile("../local_sample_files/multiple.out")
+	if err != nil {
+		b.Skip(err)
+	}
+	// Replay the recording several times over for a burst of ~10k events.
+	data = bytes.Repeat(data, 10)
+
+	bench := func(b *testing.B, newSend func(m *Model) func()) {
+		for b.Loop() {
+			collector := results.NewCollector()
+			m := NewModel(false, 0, collector)
+			m.TerminalWidth = 120
+			m.TerminalHeight = 40
+			event := newSend(m)
+			for evt := range engine.NewEngine().Stream(bytes.NewReader(data)) {
+				collector.Push(evt)
+				event()
+			}
+		}
+	}
+
+	b.Run("every-50-events", func(b *testing.B) {
+		bench(b, func(m *Model) func() {
+			var n int
+			return func() {
+				if n++; n%50 == 0 {
+					_ = m.View()
+				}
+			}
+		})
+	})
+	b.Run("coalesced", func(b *testing.B) {
+		bench(b, func(m *Model) func() {
+			r := NewRepainter(func(tea.Msg) { _ = m.View() })
+			return func() { r.Event(time.Now()) }
+		})
+	})
+}
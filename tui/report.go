@@ -0,0 +1,284 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+// ReportAction is what the user chose to do when closing a ReportModel.
+type ReportAction int
+
+const (
+	ReportQuit        ReportAction = iota // Close the report
+	ReportRerunAll                        // Run the same tests again
+	ReportRerunFailed                     // Run the tests which failed again
+)
+
+// ReportModel is a full-screen, scrollable view of a finished run's summary.
+// Pressing f cycles between the all / failures / slow views without
+// re-running; q, esc or enter closes it. SelectedView reports the view in
+// use when the model quit, so the caller can print the same report to the
+// terminal.
+//
+// When reruns are allowed (see AllowRerun), r and F close the report asking
+// to rerun all tests or the failed ones, as reported by Action, so tang can
+// drive a test loop.
+//
+// Tests whose output the live display elided can be offered with
+// SetReplayable: tab and shift+tab select one, and o queues its full output
+// for Replays, to be printed to the scrollback once the report closes (the
+// alternate screen drops output printed while it's shown).
+//
+// / searches the output of every test of the run, listing the tests whose
+// output matches; enter shows the selected test's output at its first
+// matching line, and n and N move between its matching lines. With
+// SetHistory, the test's results in earlier runs are shown above it.
+type ReportModel struct {
+	summary *format.Summary
+	options format.SummaryOptions
+	noColor bool
+
+	canRerun       bool
+	canRerunFailed bool
+	action         ReportAction
+
+	TerminalWidth  int
+	TerminalHeight int
+
+	view   format.SummaryView
+	offset int // First visible line of the report
+	lines  []string
+
+	replayable []*results.TestResult
+	selected   int
+	queued     map[*results.TestResult]bool
+	replays    []string
+
+	search   reportSearch
+	pastRuns []*format.SummaryJSON // Earlier runs, oldest first; see SetHistory
+
+	headerStyle lipgloss.Style
+}
+
+// NewReportModel creates a report over the given summary, formatted with
+// opts in the all view.
+func NewReportModel(summary *format.Summary, opts format.SummaryOptions, noColor bool) *ReportModel {
+	m := &ReportModel{
+		summary:        summary,
+		options:        opts,
+		noColor:        noColor,
+		TerminalWidth:  80,
+		TerminalHeight: 24,
+		headerStyle:    lipgloss.NewStyle().Reverse(true),
+	}
+	if noColor {
+		m.headerStyle = lipgloss.NewStyle()
+	}
+	m.format()
+	return m
+}
+
+// AllowRerun enables the rerun keys: r, and F if failed is set, i.e. the
+// run has failed tests to rerun.
+func (m *ReportModel) AllowRerun(failed bool) {
+	m.canRerun = true
+	m.canRerunFailed = failed
+}
+
+// Action returns what the user chose to do when closing the report.
+func (m *ReportModel) Action() ReportAction {
+	return m.action
+}
+
+// SetReplayable offers the output of tests for replay, usually those the
+// live display elided output of.
+func (m *ReportModel) SetReplayable(tests []*results.TestResult) {
+	m.replayable = tests
+	m.selected = 0
+	m.offset = min(m.offset, m.maxOffset())
+}
+
+// SetHistory sets the earlier runs, oldest first, e.g. from a -history
+// file, whose results of a test are shown with its output.
+func (m *ReportModel) SetHistory(runs []*format.SummaryJSON) {
+	m.pastRuns = runs
+}
+
+// Replays returns the output queued with o, in the order it was queued,
+// each preceded by a line naming its test.
+func (m *ReportModel) Replays() []string {
+	return m.replays
+}
+
+// replay queues the output of the selected test, once.
+func (m *ReportModel) replay() {
+	if len(m.replayable) == 0 {
+		return
+	}
+	test := m.replayable[m.selected]
+	if m.queued[test] {
+		return
+	}
+	if m.queued == nil {
+		m.queued = make(map[*results.TestResult]bool)
+	}
+	m.queued[test] = true
+	m.replays = append(m.replays, fmt.Sprintf("=== OUTPUT %s %s", test.Package, test.Name))
+	m.replays = append(m.replays, test.Output()...)
+}
+
+// SelectedView returns the currently selected summary view.
+func (m *ReportModel) SelectedView() format.SummaryView {
+	return m.view
+}
+
+// format re-renders the report lines for the current view and width.
+func (m *ReportModel) format() {
+	summary, opts := m.view.Apply(m.summary, m.options)
+	text := format.NewSummaryFormatter(m.TerminalWidth, m.noColor, opts).Format(summary)
+	m.lines = strings.Split(strings.TrimRight(expandTabs(text, 8), "\n"), "\n")
+	m.offset = min(m.offset, m.maxOffset())
+}
+
+// headerLines is the number of lines of the header: the view and keys,
+// the history of the test whose output is shown, if any, and the test
+// selected for replay, if any.
+func (m *ReportModel) headerLines() int {
+	n := 1
+	if m.historyLine() != "" {
+		n++
+	}
+	if len(m.replayable) > 0 {
+		n++
+	}
+	return n
+}
+
+// pageSize is the number of report lines visible below the header.
+func (m *ReportModel) pageSize() int {
+	return max(m.TerminalHeight-m.headerLines(), 1)
+}
+
+func (m *ReportModel) maxOffset() int {
+	return max(len(m.lines)-m.pageSize(), 0)
+}
+
+func (m *ReportModel) scroll(n int) {
+	m.offset = max(0, min(m.offset+n, m.maxOffset()))
+}
+
+// Init implements tea.Model.
+func (m *ReportModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m *ReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.TerminalWidth = msg.Width
+		m.TerminalHeight = msg.Height
+		m.format()
+		if m.search.matches != nil {
+			m.layoutSearch()
+		}
+
+	case tea.KeyPressMsg:
+		if m.search.active() {
+			return m.updateSearch(msg)
+		}
+		switch msg.String() {
+		case "q", "esc", "enter", "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			if m.canRerun {
+				m.action = ReportRerunAll
+				return m, tea.Quit
+			}
+		case "F":
+			if m.canRerunFailed {
+				m.action = ReportRerunFailed
+				return m, tea.Quit
+			}
+		case "tab":
+			if len(m.replayable) > 0 {
+				m.selected = (m.selected + 1) % len(m.replayable)
+			}
+		case "shift+tab":
+			if len(m.replayable) > 0 {
+				m.selected = (m.selected + len(m.replayable) - 1) % len(m.replayable)
+			}
+		case "o":
+			m.replay()
+		case "/":
+			m.startSearch()
+		case "f":
+			m.view = m.view.Next()
+			m.offset = 0
+			m.format()
+		case "j", "down":
+			m.scroll(1)
+		case "k", "up":
+			m.scroll(-1)
+		case "space", "pgdown":
+			m.scroll(m.pageSize())
+		case "b", "pgup":
+			m.scroll(-m.pageSize())
+		case "g", "home":
+			m.offset = 0
+		case "G", "end":
+			m.offset = m.maxOffset()
+		}
+	}
+	return m, nil
+}
+
+// render produces the header line and the visible slice of the report.
+func (m *ReportModel) render() string {
+	var b strings.Builder
+	keys := "f: cycle view, ↑/↓: scroll"
+	if m.canRerun {
+		keys += ", r: rerun all"
+	}
+	if m.canRerunFailed {
+		keys += ", F: rerun failures"
+	}
+	header := fmt.Sprintf(" view: %s  (%s, /: search, q: quit)", m.view, keys)
+	if m.search.active() {
+		header = m.searchHeader()
+	}
+	b.WriteString(m.headerStyle.Render(truncateLine(header, m.TerminalWidth)))
+	if line := m.historyLine(); line != "" {
+		b.WriteString("\n")
+		b.WriteString(truncateLine(line, m.TerminalWidth))
+	}
+	if len(m.replayable) > 0 {
+		test := m.replayable[m.selected]
+		status := "o: print on exit"
+		if m.queued[test] {
+			status = "printed on exit"
+		}
+		line := fmt.Sprintf(" elided output %d/%d: %s %s  (tab: next test, %s)", m.selected+1, len(m.replayable), test.Name, m.options.PackageNames.Shorten(test.Package), status)
+		b.WriteString("\n")
+		b.WriteString(m.headerStyle.Render(truncateLine(line, m.TerminalWidth)))
+	}
+	end := min(m.offset+m.pageSize(), len(m.lines))
+	for _, line := range m.lines[m.offset:end] {
+		b.WriteString("\n")
+		b.WriteString(truncateLine(line, m.TerminalWidth))
+	}
+	return b.String()
+}
+
+// View renders the report on the alternate screen, leaving the scrollback
+// untouched.
+func (m *ReportModel) View() tea.View {
+	v := tea.NewView(m.render())
+	v.AltScreen = true
+	return v
+}
@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+)
+
+func TestReportModelCycleView(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed}
+	for name, status := range map[string]results.Status{"TestFail": results.StatusFailed, "TestSlow": results.StatusPassed} {
+		tr := results.NewTestResult("pkg1", name)
+		tr.Latest().Status = status
+		if name == "TestSlow" {
+			tr.Latest().Elapsed = 20 * time.Second
+		}
+		run.TestResults["pkg1/"+name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, name)
+	}
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+
+	m := NewReportModel(format.ComputeSummary(run), format.SummaryOptions{IncludeSlow: true}, true)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+
+	if out := m.render(); !strings.Contains(out, "view: all") || !strings.Contains(out, "TestSlow") {
+		t.Errorf("Expected the all view with slow tests, got:\n%s", out)
+	}
+
+	m.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+	if m.SelectedView() != format.SummaryViewFailures {
+		t.Fatalf("Expected failures view after f, got %v", m.SelectedView())
+	}
+	if out := m.render(); !strings.Contains(out, "TestFail") || strings.Contains(out, "TestSlow") {
+		t.Errorf("Expected only failures, got:\n%s", out)
+	}
+
+	m.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+	if out := m.render(); !strings.Contains(out, "view: slow") || strings.Contains(out, "TestFail") {
+		t.Errorf("Expected only slow tests, got:\n%s", out)
+	}
+
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'q', Text: "q"}); cmd == nil {
+		t.Error("Expected q to quit the report")
+	}
+}
+
+func TestReportModelRerun(t *testing.T) {
+	run := results.NewRun(1)
+	summary := format.ComputeSummary(run)
+
+	// Without reruns, r and F do nothing.
+	m := NewReportModel(summary, format.SummaryOptions{}, true)
+	for _, key := range []rune{'r', 'F'} {
+		if _, cmd := m.Update(tea.KeyPressMsg{Code: key, Text: string(key)}); cmd != nil {
+			t.Errorf("Expected %c to do nothing without reruns", key)
+		}
+	}
+	if strings.Contains(m.render(), "rerun") {
+		t.Errorf("Expected no rerun keys in the header, got:\n%s", m.render())
+	}
+
+	// With nothing failed, only r is offered.
+	m = NewReportModel(summary, format.SummaryOptions{}, true)
+	m.AllowRerun(false)
+	if out := m.render(); !strings.Contains(out, "r: rerun all") || strings.Contains(out, "F: rerun failures") {
+		t.Errorf("Expected only r: rerun all in the header, got:\n%s", out)
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'F', Text: "F"}); cmd != nil {
+		t.Error("Expected F to do nothing with no failures")
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'r', Text: "r"}); cmd == nil || m.Action() != ReportRerunAll {
+		t.Errorf("Expected r to quit asking to rerun all, got action %v", m.Action())
+	}
+
+	m = NewReportModel(summary, format.SummaryOptions{}, true)
+	m.AllowRerun(true)
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'F', Text: "F"}); cmd == nil || m.Action() != ReportRerunFailed {
+		t.Errorf("Expected F to quit asking to rerun failures, got action %v", m.Action())
+	}
+
+	m = NewReportModel(summary, format.SummaryOptions{}, true)
+	m.AllowRerun(true)
+	m.Update(tea.KeyPressMsg{Code: 'q', Text: "q"})
+	if m.Action() != ReportQuit {
+		t.Errorf("Expected q to quit, got action %v", m.Action())
+	}
+}
+
+func TestReportModelReplay(t *testing.T) {
+	var tests []*results.TestResult
+	for _, name := range []string{"TestA", "TestB"} {
+		tr := results.NewTestResult("example.com/pkg", name)
+		tr.Latest().Status = results.StatusPassed
+		tr.Latest().Output = []string{"    " + name + " line 1", "    " + name + " line 2"}
+		tests = append(tests, tr)
+	}
+
+	m := NewReportModel(format.ComputeSummary(results.NewRun(1)), format.SummaryOptions{}, true)
+	if strings.Contains(m.render(), "elided output") {
+		t.Errorf("Expected no replay line without replayable tests, got:\n%s", m.render())
+	}
+
+	m.SetReplayable(tests)
+	if out := m.render(); !strings.Contains(out, "elided output 1/2: TestA") {
+		t.Errorf("Expected TestA selected, got:\n%s", out)
+	}
+	m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	if out := m.render(); !strings.Contains(out, "elided output 2/2: TestB") {
+		t.Errorf("Expected TestB selected after tab, got:\n%s", out)
+	}
+
+	// Queuing the same test twice prints its output once.
+	m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+	m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+	if out := m.render(); !strings.Contains(out, "printed on exit") {
+		t.Errorf("Expected TestB marked as queued, got:\n%s", out)
+	}
+	m.Update(tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift})
+	m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+
+	want := []string{
+		"=== OUTPUT example.com/pkg TestB", "    TestB line 1", "    TestB line 2",
+		"=== OUTPUT example.com/pkg TestA", "    TestA line 1", "    TestA line 2",
+	}
+	if got := m.Replays(); !slices.Equal(got, want) {
+		t.Errorf("Replays() = %q, want %q", got, want)
+	}
+}
+
+func TestReportModelTinyTerminal(t *testing.T) {
+	m := NewReportModel(format.ComputeSummary(results.NewRun(1)), format.SummaryOptions{}, true)
+	for _, size := range []tea.WindowSizeMsg{{Width: 0, Height: 0}, {Width: 5, Height: 1}} {
+		m.Update(size)
+		m.Update(tea.KeyPressMsg{Code: tea.KeyPgDown})
+		for _, line := range strings.Split(m.render(), "\n") {
+			if len([]rune(line)) > size.Width {
+				t.Errorf("%dx%d: line %q is too wide", size.Width, size.Height, line)
+			}
+		}
+	}
+}
+
+func TestReportModelSearch(t *testing.T) {
+	run := results.NewRun(1)
+	pkg := &results.PackageResult{Name: "pkg1", Status: results.StatusFailed}
+	for name, output := range map[string][]string{
+		"TestA": {"connecting", "    a_test.go:10: Connection refused", "retrying", "connection refused again"},
+		"TestB": {"all good"},
+		"TestC": {"    c_test.go:5: CONNECTION REFUSED"},
+	} {
+		tr := results.NewTestResult("pkg1", name)
+		tr.Latest().Status = results.StatusPassed
+		tr.Latest().Output = output
+		run.TestResults["pkg1/"+name] = tr
+		pkg.TestOrder = append(pkg.TestOrder, name)
+	}
+	slices.Sort(pkg.TestOrder)
+	run.Packages["pkg1"] = pkg
+	run.PackageOrder = []string{"pkg1"}
+
+	m := NewReportModel(format.ComputeSummary(run), format.SummaryOptions{}, true)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	type key = tea.KeyPressMsg
+	m.Update(key{Code: '/', Text: "/"})
+	for _, r := range "refusedX" {
+		m.Update(key{Code: r, Text: string(r)})
+	}
+	m.Update(key{Code: tea.KeyBackspace})
+	if out := m.render(); !strings.Contains(out, "search output: refused█") {
+		t.Fatalf("Expected the query being typed, got:\n%s", out)
+	}
+
+	m.Update(key{Code: tea.KeyEnter})
+	out := m.render()
+	if !strings.Contains(out, `2 tests with output matching "refused"`) ||
+		!strings.Contains(out, "> passed TestA pkg1 (2) a_test.go:10: Connection refused") ||
+		!strings.Contains(out, "  passed TestC pkg1 (1) c_test.go:5: CONNECTION REFUSED") || strings.Contains(out, "TestB") {
+		t.Fatalf("Expected the matching tests listed, got:\n%s", out)
+	}
+
+	m.Update(key{Code: tea.KeyEnter})
+	if out := m.render(); !strings.Contains(out, "TestA pkg1: match 1/2") || !strings.Contains(out, ">     a_test.go:10: Connection refused") || !strings.Contains(out, "  retrying") {
+		t.Fatalf("Expected TestA's output, got:\n%s", out)
+	}
+	m.Update(key{Code: 'n', Text: "n"})
+	if out := m.render(); !strings.Contains(out, "match 2/2") {
+		t.Errorf("Expected the second match after n, got:\n%s", out)
+	}
+
+	m.Update(key{Code: tea.KeyEscape})
+	m.Update(key{Code: tea.KeyDown})
+	m.Update(key{Code: tea.KeyEnter})
+	if out := m.render(); !strings.Contains(out, "TestC pkg1: match 1/1") {
+		t.Errorf("Expected TestC's output, got:\n%s", out)
+	}
+
+	m.Update(key{Code: tea.KeyEscape})
+	m.Update(key{Code: tea.KeyEscape})
+	if out := m.render(); !strings.Contains(out, "view: all") {
+		t.Errorf("Expected the report after esc, got:\n%s", out)
+	}
+}
+
+func TestReportModelSearchHistory(t *testing.T) {
+	run := results.NewRun(1)
+	tr := results.NewTestResult("pkg1", "TestA")
+	tr.Latest().Status = results.StatusFailed
+	tr.Latest().Output = []string{"    a_test.go:10: boom"}
+	run.TestResults["pkg1/TestA"] = tr
+	run.Packages["pkg1"] = &results.PackageResult{Name: "pkg1", Status: results.StatusFailed, TestOrder: []string{"TestA"}}
+	run.PackageOrder = []string{"pkg1"}
+
+	m := NewReportModel(format.ComputeSummary(run), format.SummaryOptions{}, true)
+	m.SetHistory([]*format.SummaryJSON{
+		{Results: []format.TestResultJSON{{Package: "pkg1", Name: "TestA", Status: "passed", Elapsed: 1}}},
+		{Results: []format.TestResultJSON{{Package: "pkg1", Name: "TestA", Status: "passed", Elapsed: 2}}},
+	})
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	type key = tea.KeyPressMsg
+	m.Update(key{Code: '/', Text: "/"})
+	for _, r := range "boom" {
+		m.Update(key{Code: r, Text: string(r)})
+	}
+	m.Update(key{Code: tea.KeyEnter})
+	if out := m.render(); strings.Contains(out, "last 2 runs") {
+		t.Fatalf("Expected no history in the list of matches, got:\n%s", out)
+	}
+
+	m.Update(key{Code: tea.KeyEnter})
+	lines := strings.Split(m.render(), "\n")
+	if len(lines) < 3 || lines[1] != " last 2 runs: ✓✓  ▅█  new failure" || !strings.Contains(lines[2], "a_test.go:10: boom") {
+		t.Errorf("Expected the test's history under the header, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ansel1/tang/history"
+	"github.com/ansel1/tang/results"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// reportSearch is the state of a ReportModel's search of the run's test
+// output: the query being typed, then the tests matching it, then the
+// output of one of them.
+type reportSearch struct {
+	editing  bool   // Whether the query is being typed
+	query    string // The query, matched case-insensitively
+	matches  []searchMatch
+	selected int          // Index of the selected match
+	open     *searchMatch // The match whose output is shown, or nil
+	hit      int          // Index in open.hits of the current matching line
+	listTop  int          // Offset of the match list, restored when going back to it
+}
+
+// active reports whether the search replaces the report.
+func (s *reportSearch) active() bool {
+	return s.editing || s.matches != nil
+}
+
+// searchMatch is a test whose output matches the query.
+type searchMatch struct {
+	test  *results.TestResult
+	lines []string // The test's output, of every execution
+	hits  []int    // Indexes of the lines matching the query
+}
+
+// searchOutput returns the tests of run whose output contains query, in
+// the order they started. Matching ignores case and ANSI escapes.
+func searchOutput(run *results.Run, query string) []searchMatch {
+	matches := []searchMatch{}
+	if run == nil || query == "" {
+		return matches
+	}
+	query = strings.ToLower(query)
+	for _, pkgName := range run.PackageOrder {
+		pkg := run.Packages[pkgName]
+		if pkg == nil {
+			continue
+		}
+		for _, testName := range pkg.TestOrder {
+			tr := run.TestResults[pkgName+"/"+testName]
+			if tr == nil {
+				continue
+			}
+			match := searchMatch{test: tr, lines: testOutput(tr)}
+			for i, line := range match.lines {
+				if strings.Contains(strings.ToLower(ansi.Strip(line)), query) {
+					match.hits = append(match.hits, i)
+				}
+			}
+			if len(match.hits) > 0 {
+				matches = append(matches, match)
+			}
+		}
+	}
+	return matches
+}
+
+// testOutput returns the output of every execution of tr, each preceded by
+// a line naming it when there are several, e.g. with -count=N.
+func testOutput(tr *results.TestResult) []string {
+	if len(tr.Executions) == 1 {
+		return tr.Executions[0].Output
+	}
+	var lines []string
+	for i, exec := range tr.Executions {
+		lines = append(lines, "=== "+results.ExecutionDisplayName(tr.Name, i+1, len(tr.Executions)))
+		lines = append(lines, exec.Output...)
+	}
+	return lines
+}
+
+// startSearch starts typing a new search query.
+func (m *ReportModel) startSearch() {
+	m.search = reportSearch{editing: true}
+}
+
+// endSearch leaves the search, showing the report again.
+func (m *ReportModel) endSearch() {
+	m.search = reportSearch{}
+	m.offset = 0
+	m.format()
+}
+
+// updateSearch handles a key while the search replaces the report.
+func (m *ReportModel) updateSearch(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	s := &m.search
+	if s.editing {
+		switch msg.String() {
+		case "enter":
+			s.editing = false
+			if s.query == "" {
+				m.endSearch()
+				return m, nil
+			}
+			s.matches = searchOutput(m.summary.Run, s.query)
+			s.selected = 0
+			m.offset = 0
+			m.layoutSearch()
+		case "esc":
+			m.endSearch()
+		case "ctrl+c":
+			return m, tea.Quit
+		case "backspace":
+			if s.query != "" {
+				_, size := utf8.DecodeLastRuneInString(s.query)
+				s.query = s.query[:len(s.query)-size]
+			}
+		default:
+			if msg.Text != "" {
+				s.query += msg.Text
+			}
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "/":
+		m.startSearch()
+		return m, nil
+	case "esc":
+		if s.open == nil {
+			m.endSearch()
+			return m, nil
+		}
+		s.open = nil
+		m.offset = s.listTop
+	case "enter":
+		if s.open == nil && len(s.matches) > 0 {
+			s.open = &s.matches[s.selected]
+			s.listTop = m.offset
+			s.hit = 0
+		}
+	case "j", "down":
+		if s.open == nil {
+			s.selected = min(s.selected+1, max(len(s.matches)-1, 0))
+		} else {
+			m.scroll(1)
+		}
+	case "k", "up":
+		if s.open == nil {
+			s.selected = max(s.selected-1, 0)
+		} else {
+			m.scroll(-1)
+		}
+	case "n":
+		if s.open != nil {
+			s.hit = (s.hit + 1) % len(s.open.hits)
+		}
+	case "N":
+		if s.open != nil {
+			s.hit = (s.hit + len(s.open.hits) - 1) % len(s.open.hits)
+		}
+	case "space", "pgdown":
+		m.scroll(m.pageSize())
+	case "b", "pgup":
+		m.scroll(-m.pageSize())
+	default:
+		return m, nil
+	}
+	m.layoutSearch()
+	switch key := msg.String(); {
+	case s.open == nil:
+		// Keep the selected test on screen.
+		m.offset = max(min(m.offset, s.selected), s.selected-m.pageSize()+1, 0)
+	case key == "enter", key == "n", key == "N":
+		// Show the current matching line, with some context above it.
+		m.offset = max(0, min(s.open.hits[s.hit]-2, m.maxOffset()))
+	}
+	return m, nil
+}
+
+// layoutSearch replaces the report lines with the search's: the list of
+// matching tests, or the output of the open one with its matching lines
+// marked.
+func (m *ReportModel) layoutSearch() {
+	s := &m.search
+	m.lines = nil
+	if s.open != nil {
+		hits := make(map[int]bool, len(s.open.hits))
+		for _, i := range s.open.hits {
+			hits[i] = true
+		}
+		for i, line := range s.open.lines {
+			line = expandTabs(line, 8)
+			switch {
+			case i == s.open.hits[s.hit]:
+				m.lines = append(m.lines, m.headerStyle.Render(">")+" "+line)
+			case hits[i]:
+				m.lines = append(m.lines, "> "+line)
+			default:
+				m.lines = append(m.lines, "  "+line)
+			}
+		}
+		return
+	}
+	if len(s.matches) == 0 {
+		m.lines = append(m.lines, fmt.Sprintf("No test output contains %q.", s.query))
+		return
+	}
+	for i, match := range s.matches {
+		first := strings.TrimSpace(ansi.Strip(match.lines[match.hits[0]]))
+		line := fmt.Sprintf("%s %s %s (%d) %s", match.test.Status(), match.test.Name,
+			m.options.PackageNames.Shorten(match.test.Package), len(match.hits), first)
+		if i == s.selected {
+			line = m.headerStyle.Render(truncateLine("> "+line, m.TerminalWidth))
+		} else {
+			line = "  " + line
+		}
+		m.lines = append(m.lines, line)
+	}
+}
+
+// searchHeader returns the header line shown while searching.
+func (m *ReportModel) searchHeader() string {
+	s := &m.search
+	switch {
+	case s.editing:
+		return fmt.Sprintf(" search output: %s█  (enter: search, esc: cancel)", s.query)
+	case s.open != nil:
+		return fmt.Sprintf(" %s %s: match %d/%d for %q  (n/N: next/previous match, ↑/↓: scroll, esc: back, q: quit)",
+			s.open.test.Name, m.options.PackageNames.Shorten(s.open.test.Package), s.hit+1, len(s.open.hits), s.query)
+	default:
+		return fmt.Sprintf(" %d tests with output matching %q  (↑/↓: select, enter: show output, /: new search, esc: back, q: quit)",
+			len(s.matches), s.query)
+	}
+}
+
+// historyLine returns the line summarizing the earlier results of the test
+// whose output is shown, or "" if there's none or no history.
+func (m *ReportModel) historyLine() string {
+	if m.search.open == nil || len(m.pastRuns) == 0 {
+		return ""
+	}
+	test := m.search.open.test
+	records := history.ForTest(m.pastRuns, test.Package, test.Name)
+	return fmt.Sprintf(" last %d runs: %s", len(records), history.Trend(records, test.Status() == results.StatusFailed))
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ansel1/tang/results"
+)
+
+// hidden reports whether HideSubtests keeps the test named name from
+// having a line of its own: it's a subtest.
+func (m *Model) hidden(name string) bool {
+	return m.HideSubtests && strings.Contains(name, "/")
+}
+
+// subtestStatus returns, for a top-level test whose subtests HideSubtests
+// hides, the counts of its subtests, e.g. "120 subtests: ✓ 117 ✗ 1 ▶ 2",
+// and whichever of it and its running subtests wrote output most
+// recently, whose last line stands in for the lines the subtests would
+// have shown. Otherwise it returns "" and test.
+func (m *Model) subtestStatus(run *results.Run, test *results.TestResult) (counts string, latest *results.TestResult) {
+	if !m.HideSubtests || strings.Contains(test.Name, "/") {
+		return "", test
+	}
+	pkg := run.Packages[test.Package]
+	if pkg == nil {
+		return "", test
+	}
+
+	var total, running, passed, failed, skipped int
+	var busiest *results.TestResult // The running subtest which wrote output last
+	prefix := test.Name + "/"
+	for _, name := range pkg.TestOrder {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		sub := run.TestResults[pkg.Name+"/"+name]
+		if sub == nil {
+			continue
+		}
+		total++
+		switch sub.Status() {
+		case results.StatusRunning:
+			running++
+			if len(sub.Output()) > 0 && (busiest == nil || sub.Latest().WallLastOutputTime.After(busiest.Latest().WallLastOutputTime)) {
+				busiest = sub
+			}
+		case results.StatusPassed:
+			passed++
+		case results.StatusFailed:
+			failed++
+		case results.StatusSkipped:
+			skipped++
+		}
+	}
+	if total == 0 {
+		return "", test
+	}
+	// A subtest's output counts as its parent's activity too (see
+	// results.TestExecution.LastOutputTime), so the parent is only more
+	// recent if it wrote, or resumed, since.
+	latest = test
+	if busiest != nil && !test.Latest().WallLastOutputTime.After(busiest.Latest().WallLastOutputTime) {
+		latest = busiest
+	}
+
+	sym := m.SummaryOptions.Symbols
+	noun := "subtests"
+	if total == 1 {
+		noun = "subtest"
+	}
+	parts := []string{m.darkStyle.Render(fmt.Sprintf("%d %s:", total, noun))}
+	if passed > 0 {
+		parts = append(parts, m.darkStyle.Render(fmt.Sprintf("%s %d", sym.Pass(), passed)))
+	}
+	if failed > 0 {
+		parts = append(parts, m.failStyle.Render(fmt.Sprintf("%s %d", sym.Fail(), failed)))
+	}
+	if skipped > 0 {
+		parts = append(parts, m.skipStyle.Render(fmt.Sprintf("%s %d", sym.Skip(), skipped)))
+	}
+	if running > 0 {
+		parts = append(parts, m.darkStyle.Render(fmt.Sprintf("%s %d", sym.Running(), running)))
+	}
+	return strings.Join(parts, " "), latest
+}
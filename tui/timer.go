@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// RefreshInterval is how often the live display redraws elapsed times and
+// advances the spinner while a run is in progress.
+const RefreshInterval = 100 * time.Millisecond
+
+// TickMsg is used for timer updates to refresh elapsed times
+type TickMsg struct {
+	gen int
+}
+
+// refreshTimer drives redraws of the live display between events. While a
+// run is in progress it ticks every interval, in step with the system
+// clock, so a tick delayed by a busy or suspended process is followed by
+// the next one on schedule rather than a burst. It stops while idle, so an
+// idle display costs no CPU, and is woken by the next event.
+//
+// Each start begins a new generation of ticks; ticks from earlier
+// generations are dropped, so restarting never leaves two tick chains
+// running.
+type refreshTimer struct {
+	interval time.Duration
+	gen      int
+	running  bool
+}
+
+// start begins a new tick chain, abandoning any current one.
+func (t *refreshTimer) start() tea.Cmd {
+	t.gen++
+	t.running = true
+	return t.schedule()
+}
+
+// wake starts the timer if it's stopped.
+func (t *refreshTimer) wake() tea.Cmd {
+	if t.running {
+		return nil
+	}
+	return t.start()
+}
+
+// stop stops the timer after the current tick.
+func (t *refreshTimer) stop() {
+	t.running = false
+}
+
+// accept reports whether msg belongs to the current tick chain.
+func (t *refreshTimer) accept(msg TickMsg) bool {
+	return t.running && msg.gen == t.gen
+}
+
+// schedule returns a command delivering the next tick.
+func (t *refreshTimer) schedule() tea.Cmd {
+	gen := t.gen
+	return tea.Every(t.interval, func(time.Time) tea.Msg {
+		return TickMsg{gen: gen}
+	})
+}
@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/parser"
+	"github.com/ansel1/tang/results"
+)
+
+func TestRefreshTimerGenerations(t *testing.T) {
+	timer := refreshTimer{interval: RefreshInterval}
+	if cmd := timer.start(); cmd == nil {
+		t.Fatal("Expected start to schedule a tick")
+	}
+	first := TickMsg{gen: timer.gen}
+	if !timer.accept(first) {
+		t.Error("Expected the current tick to be accepted")
+	}
+	if cmd := timer.wake(); cmd != nil {
+		t.Error("Expected wake to do nothing while running")
+	}
+
+	// Restarting abandons the earlier chain.
+	timer.start()
+	if timer.accept(first) {
+		t.Error("Expected a tick from an earlier chain to be dropped")
+	}
+
+	timer.stop()
+	if timer.accept(TickMsg{gen: timer.gen}) {
+		t.Error("Expected ticks to be dropped while stopped")
+	}
+	if cmd := timer.wake(); cmd == nil {
+		t.Error("Expected wake to restart a stopped timer")
+	}
+}
+
+func TestModelTickStopsWhenIdle(t *testing.T) {
+	collector := results.NewCollector()
+	m := NewModel(false, 1.0, collector)
+	m.Init()
+
+	now := time.Now()
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "start", Package: "example.com/pkg"}})
+
+	frame := m.spinner.View()
+	_, cmd := m.Update(TickMsg{gen: m.timer.gen})
+	if cmd == nil {
+		t.Error("Expected the next tick to be scheduled while running")
+	}
+	if m.spinner.View() == frame {
+		t.Error("Expected a tick to advance the spinner")
+	}
+
+	// A stale tick neither advances the spinner nor schedules another.
+	frame = m.spinner.View()
+	if _, cmd := m.Update(TickMsg{gen: m.timer.gen - 1}); cmd != nil || m.spinner.View() != frame {
+		t.Error("Expected a stale tick to be dropped")
+	}
+
+	collector.Push(engine.Event{Type: engine.EventTest, TestEvent: parser.TestEvent{Time: now, Action: "pass", Package: "example.com/pkg"}})
+	collector.Push(engine.Event{Type: engine.EventComplete})
+	if _, cmd := m.Update(TickMsg{gen: m.timer.gen}); cmd != nil {
+		t.Error("Expected ticking to stop once idle")
+	}
+	if _, cmd := m.Update(tea.ResumeMsg{}); cmd == nil {
+		t.Error("Expected resuming to restart the timer")
+	}
+}
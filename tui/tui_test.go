@@ -1,8 +1,6 @@
 package tui
 
 import (
-	"bufio"
-	"os"
 	"strings"
 	"testing"
 	"time"
@@ -12,81 +10,6 @@ import (
 	"github.com/ansel1/tang/results"
 )
 
-// ScriptBlock represents a test block with input, expected output, and match type
-type ScriptBlock struct {
-	Input     string
-	Expected  string
-	MatchType string // "contains" for ">>>", "equals" for "==="
-}
-
-// parseScriptFile reads a file and parses it into a []ScriptBlock slice.
-func parseScriptFile(filename string) ([]ScriptBlock, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = file.Close() }()
-
-	scanner := bufio.NewScanner(file)
-	var result []ScriptBlock
-	var currentInput []string
-	var currentExpected []string
-	var currentMatchType string
-	mode := "input" // "input" or "expected"
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		switch trimmed {
-		case "###":
-			// Start of a new block - save previous block if it exists
-			if len(currentInput) > 0 && len(currentExpected) > 0 {
-				result = append(result, ScriptBlock{
-					Input:     strings.Join(currentInput, "\n"),
-					Expected:  strings.Join(currentExpected, "\n"),
-					MatchType: currentMatchType,
-				})
-			}
-			currentInput = nil
-			currentExpected = nil
-			currentMatchType = ""
-			mode = "input"
-		case "===":
-			// Separator for exact match
-			mode = "expected"
-			currentMatchType = "equals"
-		case ">>>":
-			// Separator for contains match
-			mode = "expected"
-			currentMatchType = "contains"
-		default:
-			// Regular line - add to current section
-			switch mode {
-			case "input":
-				currentInput = append(currentInput, line)
-			case "expected":
-				currentExpected = append(currentExpected, line)
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	// Don't forget the last block if file doesn't end with ###
-	if len(currentInput) > 0 && len(currentExpected) > 0 {
-		result = append(result, ScriptBlock{
-			Input:     strings.Join(currentInput, "\n"),
-			Expected:  strings.Join(currentExpected, "\n"),
-			MatchType: currentMatchType,
-		})
-	}
-
-	return result, nil
-}
-
 // TestHierarchicalRendering validates the new hierarchical TUI format
 func TestHierarchicalRendering(t *testing.T) {
 	collector := results.NewCollector()
@@ -241,50 +164,6 @@ func TestRunningPackagesShowTests(t *testing.T) {
 	}
 }
 
-func TestParseScriptFile(t *testing.T) {
-	result, err := parseScriptFile("testdata/script1")
-	if err != nil {
-		t.Fatalf("Failed to parse script file: %v", err)
-	}
-
-	// Check that we got the expected number of blocks
-	expectedBlocks := 4
-	if len(result) != expectedBlocks {
-		t.Errorf("Expected %d blocks, got %d", expectedBlocks, len(result))
-	}
-
-	// Verify each block has required fields
-	for i, block := range result {
-		if block.Input == "" {
-			t.Errorf("Block %d: input is empty", i)
-		}
-		if block.Expected == "" {
-			t.Errorf("Block %d: expected is empty", i)
-		}
-		if block.MatchType == "" {
-			t.Errorf("Block %d: matchType is empty", i)
-		}
-		// script1 should use "contains" match type
-		if block.MatchType != "contains" {
-			t.Errorf("Block %d: expected matchType 'contains', got '%s'", i, block.MatchType)
-		}
-	}
-
-	// Test a specific block to verify parsing correctness
-	if len(result) > 0 {
-		firstInput := result[0].Input
-		firstOutput := result[0].Expected
-
-		// The first block should contain the build-output JSON
-		if !strings.Contains(firstInput, `"Action":"build-output"`) {
-			t.Errorf("First block input doesn't contain expected JSON content")
-		}
-		if !strings.Contains(firstOutput, "# github.com/ansel1/tang/tui") {
-			t.Errorf("First block output doesn't contain expected content")
-		}
-	}
-}
-
 func TestRunScripts(t *testing.T) {
 	t.Skip("Skipping old flat-format tests - replaced with new hierarchical format per spec")
 }
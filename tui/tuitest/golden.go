@@ -0,0 +1,82 @@
+package tuitest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Update makes AssertGolden write golden files rather than compare with
+// them. Run with: go test ./... -update
+var Update = flag.Bool("update", false, "update golden files")
+
+// spinnerRE matches the MiniDot spinner characters.
+var spinnerRE = regexp.MustCompile("[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏]")
+
+// elapsedRE matches an elapsed-time value (e.g. "1.2s", "606090.4m") that may
+// be wrapped in ANSI escape sequences (bold, color).
+//
+// Group layout:
+//
+//	(1) leading whitespace + optional ANSI codes
+//	(2) the numeric value including unit  e.g. "0.1s", or "X.Xs" once
+//	    scrubbed, so scrubbing twice changes nothing
+//	(3) optional trailing ANSI codes
+var elapsedRE = regexp.MustCompile(`(\s(?:\x1b\[[0-9;]*m)*)(\d+\.\d+[sm]|X\.Xs)((?:\x1b\[[0-9;]*m)*)`)
+
+// Scrub replaces non-deterministic content so that frames can be compared
+// stably across runs.
+//
+//   - Spinner frames (⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏) → ~
+//   - Trailing elapsed time values (\d+\.\d+[sm]) → X.Xs
+func Scrub(s string) string {
+	s = spinnerRE.ReplaceAllString(s, "~")
+
+	// Scrub elapsed times line-by-line: only replace the last occurrence of an
+	// elapsed-time pattern on each line (the right-aligned column), leaving
+	// times embedded in package output (left side) untouched.
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		matches := elapsedRE.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		// Use the last match — this is the right-aligned elapsed column.
+		last := matches[len(matches)-1]
+		// Replace group 2 (the numeric value) with X.Xs, preserving
+		// surrounding whitespace and ANSI codes.
+		numStart := last[4]
+		numEnd := last[5]
+		lines[i] = line[:numStart] + "X.Xs" + line[numEnd:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssertGolden compares frame, scrubbed, with the golden file at path, or
+// writes it there if -update is set (see Update).
+func AssertGolden(t testing.TB, path, frame string) {
+	t.Helper()
+
+	scrubbed := Scrub(frame)
+	if *Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(scrubbed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("updated golden file: %s", path)
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file missing; run with -update to create: %v", err)
+	}
+	if string(expected) != scrubbed {
+		t.Errorf("golden mismatch for %s (run with -update to accept)\nexpected:\n%s\nactual:\n%s", path, expected, scrubbed)
+	}
+}
@@ -0,0 +1,128 @@
+// Package tuitest renders tang's live display from recorded go test -json
+// streams, for golden tests of the display: of tang's own layout, and of
+// the themes and layouts of programs customizing it.
+//
+// A Harness feeds a recording to a tui.Model of a fixed size, and renders
+// frames of it, with Scrub replacing what changes from run to run, such
+// as spinners and the elapsed times of running tests:
+//
+//	frames, err := tuitest.Frames(f, 80, 24, tuitest.WithTheme(theme))
+//
+// Script files (see ParseScript) pair fragments of a recording with the
+// display expected after each, and golden files (see AssertGolden) hold
+// whole frames.
+package tuitest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ansel1/tang/engine"
+	"github.com/ansel1/tang/output/format"
+	"github.com/ansel1/tang/results"
+	"github.com/ansel1/tang/tui"
+)
+
+// Option customizes the model a Harness renders.
+type Option func(m *tui.Model)
+
+// WithTheme colors the display with t.
+func WithTheme(t format.Theme) Option {
+	return func(m *tui.Model) {
+		m.SummaryOptions.Theme = t
+		m.SetTheme(t)
+	}
+}
+
+// WithSummaryOptions sets the options the display shares with the summary,
+// e.g. how package names are shortened, and colors it with their theme
+// and marks it with their symbols.
+func WithSummaryOptions(opts format.SummaryOptions) Option {
+	return func(m *tui.Model) {
+		m.SummaryOptions = opts
+		m.SetTheme(opts.Theme)
+		m.SetSymbols(opts.Symbols)
+	}
+}
+
+// Harness feeds go test -json streams to a live display of a fixed size.
+type Harness struct {
+	// Model is the display. Its exported fields, e.g. FailuresPane, may be
+	// set between feeds.
+	Model *tui.Model
+
+	collector *results.Collector
+}
+
+// New returns a Harness rendering a display of width by height cells.
+func New(width, height int, opts ...Option) *Harness {
+	collector := results.NewCollector()
+	m := tui.NewModel(false, 1.0, collector)
+	m.TerminalWidth = width
+	m.TerminalHeight = height
+	for _, opt := range opts {
+		opt(m)
+	}
+	return &Harness{Model: m, collector: collector}
+}
+
+// Feed pushes the events of r, a go test -json stream or a fragment of
+// one, to the display. The run isn't finished when r is exhausted, so a
+// stream may be fed in several fragments; see Finish.
+func (h *Harness) Feed(r io.Reader) error {
+	return h.feed(r, nil)
+}
+
+// feed is Feed, calling frame, if not nil, after each event.
+func (h *Harness) feed(r io.Reader, frame func()) error {
+	var err error
+	for evt := range engine.NewEngine().Stream(r) {
+		switch evt.Type {
+		case engine.EventComplete:
+			continue
+		case engine.EventError:
+			if err == nil {
+				err = fmt.Errorf("reading stream: %w", evt.Error)
+			}
+			continue
+		}
+		h.collector.Push(evt)
+		if frame != nil {
+			frame()
+		}
+	}
+	return err
+}
+
+// Finish ends the stream, finishing the current run, as tang does at the
+// end of its input.
+func (h *Harness) Finish() {
+	h.collector.Push(engine.Event{Type: engine.EventComplete})
+}
+
+// Frame renders the display, showing the most recent run whether or not
+// it's finished. Scrub it before comparing it with a frame of another run.
+func (h *Harness) Frame() string {
+	return h.Model.String()
+}
+
+// Frames renders the display of width by height cells after each event of
+// the go test -json stream r, and once more after the stream ends. Frames
+// are scrubbed (see Scrub), and those identical to the frame before are
+// left out.
+func Frames(r io.Reader, width, height int, opts ...Option) ([]string, error) {
+	h := New(width, height, opts...)
+	var frames []string
+	snapshot := func() {
+		frame := Scrub(h.Frame())
+		if len(frames) == 0 || frames[len(frames)-1] != frame {
+			frames = append(frames, frame)
+		}
+	}
+	if err := h.feed(r, snapshot); err != nil {
+		return nil, err
+	}
+	h.Finish()
+	snapshot()
+	return frames, nil
+}
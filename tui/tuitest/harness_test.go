@@ -0,0 +1,52 @@
+package tuitest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ansel1/tang/output/format"
+)
+
+func TestFrames(t *testing.T) {
+	f, err := os.Open("testdata/pass_fail.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	frames, err := Frames(f, 60, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) < 2 {
+		t.Fatalf("expected a frame per change of the display, got %d", len(frames))
+	}
+	for i := 1; i < len(frames); i++ {
+		if frames[i] == frames[i-1] {
+			t.Errorf("frame %d repeats the frame before", i)
+		}
+	}
+	AssertGolden(t, "testdata/pass_fail.golden", frames[len(frames)-1])
+}
+
+func TestWithTheme(t *testing.T) {
+	data, err := os.ReadFile("testdata/pass_fail.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := New(60, 20)
+	themed := New(60, 20, WithTheme(format.Theme{Fail: "#ff0000"}))
+	for _, h := range []*Harness{plain, themed} {
+		if err := h.Feed(strings.NewReader(string(data))); err != nil {
+			t.Fatal(err)
+		}
+		h.Finish()
+	}
+	if !strings.Contains(themed.Frame(), "\x1b[38;2;255;0;0m") {
+		t.Errorf("expected failures in the theme's color\nGot:\n%s", themed.Frame())
+	}
+	if plain.Frame() == themed.Frame() {
+		t.Error("expected the theme to change the display")
+	}
+}
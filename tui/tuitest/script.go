@@ -0,0 +1,147 @@
+package tuitest
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Match is how a ScriptBlock's expected display is compared with the
+// rendered one.
+type Match string
+
+const (
+	MatchContains Match = "contains" // The display contains the expected lines; introduced by ">>>"
+	MatchEquals   Match = "equals"   // The display is the expected lines; introduced by "==="
+)
+
+// ScriptBlock is a block of a script file: a fragment of a go test -json
+// stream, and the display expected once it's fed.
+type ScriptBlock struct {
+	Input     string
+	Expected  string
+	MatchType Match
+}
+
+// ParseScript parses a script file. A script is a series of blocks, each
+// starting with a "###" line, followed by lines of a go test -json
+// stream, then a ">>>" or "===" line and the display expected after them,
+// without colors and scrubbed (see Scrub). With ">>>" the display must
+// contain the expected lines; with "===" it must be exactly them:
+//
+//	###
+//	{"Action":"start","Package":"example.com/pkg"}
+//	>>>
+//	example.com/pkg
+//
+// Blocks without input or expected lines are ignored.
+func ParseScript(r io.Reader) ([]ScriptBlock, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var result []ScriptBlock
+	var currentInput []string
+	var currentExpected []string
+	var currentMatchType Match
+	mode := "input" // "input" or "expected"
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch trimmed {
+		case "###":
+			// Start of a new block - save previous block if it exists
+			if len(currentInput) > 0 && len(currentExpected) > 0 {
+				result = append(result, ScriptBlock{
+					Input:     strings.Join(currentInput, "\n"),
+					Expected:  strings.Join(currentExpected, "\n"),
+					MatchType: currentMatchType,
+				})
+			}
+			currentInput = nil
+			currentExpected = nil
+			currentMatchType = ""
+			mode = "input"
+		case "===":
+			// Separator for exact match
+			mode = "expected"
+			currentMatchType = MatchEquals
+		case ">>>":
+			// Separator for contains match
+			mode = "expected"
+			currentMatchType = MatchContains
+		default:
+			// Regular line - add to current section
+			switch mode {
+			case "input":
+				currentInput = append(currentInput, line)
+			case "expected":
+				currentExpected = append(currentExpected, line)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Don't forget the last block if file doesn't end with ###
+	if len(currentInput) > 0 && len(currentExpected) > 0 {
+		result = append(result, ScriptBlock{
+			Input:     strings.Join(currentInput, "\n"),
+			Expected:  strings.Join(currentExpected, "\n"),
+			MatchType: currentMatchType,
+		})
+	}
+
+	return result, nil
+}
+
+// RunScript feeds the blocks of the script file at path, in order, to a
+// display of width by height cells, and checks the display after each.
+func RunScript(t testing.TB, path string, width, height int, opts ...Option) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := ParseScript(f)
+	_ = f.Close()
+	if err != nil {
+		t.Fatalf("parsing script %s: %v", path, err)
+	}
+
+	h := New(width, height, opts...)
+	for i, block := range blocks {
+		if err := h.Feed(strings.NewReader(block.Input + "\n")); err != nil {
+			t.Fatalf("%s block %d: %v", path, i+1, err)
+		}
+		display := trimLines(Scrub(ansi.Strip(h.Frame())))
+		expected := trimLines(block.Expected)
+		switch block.MatchType {
+		case MatchEquals:
+			if display != expected {
+				t.Errorf("%s block %d: display isn't the expected one\nexpected:\n%s\nactual:\n%s", path, i+1, expected, display)
+			}
+		default:
+			if !strings.Contains(display, expected) {
+				t.Errorf("%s block %d: display doesn't contain the expected lines\nexpected:\n%s\nactual:\n%s", path, i+1, expected, display)
+			}
+		}
+	}
+}
+
+// trimLines removes trailing spaces from each line of s, and blank lines
+// from its end, which are invisible in a script file.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
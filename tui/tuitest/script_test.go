@@ -0,0 +1,60 @@
+package tuitest
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseScriptFile(t *testing.T) {
+	f, err := os.Open("testdata/script1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	result, err := ParseScript(f)
+	if err != nil {
+		t.Fatalf("Failed to parse script file: %v", err)
+	}
+
+	// Check that we got the expected number of blocks
+	expectedBlocks := 4
+	if len(result) != expectedBlocks {
+		t.Errorf("Expected %d blocks, got %d", expectedBlocks, len(result))
+	}
+
+	// Verify each block has required fields
+	for i, block := range result {
+		if block.Input == "" {
+			t.Errorf("Block %d: input is empty", i)
+		}
+		if block.Expected == "" {
+			t.Errorf("Block %d: expected is empty", i)
+		}
+		if block.MatchType == "" {
+			t.Errorf("Block %d: matchType is empty", i)
+		}
+		// script1 should use "contains" match type
+		if block.MatchType != MatchContains {
+			t.Errorf("Block %d: expected matchType 'contains', got '%s'", i, block.MatchType)
+		}
+	}
+
+	// Test a specific block to verify parsing correctness
+	if len(result) > 0 {
+		firstInput := result[0].Input
+		firstOutput := result[0].Expected
+
+		// The first block should contain the build-output JSON
+		if !strings.Contains(firstInput, `"Action":"build-output"`) {
+			t.Errorf("First block input doesn't contain expected JSON content")
+		}
+		if !strings.Contains(firstOutput, "# github.com/ansel1/tang/tui") {
+			t.Errorf("First block output doesn't contain expected content")
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	RunScript(t, "testdata/pass_fail.script", 60, 20)
+}
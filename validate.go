@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ansel1/tang/parser"
+)
+
+// runValidate implements the `tang validate` subcommand, which checks a
+// recorded go test -json stream for structural problems.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	infile := fs.String("f", "", "Recorded go test -json stream to validate, e.g. from -jsonfile (default stdin)")
+	maxProblems := fs.Int("max", 50, "Number of problems to list (0 for all)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tang validate [-f file.json] [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Check a recorded go test -json stream for malformed lines, unknown actions, events out of\n")
+		fmt.Fprintf(os.Stderr, "order and events of tests which never ran, without acting on it. Exits non-zero if any of\n")
+		fmt.Fprintf(os.Stderr, "these errors are found; unknown actions are only warned about.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	var input io.Reader = os.Stdin
+	name := "stdin"
+	if *infile != "" {
+		f, err := os.Open(*infile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		input, name = f, *infile
+	}
+
+	v, err := parser.Validate(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+		return 1
+	}
+
+	fmt.Printf("%s: %d lines, %d events, %d packages, %d tests, %d non-JSON lines\n",
+		name, v.Lines, v.Events, v.Packages, v.Tests, v.RawLines)
+	for i, p := range v.Problems {
+		if *maxProblems > 0 && i == *maxProblems {
+			fmt.Printf("...and %d more problems\n", len(v.Problems)-i)
+			break
+		}
+		fmt.Println(p)
+	}
+	warnings := len(v.Problems) - v.Errors
+	fmt.Printf("%d errors, %d warnings\n", v.Errors, warnings)
+	if v.Errors > 0 {
+		return 1
+	}
+	return 0
+}